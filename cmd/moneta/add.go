@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/shivavenkatesh/moneta/pkg/types"
@@ -15,8 +17,14 @@ var (
 	addFilePath string
 	addLanguage string
 	addMetadata []string
+	addAt       string
+	addHere     bool
 )
 
+// hereEnv names the environment variable --here reads, set by editor
+// integrations to the current file:line
+const hereEnv = "MONETA_AT"
+
 var addCmd = &cobra.Command{
 	Use:   "add <content>",
 	Short: "Add a memory",
@@ -34,7 +42,10 @@ Types:
 Examples:
   moneta add "We use Repository pattern for DB access" --type pattern
   moneta add "Always validate user input before SQL queries" --type gotcha
-  moneta add "Chose PostgreSQL for ACID transactions" --type decision`,
+  moneta add "Chose PostgreSQL for ACID transactions" --type decision
+  moneta add "Retries must be idempotent here" --at internal/api/client.go:42
+  moneta add "Hot loop, keep allocation-free" --at sim/step.go:10-35
+  MONETA_AT=main.go:7 moneta add "Flags are parsed before config" --here`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runAdd,
 }
@@ -44,6 +55,38 @@ func init() {
 	addCmd.Flags().StringVarP(&addFilePath, "file", "f", "", "Associated file path")
 	addCmd.Flags().StringVarP(&addLanguage, "lang", "l", "", "Programming language")
 	addCmd.Flags().StringArrayVarP(&addMetadata, "meta", "m", nil, "Metadata as key=value pairs")
+	addCmd.Flags().StringVar(&addAt, "at", "", "Anchor to a code location as file:line or file:start-end")
+	addCmd.Flags().BoolVar(&addHere, "here", false, "Anchor to the location in $"+hereEnv+" (file:line)")
+}
+
+// parseLocation parses "file:line" or "file:start-end"
+func parseLocation(spec string) (path string, start, end int, err error) {
+	path, lines, ok := cutLast(spec, ":")
+	if !ok || path == "" || lines == "" {
+		return "", 0, 0, fmt.Errorf("invalid location %q: expected file:line or file:start-end", spec)
+	}
+
+	first, last, isRange := strings.Cut(lines, "-")
+	start, err = strconv.Atoi(first)
+	if err != nil || start < 1 {
+		return "", 0, 0, fmt.Errorf("invalid location %q: line must be a positive number", spec)
+	}
+	end = start
+	if isRange {
+		end, err = strconv.Atoi(last)
+		if err != nil || end < start {
+			return "", 0, 0, fmt.Errorf("invalid location %q: end line must be a number >= %d", spec, start)
+		}
+	}
+	return path, start, end, nil
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -54,12 +97,6 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("content is required")
 	}
 
-	svc, err := initService()
-	if err != nil {
-		return err
-	}
-	defer svc.Close()
-
 	// Parse metadata
 	metadata := make(map[string]string)
 	for _, m := range addMetadata {
@@ -69,11 +106,42 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Anchor to a code location, stored like indexed chunks so the memory
+	// shows up in searches scoped to that file
+	filePath := addFilePath
+	location := addAt
+	if addHere {
+		if location != "" {
+			return fmt.Errorf("--here and --at cannot be used together")
+		}
+		if location = os.Getenv(hereEnv); location == "" {
+			return fmt.Errorf("--here requires $%s to be set to file:line", hereEnv)
+		}
+	}
+	if location != "" {
+		if filePath != "" {
+			return fmt.Errorf("--file cannot be combined with --at or --here")
+		}
+		path, start, end, err := parseLocation(location)
+		if err != nil {
+			return err
+		}
+		filePath = filepath.Clean(path)
+		metadata["start_line"] = strconv.Itoa(start)
+		metadata["end_line"] = strconv.Itoa(end)
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
 	req := types.AddMemoryRequest{
 		Content:  content,
 		Project:  getProject(),
 		Type:     types.MemoryType(addType),
-		FilePath: addFilePath,
+		FilePath: filePath,
 		Language: addLanguage,
 		Metadata: metadata,
 	}
@@ -96,17 +164,26 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// truncate shortens s to maxLen characters; a negative maxLen disables truncation
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if maxLen < 0 || len(s) <= maxLen {
 		return s
 	}
+	if maxLen <= 3 {
+		return s[:maxLen]
+	}
 	return s[:maxLen-3] + "..."
 }
 
+// getProject returns -p, then the project set with 'moneta use', then the
+// current directory name
 func getProject() string {
 	if project != "" {
 		return project
 	}
+	if active := activeProject(); active != "" {
+		return active
+	}
 	// Default to current directory name
 	dir, err := os.Getwd()
 	if err != nil {
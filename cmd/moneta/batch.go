@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// batchResult is one line of search --batch output
+type batchResult struct {
+	Query string `json:"query"`
+	*types.SearchResponse
+	Error string `json:"error,omitempty"`
+}
+
+// batchItem is a query that has been parsed and embedded, ready to search
+type batchItem struct {
+	req       types.SearchRequest
+	embedding []float32
+	err       error
+}
+
+// searchBatchQueries searches each line of in and writes one result per
+// line to out. Embedding and searching run as a two-stage pipeline: the
+// next query is embedded while the current one is searched.
+func searchBatchQueries(ctx context.Context, svc memory.Service, base types.SearchRequest, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan batchItem)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(items)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			item := batchItem{}
+			item.req, item.err = batchRequest(base, line)
+			if item.err == nil {
+				item.embedding, item.err = svc.EmbedQuery(ctx, item.req)
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				readErr <- nil
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	enc := json.NewEncoder(out)
+	for item := range items {
+		result := batchResult{Query: item.req.Query}
+		if item.err == nil {
+			result.SearchResponse, item.err = svc.SearchEmbedded(ctx, item.req, item.embedding)
+		}
+		if item.err != nil {
+			result.Error = item.err.Error()
+		}
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+	}
+
+	if err := <-readErr; err != nil {
+		return fmt.Errorf("failed to read queries: %w", err)
+	}
+	return nil
+}
+
+// batchRequest parses a line of search --batch input: a JSON search
+// request, or a plain query searched with the flags in base
+func batchRequest(base types.SearchRequest, line string) (types.SearchRequest, error) {
+	if !strings.HasPrefix(line, "{") {
+		req := base
+		req.Query = line
+		return req, nil
+	}
+
+	var req types.SearchRequest
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return req, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Query == "" {
+		return req, fmt.Errorf("query is required")
+	}
+	if req.Project == "" {
+		req.Project = base.Project
+	}
+	return req, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/store/sqlite"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchTune bool
+	benchSize int
+)
+
+// topKConfigFile is the file in the data directory holding the search
+// ranking switch points saved by 'moneta bench --tune'
+const topKConfigFile = "topk.json"
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure search ranking strategies on this machine",
+	Long: `Time the strategies search uses to pick the best results (selection, a
+heap, and a full sort) for a range of result limits, and report the switch
+points that are fastest on this machine.
+
+By default the measurements use as many results as there are stored
+memories (at least 1000). With --tune the switch points are saved in the
+data directory and used by every later search.
+
+Examples:
+  moneta bench
+  moneta bench --tune
+  moneta bench --size 50000`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().BoolVar(&benchTune, "tune", false, "Save the measured switch points for future searches")
+	benchCmd.Flags().IntVar(&benchSize, "size", 0, "Number of results to rank (default: stored memory count, at least 1000)")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	size := benchSize
+	if size <= 0 {
+		svc, err := initService()
+		if err != nil {
+			return err
+		}
+		stats, err := svc.Stats(context.Background())
+		svc.Close()
+		if err != nil {
+			return err
+		}
+		size = stats.TotalMemories
+		if size < 1000 {
+			size = 1000
+		}
+	}
+
+	cfg, timings := sqlite.CalibrateTopK(size)
+
+	fmt.Printf("Ranking %d results:\n\n", size)
+	fmt.Printf("%8s  %12s  %12s  %12s\n", "k", "select", "heap", "sort")
+	for _, t := range timings {
+		fmt.Printf("%8d  %12s  %12s  %12s\n", t.K,
+			t.Select.Round(time.Microsecond), t.Heap.Round(time.Microsecond), t.Sort.Round(time.Microsecond))
+	}
+
+	current, err := loadTopKConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	printTopKConfig("Current", current)
+	printTopKConfig("Measured", cfg)
+
+	if !benchTune {
+		infof("\nRun with --tune to use the measured switch points\n")
+		return nil
+	}
+
+	path, err := topKConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode switch points: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to save switch points: %w", err)
+	}
+	fmt.Printf("\nSaved to %s\n", path)
+	return nil
+}
+
+// printTopKConfig prints switch points in words
+func printTopKConfig(label string, cfg sqlite.TopKConfig) {
+	sel := fmt.Sprintf("selection for k <= %d", cfg.SelectMaxK)
+	if cfg.SelectMaxK < 0 {
+		sel = "no selection"
+	}
+	fmt.Printf("%-9s %s, full sort once k >= %.1f%% of results\n", label+":", sel, cfg.SortRatio*100)
+}
+
+// topKConfigPath returns the path of the saved switch points
+func topKConfigPath() (string, error) {
+	dir, err := resolveDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, topKConfigFile), nil
+}
+
+// loadTopKConfig returns the switch points saved by 'moneta bench --tune',
+// or the defaults if none are saved
+func loadTopKConfig() (sqlite.TopKConfig, error) {
+	path, err := topKConfigPath()
+	if err != nil {
+		return sqlite.TopKConfig{}, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sqlite.DefaultTopKConfig(), nil
+	}
+	if err != nil {
+		return sqlite.TopKConfig{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var cfg sqlite.TopKConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return sqlite.TopKConfig{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var checkpointMode string
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Flush the write-ahead log into the database",
+	Long: `Copy the SQLite write-ahead log (moneta.db-wal, or each project's
+projects/*.db-wal with --per-project-db) into the database and, by default,
+truncate it. Indexing checkpoints automatically, but the log can
+still grow large after heavy writes from several processes.
+
+Modes:
+  truncate  - Checkpoint and empty the -wal file (default)
+  passive   - Checkpoint what it can without waiting on other connections
+  full      - Wait for writers, then checkpoint everything
+  restart   - Like full, and make the next writer restart the log
+
+Examples:
+  moneta checkpoint
+  moneta checkpoint --mode passive`,
+	Args: cobra.NoArgs,
+	RunE: runCheckpoint,
+}
+
+func init() {
+	checkpointCmd.Flags().StringVar(&checkpointMode, "mode", "truncate", "Checkpoint mode (truncate, passive, full, restart)")
+}
+
+func runCheckpoint(cmd *cobra.Command, args []string) error {
+	dir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	before := walSize(dir)
+	if err := svc.Checkpoint(context.Background(), checkpointMode); err != nil {
+		return err
+	}
+	after := walSize(dir)
+
+	fmt.Printf("WAL size: %.2f MB -> %.2f MB\n", float64(before)/1024/1024, float64(after)/1024/1024)
+	return nil
+}
+
+// walSize returns the size of the store's write-ahead log, summed over
+// every project database in per-project mode
+func walSize(dir string) int64 {
+	path, perProject := storePath(dir)
+	if !perProject {
+		return fileSize(path + "-wal")
+	}
+	wals, _ := filepath.Glob(filepath.Join(path, "*.db-wal"))
+	var total int64
+	for _, wal := range wals {
+		total += fileSize(wal)
+	}
+	return total
+}
+
+// fileSize returns the size of path, or 0 if it doesn't exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
 
 	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +22,31 @@ var listCmd = &cobra.Command{
 Examples:
   moneta list
   moneta list --type pattern
-  moneta list --limit 20`,
+  moneta list --limit 20
+  moneta list --all
+  moneta list --meta team=payments
+  moneta list --width 120`,
 	RunE: runList,
 }
 
 var (
 	listLimit int
 	listType  string
+	listLang  string
+	listPath  string
+	listAll   bool
+	listMeta  []string
 )
 
 func init() {
 	listCmd.Flags().IntVarP(&listLimit, "limit", "n", 20, "Maximum results")
 	listCmd.Flags().StringVar(&listType, "type", "", "Filter by type")
+	listCmd.Flags().StringVarP(&listLang, "lang", "l", "", "Filter by language (e.g. go, python)")
+	listCmd.Flags().StringVar(&listPath, "path", "", "Only memories whose file path starts with this prefix")
+	listCmd.Flags().BoolVar(&listAll, "all", false, "List every memory, ignoring --limit")
+	listCmd.Flags().StringArrayVar(&listMeta, "meta", nil, "Only memories with this metadata, as key=value (repeatable)")
+	addAllProjectsFlag(listCmd)
+	addPreviewFlags(listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -40,10 +59,19 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer svc.Close()
 
 	opts := store.ListOptions{
-		Project:    getProject(),
-		Limit:      listLimit,
-		Descending: true,
-		OrderBy:    "created_at",
+		Project:        scopeProject(),
+		Type:           types.MemoryType(listType),
+		Language:       listLang,
+		FilePathPrefix: listPath,
+		Limit:          listLimit,
+		Descending:     true,
+		OrderBy:        "created_at",
+	}
+	if listAll {
+		opts.Limit = store.NoLimit
+	}
+	if opts.MetadataFilters, err = parseMetaFilters(listMeta); err != nil {
+		return err
 	}
 
 	memories, err := svc.List(ctx, opts)
@@ -52,28 +80,88 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(memories) == 0 {
-		fmt.Println("No memories found")
+		infof("No memories found\n")
 		return nil
 	}
 
-	fmt.Printf("Memories in project '%s':\n\n", getProject())
+	if opts.Project == "" {
+		infof("Memories in all projects:\n\n")
+	} else {
+		infof("Memories in project '%s':\n\n", opts.Project)
+	}
 	for _, m := range memories {
-		fmt.Printf("  [%s] %s\n", formatType(m.Type), truncate(m.Content, 80))
+		limit := previewLimit(len(m.Type)+5, 80)
+		fmt.Printf("  [%s] %s\n", formatType(m.Type), formatContent(m.Content, limit))
 		fmt.Printf("    ID: %s\n\n", m.ID)
 	}
 
 	return nil
 }
 
+var getCmd = &cobra.Command{
+	Use:   "get <id>",
+	Short: "Show a memory",
+	Long: `Show a single memory by its ID. Content is shown in full unless --width
+is given.
+
+Examples:
+  moneta get abc123
+  moneta get abc123 --width 200`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGet,
+}
+
+func init() {
+	addPreviewFlags(getCmd)
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	m, err := svc.Get(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+
+	// A single memory is shown in full unless a width is requested
+	limit := -1
+	if previewChars > 0 && !previewFull {
+		limit = previewChars
+	}
+
+	fmt.Printf("ID:      %s\n", m.ID)
+	fmt.Printf("Type:    %s\n", formatType(m.Type))
+	fmt.Printf("Project: %s\n", m.Project)
+	if m.FilePath != "" {
+		fmt.Printf("File:    %s\n", m.FilePath)
+	}
+	if m.Language != "" {
+		fmt.Printf("Lang:    %s\n", m.Language)
+	}
+	fmt.Printf("Created: %s\n", m.CreatedAt.Format(time.RFC3339))
+	fmt.Printf("Updated: %s\n", m.UpdatedAt.Format(time.RFC3339))
+	fmt.Println()
+	fmt.Println(truncate(m.Content, limit))
+
+	return nil
+}
+
 var deleteCmd = &cobra.Command{
-	Use:   "delete <id>",
-	Short: "Delete a memory",
-	Long: `Delete a memory by its ID.
+	Use:   "delete <id>...",
+	Short: "Delete memories",
+	Long: `Delete one or more memories by ID. Multiple IDs are deleted in a single
+transaction.
 
 Examples:
   moneta delete abc123
+  moneta delete abc123 def456 ghi789
   moneta delete --all  # Delete all memories in project`,
-	Args: cobra.MaximumNArgs(1),
 	RunE: runDelete,
 }
 
@@ -104,27 +192,67 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("memory ID required (or use --all)")
 	}
 
-	id := args[0]
-	if err := svc.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete memory: %w", err)
+	if len(args) == 1 {
+		id := args[0]
+		if err := svc.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete memory: %w", err)
+		}
+
+		fmt.Printf("Deleted: %s\n", id)
+		return nil
+	}
+
+	deleted, err := svc.DeleteBatch(ctx, args)
+	if err != nil {
+		return fmt.Errorf("failed to delete memories: %w", err)
 	}
 
-	fmt.Printf("Deleted: %s\n", id)
+	fmt.Printf("Deleted %d of %d memories", deleted, len(args))
+	if missing := len(args) - deleted; missing > 0 {
+		fmt.Printf(" (%d not found)", missing)
+	}
+	fmt.Println()
 	return nil
 }
 
+var (
+	statsWatch    bool
+	statsInterval time.Duration
+	statsRuntime  bool
+)
+
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show statistics",
 	Long: `Show statistics about stored memories.
 
+With --watch the stats are redrawn on an interval until Ctrl-C, which is
+useful for following a long-running index in another terminal.
+
+--runtime adds what this process has done: adds, searches, embeddings, and
+search cache hits. A one-shot command has done little beyond opening the
+store; GET /stats on a running server reports the server's counts.
+
 Examples:
-  moneta stats`,
+  moneta stats
+  moneta stats --watch --interval 5s
+  moneta stats --runtime`,
 	RunE: runStats,
 }
 
+func init() {
+	statsCmd.Flags().BoolVar(&statsWatch, "watch", false, "Refresh stats continuously until interrupted")
+	statsCmd.Flags().DurationVar(&statsInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	statsCmd.Flags().BoolVar(&statsRuntime, "runtime", false, "Also show activity counts for this process")
+}
+
 func runStats(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if statsWatch && statsInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
 
 	svc, err := initService()
 	if err != nil {
@@ -132,25 +260,95 @@ func runStats(cmd *cobra.Command, args []string) error {
 	}
 	defer svc.Close()
 
-	stats, err := svc.Stats(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get stats: %w", err)
+	for {
+		stats, err := svc.Stats(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+
+		if statsWatch {
+			// Clear the screen and move the cursor home
+			fmt.Print("\033[H\033[2J")
+		}
+		if statsRuntime {
+			metrics := svc.Metrics()
+			stats.Runtime = &metrics
+		}
+		printStats(stats)
+		if !statsWatch {
+			return nil
+		}
+		infof("\nUpdated %s (every %s, Ctrl-C to stop)\n", time.Now().Format("15:04:05"), statsInterval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(statsInterval):
+		}
 	}
+}
 
+// printStats renders a stats snapshot
+func printStats(stats *types.StatsResponse) {
 	fmt.Println("Moneta Statistics")
 	fmt.Println("─────────────────")
 	fmt.Printf("Total memories:  %d\n", stats.TotalMemories)
 	fmt.Printf("Projects:        %d\n", stats.ProjectCount)
 	fmt.Printf("Embedding model: %s\n", stats.EmbeddingModel)
 	fmt.Printf("Storage size:    %.2f MB\n", float64(stats.StorageBytes)/1024/1024)
+	if stats.EmbedderCircuit != "" && stats.EmbedderCircuit != "closed" {
+		fmt.Printf("Embedder:        unavailable (circuit %s)\n", stats.EmbedderCircuit)
+	}
+	if stats.CacheDisabled {
+		fmt.Println("Cache:           bypassed (--no-cache)")
+	} else if stats.EmbedRequests > 0 {
+		fmt.Printf("Cache hit rate:  %.1f%%\n", stats.CacheHitRate)
+	}
+	if stats.EmbedP50LatencyMs > 0 {
+		fmt.Printf("Embed latency:   p50 %.1f ms, p95 %.1f ms, p99 %.1f ms\n",
+			stats.EmbedP50LatencyMs, stats.EmbedP95LatencyMs, stats.EmbedP99LatencyMs)
+	}
 	fmt.Println()
 
-	if len(stats.MemoriesByType) > 0 {
-		fmt.Println("By type:")
-		for t, count := range stats.MemoriesByType {
-			fmt.Printf("  %-15s %d\n", t, count)
-		}
+	printCounts("By type:", stats.MemoriesByType)
+	if len(stats.MemoriesByType) > 0 && len(stats.MemoriesByLanguage) > 0 {
+		fmt.Println()
 	}
+	printCounts("By language:", stats.MemoriesByLanguage)
 
-	return nil
+	if m := stats.Runtime; m != nil {
+		fmt.Println()
+		fmt.Println("This process:")
+		fmt.Printf("  Adds:            %d\n", m.Adds)
+		fmt.Printf("  Searches:        %d (avg %.1f ms)\n", m.Searches, m.AvgSearchLatencyMs)
+		fmt.Printf("  Index runs:      %d\n", m.Indexes)
+		fmt.Printf("  Embeddings:      %d\n", m.Embeddings)
+		fmt.Printf("  Search cache:    %d hits, %d misses\n", m.CacheHits, m.CacheMisses)
+	}
+}
+
+// printCounts prints a count breakdown in stable, descending order
+func printCounts(title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Println(title)
+	for _, k := range keys {
+		fmt.Printf("  %-15s %d\n", k, counts[k])
+	}
 }
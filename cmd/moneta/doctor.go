@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the store and embedder are working",
+	Long: `Run health checks against the local database and the embedder selected
+with --provider.
+
+Examples:
+  moneta doctor`,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	failed := false
+	report := func(name string, err error) {
+		if err != nil {
+			failed = true
+			fmt.Printf("  %-10s FAIL: %v\n", name, err)
+			return
+		}
+		fmt.Printf("  %-10s ok\n", name)
+	}
+
+	svc, err := initService()
+	if err != nil {
+		report("store", err)
+	} else {
+		defer svc.Close()
+		report("store", svc.Ping(ctx))
+		model, dims, healthy := svc.EmbedderInfo()
+		fmt.Printf("  %-10s %s (%d dims)\n", "model", model, dims)
+		if !healthy {
+			report("circuit", fmt.Errorf("open, embedder calls are failing fast"))
+		}
+	}
+
+	embedder, err := newEmbedder(0, "")
+	if err != nil {
+		report("embedder", err)
+	} else {
+		defer embedder.Close()
+		start := time.Now()
+		err := embedder.Ping(ctx)
+		report("embedder", err)
+		if err == nil {
+			// A single request, so no percentiles; GET /stats has those
+			fmt.Printf("  %-10s %s\n", "latency", time.Since(start).Round(time.Microsecond))
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("one or more checks failed")
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOut    string
+	exportResume bool
+	exportAll    bool
+	exportFormat string
+	exportNoEmb  bool
+
+	importOnConflict string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export memories as NDJSON, CSV, or Parquet",
+	Long: `Export memories as newline-delimited JSON, one memory per line in stable
+ID order. Embeddings are included so an import does not need to re-embed.
+--no-embeddings leaves them out, for moving memories to a machine that
+uses a different embedding model; the import then embeds them again.
+
+If an export to a file is interrupted, re-run it with --resume to continue
+after the last complete line.
+
+For analysis or moving to another vector database, --format csv or
+--format parquet writes one row per memory with id, content, project,
+type, and embedding columns. CSV holds each embedding as base64
+little-endian float32; Parquet as a list of floats. Only NDJSON can be
+imported back.
+
+Examples:
+  moneta export -o backup.ndjson
+  moneta export --all -o backup.ndjson --resume
+  moneta export -p myapp --no-embeddings -o myapp.ndjson
+  moneta export | gzip > backup.ndjson.gz
+  moneta export --all --format parquet -o memories.parquet`,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import memories from NDJSON",
+	Long: `Import memories from an NDJSON export. A memory is a duplicate when its ID
+already exists or its content matches a memory in the same project.
+--on-conflict decides what happens to duplicates:
+
+  skip     - Keep the existing memory (default)
+  merge    - Add the incoming tags and metadata to the existing memory
+  replace  - Overwrite the existing memory
+
+Re-running an import with skip or merge changes nothing, so an interrupted
+import can simply be re-run.
+
+Projects are preserved from the export unless --project is given.
+Memories exported with --no-embeddings are embedded with the configured
+model as they are imported.
+
+Examples:
+  moneta import backup.ndjson
+  moneta import backup.ndjson --project myapp
+  moneta import laptop.ndjson --on-conflict merge
+  gunzip -c backup.ndjson.gz | moneta import -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Output file (default: stdout)")
+	exportCmd.Flags().BoolVar(&exportResume, "resume", false, "Resume an interrupted export to --out")
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "Export all projects")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "ndjson", "Output format: ndjson, csv, or parquet")
+	exportCmd.Flags().BoolVar(&exportNoEmb, "no-embeddings", false, "Leave out embeddings; importing re-embeds with its own model")
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", "skip", "What to do with duplicates: skip, merge, or replace")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if exportResume && exportOut == "" {
+		return fmt.Errorf("--resume requires --out")
+	}
+	switch exportFormat {
+	case "ndjson":
+	case string(memory.FormatCSV), string(memory.FormatParquet):
+		if exportResume {
+			return fmt.Errorf("--resume only works with --format ndjson")
+		}
+		if exportNoEmb {
+			return fmt.Errorf("--no-embeddings only works with --format ndjson")
+		}
+	default:
+		return fmt.Errorf("unknown --format %q (want ndjson, csv, or parquet)", exportFormat)
+	}
+
+	opts := store.ExportOptions{NoEmbeddings: exportNoEmb}
+	if !exportAll {
+		opts.Project = getProject()
+	}
+
+	var w io.Writer = os.Stdout
+	if exportOut != "" {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if exportResume {
+			afterID, err := resumeExport(exportOut)
+			if err != nil {
+				return err
+			}
+			opts.AfterID = afterID
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+
+		f, err := os.OpenFile(exportOut, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	var count int
+	if exportFormat == "ndjson" {
+		count, err = svc.Export(ctx, w, opts)
+	} else {
+		count, err = svc.ExportTable(ctx, w, memory.TableFormat(exportFormat), opts)
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	if exportOut != "" {
+		fmt.Printf("Exported %d memories to %s\n", count, exportOut)
+	}
+	return nil
+}
+
+// resumeExport trims any partial trailing line from an interrupted export
+// and returns the ID of the last complete record
+func resumeExport(path string) (string, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// Read progressively larger tails until we hold a complete last line
+	size := info.Size()
+	for window := int64(64 * 1024); ; window *= 2 {
+		if window > size {
+			window = size
+		}
+		tail := make([]byte, window)
+		if _, err := f.ReadAt(tail, size-window); err != nil {
+			return "", fmt.Errorf("failed to read export: %w", err)
+		}
+
+		end := bytes.LastIndexByte(tail, '\n')
+		if end == -1 {
+			if window == size {
+				// No complete line at all: start over
+				return "", f.Truncate(0)
+			}
+			continue
+		}
+
+		start := bytes.LastIndexByte(tail[:end], '\n') + 1
+		if start == 0 && window < size {
+			continue
+		}
+
+		var rec struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(tail[start:end], &rec); err != nil || rec.ID == "" {
+			return "", fmt.Errorf("cannot resume: last line of %s is not a valid record", path)
+		}
+
+		// Drop anything written after the last complete line
+		if err := f.Truncate(size - window + int64(end) + 1); err != nil {
+			return "", fmt.Errorf("failed to truncate partial line: %w", err)
+		}
+		return rec.ID, nil
+	}
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	policy, err := store.ParseConflictPolicy(importOnConflict)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open import: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	// Only override projects when explicitly requested
+	result, err := svc.Import(ctx, r, memory.ImportOptions{Project: project, OnConflict: policy})
+	if result != nil {
+		fmt.Printf("Imported %d memories (%d skipped, %d merged, %d replaced)\n",
+			result.Imported, result.Skipped, result.Merged, result.Replaced)
+		if result.Embedded > 0 {
+			fmt.Printf("Embedded %d memories exported without embeddings\n", result.Embedded)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	return nil
+}
@@ -3,14 +3,38 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/shivavenkatesh/moneta/internal/chunking"
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/internal/redact"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	indexLanguage string
+	indexLanguage   string
+	indexSummaries  bool
+	indexExtensions []string
+	indexEmbedPath  bool
+	indexFollow     bool
+	indexEmbedCore  bool
+	indexInclude    []string
+	indexChunkSums  bool
+	indexDiff       bool
+	indexJSON       bool
+	indexStoreFiles bool
+	indexRedact     bool
+	indexSecretPats []string
+	indexChunkBy    string
+	indexMaxTokens  int
+	indexOutline    bool
+	indexForce      bool
+	indexResume     bool
 )
 
 var indexCmd = &cobra.Command{
@@ -24,19 +48,113 @@ Supported file types:
   Code: .go, .py, .js, .ts, .rs, .java, .c, .cpp, .rb, .php, .swift
   Text: .md, .txt, .yaml, .json, .toml, .sql, .sh
 
+Additional extensions can be indexed with --ext. Use ext=language to
+also choose the language used for chunking (e.g. --ext .vue=javascript).
+
+--outline keeps only the signature and doc comment of each function,
+method, and class in Go, Python, JavaScript, and TypeScript files, which
+is enough to navigate a large dependency at a fraction of the storage and
+embedding cost. Files in other languages are indexed in full.
+
+Chunks hold up to 1500 characters. With --chunk-by tokens they are also
+split to stay under --max-tokens, estimated at 1.3 tokens per word, so
+dense code fits the embedding model's context.
+
+Reindexing skips files whose content hasn't changed since they were last
+indexed, and replaces the chunks of files that have. --force reindexes
+every file, e.g. after changing how files are chunked or embedded.
+
+Indexing a directory keeps a journal of finished files in the data
+directory until it completes. If it is interrupted, --resume picks up where
+it stopped, skipping the files already done even with --force.
+
 Ignored by default:
   .git, node_modules, vendor, __pycache__, .venv
 
 Examples:
   moneta index ./src
   moneta index ./README.md
-  moneta index . --project myapp`,
+  moneta index . --project myapp
+  moneta index ./src --summaries
+  moneta index ./api --ext .proto --ext .tf
+  moneta index . --follow-symlinks
+  moneta index . --include '*.go' --include 'docs/*.md'
+  moneta index ./src --diff
+  moneta index ./src --force --embed-path
+  moneta index ./src --force --embed-path --resume
+  moneta index ./src --chunk-by tokens --max-tokens 256
+  moneta index ~/go/pkg/mod/github.com/spf13/cobra@v1.8.0 --outline -p cobra
+  moneta index . --redact-secrets --secret-pattern 'stripe=sk_live_[0-9a-zA-Z]{24}'`,
 	Args: cobra.ExactArgs(1),
 	RunE: runIndex,
 }
 
 func init() {
 	indexCmd.Flags().StringVarP(&indexLanguage, "lang", "l", "", "Override language detection")
+	indexCmd.Flags().BoolVar(&indexSummaries, "summaries", false, "Add a summary memory per directory (extra embeddings)")
+	indexCmd.Flags().StringArrayVar(&indexExtensions, "ext", nil, "Additional file extension to index, optionally ext=language (repeatable)")
+	indexCmd.Flags().BoolVar(&indexEmbedPath, "embed-path", false, "Include the file path and chunk name in the embedded text")
+	indexCmd.Flags().BoolVar(&indexEmbedCore, "embed-core-only", false, "Embed chunks without the overlap repeated from the previous chunk")
+	indexCmd.Flags().StringArrayVar(&indexInclude, "include", nil, "Only index files matching this glob, by name or relative path (repeatable)")
+	indexCmd.Flags().BoolVar(&indexChunkSums, "chunk-summaries", false, "Generate and embed a one-line summary per chunk (one LLM call per chunk)")
+	indexCmd.Flags().BoolVar(&indexDiff, "diff", false, "Preview added, removed, and changed chunks without indexing")
+	indexCmd.Flags().BoolVar(&indexJSON, "json", false, "Output the --diff preview as JSON")
+	indexCmd.Flags().BoolVar(&indexStoreFiles, "store-files", false, "Keep a copy of each indexed file (up to 1 MiB) in the database")
+	indexCmd.Flags().BoolVar(&indexFollow, "follow-symlinks", false, "Index symlinked directories (each directory is visited once)")
+	indexCmd.Flags().BoolVar(&indexRedact, "redact-secrets", false, "Replace API keys, tokens, and other secrets before embedding and storing")
+	indexCmd.Flags().StringArrayVar(&indexSecretPats, "secret-pattern", nil, "Extra secret pattern for --redact-secrets, as name=regexp (repeatable)")
+	indexCmd.Flags().BoolVar(&indexOutline, "outline", false, "Index only the signatures and doc comments of functions, methods, and classes")
+	indexCmd.Flags().StringVar(&indexChunkBy, "chunk-by", "chars", "Limit chunks by chars, or by chars and tokens (tokens)")
+	indexCmd.Flags().BoolVar(&indexForce, "force", false, "Reindex every file, including ones unchanged since they were last indexed")
+	indexCmd.Flags().BoolVar(&indexResume, "resume", false, "Skip files an interrupted index of this directory already finished")
+	indexCmd.Flags().IntVar(&indexMaxTokens, "max-tokens", chunking.DefaultMaxTokens, "Token limit per chunk with --chunk-by tokens")
+}
+
+// newRedactor builds the --redact-secrets detectors: the defaults plus any
+// --secret-pattern
+func newRedactor() (*redact.Redactor, error) {
+	cfg := redact.DefaultConfig()
+	cfg.Patterns = append([]redact.Pattern(nil), cfg.Patterns...)
+	for _, spec := range indexSecretPats {
+		p, err := redact.ParsePattern(spec)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Patterns = append(cfg.Patterns, p)
+	}
+	return redact.New(cfg)
+}
+
+// parseExtensions splits --ext values into extensions and language overrides
+func parseExtensions(specs []string) ([]string, map[string]string) {
+	var exts []string
+	langs := make(map[string]string)
+	for _, spec := range specs {
+		ext, lang, _ := strings.Cut(spec, "=")
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		exts = append(exts, ext)
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs[ext] = lang
+		}
+	}
+	return exts, langs
+}
+
+// indexProgressPrinter returns a callback that redraws a progress line on
+// stderr, or nil when stderr is not a terminal or --quiet is set
+func indexProgressPrinter() func(types.IndexProgress) {
+	if quiet || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return func(p types.IndexProgress) {
+		fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %d chunks  %s", p.FilesDone, p.FilesTotal, p.Chunks, filepath.Base(p.Path))
+		if p.FilesDone == p.FilesTotal {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
 }
 
 func runIndex(cmd *cobra.Command, args []string) error {
@@ -49,22 +167,73 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	}
 	defer svc.Close()
 
-	fmt.Printf("Indexing %s...\n", path)
-	start := time.Now()
-
 	req := types.IndexRequest{
-		Path:     path,
-		Project:  getProject(),
-		Language: indexLanguage,
+		Path:              path,
+		Project:           getProject(),
+		Language:          indexLanguage,
+		GenerateSummaries: indexSummaries,
+		FollowSymlinks:    indexFollow,
+		SummarizeChunks:   indexChunkSums,
+		OutlineOnly:       indexOutline,
+		Force:             indexForce,
+		Resume:            indexResume,
+	}
+
+	if indexDiff {
+		return runIndexDiff(ctx, svc, req)
+	}
+	if indexJSON {
+		return fmt.Errorf("--json requires --diff")
 	}
 
-	count, err := svc.Index(ctx, req)
+	infof("Indexing %s...\n", path)
+	start := time.Now()
+
+	var last types.IndexProgress
+	printer := indexProgressPrinter()
+	count, err := svc.IndexWithProgress(ctx, req, func(p types.IndexProgress) {
+		last = p
+		if printer != nil {
+			printer(p)
+		}
+	})
 	if err != nil {
 		return fmt.Errorf("indexing failed: %w", err)
 	}
 
 	elapsed := time.Since(start)
-	fmt.Printf("Indexed %d chunks in %s\n", count, elapsed.Round(time.Millisecond))
+	if last.FilesSkipped > 0 {
+		fmt.Printf("Indexed %d chunks in %s (%d files skipped)\n", count, elapsed.Round(time.Millisecond), last.FilesSkipped)
+	} else {
+		fmt.Printf("Indexed %d chunks in %s\n", count, elapsed.Round(time.Millisecond))
+	}
 
 	return nil
 }
+
+// runIndexDiff prints what indexing req would change
+func runIndexDiff(ctx context.Context, svc memory.Service, req types.IndexRequest) error {
+	diff, err := svc.IndexDiff(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", req.Path, err)
+	}
+	if indexJSON {
+		return printJSON(diff)
+	}
+
+	fmt.Printf("%d added, %d removed, %d changed, %d unchanged\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged)
+	for _, group := range []struct {
+		mark    string
+		changes []types.ChunkChange
+	}{{"+", diff.Added}, {"-", diff.Removed}, {"~", diff.Changed}} {
+		for _, c := range group.changes {
+			line := fmt.Sprintf("%s %s:%d-%d", group.mark, c.FilePath, c.StartLine, c.EndLine)
+			if c.Name != "" {
+				line += " " + c.Name
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
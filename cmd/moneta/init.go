@@ -1,26 +1,363 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/shivavenkatesh/moneta/internal/chunking"
 	"github.com/shivavenkatesh/moneta/internal/embeddings"
+	"github.com/shivavenkatesh/moneta/internal/expand"
 	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/internal/redact"
+	"github.com/shivavenkatesh/moneta/internal/rerank"
+	"github.com/shivavenkatesh/moneta/internal/store"
 	"github.com/shivavenkatesh/moneta/internal/store/sqlite"
+	"github.com/shivavenkatesh/moneta/internal/summarize"
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
 )
 
-// initService creates and initializes the memory service
-func initService() (memory.Service, error) {
-	// Determine data directory
-	dir := dataDir
-	if dir == "" {
-		home, err := os.UserHomeDir()
+// resolveDataDir returns --data-dir or the default ~/.moneta
+func resolveDataDir() (string, error) {
+	if dataDir != "" {
+		return dataDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".moneta"), nil
+}
+
+// embedderClient is an embedder that can check its own health
+type embedderClient interface {
+	embeddings.Embedder
+	Ping(ctx context.Context) error
+}
+
+// providerEnv supplies --provider when the flag is not given, so a fallback
+// chain can be set once for every command
+const providerEnv = "MONETA_PROVIDER"
+
+// providers returns the provider list from --provider, then
+// $MONETA_PROVIDER, then ollama
+func providers() string {
+	if provider != "" {
+		return provider
+	}
+	if env := os.Getenv(providerEnv); env != "" {
+		return env
+	}
+	return "ollama"
+}
+
+// newEmbedder creates the embedding client selected by --provider or
+// $MONETA_PROVIDER. A comma-separated list chains providers in order of preference, falling
+// back to the next while one is unavailable. It rejects embeddings that
+// aren't dims long; zero accepts the first length the model returns.
+// stored is the prompt template the store was embedded with, used unless
+// EMBEDDING_TEMPLATE overrides it; empty uses the model's default.
+func newEmbedder(dims int, stored string) (embedderClient, error) {
+	tmpl, err := promptTemplate(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	names := strings.Split(providers(), ",")
+	if len(names) == 1 {
+		return newProvider(strings.TrimSpace(names[0]), dims, tmpl)
+	}
+
+	chain := make([]embeddings.Embedder, 0, len(names))
+	closeAll := func() {
+		for _, e := range chain {
+			e.Close()
+		}
+	}
+	for _, name := range names {
+		client, err := newProvider(strings.TrimSpace(name), dims, tmpl)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+			closeAll()
+			return nil, err
 		}
-		dir = filepath.Join(home, ".moneta")
+		chain = append(chain, client)
+	}
+	fallback, err := embeddings.NewFallbackEmbedder(chain, 0)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	return fallback, nil
+}
+
+// defaultTemplate is recorded in stores embedded with each model's default
+// prompt template
+const defaultTemplate = "default"
+
+// configuredTemplate names the prompt template new stores are embedded
+// with: EMBEDDING_TEMPLATE, or the model's default
+func configuredTemplate() string {
+	if src := os.Getenv("EMBEDDING_TEMPLATE"); src != "" {
+		return src
+	}
+	return defaultTemplate
+}
+
+// promptTemplate returns the prompt template to embed with, given the one
+// the store was embedded with. Nil uses the model's default.
+func promptTemplate(stored string) (*embeddings.PromptTemplate, error) {
+	if src := os.Getenv("EMBEDDING_TEMPLATE"); src != "" {
+		tmpl, err := embeddings.NewPromptTemplate(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMBEDDING_TEMPLATE: %w", err)
+		}
+		return tmpl, nil
+	}
+	switch stored {
+	case "", defaultTemplate:
+		return nil, nil
+	case sqlite.NoPromptTemplate:
+		// Stores from before templates were recorded embedded text unchanged,
+		// so queries must too
+		return embeddings.NewPromptTemplate(embeddings.PlainTemplate)
+	}
+	tmpl, err := embeddings.NewPromptTemplate(stored)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template recorded in the store: %w", err)
+	}
+	return tmpl, nil
+}
+
+// newProvider creates the embedding client for one provider name
+func newProvider(name string, dims int, tmpl *embeddings.PromptTemplate) (embedderClient, error) {
+	// EMBEDDING_MAX_TOKENS truncates texts the model couldn't take whole
+	var maxTokens int
+	var tok tokenizer.Tokenizer
+	if v := os.Getenv("EMBEDDING_MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid EMBEDDING_MAX_TOKENS %q (want a positive number)", v)
+		}
+		if tok, err = loadTokenizer(); err != nil {
+			return nil, err
+		}
+		maxTokens = n
+	}
+
+	switch name {
+	case "", "ollama":
+		// EMBEDDING_CONCURRENCY sets how many texts are embedded at once
+		var concurrency int
+		if v := os.Getenv("EMBEDDING_CONCURRENCY"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid EMBEDDING_CONCURRENCY %q (want a positive number)", v)
+			}
+			concurrency = n
+		}
+		cfg := embeddings.OllamaConfig{
+			Dimensions:     dims,
+			CacheSize:      1000,
+			DisableCache:   noCache,
+			PromptTemplate: tmpl,
+			Concurrency:    concurrency,
+			PersistCache:   persistCache,
+			MaxTokens:      maxTokens,
+			Tokenizer:      tok,
+			Logf:           infof,
+		}
+		if persistCache {
+			dir, err := resolveDataDir()
+			if err != nil {
+				return nil, err
+			}
+			cfg.CacheDir = filepath.Join(dir, "cache")
+		}
+		return embeddings.NewOllamaClient(cfg), nil
+	case "llamacpp":
+		return embeddings.NewLlamaCppClient(embeddings.LlamaCppConfig{
+			Dimensions:     dims,
+			CacheSize:      1000,
+			DisableCache:   noCache,
+			PromptTemplate: tmpl,
+			MaxTokens:      maxTokens,
+			Tokenizer:      tok,
+			Logf:           infof,
+		}), nil
+	case "onnx":
+		cfg := embeddings.DefaultONNXConfig()
+		if dims != 0 {
+			cfg.Dimensions = dims
+		}
+		cfg.DisableCache = noCache
+		client, err := embeddings.NewONNXClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (want ollama, llamacpp, or onnx)", name)
+	}
+}
+
+// loadTokenizer returns the tokenizer.json tokenizer named by
+// EMBEDDING_TOKENIZER, or the heuristic estimate when it is unset
+func loadTokenizer() (tokenizer.Tokenizer, error) {
+	path := os.Getenv("EMBEDDING_TOKENIZER")
+	if path == "" {
+		return tokenizer.Heuristic{}, nil
+	}
+	tok, err := tokenizer.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMBEDDING_TOKENIZER: %w", err)
+	}
+	return tok, nil
+}
+
+// parseDimensions returns --dimensions, or 0 for auto
+func parseDimensions() (int, error) {
+	if dims == "" || dims == "auto" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(dims)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid --dimensions %q (want auto or a positive number)", dims)
+	}
+	return n, nil
+}
+
+// dataStore is the store behind the service: one shared database, or a
+// database file per project
+type dataStore interface {
+	store.Store
+	Dimensions() int
+	PromptTemplate() string
+	VectorBackend() string
+	VectorBackendError() error
+}
+
+// perProjectEnv supplies --per-project-db when the flag is not given
+const perProjectEnv = "MONETA_PER_PROJECT_DB"
+
+// usePerProjectDB reports whether --per-project-db or $MONETA_PER_PROJECT_DB
+// asks for a database file per project
+func usePerProjectDB() bool {
+	if perProjectDB {
+		return true
+	}
+	on, _ := strconv.ParseBool(os.Getenv(perProjectEnv))
+	return on
+}
+
+// storePath returns where dir keeps memories, and whether that is a
+// directory of per-project files
+func storePath(dir string) (string, bool) {
+	if usePerProjectDB() {
+		return filepath.Join(dir, "projects"), true
+	}
+	return filepath.Join(dir, "moneta.db"), false
+}
+
+// checkStoreLayout refuses to open a new shared database in front of
+// per-project files, and warns when the layout not in use holds memories
+// of its own
+func checkStoreLayout(dir string) error {
+	shared, projects := filepath.Join(dir, "moneta.db"), filepath.Join(dir, "projects")
+	_, err := os.Stat(shared)
+	hasShared := err == nil
+	info, err := os.Stat(projects)
+	hasProjects := err == nil && info.IsDir()
+
+	switch {
+	case usePerProjectDB() && hasShared:
+		fmt.Fprintf(os.Stderr, "Warning: %s is not used with --per-project-db; its memories won't be listed or searched\n", shared)
+	case !usePerProjectDB() && hasProjects && !hasShared:
+		return fmt.Errorf("%s holds per-project databases; pass --per-project-db or set %s=1 to use them", projects, perProjectEnv)
+	case !usePerProjectDB() && hasProjects:
+		fmt.Fprintf(os.Stderr, "Warning: %s is not used without --per-project-db; its memories won't be listed or searched\n", projects)
+	}
+	return nil
+}
+
+// newStore opens cfg.Path as a shared database, or as a directory of
+// project files when perProject is set
+func newStore(cfg sqlite.Config, perProject bool) (dataStore, error) {
+	if perProject {
+		s, err := sqlite.NewProjectStores(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	s, err := sqlite.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// openStore opens the store at path. With --dimensions auto and no
+// embeddings stored yet, the embedder is asked for its dimensions first.
+func openStore(path string, perProject bool, topk sqlite.TopKConfig) (dataStore, error) {
+	n, err := parseDimensions()
+	if err != nil {
+		return nil, err
+	}
+	cfg := sqlite.Config{
+		Path:           path,
+		Dimensions:     n,
+		TopK:           topk,
+		VecExtension:   os.Getenv("SQLITE_VEC_PATH"),
+		PromptTemplate: configuredTemplate(),
+	}
+
+	store, err := newStore(cfg, perProject)
+	if errors.Is(err, sqlite.ErrUnknownDimensions) {
+		if cfg.Dimensions, err = probeDimensions(); err != nil {
+			return nil, err
+		}
+		store, err = newStore(cfg, perProject)
+	}
+	if errors.Is(err, sqlite.ErrDimensionMismatch) {
+		return nil, fmt.Errorf("%w; run 'moneta migrate' to re-embed with the current model", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+
+	if err := store.VectorBackendError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load sqlite-vec, searching without it: %v\n", err)
+	}
+	if src := os.Getenv("EMBEDDING_TEMPLATE"); src != "" && store.PromptTemplate() != src {
+		fmt.Fprintf(os.Stderr, "Warning: EMBEDDING_TEMPLATE differs from the prompt template this store was embedded with (%s); searches will match poorly until its memories are re-embedded\n", store.PromptTemplate())
+	}
+	return store, nil
+}
+
+// probeDimensions embeds a probe string to learn the embedder's dimensions
+func probeDimensions() (int, error) {
+	client, err := newEmbedder(0, "")
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	embedding, err := client.Embed(context.Background(), "dimension probe")
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect embedding dimensions (or set --dimensions): %w", err)
+	}
+	return len(embedding), nil
+}
+
+// initService creates and initializes the memory service
+func initService() (memory.Service, error) {
+	dir, err := resolveDataDir()
+	if err != nil {
+		return nil, err
 	}
 
 	// Ensure directory exists
@@ -28,39 +365,113 @@ func initService() (memory.Service, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
+	// The synonym expander is free until a search asks for expansion
+	var expander expand.Expander
+	switch searchExpandWith {
+	case "", "synonyms":
+		expander = expand.NewSynonymExpander(0)
+	case "llm":
+		expander = expand.NewOllamaExpander(expand.DefaultOllamaConfig())
+	default:
+		return nil, fmt.Errorf("unknown expansion strategy %q (want synonyms or llm)", searchExpandWith)
+	}
+
+	var redactor *redact.Redactor
+	if indexRedact {
+		if redactor, err = newRedactor(); err != nil {
+			return nil, err
+		}
+	}
+
+	topk, err := loadTopKConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds, err := typeThresholds()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize store
-	dbPath := filepath.Join(dir, "moneta.db")
-	store, err := sqlite.New(sqlite.Config{
-		Path:       dbPath,
-		Dimensions: 768, // nomic-embed-text dimensions
-	})
+	if err := checkStoreLayout(dir); err != nil {
+		return nil, err
+	}
+	dbPath, perProject := storePath(dir)
+	store, err := openStore(dbPath, perProject, topk)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize store: %w", err)
+		return nil, err
+	}
+
+	client, err := newEmbedder(store.Dimensions(), store.PromptTemplate())
+	if err != nil {
+		store.Close()
+		return nil, err
 	}
 
-	// Initialize embedder
-	embedder := embeddings.NewOllamaClient(embeddings.OllamaConfig{
-		Dimensions: 768,
-		CacheSize:  1000,
-	})
+	// Fail fast instead of retrying every chunk while the embedder is down
+	embedder := embeddings.NewCircuitBreaker(client, embeddings.DefaultBreakerConfig())
 
 	// Initialize chunker
-	chunker := chunking.NewCodeChunker(1500, 100)
+	extensions, languages := parseExtensions(indexExtensions)
+	registry := chunking.DefaultRegistry(1500, 100)
+	registry.SetLanguageOverrides(languages)
+	var chunker chunking.Chunker = registry
+	switch indexChunkBy {
+	case "", "chars":
+	case "tokens":
+		// Code is dense, so a chunk under 1500 characters can still overflow
+		// the embedding model's context
+		tok, err := loadTokenizer()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		chunker = chunking.NewTokenChunker(registry, indexMaxTokens, tok)
+	default:
+		store.Close()
+		return nil, fmt.Errorf("unknown --chunk-by %q (want chars or tokens)", indexChunkBy)
+	}
 
 	// Create service
 	cfg := memory.Config{
-		DataDir:                dir,
-		EmbedBatchSize:         50,
-		IndexIgnore:            []string{".git", "node_modules", "vendor", "__pycache__", ".venv", "dist", "build"},
-		DefaultSearchLimit:     10,
-		DefaultSearchThreshold: 0.5,
+		DataDir:            dir,
+		EmbedBatchSize:     50,
+		IndexIgnore:        []string{".git", "node_modules", "vendor", "__pycache__", ".venv", "dist", "build"},
+		IndexInclude:       indexInclude,
+		IndexExtensions:    extensions,
+		EmbedWithPath:      indexEmbedPath,
+		EmbedCoreOnly:      indexEmbedCore,
+		DefaultSearchLimit: 10,
+		ScoreScale:         memory.ScoreScale(searchScoreScale),
+		ThresholdByType:    thresholds,
+		StoreFileContent:   indexStoreFiles,
+		Expander:           expander,
+		SearchCacheSize:    serveCache,
+		RedactSecrets:      indexRedact,
+		Redactor:           redactor,
+	}
+
+	if searchRerank || searchRerankModel != "" {
+		cfg.Reranker = rerank.NewOllamaReranker(rerank.OllamaConfig{Model: searchRerankModel})
+	}
+
+	if indexChunkSums {
+		cfg.Summarizer = summarize.NewOllamaSummarizer(summarize.DefaultOllamaConfig())
 	}
 
 	svc := memory.NewService(store, embedder, chunker, cfg)
 
 	if verbose {
-		fmt.Printf("Data directory: %s\n", dir)
-		fmt.Printf("Database: %s\n", dbPath)
+		fmt.Fprintf(os.Stderr, "Data directory: %s\n", dir)
+		fmt.Fprintf(os.Stderr, "Database: %s\n", dbPath)
+		fmt.Fprintf(os.Stderr, "Vector search: %s\n", store.VectorBackend())
+		threshold, known := memory.DefaultThreshold(embedder.Model())
+		source := "recommended for " + embedder.Model()
+		if !known {
+			source = "fallback for unknown model " + embedder.Model()
+		}
+		fmt.Fprintf(os.Stderr, "Default threshold: %.2f cosine (%s)\n", threshold, source)
 	}
 
 	return svc, nil
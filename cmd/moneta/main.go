@@ -14,9 +14,15 @@ var (
 	Version = "dev"
 
 	// Global flags
-	dataDir string
-	project string
-	verbose bool
+	dataDir      string
+	project      string
+	verbose      bool
+	quiet        bool
+	noCache      bool
+	persistCache bool
+	perProjectDB bool
+	provider     string
+	dims         string
 )
 
 func main() {
@@ -26,6 +32,15 @@ func main() {
 	}
 }
 
+// infof prints a progress or status message to stderr unless --quiet is
+// set. Command results go to stdout so output stays pipeable.
+func infof(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "moneta",
 	Short: "Local-first code memory system",
@@ -55,13 +70,35 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "Data directory (default: ~/.moneta)")
 	rootCmd.PersistentFlags().StringVarP(&project, "project", "p", "", "Project name (default: current directory name)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress and status messages on stderr")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "", "Embedding provider: ollama, llamacpp, or onnx, or a comma-separated list to fall back through in order (default: $"+providerEnv+", or ollama)")
+	rootCmd.PersistentFlags().StringVar(&dims, "dimensions", "auto", "Embedding dimensions, or auto to ask the model when the store is empty")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Bypass the embedding cache (for debugging and benchmarking)")
+	rootCmd.PersistentFlags().BoolVar(&persistCache, "persist-cache", false, "Keep cached embeddings on disk under the data directory, so they survive restarts")
+	rootCmd.PersistentFlags().BoolVar(&perProjectDB, "per-project-db", false, "Keep each project in its own database file under the data directory's projects/ (default: $"+perProjectEnv+")")
 
 	// Add subcommands
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(indexCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(projectsCmd)
+	rootCmd.AddCommand(projectCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(retagCmd)
+	rootCmd.AddCommand(retypeCmd)
+	rootCmd.AddCommand(checkpointCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(migrateCmd)
 }
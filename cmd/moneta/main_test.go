@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/internal/store/sqlite"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// fakeOllama serves /api/embed with a fixed 768-dimension embedding per input
+func fakeOllama(t *testing.T) *httptest.Server {
+	return recordingOllama(t, nil)
+}
+
+// recordingOllama is fakeOllama, sending every input text to inputs
+func recordingOllama(t *testing.T, inputs chan<- string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input json.RawMessage `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var texts []string
+		if json.Unmarshal(req.Input, &texts) != nil {
+			var text string
+			json.Unmarshal(req.Input, &text)
+			texts = []string{text}
+		}
+		n := len(texts)
+		if inputs != nil {
+			for _, text := range texts {
+				inputs <- text
+			}
+		}
+
+		embedding := make([]float32, 768)
+		for i := range embedding {
+			embedding[i] = float32(i%7) + 1
+		}
+		embeddings := make([][]float32, n)
+		for i := range embeddings {
+			embeddings[i] = embedding
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// runCLI runs the root command with args and returns what it wrote to
+// stdout and stderr
+func runCLI(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+
+	capture := func(f **os.File) (func() string, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		orig := *f
+		*f = w
+		var buf bytes.Buffer
+		done := make(chan struct{})
+		go func() {
+			io.Copy(&buf, r)
+			close(done)
+		}()
+		return func() string {
+			w.Close()
+			<-done
+			*f = orig
+			return buf.String()
+		}, nil
+	}
+
+	stopOut, err := capture(&os.Stdout)
+	if err != nil {
+		t.Fatalf("failed to capture stdout: %v", err)
+	}
+	stopErr, err := capture(&os.Stderr)
+	if err != nil {
+		stopOut()
+		t.Fatalf("failed to capture stderr: %v", err)
+	}
+
+	rootCmd.SetArgs(args)
+	execErr := rootCmd.Execute()
+	stderr := stopErr()
+	stdout := stopOut()
+	if execErr != nil {
+		t.Fatalf("moneta %v failed: %v\nstderr: %s", args, execErr, stderr)
+	}
+	return stdout, stderr
+}
+
+func TestSearch_JSONQuietWritesOnlyJSON(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	dir := t.TempDir()
+
+	runCLI(t, "add", "We use JWT tokens for auth", "--data-dir", dir, "-p", "app")
+
+	// --verbose adds diagnostics, which must stay off stdout
+	stdout, _ := runCLI(t, "search", "auth tokens", "--data-dir", dir, "-p", "app", "--json", "--quiet", "--verbose")
+
+	if !json.Valid([]byte(stdout)) {
+		t.Fatalf("expected only JSON on stdout, got:\n%s", stdout)
+	}
+	var resp types.SearchResponse
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("failed to decode search response: %v", err)
+	}
+	if len(resp.Results) != 1 {
+		t.Errorf("expected 1 result, got %d", len(resp.Results))
+	}
+}
+
+func TestDimensions_AutoProbesNewStore(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	dir := t.TempDir()
+
+	runCLI(t, "add", "Retries back off exponentially", "--data-dir", dir, "-p", "app", "--dimensions", "auto")
+
+	st, err := sqlite.New(sqlite.Config{Path: filepath.Join(dir, "moneta.db")})
+	if err != nil {
+		t.Fatalf("expected the probed dimensions to be saved, got %v", err)
+	}
+	defer st.Close()
+	if st.Dimensions() != 768 {
+		t.Errorf("expected 768 dimensions from the model, got %d", st.Dimensions())
+	}
+}
+
+func TestSearch_OldStoreKeepsUnprefixedQueries(t *testing.T) {
+	inputs := make(chan string, 100)
+	t.Setenv("OLLAMA_HOST", recordingOllama(t, inputs).URL)
+	t.Setenv("EMBEDDING_TEMPLATE", "")
+	dir := t.TempDir()
+
+	// A store indexed before templates were recorded, with text embedded as is
+	st, err := sqlite.New(sqlite.Config{Path: filepath.Join(dir, "moneta.db"), Dimensions: 768})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	st.Add(context.Background(), &types.Memory{ID: "m", Content: "Retries back off exponentially", Project: "app", Type: types.TypeContext, Embedding: make([]float32, 768)})
+	st.Close()
+
+	runCLI(t, "search", "retry policy", "--data-dir", dir, "-p", "app", "--quiet")
+	if got := <-inputs; got != "retry policy" {
+		t.Errorf("expected the query embedded without a prefix, got %q", got)
+	}
+
+	// A new store uses the model's prefixes
+	dir = t.TempDir()
+	runCLI(t, "add", "Retries back off exponentially", "--data-dir", dir, "-p", "app", "--quiet")
+	for len(inputs) > 0 {
+		<-inputs
+	}
+	runCLI(t, "search", "retry policy", "--data-dir", dir, "-p", "app", "--quiet")
+	if got := <-inputs; got != "search_query: retry policy" {
+		t.Errorf("expected the query embedded with nomic's prefix, got %q", got)
+	}
+}
+
+func TestMigrate_SwitchesDimensions(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "moneta.db")
+
+	st, err := sqlite.New(sqlite.Config{Path: path, Dimensions: 16})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	st.Add(context.Background(), &types.Memory{ID: "m", Content: "Retries back off exponentially", Project: "app", Type: types.TypeContext, Embedding: make([]float32, 16)})
+	st.Close()
+
+	stdout, _ := runCLI(t, "migrate", "--data-dir", dir, "--dimensions", "auto", "--quiet")
+	if !strings.Contains(stdout, "Migrated 1 memories to 768 dimensions") {
+		t.Errorf("expected a migration summary, got %q", stdout)
+	}
+
+	st, err = sqlite.New(sqlite.Config{Path: path, Dimensions: 768})
+	if err != nil {
+		t.Fatalf("expected the store to open at the model's dimensions, got %v", err)
+	}
+	st.Close()
+}
+
+func TestUpdate_KeepsIDAndCreatedAt(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	dir := t.TempDir()
+
+	// Flags persist between runs, so reset --verbose left by earlier tests
+	stdout, _ := runCLI(t, "add", "Retries back off linearly", "--data-dir", dir, "-p", "app", "--verbose=false")
+	id := strings.TrimSpace(strings.TrimPrefix(stdout, "Added:"))
+
+	st, err := sqlite.New(sqlite.Config{Path: filepath.Join(dir, "moneta.db")})
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	before, err := st.Get(context.Background(), id)
+	st.Close()
+	if err != nil {
+		t.Fatalf("expected memory %q, got %v", id, err)
+	}
+
+	stdout, _ = runCLI(t, "update", id, "--data-dir", dir, "--content", "Retries back off exponentially", "--type", "gotcha", "--meta", "owner=infra", "--verbose=false")
+	if !strings.Contains(stdout, "Updated: "+id) {
+		t.Errorf("expected an update confirmation, got %q", stdout)
+	}
+
+	st, err = sqlite.New(sqlite.Config{Path: filepath.Join(dir, "moneta.db")})
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+	after, err := st.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("expected the memory to keep its ID, got %v", err)
+	}
+	if after.Content != "Retries back off exponentially" || after.Type != types.TypeGotcha || after.Metadata["owner"] != "infra" {
+		t.Errorf("expected content, type, and metadata updated, got %+v", after)
+	}
+	if !after.CreatedAt.Equal(before.CreatedAt) || !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Errorf("expected created_at kept and updated_at bumped, got %v/%v -> %v/%v",
+			before.CreatedAt, before.UpdatedAt, after.CreatedAt, after.UpdatedAt)
+	}
+}
+
+// slowSearcher takes delay to embed a query and delay to search with it
+type slowSearcher struct {
+	memory.Service
+	delay time.Duration
+}
+
+func (s slowSearcher) EmbedQuery(ctx context.Context, req types.SearchRequest) ([]float32, error) {
+	time.Sleep(s.delay)
+	return []float32{float32(len(req.Query))}, nil
+}
+
+func (s slowSearcher) SearchEmbedded(ctx context.Context, req types.SearchRequest, embedding []float32) (*types.SearchResponse, error) {
+	time.Sleep(s.delay)
+	if embedding == nil || int(embedding[0]) != len(req.Query) {
+		return nil, fmt.Errorf("search for %q got the wrong embedding", req.Query)
+	}
+	return &types.SearchResponse{Total: len(req.Query)}, nil
+}
+
+func TestSearchBatch_OverlapsEmbedding(t *testing.T) {
+	const n, delay = 8, 20 * time.Millisecond
+	var in strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&in, "%s\n", strings.Repeat("q", i+1))
+	}
+	in.WriteString(`{"query": ""}` + "\n")
+
+	var out bytes.Buffer
+	start := time.Now()
+	err := searchBatchQueries(context.Background(), slowSearcher{delay: delay}, types.SearchRequest{Project: "p"}, strings.NewReader(in.String()), &out)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != n+1 {
+		t.Fatalf("expected %d results, got %d", n+1, len(lines))
+	}
+	for i, line := range lines[:n] {
+		var result batchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("invalid result %q: %v", line, err)
+		}
+		if result.Error != "" || result.Total != i+1 {
+			t.Errorf("expected result %d in input order, got %s", i, line)
+		}
+	}
+	if !strings.Contains(lines[n], `"error":"query is required"`) {
+		t.Errorf("expected an error for the empty request, got %s", lines[n])
+	}
+
+	// Serially, each query costs both delays; pipelined, the embedding
+	// hides behind the previous search
+	if serial := n * 2 * delay; elapsed > serial*3/4 {
+		t.Errorf("expected embedding to overlap searching, took %v against %v serially", elapsed, serial)
+	}
+	t.Logf("%d queries in %v, %v serially", n, elapsed, n*2*delay)
+}
+
+func TestSearch_JSONLines(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	t.Cleanup(func() { searchJSONLines = false })
+	dir := t.TempDir()
+
+	runCLI(t, "add", "We use JWT tokens for auth", "--data-dir", dir, "-p", "app")
+	runCLI(t, "add", "Auth tokens expire after an hour", "--data-dir", dir, "-p", "app")
+
+	// Flags keep their values between runs, so clear --json explicitly
+	stdout, _ := runCLI(t, "search", "auth tokens", "--data-dir", dir, "-p", "app", "--json=false", "--json-lines", "--quiet")
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 results and a summary, got:\n%s", stdout)
+	}
+	for _, line := range lines[:2] {
+		var result types.SearchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil || result.Memory.ID == "" {
+			t.Errorf("expected a result, got %s", line)
+		}
+	}
+	var summary struct {
+		Summary *jsonLinesSummary `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil || summary.Summary == nil {
+		t.Fatalf("expected a summary line, got %s", lines[2])
+	}
+	if summary.Summary.Returned != 2 || summary.Summary.Total != 2 {
+		t.Errorf("expected 2 of 2 results in the summary, got %+v", summary.Summary)
+	}
+}
+
+func TestWalSize(t *testing.T) {
+	shared := t.TempDir()
+	writeSized := func(path string, n int) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, n), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSized(filepath.Join(shared, "moneta.db-wal"), 7)
+	if got := walSize(shared); got != 7 {
+		t.Errorf("expected the shared database's log, got %d bytes", got)
+	}
+
+	perProjectDB = true
+	t.Cleanup(func() { perProjectDB = false })
+	perProject := t.TempDir()
+	writeSized(filepath.Join(perProject, "projects", "a.db-wal"), 10)
+	writeSized(filepath.Join(perProject, "projects", "b.db-wal"), 5)
+	writeSized(filepath.Join(perProject, "projects", "a.db"), 100)
+	if got := walSize(perProject); got != 15 {
+		t.Errorf("expected every project's log summed, got %d bytes", got)
+	}
+}
+
+func TestCheckStoreLayout(t *testing.T) {
+	t.Setenv(perProjectEnv, "")
+	mkdir := func(path string) {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A projects directory alone must not be hidden behind a new moneta.db
+	dir := t.TempDir()
+	mkdir(filepath.Join(dir, "projects"))
+	if err := checkStoreLayout(dir); err == nil || !strings.Contains(err.Error(), "--per-project-db") {
+		t.Errorf("expected an error naming --per-project-db, got %v", err)
+	}
+	if path, perProject := storePath(dir); perProject || filepath.Base(path) != "moneta.db" {
+		t.Errorf("expected the shared database without the flag, got %s", path)
+	}
+
+	t.Setenv(perProjectEnv, "1")
+	if err := checkStoreLayout(dir); err != nil {
+		t.Errorf("expected $%s to select the projects directory, got %v", perProjectEnv, err)
+	}
+	if _, perProject := storePath(dir); !perProject {
+		t.Error("expected per-project mode from the environment")
+	}
+
+	// Both layouts present: open the one asked for
+	t.Setenv(perProjectEnv, "")
+	if err := os.WriteFile(filepath.Join(dir, "moneta.db"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkStoreLayout(dir); err != nil {
+		t.Errorf("expected only a warning with both layouts, got %v", err)
+	}
+}
+
+func TestQuiet_NoStderr(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", fakeOllama(t).URL)
+	dir := t.TempDir()
+
+	// Flags keep their values between runs of the root command
+	verbose = false
+	t.Cleanup(func() { persistCache, quiet = false, false })
+
+	// A file where the cache directory belongs makes the persistent cache
+	// fall back to memory, which is reported as status
+	if err := os.WriteFile(filepath.Join(dir, "cache"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := [][]string{
+		{"use", "app"},
+		{"add", "Retries back off exponentially", "--dimensions", "auto", "--persist-cache"},
+		{"list"},
+		{"use", "--clear"},
+	}
+	for _, args := range commands {
+		args = append(args, "--data-dir", dir, "--quiet")
+		if _, stderr := runCLI(t, args...); stderr != "" {
+			t.Errorf("moneta %v --quiet wrote to stderr: %q", args, stderr)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		spec       string
+		path       string
+		start, end int
+		ok         bool
+	}{
+		{"main.go:7", "main.go", 7, 7, true},
+		{"sim/step.go:10-35", "sim/step.go", 10, 35, true},
+		{"sim/step.go:12-12", "sim/step.go", 12, 12, true},
+		{`C:\src\main.go:3`, `C:\src\main.go`, 3, 3, true},
+		{"sim/step.go:35-10", "", 0, 0, false},
+		{"sim/step.go:10-", "", 0, 0, false},
+		{"main.go:0", "", 0, 0, false},
+		{"main.go:x", "", 0, 0, false},
+		{"main.go", "", 0, 0, false},
+		{":7", "", 0, 0, false},
+		{"", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		path, start, end, err := parseLocation(tt.spec)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseLocation(%q) error = %v, want ok %v", tt.spec, err, tt.ok)
+			continue
+		}
+		if path != tt.path || start != tt.start || end != tt.end {
+			t.Errorf("parseLocation(%q) = %q, %d, %d, want %q, %d, %d", tt.spec, path, start, end, tt.path, tt.start, tt.end)
+		}
+	}
+}
+
+func TestProviders(t *testing.T) {
+	t.Cleanup(func() { provider = "" })
+
+	provider = ""
+	t.Setenv(providerEnv, "")
+	if got := providers(); got != "ollama" {
+		t.Errorf("expected ollama by default, got %q", got)
+	}
+
+	t.Setenv(providerEnv, "llamacpp,ollama")
+	if got := providers(); got != "llamacpp,ollama" {
+		t.Errorf("expected the chain from $%s, got %q", providerEnv, got)
+	}
+
+	provider = "ollama"
+	if got := providers(); got != "ollama" {
+		t.Errorf("expected --provider to override $%s, got %q", providerEnv, got)
+	}
+}
+
+func TestTypeThresholds(t *testing.T) {
+	t.Cleanup(func() { searchTypeThresholds = nil })
+
+	tests := []struct {
+		flags []string
+		env   string
+		want  map[types.MemoryType]float32
+		ok    bool
+	}{
+		{nil, "", nil, true},
+		{[]string{"gotcha=0.6", "architecture=0.4"}, "", map[types.MemoryType]float32{"gotcha": 0.6, "architecture": 0.4}, true},
+		{nil, "gotcha=0.6, architecture=0.4", map[types.MemoryType]float32{"gotcha": 0.6, "architecture": 0.4}, true},
+		{[]string{"decision=0.5"}, "gotcha=0.6", map[types.MemoryType]float32{"decision": 0.5}, true},
+		{[]string{"gotcha=1"}, "", map[types.MemoryType]float32{"gotcha": 1}, true},
+		{[]string{"gotcha"}, "", nil, false},
+		{[]string{"=0.5"}, "", nil, false},
+		{[]string{"gotcha=0"}, "", nil, false},
+		{[]string{"gotcha=1.5"}, "", nil, false},
+		{nil, "gotcha=high", nil, false},
+	}
+
+	for _, tt := range tests {
+		searchTypeThresholds = tt.flags
+		t.Setenv(typeThresholdsEnv, tt.env)
+		got, err := typeThresholds()
+		if (err == nil) != tt.ok {
+			t.Errorf("flags %v, env %q: error = %v, want ok %v", tt.flags, tt.env, err, tt.ok)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("flags %v, env %q: got %v, want %v", tt.flags, tt.env, got, tt.want)
+			continue
+		}
+		for typ, want := range tt.want {
+			if got[typ] != want {
+				t.Errorf("flags %v, env %q: %s = %v, want %v", tt.flags, tt.env, typ, got[typ], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/shivavenkatesh/moneta/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpMaxLimit int
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run an MCP server over stdin and stdout",
+	Long: `Run a Model Context Protocol server on stdin and stdout, so MCP clients
+such as Claude Code can use moneta's memory tools (search_memory,
+add_memory, get_memory, delete_memory, list_projects) without an HTTP
+port. The client starts the command and talks JSON-RPC to it, one message
+per line. Diagnostics go to stderr.
+
+Add it to a client's MCP configuration, e.g.:
+
+  {
+    "mcpServers": {
+      "moneta": {"command": "moneta", "args": ["mcp"]}
+    }
+  }
+
+Examples:
+  moneta mcp
+  moneta mcp --data-dir ~/.moneta-work`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	mcpCmd.Flags().IntVar(&mcpMaxLimit, "max-limit", 200, "Maximum results a search_memory call may request")
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := mcp.New(svc, mcp.Config{Version: Version, MaxSearchLimit: mcpMaxLimit})
+	return srv.ServeStdio(ctx, os.Stdin, os.Stdout)
+}
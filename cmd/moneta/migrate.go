@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/shivavenkatesh/moneta/internal/store/sqlite"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Re-embed all memories after switching embedding models",
+	Long: `Re-embed every stored memory with the embedder selected by --provider and
+the environment, and switch the store to its dimensions. Run it after moving
+to a model of another size, when other commands fail with an embedding
+dimensions mismatch.
+
+The store stays usable while it runs. An interrupted migration picks up
+where it stopped. Chunk summary embeddings are dropped; reindex with
+--chunk-summaries to restore them.
+
+Examples:
+  EMBEDDING_MODEL=all-minilm moneta migrate
+  moneta migrate --dimensions 384`,
+	Args: cobra.NoArgs,
+	RunE: runMigrate,
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	dir, err := resolveDataDir()
+	if err != nil {
+		return err
+	}
+
+	if err := checkStoreLayout(dir); err != nil {
+		return err
+	}
+	path, perProject := storePath(dir)
+	if perProject {
+		return migrateProjects(path)
+	}
+
+	// Open at whatever the store holds; the mismatch is what's being fixed
+	store, err := sqlite.New(sqlite.Config{
+		Path:         path,
+		VecExtension: os.Getenv("SQLITE_VEC_PATH"),
+	})
+	if errors.Is(err, sqlite.ErrUnknownDimensions) {
+		infof("No embeddings stored, nothing to migrate\n")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize store: %w", err)
+	}
+	defer store.Close()
+
+	n, err := parseDimensions()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if n, err = probeDimensions(); err != nil {
+			return err
+		}
+	}
+	client, err := newEmbedder(n, "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	from := store.Dimensions()
+	infof("Re-embedding memories with %s (%d -> %d dimensions)...\n", client.Model(), from, n)
+	count := 0
+	err = store.Migrate(ctx, n, func(content string) ([]float32, error) {
+		count++
+		if count%100 == 0 {
+			infof("  %d memories re-embedded\n", count)
+		}
+		return client.Embed(ctx, content)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate after %d memories: %w", count, err)
+	}
+	if from != n {
+		// Every embedding was remade, with the current template
+		if err := store.SetPromptTemplate(configuredTemplate()); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Migrated %d memories to %d dimensions\n", count, n)
+	return nil
+}
+
+// migrateProjects re-embeds the project files in dir one at a time, each
+// from whatever dimensions it holds
+func migrateProjects(dir string) error {
+	n, err := parseDimensions()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if n, err = probeDimensions(); err != nil {
+			return err
+		}
+	}
+	client, err := newEmbedder(n, "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	infof("Re-embedding memories in %s with %s (%d dimensions)...\n", dir, client.Model(), n)
+	count := 0
+	cfg := sqlite.Config{Path: dir, VecExtension: os.Getenv("SQLITE_VEC_PATH"), PromptTemplate: configuredTemplate()}
+	err = sqlite.MigrateProjects(ctx, cfg, n, func(content string) ([]float32, error) {
+		count++
+		if count%100 == 0 {
+			infof("  %d memories re-embedded\n", count)
+		}
+		return client.Embed(ctx, content)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate after %d memories: %w", count, err)
+	}
+
+	fmt.Printf("Migrated %d memories to %d dimensions\n", count, n)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	previewChars int
+	previewFull  bool
+)
+
+// addPreviewFlags registers the content preview flags on a command
+func addPreviewFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&previewChars, "width", "w", 0, "Characters of content to show per result (default: terminal width)")
+	cmd.Flags().IntVar(&previewChars, "preview-chars", 0, "Alias for --width")
+	cmd.Flags().BoolVar(&previewFull, "full", false, "Show full, untruncated content")
+}
+
+// previewLimit returns how many characters of content to show, or -1 for
+// no truncation. indent is the space already used on the line; fallback is
+// used when stdout is not a terminal.
+func previewLimit(indent, fallback int) int {
+	if previewFull {
+		return -1
+	}
+	if previewChars > 0 {
+		return previewChars
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > indent+10 {
+		return width - indent
+	}
+	return fallback
+}
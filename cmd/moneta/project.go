@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectName string
+	projectDesc string
+)
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "List projects",
+	Long: `List all projects with their display names and memory counts.
+
+Examples:
+  moneta projects`,
+	RunE: runProjects,
+}
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage project settings",
+}
+
+var projectSetCmd = &cobra.Command{
+	Use:   "set <key>",
+	Short: "Set a project's display name and description",
+	Long: `Set a human-friendly display name and description for a project. The
+project key itself is unchanged and is still used for filtering.
+
+Pass an empty value to clear a field.
+
+Examples:
+  moneta project set my-service-v2-final --name "My Service"
+  moneta project set my-service-v2-final --desc "Billing API and workers"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProjectSet,
+}
+
+func init() {
+	projectSetCmd.Flags().StringVar(&projectName, "name", "", "Display name")
+	projectSetCmd.Flags().StringVar(&projectDesc, "desc", "", "Description")
+	projectCmd.AddCommand(projectSetCmd)
+}
+
+func runProjects(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	projects, err := svc.ListProjects(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	if len(projects) == 0 {
+		infof("No projects found\n")
+		return nil
+	}
+
+	for _, p := range projects {
+		if p.Name != "" {
+			fmt.Printf("%s (%s)  %d memories\n", p.Name, p.Key, p.MemoryCount)
+		} else {
+			fmt.Printf("%s  %d memories\n", p.Key, p.MemoryCount)
+		}
+		if p.Description != "" {
+			fmt.Printf("  %s\n", p.Description)
+		}
+	}
+
+	return nil
+}
+
+func runProjectSet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var name, desc *string
+	if cmd.Flags().Changed("name") {
+		name = &projectName
+	}
+	if cmd.Flags().Changed("desc") {
+		desc = &projectDesc
+	}
+	if name == nil && desc == nil {
+		return fmt.Errorf("specify --name and/or --desc")
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	if err := svc.SetProjectInfo(ctx, args[0], name, desc); err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	fmt.Printf("Updated project %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDuplicates bool
+	pruneSimilarity float32
+	pruneOlderThan  string
+	pruneYes        bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove duplicate and near-duplicate memories",
+	Long: `Remove low-value memories from the current project. The newest copy of
+each duplicate is always kept.
+
+Without --yes, prune only previews what would be removed.
+
+Examples:
+  moneta prune --duplicates
+  moneta prune --duplicates --yes
+  moneta prune --similar-above 0.98 --older-than 90d`,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneDuplicates, "duplicates", false, "Remove memories with identical content")
+	pruneCmd.Flags().Float32Var(&pruneSimilarity, "similar-above", 0, "Remove memories whose similarity to a newer one is at or above this (e.g. 0.98)")
+	pruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "Only prune memories not updated within this age (e.g. 90d, 12h)")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Delete without a dry-run preview")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if !pruneDuplicates && pruneSimilarity <= 0 {
+		return fmt.Errorf("specify --duplicates and/or --similar-above")
+	}
+	if pruneSimilarity > 1 {
+		return fmt.Errorf("--similar-above must be between 0 and 1")
+	}
+
+	olderThan, err := parseAge(pruneOlderThan)
+	if err != nil {
+		return err
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	result, err := svc.Prune(ctx, memory.PruneOptions{
+		Project:             getProject(),
+		Duplicates:          pruneDuplicates,
+		SimilarityThreshold: pruneSimilarity,
+		OlderThan:           olderThan,
+		DryRun:              !pruneYes,
+	})
+	if err != nil {
+		return fmt.Errorf("prune failed: %w", err)
+	}
+
+	total := len(result.Duplicates) + len(result.NearDuplicates)
+	if total == 0 {
+		infof("Nothing to prune\n")
+		return nil
+	}
+
+	if !pruneYes {
+		fmt.Printf("Would remove %d memories from project '%s':\n\n", total, getProject())
+		for _, m := range result.Duplicates {
+			fmt.Printf("  [duplicate] %s  %s\n", m.ID, truncate(m.Content, 60))
+		}
+		for _, m := range result.NearDuplicates {
+			fmt.Printf("  [similar]   %s  %s\n", m.ID, truncate(m.Content, 60))
+		}
+		infof("\nRe-run with --yes to delete\n")
+		return nil
+	}
+
+	fmt.Printf("Pruned %d memories (%d duplicates, %d near-duplicates)\n",
+		result.Deleted, len(result.Duplicates), len(result.NearDuplicates))
+	return nil
+}
+
+// parseAge parses a duration, additionally accepting a day suffix (e.g. "90d")
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age: %s", s)
+	}
+	return d, nil
+}
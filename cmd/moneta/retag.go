@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bulkPath   string
+	bulkType   string
+	bulkLang   string
+	bulkDryRun bool
+
+	retagAdd []string
+	retypeTo string
+)
+
+var retagCmd = &cobra.Command{
+	Use:   "retag",
+	Short: "Add tags to every matching memory",
+	Long: `Add tags to all memories in the current project that match the filters.
+Tags are stored in the "tags" metadata key as a comma-separated list.
+Content and embeddings are untouched, so nothing is re-embedded.
+
+Examples:
+  moneta retag --path docs/ --add-tag documentation
+  moneta retag --type gotcha --add-tag review --dry-run`,
+	RunE: runRetag,
+}
+
+var retypeCmd = &cobra.Command{
+	Use:   "retype",
+	Short: "Change the type of every matching memory",
+	Long: `Change the type of all memories in the current project that match the
+filters. Content and embeddings are untouched, so nothing is re-embedded.
+
+Examples:
+  moneta retype --from context --to architecture --path docs/adr/
+  moneta retype -p myapp --from context --to architecture --dry-run`,
+	RunE: runRetype,
+}
+
+// addBulkFilterFlags registers the filter flags shared by retag and retype
+func addBulkFilterFlags(cmd *cobra.Command, typeFlag, typeUsage string) {
+	cmd.Flags().StringVar(&bulkPath, "path", "", "Only memories whose file path starts with this prefix")
+	cmd.Flags().StringVar(&bulkType, typeFlag, "", typeUsage)
+	cmd.Flags().StringVarP(&bulkLang, "lang", "l", "", "Only memories in this language")
+	cmd.Flags().BoolVar(&bulkDryRun, "dry-run", false, "Show how many memories would change without updating")
+}
+
+func init() {
+	addBulkFilterFlags(retagCmd, "type", "Only memories of this type")
+	retagCmd.Flags().StringArrayVar(&retagAdd, "add-tag", nil, "Tag to add (repeatable)")
+
+	addBulkFilterFlags(retypeCmd, "from", "Only memories of this type")
+	retypeCmd.Flags().StringVar(&retypeTo, "to", "", "New memory type")
+}
+
+func runRetag(cmd *cobra.Command, args []string) error {
+	if len(retagAdd) == 0 {
+		return fmt.Errorf("specify at least one --add-tag")
+	}
+	return runBulkUpdate(store.UpdateChanges{AddTags: retagAdd, DryRun: bulkDryRun})
+}
+
+func runRetype(cmd *cobra.Command, args []string) error {
+	if retypeTo == "" {
+		return fmt.Errorf("--to is required")
+	}
+	return runBulkUpdate(store.UpdateChanges{Type: types.MemoryType(retypeTo), DryRun: bulkDryRun})
+}
+
+// runBulkUpdate applies changes to the memories selected by the filter flags
+func runBulkUpdate(changes store.UpdateChanges) error {
+	ctx := context.Background()
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	filter := store.UpdateFilter{
+		Project:        getProject(),
+		Type:           types.MemoryType(bulkType),
+		Language:       bulkLang,
+		FilePathPrefix: bulkPath,
+	}
+
+	n, err := svc.UpdateWhere(ctx, filter, changes)
+	if err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	if changes.DryRun {
+		fmt.Printf("Would update %d memories in project '%s'\n", n, filter.Project)
+		return nil
+	}
+	fmt.Printf("Updated %d memories\n", n)
+	return nil
+}
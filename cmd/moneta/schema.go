@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shivavenkatesh/moneta/internal/schema"
+	"github.com/shivavenkatesh/moneta/internal/server"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var schemaFormat string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the API request and response schemas",
+	Long: `Print JSON Schema definitions for the API request and response types, or
+an OpenAPI document for the endpoints served by 'moneta serve'. Schemas are
+generated from the Go types, so they always match the server.
+
+Examples:
+  moneta schema
+  moneta schema --format openapi > openapi.json`,
+	RunE: runSchema,
+}
+
+func init() {
+	schemaCmd.Flags().StringVar(&schemaFormat, "format", "json-schema", "Output format: json-schema or openapi")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	var doc schema.Object
+
+	switch schemaFormat {
+	case "json-schema":
+		g := server.NewSchemaGenerator("#/$defs/")
+		doc = schema.JSONSchema(g,
+			types.AddMemoryRequest{}, types.UpdateMemoryRequest{}, types.Memory{},
+			types.SearchRequest{}, types.SearchResponse{},
+			types.IndexRequest{}, types.IndexResponse{},
+			types.ListResponse{}, types.DeleteResponse{},
+			types.ProjectsResponse{}, types.StatsResponse{},
+			types.StatusResponse{}, types.ErrorResponse{},
+		)
+	case "openapi":
+		doc = server.OpenAPISpec()
+	default:
+		return fmt.Errorf("unknown format %q (use json-schema or openapi)", schemaFormat)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -2,18 +2,40 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
+	"github.com/shivavenkatesh/moneta/internal/memory"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 	"github.com/spf13/cobra"
 )
 
 var (
-	searchLimit     int
-	searchThreshold float32
-	searchType      string
-	searchJSON      bool
+	searchLimit          int
+	searchThreshold      float32
+	searchTypeThresholds []string
+	searchType           string
+	searchJSON           bool
+	searchJSONLines      bool
+	searchFiles          []string
+	searchExact          []string
+	searchBoosts         []string
+	searchPerFile        int
+	searchMeta           []string
+
+	searchRerank      bool
+	searchRerankModel string
+	searchStitch      bool
+	searchScoreScale  string
+	searchExpand      bool
+	searchExpandWith  string
+	searchContext     bool
+	searchMaxTokens   int
+	searchBatch       bool
 )
 
 var searchCmd = &cobra.Command{
@@ -26,25 +48,75 @@ Examples:
   moneta search "how do we handle authentication"
   moneta search "database patterns" --limit 5
   moneta search "error handling" --type gotcha
-  moneta search "API design" --threshold 0.7`,
-	Args: cobra.MinimumNArgs(1),
+  moneta search "API design" --threshold 0.7
+  moneta search "nil map" --type gotcha --type-threshold gotcha=0.6
+  moneta search "connection pool" --file internal/store/
+  moneta search "scan rows" --file-exact internal/store/sqlite/sqlite.go
+  moneta search "retry logic" --full
+  moneta search "auth flow" --boost decision=1.5 --boost gotcha=1.2
+  moneta search "retry logic" --max-per-file 2
+  moneta search "refund flow" --meta team=payments
+  moneta search "token refresh" --rerank --rerank-model qwen2.5:1.5b
+  moneta search "request handling" --stitch --full
+  moneta search "db" --expand
+  moneta search "retry budget" --expand --expand-with llm
+  moneta search "how are retries configured" --context --max-tokens 2000 | pbcopy
+  printf 'auth flow\nretry logic\n' | moneta search --batch --limit 3
+  moneta search "error handling" --json-lines | jq -c 'select(.memory) | .memory.file_path'
+
+With --batch, each line of stdin is a query searched with the other flags,
+or a JSON search request such as {"query": "auth", "type": "decision"}.
+Each result is written as one line of JSON with the query and its results
+(or an error), in input order. The next query is embedded while the
+current one is searched, so a long-running caller pays the embedding
+latency only once per query.
+
+With --json-lines, each result is written as one line of JSON as soon as it
+is ready, followed by a line {"summary": {...}} with the total and timing.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if searchBatch {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runSearch,
 }
 
 func init() {
 	searchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 10, "Maximum results to return")
-	searchCmd.Flags().Float32VarP(&searchThreshold, "threshold", "t", 0.5, "Minimum similarity threshold (0-1)")
+	searchCmd.Flags().Float32VarP(&searchThreshold, "threshold", "t", 0, "Minimum similarity, on the --score-scale range (default: recommended for the embedding model, else 0.5)")
 	searchCmd.Flags().StringVar(&searchType, "type", "", "Filter by memory type")
+	searchCmd.Flags().StringArrayVar(&searchTypeThresholds, "type-threshold", nil, "Default --threshold for searches filtered to a type, as type=similarity (repeatable; default: $"+typeThresholdsEnv+")")
 	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output as JSON")
+	searchCmd.Flags().BoolVar(&searchJSONLines, "json-lines", false, "Output one JSON result per line, then a summary line")
+	searchCmd.Flags().StringArrayVar(&searchFiles, "file", nil, "Limit to file paths with this prefix (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchExact, "file-exact", nil, "Limit to this exact file path (repeatable)")
+	searchCmd.Flags().StringArrayVar(&searchBoosts, "boost", nil, "Weight a memory type as type=multiplier (repeatable)")
+	searchCmd.Flags().IntVar(&searchPerFile, "max-per-file", 0, "Maximum results from any one file (0 = unlimited)")
+	searchCmd.Flags().StringArrayVar(&searchMeta, "meta", nil, "Only memories with this metadata, as key=value (repeatable)")
+	searchCmd.Flags().BoolVar(&searchRerank, "rerank", false, "Reorder candidates by scoring each with an Ollama model (slower)")
+	searchCmd.Flags().StringVar(&searchRerankModel, "rerank-model", "", "Ollama model used by --rerank (default $RERANK_MODEL or llama3.2:1b; implies --rerank)")
+	searchCmd.Flags().StringVar(&searchScoreScale, "score-scale", "cosine", "Similarity range: cosine (negatives shown as 0) or unit ((cosine+1)/2, always 0-1)")
+	searchCmd.Flags().BoolVar(&searchExpand, "expand", false, "Also search related phrasings of the query (one extra embedding each)")
+	searchCmd.Flags().StringVar(&searchExpandWith, "expand-with", "synonyms", "How --expand rephrases: synonyms (built-in code terms) or llm (Ollama paraphrases)")
+	searchCmd.Flags().BoolVar(&searchContext, "context", false, "Print results as markdown code blocks for pasting into a prompt")
+	searchCmd.Flags().IntVar(&searchMaxTokens, "max-tokens", 4000, "Token budget for --context (0 = unlimited)")
+	searchCmd.Flags().BoolVar(&searchBatch, "batch", false, "Read queries from stdin, one per line, and write one JSON response per line")
+	searchCmd.Flags().BoolVar(&searchStitch, "stitch", false, "Merge adjacent chunks of the same file into one result")
+	addAllProjectsFlag(searchCmd)
+	addPreviewFlags(searchCmd)
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	query := strings.Join(args, " ")
-	if query == "" {
+	if query == "" && !searchBatch {
 		return fmt.Errorf("query is required")
 	}
+	if searchJSONLines && (searchJSON || searchContext || searchBatch) {
+		return fmt.Errorf("--json-lines cannot be combined with --json, --context, or --batch")
+	}
 
 	svc, err := initService()
 	if err != nil {
@@ -52,15 +124,17 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 	defer svc.Close()
 
-	req := types.SearchRequest{
-		Query:     query,
-		Project:   getProject(),
-		Limit:     searchLimit,
-		Threshold: searchThreshold,
+	if searchBatch {
+		base, err := searchRequest(cmd, "")
+		if err != nil {
+			return err
+		}
+		return searchBatchQueries(ctx, svc, base, os.Stdin, os.Stdout)
 	}
 
-	if searchType != "" {
-		req.Type = types.MemoryType(searchType)
+	req, err := searchRequest(cmd, query)
+	if err != nil {
+		return err
 	}
 
 	resp, err := svc.Search(ctx, req)
@@ -68,8 +142,18 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("search failed: %w", err)
 	}
 
-	if len(resp.Results) == 0 {
-		fmt.Println("No results found")
+	if searchStitch {
+		resp.Results = memory.StitchAdjacent(resp.Results)
+		resp.Total = len(resp.Results)
+	}
+
+	// Context output is meant for piping, so print nothing when empty
+	if searchContext {
+		tok, err := loadTokenizer()
+		if err != nil {
+			return err
+		}
+		fmt.Print(memory.FormatContext(resp.Results, searchMaxTokens, tok))
 		return nil
 	}
 
@@ -77,14 +161,32 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return printJSON(resp)
 	}
 
-	// Print results
-	fmt.Printf("Found %d results (%.0fms):\n\n", resp.Total, float64(resp.Timing))
+	if searchJSONLines {
+		return writeJSONLines(os.Stdout, resp)
+	}
+
+	if len(resp.Results) == 0 {
+		infof("No results found\n")
+		return nil
+	}
 
+	// Print results, naming the project when it has a display name
+	found := fmt.Sprintf("%d results", resp.Total)
+	if resp.Total > len(resp.Results) {
+		found = fmt.Sprintf("%d of %d results", len(resp.Results), resp.Total)
+	}
+	if p, err := svc.GetProject(ctx, req.Project); err == nil && p.Name != "" {
+		fmt.Printf("Found %s in %s (%.0fms):\n\n", found, p.Name, float64(resp.Timing))
+	} else {
+		fmt.Printf("Found %s (%.0fms):\n\n", found, float64(resp.Timing))
+	}
+
+	limit := previewLimit(3, 200)
 	for i, result := range resp.Results {
 		fmt.Printf("%d. [%.2f] %s\n", i+1, result.Similarity, formatType(result.Memory.Type))
-		fmt.Printf("   %s\n", formatContent(result.Memory.Content))
+		fmt.Printf("   %s\n", formatContent(result.Memory.Content, limit))
 		if result.Memory.FilePath != "" {
-			fmt.Printf("   File: %s\n", result.Memory.FilePath)
+			fmt.Printf("   File: %s%s\n", result.Memory.FilePath, lineRange(result.Memory))
 		}
 		fmt.Println()
 	}
@@ -92,6 +194,83 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// jsonLinesSummary ends --json-lines output, under a "summary" key so it
+// can't be mistaken for a result
+type jsonLinesSummary struct {
+	Returned   int    `json:"returned"`
+	Total      int    `json:"total"`
+	HasMore    bool   `json:"has_more"`
+	Timing     int64  `json:"timing_ms"`
+	ScoreScale string `json:"score_scale,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+// writeJSONLines writes each result of resp as one line of JSON, then a
+// summary line. Every line is written as soon as it is encoded, so a
+// reader can process results as they arrive.
+func writeJSONLines(w io.Writer, resp *types.SearchResponse) error {
+	enc := json.NewEncoder(w)
+	for _, result := range resp.Results {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	return enc.Encode(map[string]jsonLinesSummary{"summary": {
+		Returned:   len(resp.Results),
+		Total:      resp.Total,
+		HasMore:    resp.HasMore,
+		Timing:     resp.Timing,
+		ScoreScale: resp.ScoreScale,
+		Mode:       resp.Mode,
+	}})
+}
+
+// searchRequest builds a search for query from the command's flags
+func searchRequest(cmd *cobra.Command, query string) (types.SearchRequest, error) {
+	req := types.SearchRequest{
+		Query:          query,
+		Project:        scopeProject(),
+		Limit:          searchLimit,
+		FilePaths:      searchFiles,
+		ExactFilePaths: searchExact,
+		MaxPerFile:     searchPerFile,
+		Rerank:         searchRerank || searchRerankModel != "",
+		Expand:         searchExpand,
+	}
+
+	// Only send an explicit threshold so per-type defaults can apply
+	if cmd.Flags().Changed("threshold") {
+		req.Threshold = searchThreshold
+	}
+
+	if searchType != "" {
+		req.Type = types.MemoryType(searchType)
+	}
+
+	var err error
+	if req.Metadata, err = parseMetaFilters(searchMeta); err != nil {
+		return req, err
+	}
+
+	if len(searchBoosts) > 0 {
+		boosts, err := parseTypeBoosts(searchBoosts)
+		if err != nil {
+			return req, err
+		}
+		req.TypeBoosts = boosts
+	}
+	return req, nil
+}
+
+// lineRange formats a memory's indexed line range as ":start-end", if known
+func lineRange(m types.Memory) string {
+	start, end := m.Metadata["start_line"], m.Metadata["end_line"]
+	if start == "" || end == "" {
+		return ""
+	}
+	return ":" + start + "-" + end
+}
+
 func formatType(t types.MemoryType) string {
 	colors := map[types.MemoryType]string{
 		types.TypeArchitecture: "\033[34m", // Blue
@@ -109,18 +288,81 @@ func formatType(t types.MemoryType) string {
 	return fmt.Sprintf("%s%s%s", color, t, reset)
 }
 
-func formatContent(content string) string {
+// formatContent flattens content onto one line and truncates it to maxLen
+// characters (negative for no truncation)
+func formatContent(content string, maxLen int) string {
 	// Truncate and clean up
 	content = strings.ReplaceAll(content, "\n", " ")
 	content = strings.Join(strings.Fields(content), " ") // Normalize whitespace
-	if len(content) > 200 {
-		content = content[:197] + "..."
-	}
-	return content
+	return truncate(content, maxLen)
 }
 
 func printJSON(v interface{}) error {
-	// Simple JSON output (could use encoding/json for pretty print)
-	fmt.Printf("%+v\n", v)
-	return nil
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// parseMetaFilters parses key=value metadata filters; none gives nil
+func parseMetaFilters(specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	filters := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata filter %q: expected key=value", spec)
+		}
+		filters[key] = value
+	}
+	return filters, nil
+}
+
+// parseTypeBoosts parses "type=multiplier" pairs
+// typeThresholdsEnv supplies --type-threshold when the flag is not given, as
+// a comma-separated list
+const typeThresholdsEnv = "MONETA_TYPE_THRESHOLDS"
+
+// typeThresholds returns the per-type default thresholds from
+// --type-threshold, or else $MONETA_TYPE_THRESHOLDS
+func typeThresholds() (map[types.MemoryType]float32, error) {
+	specs := searchTypeThresholds
+	if len(specs) == 0 {
+		env := os.Getenv(typeThresholdsEnv)
+		if env == "" {
+			return nil, nil
+		}
+		specs = strings.Split(env, ",")
+	}
+
+	thresholds := make(map[types.MemoryType]float32, len(specs))
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid type threshold %q: expected type=similarity", spec)
+		}
+		t, err := strconv.ParseFloat(value, 32)
+		if err != nil || t <= 0 || t > 1 {
+			return nil, fmt.Errorf("invalid type threshold %q: similarity must be above 0 and at most 1", spec)
+		}
+		thresholds[types.MemoryType(name)] = float32(t)
+	}
+	return thresholds, nil
+}
+
+func parseTypeBoosts(specs []string) (map[types.MemoryType]float32, error) {
+	boosts := make(map[types.MemoryType]float32, len(specs))
+	for _, spec := range specs {
+		name, weight, ok := strings.Cut(spec, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid boost %q: expected type=multiplier", spec)
+		}
+		w, err := strconv.ParseFloat(weight, 32)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid boost %q: multiplier must be a positive number", spec)
+		}
+		boosts[types.MemoryType(name)] = float32(w)
+	}
+	return boosts, nil
 }
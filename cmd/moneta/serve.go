@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,10 +12,20 @@ import (
 )
 
 var (
-	servePort int
-	serveHost string
+	servePort     int
+	serveHost     string
+	serveMaxLimit int
+	serveOpenAPI  bool
+	serveOrigins  []string
+	serveCreds    bool
+	serveCache    int
+	serveMCP      bool
+	serveAPIKey   string
 )
 
+// apiKeyEnv supplies --api-key when the flag is not given
+const apiKeyEnv = "MONETA_API_KEY"
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the HTTP server",
@@ -31,13 +42,34 @@ memory across coding sessions.
 Examples:
   moneta serve
   moneta serve --port 3456
-  moneta serve --host 0.0.0.0 --port 8080`,
+  moneta serve --host 0.0.0.0 --port 8080 --api-key "$(cat ~/.moneta-key)"
+  moneta serve --cors-origin https://claude.ai
+  moneta serve --cors-origin https://app.example --cors-credentials
+  moneta serve --mcp-http
+
+With --mcp-http, MCP clients can use moneta's memory tools (search_memory,
+add_memory, get_memory, delete_memory, list_projects) over the Streamable
+HTTP transport at http://<host>:<port>/mcp.
+
+With --api-key (or $MONETA_API_KEY), every route except /health requires
+an "Authorization: Bearer <key>" header. Set one before binding to
+anything other than localhost.`,
 	RunE: runServe,
 }
 
 func init() {
 	serveCmd.Flags().IntVar(&servePort, "port", 3456, "Port to listen on")
 	serveCmd.Flags().StringVar(&serveHost, "host", "127.0.0.1", "Host to bind to")
+	serveCmd.Flags().StringArrayVar(&serveOrigins, "cors-origin", nil,
+		"Origin allowed to call the API from a browser (repeatable; default: localhost only). "+
+			"'*' lets any website you visit read and modify your memories")
+	serveCmd.Flags().BoolVar(&serveCreds, "cors-credentials", false,
+		"Let allowed origins send credentials such as an Authorization header")
+	serveCmd.Flags().BoolVar(&serveOpenAPI, "openapi", false, "Serve the OpenAPI document at /openapi.json")
+	serveCmd.Flags().BoolVar(&serveMCP, "mcp-http", false, "Serve MCP tools over Streamable HTTP at /mcp")
+	serveCmd.Flags().IntVar(&serveMaxLimit, "max-limit", 200, "Maximum results a client may request per call")
+	serveCmd.Flags().StringVar(&serveAPIKey, "api-key", "", "Require this bearer token on every route except /health (default: $"+apiKeyEnv+")")
+	serveCmd.Flags().IntVar(&serveCache, "search-cache", 0, "Cache this many search responses until the next write (0 disables)")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -46,9 +78,23 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if serveAPIKey == "" {
+		serveAPIKey = os.Getenv(apiKeyEnv)
+	}
+	if serveAPIKey == "" && !isLoopback(serveHost) {
+		fmt.Fprintf(os.Stderr, "Warning: listening on %s without --api-key; anyone who can reach it can read and delete memories\n", serveHost)
+	}
+
 	srv := server.New(svc, server.Config{
-		Host: serveHost,
-		Port: servePort,
+		Host:             serveHost,
+		Port:             servePort,
+		MaxSearchLimit:   serveMaxLimit,
+		OpenAPI:          serveOpenAPI,
+		AllowedOrigins:   serveOrigins,
+		AllowCredentials: serveCreds,
+		MCP:              serveMCP,
+		Version:          Version,
+		APIKey:           serveAPIKey,
 	})
 
 	// Handle graceful shutdown
@@ -57,22 +103,30 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	go func() {
 		<-done
-		fmt.Println("\nShutting down...")
+		infof("\nShutting down...\n")
 		srv.Shutdown()
 		svc.Close()
 	}()
 
 	addr := fmt.Sprintf("%s:%d", serveHost, servePort)
-	fmt.Printf("Moneta server listening on http://%s\n", addr)
-	fmt.Println("Press Ctrl+C to stop")
-	fmt.Println()
-	fmt.Println("Endpoints:")
-	fmt.Println("  POST /memory      - Add a memory")
-	fmt.Println("  POST /search      - Search memories")
-	fmt.Println("  GET  /memory/:id  - Get a memory")
-	fmt.Println("  DELETE /memory/:id - Delete a memory")
-	fmt.Println("  GET  /stats       - Get statistics")
-	fmt.Println("  GET  /health      - Health check")
+	infof("Moneta server listening on http://%s\n", addr)
+	infof("Press Ctrl+C to stop\n\n")
+	infof("Endpoints:\n")
+	for _, r := range server.Routes() {
+		if r.Path == "/openapi.json" && !serveOpenAPI || r.Path == "/mcp" && !serveMCP {
+			continue
+		}
+		infof("  %-6s %-14s - %s\n", r.Method, r.Path, r.Summary)
+	}
 
 	return srv.Start()
 }
+
+// isLoopback reports whether host only accepts connections from this machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateContent string
+	updateType    string
+	updateFile    string
+	updateLang    string
+	updateMeta    []string
+	updateUnset   []string
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "Edit a memory in place",
+	Long: `Change a memory without deleting and re-adding it, so it keeps its ID and
+creation time. Only the given fields change. New content is re-embedded;
+other changes are not.
+
+Examples:
+  moneta update abc123 --content "We use the Repository pattern for all DB access"
+  moneta update abc123 --type gotcha
+  moneta update abc123 --meta owner=payments --unset stale`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().StringVarP(&updateContent, "content", "c", "", "New content (re-embedded)")
+	updateCmd.Flags().StringVarP(&updateType, "type", "t", "", "New memory type")
+	updateCmd.Flags().StringVarP(&updateFile, "file", "f", "", "New associated file path")
+	updateCmd.Flags().StringVarP(&updateLang, "lang", "l", "", "New programming language")
+	updateCmd.Flags().StringArrayVarP(&updateMeta, "meta", "m", nil, "Set metadata as key=value (repeatable)")
+	updateCmd.Flags().StringArrayVar(&updateUnset, "unset", nil, "Remove a metadata key (repeatable)")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	req, err := updateRequest(cmd)
+	if err != nil {
+		return err
+	}
+
+	svc, err := initService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	memory, err := svc.Update(ctx, args[0], req)
+	if err != nil {
+		return fmt.Errorf("failed to update memory: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("Updated memory:\n")
+		fmt.Printf("  ID:      %s\n", memory.ID)
+		fmt.Printf("  Type:    %s\n", memory.Type)
+		fmt.Printf("  Project: %s\n", memory.Project)
+		fmt.Printf("  Content: %s\n", truncate(memory.Content, 100))
+	} else {
+		fmt.Printf("Updated: %s\n", memory.ID)
+	}
+	return nil
+}
+
+// updateRequest builds an update from the flags that were given, so an
+// unset flag leaves its field alone
+func updateRequest(cmd *cobra.Command) (types.UpdateMemoryRequest, error) {
+	var req types.UpdateMemoryRequest
+	flags := cmd.Flags()
+	if flags.Changed("content") {
+		req.Content = &updateContent
+	}
+	if flags.Changed("type") {
+		t := types.MemoryType(updateType)
+		req.Type = &t
+	}
+	if flags.Changed("file") {
+		req.FilePath = &updateFile
+	}
+	if flags.Changed("lang") {
+		req.Language = &updateLang
+	}
+
+	if len(updateMeta)+len(updateUnset) > 0 {
+		req.Metadata = make(map[string]*string)
+	}
+	for _, spec := range updateMeta {
+		key, value, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return req, fmt.Errorf("invalid metadata %q: expected key=value", spec)
+		}
+		req.Metadata[key] = &value
+	}
+	for _, key := range updateUnset {
+		req.Metadata[key] = nil
+	}
+
+	if req.Content == nil && req.Type == nil && req.FilePath == nil && req.Language == nil && req.Metadata == nil {
+		return req, fmt.Errorf("nothing to update: give --content, --type, --file, --lang, --meta, or --unset")
+	}
+	return req, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	useClear    bool
+	allProjects bool
+)
+
+// activeProjectFile is the file in the data directory holding the project
+// selected with 'moneta use'
+const activeProjectFile = "active_project"
+
+var useCmd = &cobra.Command{
+	Use:   "use [project]",
+	Short: "Set the default project for all commands",
+	Long: `Set the project every command uses unless -p overrides it, so you don't
+depend on the current directory name. The choice is saved in the data
+directory and lasts until cleared.
+
+Run without arguments to show the current default.
+
+Examples:
+  moneta use myapp
+  moneta use
+  moneta use --clear`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUse,
+}
+
+func init() {
+	useCmd.Flags().BoolVar(&useClear, "clear", false, "Go back to using the current directory name")
+}
+
+func runUse(cmd *cobra.Command, args []string) error {
+	path, err := activeProjectPath()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case useClear:
+		if len(args) > 0 {
+			return fmt.Errorf("--clear takes no project argument")
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear active project: %w", err)
+		}
+		infof("Cleared active project; using the current directory name\n")
+
+	case len(args) == 1:
+		name := strings.TrimSpace(args[0])
+		if name == "" {
+			return fmt.Errorf("project name is required")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create data directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to save active project: %w", err)
+		}
+		infof("Now using project '%s'\n", name)
+
+	default:
+		source := "current directory"
+		if project != "" {
+			source = "-p flag"
+		} else if activeProject() != "" {
+			source = "set with 'moneta use'"
+		}
+		fmt.Printf("Using project '%s' (%s)\n", getProject(), source)
+	}
+	return nil
+}
+
+// addAllProjectsFlag registers --all-projects on a read-only command
+func addAllProjectsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&allProjects, "all-projects", false, "Ignore the default project and include every project")
+}
+
+// scopeProject returns the project a read-only command filters by, or ""
+// for every project with --all-projects
+func scopeProject() string {
+	if allProjects {
+		return ""
+	}
+	return getProject()
+}
+
+// activeProjectPath returns the location of the active project file
+func activeProjectPath() (string, error) {
+	dir, err := resolveDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, activeProjectFile), nil
+}
+
+// activeProject returns the project set with 'moneta use', or ""
+func activeProject() string {
+	path, err := activeProjectPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
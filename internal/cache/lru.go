@@ -3,10 +3,10 @@ package cache
 
 import (
 	"container/list"
-	"crypto/sha256"
-	"encoding/hex"
 	"sync"
 	"sync/atomic"
+
+	"github.com/shivavenkatesh/moneta/internal/hash"
 )
 
 // LRU implements a thread-safe LRU cache with generics
@@ -121,9 +121,11 @@ func (c *LRU[K, V]) HitRate() float64 {
 	return float64(hits) / float64(total) * 100
 }
 
-// EmbeddingCache is a specialized cache for text embeddings
+// EmbeddingCache is a specialized cache for text embeddings. A nil
+// *EmbeddingCache is a disabled cache: Get always misses and Put is a no-op.
 type EmbeddingCache struct {
 	cache *LRU[string, []float32]
+	disk  *diskCache // nil unless persistent
 }
 
 // NewEmbeddingCache creates a cache for embeddings with content hashing
@@ -133,30 +135,65 @@ func NewEmbeddingCache(capacity int) *EmbeddingCache {
 	}
 }
 
-// Get retrieves an embedding by content hash
-func (c *EmbeddingCache) Get(content string) ([]float32, bool) {
-	key := hashContent(content)
-	return c.cache.Get(key)
+// Get retrieves an embedding by content hash. ctxKey identifies everything
+// besides the content that affects the embedding (model, role, prefixes),
+// so the same content embedded under different settings never collides.
+func (c *EmbeddingCache) Get(content, ctxKey string) ([]float32, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := hash.CacheKey(ctxKey, content)
+	if emb, ok := c.cache.Get(key); ok || c.disk == nil {
+		return emb, ok
+	}
+	emb, ok := c.disk.get(key)
+	if ok {
+		c.cache.Put(key, emb)
+	}
+	return emb, ok
 }
 
-// Put stores an embedding by content hash
-func (c *EmbeddingCache) Put(content string, embedding []float32) {
-	key := hashContent(content)
+// Put stores an embedding by content hash and context key
+func (c *EmbeddingCache) Put(content, ctxKey string, embedding []float32) {
+	if c == nil {
+		return
+	}
+	key := hash.CacheKey(ctxKey, content)
 	// Store a copy to prevent external modification
 	embCopy := make([]float32, len(embedding))
 	copy(embCopy, embedding)
 	c.cache.Put(key, embCopy)
+	if c.disk != nil {
+		c.disk.put(key, embCopy)
+	}
 }
 
 // Stats returns cache statistics
 func (c *EmbeddingCache) Stats() (hits, misses int64, hitRate float64) {
+	if c == nil {
+		return 0, 0, 0
+	}
 	hits, misses = c.cache.Stats()
-	hitRate = c.cache.HitRate()
+	if c.disk != nil {
+		// Disk hits were memory misses
+		diskHits := c.disk.hits.Load()
+		hits, misses = hits+diskHits, misses-diskHits
+	}
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
 	return
 }
 
-// hashContent creates a hash of the content for cache keys
-func hashContent(content string) string {
-	h := sha256.Sum256([]byte(content))
-	return hex.EncodeToString(h[:16]) // Use first 16 bytes (128 bits)
+// Enabled reports whether the cache stores anything
+func (c *EmbeddingCache) Enabled() bool {
+	return c != nil
+}
+
+// Close releases the on-disk store of a persistent cache
+func (c *EmbeddingCache) Close() error {
+	if c == nil || c.disk == nil {
+		return nil
+	}
+	return c.disk.db.Close()
 }
@@ -114,9 +114,9 @@ func TestEmbeddingCache_BasicOperations(t *testing.T) {
 	cache := NewEmbeddingCache(100)
 
 	embedding := []float32{0.1, 0.2, 0.3, 0.4}
-	cache.Put("hello world", embedding)
+	cache.Put("hello world", "model", embedding)
 
-	if got, ok := cache.Get("hello world"); !ok {
+	if got, ok := cache.Get("hello world", "model"); !ok {
 		t.Error("expected to find embedding")
 	} else {
 		if len(got) != len(embedding) {
@@ -134,14 +134,14 @@ func TestEmbeddingCache_Stats(t *testing.T) {
 	cache := NewEmbeddingCache(100)
 
 	embedding := []float32{0.1, 0.2, 0.3}
-	cache.Put("test", embedding)
+	cache.Put("test", "model", embedding)
 
 	// First access - hit
-	cache.Get("test")
+	cache.Get("test", "model")
 	// Second access - hit
-	cache.Get("test")
+	cache.Get("test", "model")
 	// Miss
-	cache.Get("nonexistent")
+	cache.Get("nonexistent", "model")
 
 	hits, misses, hitRate := cache.Stats()
 	if hits != 2 {
@@ -183,6 +183,29 @@ func BenchmarkEmbeddingCache_Put(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		cache.Put("test", embedding)
+		cache.Put("test", "model", embedding)
+	}
+}
+
+func TestEmbeddingCache_ContextKey(t *testing.T) {
+	cache := NewEmbeddingCache(100)
+
+	cache.Put("same content", "model-a", []float32{1})
+	cache.Put("same content", "model-b", []float32{2})
+
+	if got, ok := cache.Get("same content", "model-a"); !ok || got[0] != 1 {
+		t.Errorf("expected model-a embedding, got %v", got)
+	}
+	if got, ok := cache.Get("same content", "model-b"); !ok || got[0] != 2 {
+		t.Errorf("expected model-b embedding, got %v", got)
+	}
+	if _, ok := cache.Get("same content", "model-c"); ok {
+		t.Error("expected miss for a different context key")
+	}
+
+	// Shifting bytes between key and content must not alias
+	cache.Put("bc", "a", []float32{3})
+	if _, ok := cache.Get("c", "ab"); ok {
+		t.Error("expected (ab, c) not to collide with (a, bc)")
 	}
 }
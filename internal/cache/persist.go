@@ -0,0 +1,109 @@
+// Package cache persists embeddings to disk so they survive restarts
+package cache
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// diskCache stores embeddings by cache key in a SQLite file. Every error
+// reading it is a miss and every error writing it is dropped: the cache
+// only ever saves work.
+type diskCache struct {
+	db   *sql.DB
+	hits atomic.Int64
+}
+
+// NewPersistentEmbeddingCache returns an EmbeddingCache that writes every
+// embedding through to embeddings.db in dir, and falls back to that file
+// on a memory miss. The capacity most recently stored embeddings are
+// loaded into memory up front.
+func NewPersistentEmbeddingCache(dir string, capacity int) (*EmbeddingCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	path := filepath.Join(dir, "embeddings.db")
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding cache: %w", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS embeddings (
+		key TEXT PRIMARY KEY,
+		embedding BLOB NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open embedding cache %s: %w", path, err)
+	}
+
+	c := NewEmbeddingCache(capacity)
+	c.disk = &diskCache{db: db}
+	c.disk.warm(c.cache, capacity)
+	return c, nil
+}
+
+// warm loads the most recently stored embeddings into lru, oldest first so
+// the newest end up most recently used
+func (d *diskCache) warm(lru *LRU[string, []float32], n int) {
+	rows, err := d.db.Query(`SELECT key, embedding FROM (
+		SELECT rowid, key, embedding FROM embeddings ORDER BY rowid DESC LIMIT ?
+	) ORDER BY rowid`, n)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var blob []byte
+		if rows.Scan(&key, &blob) != nil {
+			continue
+		}
+		if emb, ok := decodeEmbedding(blob); ok {
+			lru.Put(key, emb)
+		}
+	}
+}
+
+func (d *diskCache) get(key string) ([]float32, bool) {
+	var blob []byte
+	if err := d.db.QueryRow(`SELECT embedding FROM embeddings WHERE key = ?`, key).Scan(&blob); err != nil {
+		return nil, false
+	}
+	emb, ok := decodeEmbedding(blob)
+	if ok {
+		d.hits.Add(1)
+	}
+	return emb, ok
+}
+
+func (d *diskCache) put(key string, embedding []float32) {
+	d.db.Exec(`INSERT OR REPLACE INTO embeddings (key, embedding) VALUES (?, ?)`, key, encodeEmbedding(embedding))
+}
+
+// encodeEmbedding packs floats as little-endian float32
+func encodeEmbedding(embedding []float32) []byte {
+	b := make([]byte, 4*len(embedding))
+	for i, f := range embedding {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// decodeEmbedding unpacks encodeEmbedding's output, rejecting blobs that
+// can't be one
+func decodeEmbedding(b []byte) ([]float32, bool) {
+	if len(b) == 0 || len(b)%4 != 0 {
+		return nil, false
+	}
+	emb := make([]float32, len(b)/4)
+	for i := range emb {
+		emb[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return emb, true
+}
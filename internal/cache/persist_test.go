@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/hash"
+)
+
+func TestPersistentEmbeddingCache_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewPersistentEmbeddingCache(dir, 2)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	c.Put("a", "model", []float32{1, 2})
+	c.Put("b", "model", []float32{3})
+	c.Put("c", "model", []float32{4, 5, 6})
+	if err := c.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	c, err = NewPersistentEmbeddingCache(dir, 2)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer c.Close()
+
+	// The two newest entries are warmed into memory
+	if n := c.cache.Len(); n != 2 {
+		t.Errorf("expected 2 warmed entries, got %d", n)
+	}
+	if got, ok := c.Get("c", "model"); !ok || len(got) != 3 || got[2] != 6 {
+		t.Errorf("expected c from memory, got %v, %v", got, ok)
+	}
+
+	// The oldest falls through to disk
+	if got, ok := c.Get("a", "model"); !ok || len(got) != 2 || got[1] != 2 {
+		t.Errorf("expected a from disk, got %v, %v", got, ok)
+	}
+	if _, ok := c.Get("a", "other model"); ok {
+		t.Error("expected a different context key to miss")
+	}
+	if hits, misses, _ := c.Stats(); hits != 2 || misses != 1 {
+		t.Errorf("expected disk hits to count as hits, got %d hits and %d misses", hits, misses)
+	}
+}
+
+func TestPersistentEmbeddingCache_Corruption(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewPersistentEmbeddingCache(dir, 10)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, err := c.disk.db.Exec(`INSERT INTO embeddings (key, embedding) VALUES (?, ?)`,
+		hash.CacheKey("model", "torn"), []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("torn", "model"); ok {
+		t.Error("expected an unreadable entry to miss")
+	}
+	c.Close()
+
+	// A file that isn't a database can't be opened as a cache
+	path := filepath.Join(dir, "embeddings.db")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(path + suffix)
+	}
+	if err := os.WriteFile(path, []byte("not a database, not even close"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPersistentEmbeddingCache(dir, 10); err == nil {
+		t.Error("expected an error for a corrupt cache file")
+	}
+}
@@ -4,6 +4,7 @@ package chunking
 import (
 	"context"
 
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 )
 
@@ -15,16 +16,24 @@ type Chunker interface {
 	// ChunkFile reads and chunks a file, detecting language automatically
 	ChunkFile(ctx context.Context, path string) ([]types.Chunk, error)
 
+	// ChunkFileWithLanguage reads and chunks a file as the given language,
+	// falling back to detection when language is empty
+	ChunkFileWithLanguage(ctx context.Context, path, language string) ([]types.Chunk, error)
+
 	// SupportedLanguages returns list of supported programming languages
 	SupportedLanguages() []string
 }
 
 // ChunkOptions configures chunking behavior
 type ChunkOptions struct {
-	Language string // Programming language or "text" for plain text
-	MaxSize  int    // Maximum chunk size in characters
-	Overlap  int    // Overlap between chunks in characters
-	Semantic bool   // Use semantic boundaries (functions, classes)
+	Language  string // Programming language or "text" for plain text
+	MaxSize   int    // Maximum chunk size in characters
+	MaxTokens int    // Maximum chunk size in tokens, honored by TokenChunker
+	Overlap   int    // Overlap between chunks in characters
+	Semantic  bool   // Use semantic boundaries (functions, classes)
+
+	// Tokenizer counts tokens for MaxTokens; nil uses the chunker's own
+	Tokenizer tokenizer.Tokenizer
 }
 
 // DefaultChunkOptions returns sensible defaults
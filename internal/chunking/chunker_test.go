@@ -4,7 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
 )
 
 func TestDefaultChunkOptions(t *testing.T) {
@@ -146,6 +149,7 @@ func TestDetectLanguage(t *testing.T) {
 		{".go", "go"},
 		{".py", "python"},
 		{".js", "javascript"},
+		{".jsx", "javascript"},
 		{".ts", "typescript"},
 		{".tsx", "typescript"},
 		{".rs", "rust"},
@@ -180,6 +184,104 @@ func TestDetectLanguage(t *testing.T) {
 	}
 }
 
+func TestLineChunker_LanguageOverrides(t *testing.T) {
+	chunker := NewLineChunker(1000, 10)
+	chunker.SetLanguageOverrides(map[string]string{
+		"proto": "protobuf",
+		".GO":   "golang",
+	})
+
+	tests := []struct {
+		ext      string
+		expected string
+	}{
+		{".proto", "protobuf"},
+		{".go", "golang"},
+		{".py", "python"},
+		{".tf", "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			result := chunker.languageFor("file"+tt.ext, nil)
+			if result != tt.expected {
+				t.Errorf("languageFor(%s) = %s, want %s", tt.ext, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectLanguageFromFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		content  string
+		expected string
+	}{
+		{"Dockerfile", "FROM golang:1.21\n", "dockerfile"},
+		{"Dockerfile.dev", "FROM node\n", "dockerfile"},
+		{"Makefile", "all:\n\tgo build\n", "makefile"},
+		{"src/CMakeLists.txt", "project(x)\n", "cmake"},
+		{"notes.txt", "#!/bin/sh\n", "text"},
+		{"script", "#!/usr/bin/env python3\nprint(1)\n", "python"},
+		{"run", "#!/bin/bash\necho hi\n", "shell"},
+		{"tool", "#!/usr/bin/env -S node --no-warnings\n", "javascript"},
+		{"build", "#!/usr/bin/ruby -w\n", "ruby"},
+		{"main.go", "#!/bin/sh\n", "go"},
+		{"README", "Just some text\n", "text"},
+		{"empty", "", "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := detectLanguageFromFile(tt.path, []byte(tt.content))
+			if result != tt.expected {
+				t.Errorf("detectLanguageFromFile(%s) = %s, want %s", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNamedLanguage(t *testing.T) {
+	tests := []struct {
+		path, content, expected string
+	}{
+		{"Dockerfile.dev", "", "dockerfile"},
+		{"GNUmakefile", "", "makefile"},
+		{"bin/run", "#!/bin/bash\n", "shell"},
+		{"README", "Just some text\n", ""},
+		{"main.go", "", ""},
+		{"notes.txt", "#!/bin/sh\n", ""},
+	}
+	for _, tt := range tests {
+		if got := NamedLanguage(tt.path, []byte(tt.content)); got != tt.expected {
+			t.Errorf("NamedLanguage(%s) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestCodeChunker_ChunkFile_Shebang(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "deploy")
+	content := "#!/usr/bin/env python\n\ndef main():\n    pass\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	chunks, err := NewCodeChunker(1000, 10).ChunkFile(context.Background(), tmpFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for _, chunk := range chunks {
+		if chunk.Type == "function" && chunk.Name == "main" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected python function chunk, got %+v", chunks)
+	}
+}
+
 func TestFindOverlapStart(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -338,6 +440,44 @@ func main() {
 	}
 }
 
+func TestCodeChunker_ChunkFileWithLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "handler.tmpl")
+	content := "package main\n\nfunc Serve() {\n}\n\nfunc Stop() {\n}\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	chunker := NewCodeChunker(2000, 0)
+	ctx := context.Background()
+
+	chunkNames := func(chunks []types.Chunk) string {
+		var names []string
+		for _, c := range chunks {
+			if c.Name != "" {
+				names = append(names, c.Name)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+
+	chunks, err := chunker.ChunkFile(ctx, tmpFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := chunkNames(chunks); names != "" {
+		t.Errorf("expected unknown extension to be chunked as text, got names %q", names)
+	}
+
+	chunks, err = chunker.ChunkFileWithLanguage(ctx, tmpFile, "go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := chunkNames(chunks); names != "Serve,Stop" {
+		t.Errorf("expected Go function chunks, got names %q", names)
+	}
+}
+
 func TestCodeChunker_SupportedLanguages(t *testing.T) {
 	chunker := NewCodeChunker(1000, 10)
 	languages := chunker.SupportedLanguages()
@@ -410,3 +550,48 @@ func main() {
 		chunker.Chunk(ctx, content, opts)
 	}
 }
+
+func TestLineChunker_OverlapRegion(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "line number "+strings.Repeat("x", i%7))
+	}
+
+	chunker := NewLineChunker(200, 40)
+	chunks, err := chunker.Chunk(context.Background(), strings.Join(lines, "\n"), ChunkOptions{MaxSize: 200, Overlap: 40})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Overlap != 0 {
+		t.Errorf("first chunk should have no overlap, got %d", chunks[0].Overlap)
+	}
+	for i := 1; i < len(chunks); i++ {
+		c := chunks[i]
+		if c.Overlap <= 0 || c.Overlap > len(c.Content) {
+			t.Fatalf("chunk %d: invalid overlap %d", i, c.Overlap)
+		}
+		prefix := strings.TrimSpace(c.Content[:c.Overlap])
+		if !strings.HasSuffix(chunks[i-1].Content, prefix) {
+			t.Errorf("chunk %d: overlap %q is not the tail of the previous chunk", i, prefix)
+		}
+	}
+}
+
+func TestGoFuncName(t *testing.T) {
+	tests := map[string]string{
+		"func Login() {":                         "Login",
+		"func (s *Store) Get(id string) error {": "Get",
+		"func (p Pair[K, V]) Key() K {":          "Key",
+		"func Map[T, U any](in []T) []U {":       "Map",
+		"	func indented(x int) {":                "indented",
+	}
+	for line, want := range tests {
+		if got := goFuncName(line); got != want {
+			t.Errorf("goFuncName(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
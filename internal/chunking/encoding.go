@@ -0,0 +1,156 @@
+// Package chunking detects file encodings and transcodes text to UTF-8
+package chunking
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnknownEncoding is returned for content that isn't text in an encoding
+// DecodeText recognizes with confidence
+var ErrUnknownEncoding = errors.New("unknown text encoding")
+
+// Encodings reported by DecodeText
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ReadTextFile reads a file and returns its content as UTF-8
+func ReadTextFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	text, _, err := DecodeText(content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return text, nil
+}
+
+// DecodeText returns content as UTF-8 along with the encoding it was found
+// in. A byte order mark decides the encoding when present; otherwise the
+// content is tried as UTF-8, then BOM-less UTF-16, then Latin-1. Content
+// that fits none of them, such as binary data, returns ErrUnknownEncoding.
+func DecodeText(content []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		content = content[len(bomUTF8):]
+		if !utf8.Valid(content) {
+			return "", "", fmt.Errorf("%w: invalid UTF-8 after byte order mark", ErrUnknownEncoding)
+		}
+		return string(content), EncodingUTF8, nil
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeUTF16(content[2:], false)
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeUTF16(content[2:], true)
+	}
+
+	if bytes.IndexByte(content, 0) < 0 {
+		if utf8.Valid(content) {
+			return string(content), EncodingUTF8, nil
+		}
+		if looksLatin1(content) {
+			return decodeLatin1(content), EncodingLatin1, nil
+		}
+		return "", "", fmt.Errorf("%w: not valid UTF-8", ErrUnknownEncoding)
+	}
+
+	if bigEndian, ok := looksUTF16(content); ok {
+		return decodeUTF16(content, bigEndian)
+	}
+	return "", "", fmt.Errorf("%w: binary content", ErrUnknownEncoding)
+}
+
+// decodeUTF16 transcodes UTF-16 without its byte order mark
+func decodeUTF16(content []byte, bigEndian bool) (string, string, error) {
+	encoding := EncodingUTF16LE
+	if bigEndian {
+		encoding = EncodingUTF16BE
+	}
+	if len(content)%2 != 0 {
+		return "", "", fmt.Errorf("%w: odd byte count for %s", ErrUnknownEncoding, encoding)
+	}
+
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		lo, hi := content[2*i], content[2*i+1]
+		if bigEndian {
+			lo, hi = hi, lo
+		}
+		units[i] = uint16(lo) | uint16(hi)<<8
+	}
+
+	runes := utf16.Decode(units)
+	for _, r := range runes {
+		if r == utf8.RuneError {
+			return "", "", fmt.Errorf("%w: invalid %s", ErrUnknownEncoding, encoding)
+		}
+	}
+	return string(runes), encoding, nil
+}
+
+// looksUTF16 reports whether BOM-less content looks like UTF-16 text: mostly
+// ASCII, so nearly every other byte is zero and the rest are not
+func looksUTF16(content []byte) (bigEndian, ok bool) {
+	if len(content) < 4 || len(content)%2 != 0 {
+		return false, false
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i < len(content); i += 2 {
+		if content[i] == 0 {
+			evenZeros++
+		}
+		if content[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	units := len(content) / 2
+	switch {
+	case oddZeros*10 >= units*9 && evenZeros*20 <= units:
+		return false, true
+	case evenZeros*10 >= units*9 && oddZeros*20 <= units:
+		return true, true
+	}
+	return false, false
+}
+
+// looksLatin1 reports whether content that isn't UTF-8 reads as Latin-1
+// text: no control characters besides whitespace (including the C1 range
+// Windows-1252 puts punctuation in), and at most 30% non-ASCII, since text
+// in other 8-bit encodings is mostly high bytes
+func looksLatin1(content []byte) bool {
+	high := 0
+	for _, b := range content {
+		switch {
+		case b == '\t' || b == '\n' || b == '\r' || b == '\f':
+		case b < 0x20 || b == 0x7F || (b >= 0x80 && b < 0xA0):
+			return false
+		case b >= 0xA0:
+			high++
+		}
+	}
+	return high*10 <= len(content)*3
+}
+
+// decodeLatin1 transcodes Latin-1, whose bytes are the first 256 code points
+func decodeLatin1(content []byte) string {
+	runes := make([]rune, len(content))
+	for i, b := range content {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
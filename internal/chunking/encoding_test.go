@@ -0,0 +1,78 @@
+package chunking
+
+import (
+	"errors"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 with an optional byte order mark
+func utf16Bytes(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestDecodeText(t *testing.T) {
+	const text = "// café ☕\nfunc main() {}\n"
+
+	tests := []struct {
+		name     string
+		content  []byte
+		encoding string
+		want     string
+	}{
+		{"utf-8", []byte(text), EncodingUTF8, text},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), EncodingUTF8, text},
+		{"utf-16le bom", utf16Bytes(text, false, true), EncodingUTF16LE, text},
+		{"utf-16be bom", utf16Bytes(text, true, true), EncodingUTF16BE, text},
+		{"utf-16le no bom", utf16Bytes("package main\n", false, false), EncodingUTF16LE, "package main\n"},
+		{"utf-16be no bom", utf16Bytes("package main\n", true, false), EncodingUTF16BE, "package main\n"},
+		{"latin-1", []byte("# caf\xe9 au lait\nprint('ol\xe1')\n"), EncodingLatin1, "# café au lait\nprint('olá')\n"},
+		{"empty", nil, EncodingUTF8, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, encoding, err := DecodeText(tt.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if encoding != tt.encoding {
+				t.Errorf("expected encoding %s, got %s", tt.encoding, encoding)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeText_Unknown(t *testing.T) {
+	tests := map[string][]byte{
+		"binary":             {0x7F, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03},
+		"mostly high bytes":  []byte("\xe9\xe8\xea\xeb\xe0 abc"),
+		"c1 controls":        []byte("quote \x93hi\x94"),
+		"odd utf-16":         append([]byte{0xFF, 0xFE}, 'a', 0, 'b'),
+		"lone surrogate":     {0xFF, 0xFE, 0x00, 0xD8, 'a', 0x00},
+		"bad utf-8 with bom": {0xEF, 0xBB, 0xBF, 0xC3, 0x28},
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := DecodeText(content); !errors.Is(err, ErrUnknownEncoding) {
+				t.Errorf("expected ErrUnknownEncoding, got %v", err)
+			}
+		})
+	}
+}
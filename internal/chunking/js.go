@@ -0,0 +1,438 @@
+// Package chunking splits JavaScript and TypeScript at functions, classes,
+// and methods
+package chunking
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+var (
+	// jsFunction matches function declarations, including anonymous
+	// default exports
+	jsFunction = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\b\s*\*?\s*([A-Za-z_$][\w$]*)?`)
+
+	// jsArrow matches a function or arrow function assigned to a variable
+	jsArrow = regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\b\s*)?(?:function\b|[A-Za-z_$][\w$]*\s*=>|\(|<)`)
+
+	// jsClass matches class declarations
+	jsClass = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\b\s*([A-Za-z_$][\w$]*)?`)
+
+	// jsMethod matches a method signature in a class body
+	jsMethod = regexp.MustCompile(`^(?:(?:static|async|get|set|public|private|protected|readonly|override|abstract)\s+)*\*?\s*(#?[A-Za-z_$][\w$]*)\s*(?:<[^>(]*>)?\s*\(`)
+
+	// jsFieldArrow matches an arrow function assigned to a class field
+	jsFieldArrow = regexp.MustCompile(`^(?:(?:static|public|private|protected|readonly|override)\s+)*(#?[A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\b\s*)?(?:\(|[A-Za-z_$][\w$]*\s*=>)`)
+)
+
+// jsKeywords start statements that look like method calls
+var jsKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "with": true, "super": true,
+}
+
+// jsLine is one line of source with the depth of the braces around it
+type jsLine struct {
+	text  string
+	code  string // text with strings, comments, and regexes blanked out
+	depth int    // brace depth at the start of the line
+	end   int    // brace depth at the end of the line
+}
+
+// chunkJS chunks JavaScript/TypeScript code into one chunk per top-level
+// function and per class method, with the class declaration and its
+// fields in a chunk of their own. Braces inside strings, template
+// literals, comments, and regexes don't count towards nesting.
+func (c *CodeChunker) chunkJS(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
+	lines := scanJS(content)
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = c.lineChunker.maxSize
+	}
+
+	var chunks []types.Chunk
+	emit := func(from, to int, chunkType, name string) {
+		// Line numbers are those of the trimmed content
+		for from <= to && strings.TrimSpace(lines[from].text) == "" {
+			from++
+		}
+		for to >= from && strings.TrimSpace(lines[to].text) == "" {
+			to--
+		}
+		if from > to {
+			return
+		}
+		texts := make([]string, 0, to-from+1)
+		for _, l := range lines[from : to+1] {
+			texts = append(texts, l.text)
+		}
+		text := strings.TrimSpace(strings.Join(texts, "\n"))
+		chunks = append(chunks, types.Chunk{
+			Content:   text,
+			StartLine: from + 1,
+			EndLine:   to + 1,
+			Type:      chunkType,
+			Name:      name,
+		})
+	}
+
+	// Lines between declarations are gathered into text chunks
+	textStart, textSize := 0, 0
+	flushText := func(before int) {
+		emit(textStart, before-1, "text", "")
+		textStart, textSize = before, 0
+	}
+
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		code := strings.TrimSpace(l.code)
+		if l.depth != 0 || code == "" {
+			textSize += len(l.text) + 1
+			if textSize > maxSize {
+				flushText(i + 1)
+			}
+			i++
+			continue
+		}
+
+		if m := jsClass.FindStringSubmatch(code); m != nil {
+			start := leadingComments(lines, textStart, i)
+			flushText(start)
+			end := c.chunkJSClass(lines, start, i, orDefault(m[1]), emit)
+			textStart = end + 1
+			i = end + 1
+			continue
+		}
+
+		name, ok := "", false
+		if m := jsFunction.FindStringSubmatch(code); m != nil {
+			name, ok = orDefault(m[1]), true
+		} else if m := jsArrow.FindStringSubmatch(code); m != nil && jsDefinesFunction(code) {
+			name, ok = m[1], true
+		}
+		if !ok {
+			textSize += len(l.text) + 1
+			if textSize > maxSize {
+				flushText(i + 1)
+			}
+			i++
+			continue
+		}
+
+		start := leadingComments(lines, textStart, i)
+		flushText(start)
+		end := jsBlockEnd(lines, i, 0)
+		emit(start, end, "function", name)
+		textStart = end + 1
+		i = end + 1
+	}
+	flushText(len(lines))
+
+	return chunks, nil
+}
+
+// chunkJSClass emits the chunks of the class declared at line decl, with
+// its leading comments from line start, and returns the class's last line.
+// The class chunk holds the declaration and the fields before the first
+// method. Each method chunk takes the fields and comments above it, and the
+// last one the closing brace.
+func (c *CodeChunker) chunkJSClass(lines []jsLine, start, decl int, name string, emit func(from, to int, chunkType, name string)) int {
+	end := jsBlockEnd(lines, decl, 0)
+	body := lines[decl].depth + 1
+
+	type method struct {
+		from int
+		name string
+	}
+	var methods []method
+	prevEnd := -1
+	for i := decl + 1; i < end; i++ {
+		if lines[i].depth != body {
+			continue
+		}
+		if name, ok := jsMethodName(lines[i].code); ok {
+			from := prevEnd + 1
+			if prevEnd < 0 {
+				from = leadingComments(lines, decl+1, i)
+			}
+			methods = append(methods, method{from: from, name: name})
+			prevEnd = jsBlockEnd(lines, i, body)
+			i = prevEnd
+		}
+	}
+
+	if len(methods) == 0 {
+		emit(start, end, "class", name)
+		return end
+	}
+	emit(start, methods[0].from-1, "class", name)
+	for k, m := range methods {
+		to := end
+		if k+1 < len(methods) {
+			to = methods[k+1].from - 1
+		}
+		emit(m.from, to, "method", m.name)
+	}
+	return end
+}
+
+// jsMethodName returns the name of the method whose signature starts code
+func jsMethodName(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if m := jsFieldArrow.FindStringSubmatch(code); m != nil && jsDefinesFunction(code) {
+		return m[1], true
+	}
+	m := jsMethod.FindStringSubmatch(code)
+	if m == nil || jsKeywords[m[1]] {
+		return "", false
+	}
+	// A signature opens a body or continues onto the next line
+	if !strings.Contains(code, "{") && strings.Count(code, "(") <= strings.Count(code, ")") {
+		return "", false
+	}
+	return m[1], true
+}
+
+// jsDefinesFunction reports whether an assignment's value is a function,
+// rather than, say, a parenthesized expression
+func jsDefinesFunction(code string) bool {
+	return strings.Contains(code, "=>") || strings.Contains(code, "function") ||
+		strings.Count(code, "(") > strings.Count(code, ")")
+}
+
+// jsBlockEnd returns the last line of the declaration starting at line
+// start, whose surrounding brace depth is depth. It ends where its braces
+// close, or at the end of its statement when it has none.
+func jsBlockEnd(lines []jsLine, start, depth int) int {
+	opened := false
+	for i := start; i < len(lines); i++ {
+		l := lines[i]
+		if l.end > depth || strings.Contains(l.code, "{") {
+			opened = true
+		}
+		if l.end > depth {
+			continue
+		}
+		if opened || !jsContinues(l.code) {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// jsContinues reports whether a statement goes on past the end of code
+func jsContinues(code string) bool {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return true
+	}
+	for _, suffix := range []string{"=>", "(", "[", ",", "=", "+", "-", "*", "?", ":", "&&", "||", "."} {
+		if strings.HasSuffix(code, suffix) {
+			return true
+		}
+	}
+	return strings.Count(code, "(") > strings.Count(code, ")")
+}
+
+// leadingComments returns the first line of the comments and decorators
+// directly above line i, no earlier than line from
+func leadingComments(lines []jsLine, from, i int) int {
+	for i > from {
+		text := strings.TrimSpace(lines[i-1].text)
+		if !strings.HasPrefix(text, "//") && !strings.HasPrefix(text, "/*") &&
+			!strings.HasPrefix(text, "*") && !strings.HasPrefix(text, "@") {
+			break
+		}
+		i--
+	}
+	return i
+}
+
+func orDefault(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// jsState is where the scanner is in the source
+type jsState int
+
+const (
+	jsCode jsState = iota
+	jsLineComment
+	jsBlockComment
+	jsSingle
+	jsDouble
+	jsTemplate
+	jsRegex
+)
+
+// jsRegexKeywords can be followed by a regex literal
+var jsRegexKeywords = map[string]bool{
+	"return": true, "typeof": true, "case": true, "do": true, "else": true,
+	"in": true, "of": true, "new": true, "delete": true, "void": true,
+	"throw": true, "yield": true, "await": true,
+}
+
+// scanJS splits content into lines and tracks brace depth through it.
+// Template literals nest: each ${ expression keeps its own brace count, so
+// "}" inside a string in an expression can't end it early. Unterminated
+// strings and regexes end at the line break, so one mistake can't hide the
+// rest of the file.
+func scanJS(content string) []jsLine {
+	var lines []jsLine
+	var code strings.Builder
+	lineStart, depth, lineDepth := 0, 0, 0
+
+	state := jsCode
+	var templates []int // Open braces of each enclosing ${ expression
+	inClass := false    // Inside [...] of a regex
+	var last byte       // Last non-space character of code
+	word := ""          // Identifier ending at last, if any
+	inWord := false     // Whether the next identifier character extends word
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if ch == '\n' {
+			switch state {
+			case jsLineComment, jsSingle, jsDouble, jsRegex:
+				state = jsCode
+			}
+			lines = append(lines, jsLine{text: content[lineStart:i], code: code.String(), depth: lineDepth, end: depth})
+			code.Reset()
+			lineStart, lineDepth = i+1, depth
+			inWord = false
+			continue
+		}
+
+		next := byte(0)
+		if i+1 < len(content) {
+			next = content[i+1]
+		}
+
+		if state != jsCode {
+			// Escapes skip the escaped character, unless it ends the line
+			if ch == '\\' && state != jsLineComment && state != jsBlockComment {
+				code.WriteByte(' ')
+				if next != 0 && next != '\n' {
+					code.WriteByte(' ')
+					i++
+				}
+				continue
+			}
+
+			end := false
+			switch state {
+			case jsBlockComment:
+				if ch == '*' && next == '/' {
+					code.WriteString("  ")
+					i++
+					state = jsCode
+					continue
+				}
+			case jsSingle:
+				end = ch == '\''
+			case jsDouble:
+				end = ch == '"'
+			case jsRegex:
+				switch {
+				case ch == '[':
+					inClass = true
+				case ch == ']':
+					inClass = false
+				case ch == '/' && !inClass:
+					end = true
+				}
+			case jsTemplate:
+				if ch == '$' && next == '{' {
+					templates = append(templates, 0)
+					code.WriteString("  ")
+					i++
+					state = jsCode
+					last, word, inWord = '{', "", false
+					continue
+				}
+				end = ch == '`'
+			}
+
+			if !end {
+				code.WriteByte(' ')
+				continue
+			}
+			code.WriteByte(ch)
+			// A literal is an operand, so a "/" after it divides
+			state = jsCode
+			last, word, inWord = ')', "", false
+			continue
+		}
+
+		switch {
+		case ch == '/' && next == '/':
+			state = jsLineComment
+			code.WriteString("  ")
+			i++
+			continue
+		case ch == '/' && next == '*':
+			state = jsBlockComment
+			code.WriteString("  ")
+			i++
+			continue
+		case ch == '/' && (last == 0 || strings.IndexByte("(,=:[!&|?{};+-*%~^", last) >= 0 || jsRegexKeywords[word]):
+			state, inClass = jsRegex, false
+			code.WriteByte(ch)
+			continue
+		case ch == '\'':
+			state = jsSingle
+		case ch == '"':
+			state = jsDouble
+		case ch == '`':
+			state = jsTemplate
+		case ch == '{':
+			if n := len(templates); n > 0 {
+				templates[n-1]++
+			} else {
+				depth++
+			}
+		case ch == '}':
+			if n := len(templates); n > 0 {
+				if templates[n-1] == 0 {
+					// Back into the template literal
+					templates = templates[:n-1]
+					state = jsTemplate
+					code.WriteByte(' ')
+					continue
+				}
+				templates[n-1]--
+			} else if depth > 0 {
+				depth--
+			}
+		}
+
+		code.WriteByte(ch)
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			inWord = false
+		case isWordByte(ch) || ch == '$':
+			if inWord {
+				word += string(ch)
+			} else {
+				word = string(ch)
+			}
+			last, inWord = ch, true
+		default:
+			last, word, inWord = ch, "", false
+		}
+	}
+
+	lines = append(lines, jsLine{text: content[lineStart:], code: code.String(), depth: lineDepth, end: depth})
+	return lines
+}
+
+// isWordByte reports whether c can be part of an identifier
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
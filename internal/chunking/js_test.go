@@ -0,0 +1,163 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func chunkJSTest(t *testing.T, src string) []types.Chunk {
+	t.Helper()
+	chunker := NewCodeChunker(1500, 0)
+	chunks, err := chunker.Chunk(context.Background(), src, ChunkOptions{Language: "typescript", MaxSize: 1500, Semantic: true})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	return chunks
+}
+
+// findChunk returns the chunk with name and type, failing when missing
+func findChunk(t *testing.T, chunks []types.Chunk, chunkType, name string) types.Chunk {
+	t.Helper()
+	for _, c := range chunks {
+		if c.Type == chunkType && c.Name == name {
+			return c
+		}
+	}
+	var got []string
+	for _, c := range chunks {
+		got = append(got, c.Type+":"+c.Name)
+	}
+	t.Fatalf("expected a %s chunk named %q, got %v", chunkType, name, got)
+	return types.Chunk{}
+}
+
+func TestChunkJS_Functions(t *testing.T) {
+	src := `import { api } from "./api";
+
+/** Adds two numbers */
+export function add(a, b) {
+  return a + b;
+}
+
+export const fetchUser = async (id: string): Promise<User> => {
+  const res = await api.get(` + "`/users/${id}`" + `);
+  return res.data;
+};
+
+const double = x => x * 2;
+
+const handler = function (event) {
+  if (event) { return 1; }
+};
+
+const total = (a + b) * 2;
+`
+	chunks := chunkJSTest(t, src)
+
+	add := findChunk(t, chunks, "function", "add")
+	if add.StartLine != 3 || add.EndLine != 6 || !strings.HasPrefix(add.Content, "/** Adds") {
+		t.Errorf("expected add with its doc comment on lines 3-6, got %d-%d %q", add.StartLine, add.EndLine, add.Content)
+	}
+	fetch := findChunk(t, chunks, "function", "fetchUser")
+	if fetch.StartLine != 8 || fetch.EndLine != 11 {
+		t.Errorf("expected the arrow function on lines 8-11, got %d-%d", fetch.StartLine, fetch.EndLine)
+	}
+	if d := findChunk(t, chunks, "function", "double"); d.StartLine != 13 || d.EndLine != 13 {
+		t.Errorf("expected the one-line arrow on line 13, got %d-%d", d.StartLine, d.EndLine)
+	}
+	findChunk(t, chunks, "function", "handler")
+
+	for _, c := range chunks {
+		if c.Name == "total" {
+			t.Error("expected a parenthesized expression not to be taken for a function")
+		}
+	}
+	if first := chunks[0]; first.Type != "text" || !strings.Contains(first.Content, "import") {
+		t.Errorf("expected the imports in a text chunk, got %+v", first)
+	}
+}
+
+func TestChunkJS_ClassMethods(t *testing.T) {
+	src := `export class UserService extends Base {
+  private cache = new Map<string, User>();
+
+  constructor(private api: Api) {
+    super();
+  }
+
+  // Loads a user, caching the result
+  async load(id: string): Promise<User> {
+    if (this.cache.has(id)) {
+      return this.cache.get(id)!;
+    }
+    return this.api.get(id);
+  }
+
+  static create() { return new UserService(defaultApi); }
+
+  onChange = (user: User) => {
+    this.cache.set(user.id, user);
+  };
+}
+
+function after() {}
+`
+	chunks := chunkJSTest(t, src)
+
+	class := findChunk(t, chunks, "class", "UserService")
+	if class.StartLine != 1 || !strings.Contains(class.Content, "private cache") || strings.Contains(class.Content, "constructor") {
+		t.Errorf("expected the class chunk to hold the declaration and fields, got %q", class.Content)
+	}
+	findChunk(t, chunks, "method", "constructor")
+	load := findChunk(t, chunks, "method", "load")
+	if !strings.HasPrefix(load.Content, "// Loads a user") || !strings.Contains(load.Content, "return this.api.get(id);") {
+		t.Errorf("expected load with its comment and whole body, got %q", load.Content)
+	}
+	findChunk(t, chunks, "method", "create")
+	onChange := findChunk(t, chunks, "method", "onChange")
+	if onChange.StartLine != 18 || onChange.EndLine != 21 {
+		t.Errorf("expected the last method on lines 18-21 with the closing brace, got %d-%d", onChange.StartLine, onChange.EndLine)
+	}
+	if f := findChunk(t, chunks, "function", "after"); f.StartLine != 23 {
+		t.Errorf("expected the function after the class on line 23, got %d", f.StartLine)
+	}
+}
+
+func TestChunkJS_BracesInLiterals(t *testing.T) {
+	src := `function render(x) {
+  const s = ` + "`<b>${x ? \"{\" : \"}\"}</b> and ${ {a: 1}.a }`" + `;
+  const re = /[{]+\}/g;
+  const q = '}}}';
+  // a stray } in a comment
+  /* and { another */
+  return s + re + q;
+}
+
+function next() {
+  return "{";
+}
+`
+	chunks := chunkJSTest(t, src)
+
+	render := findChunk(t, chunks, "function", "render")
+	if render.StartLine != 1 || render.EndLine != 8 {
+		t.Errorf("expected render on lines 1-8 despite braces in literals, got %d-%d", render.StartLine, render.EndLine)
+	}
+	if n := findChunk(t, chunks, "function", "next"); n.StartLine != 10 || n.EndLine != 12 {
+		t.Errorf("expected next on lines 10-12, got %d-%d", n.StartLine, n.EndLine)
+	}
+}
+
+func TestScanJS_Division(t *testing.T) {
+	// A "/" after an operand divides; one after "return" starts a regex
+	lines := scanJS("const r = a / b / c; {\nreturn /}/.test(s) }")
+	if lines[0].end != 1 {
+		t.Errorf("expected division not to hide the brace, got depth %d", lines[0].end)
+	}
+	if lines[1].end != 0 {
+		t.Errorf("expected the regex brace to be ignored, got depth %d", lines[1].end)
+	}
+}
@@ -4,17 +4,18 @@ package chunking
 import (
 	"bufio"
 	"context"
-	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/shivavenkatesh/moneta/pkg/types"
 )
 
 // LineChunker implements line-based chunking with overlap
 type LineChunker struct {
-	maxSize int
-	overlap int
+	maxSize   int
+	overlap   int
+	languages map[string]string // extension -> language overrides
 }
 
 // NewLineChunker creates a new line-based chunker
@@ -53,24 +54,22 @@ func (c *LineChunker) Chunk(ctx context.Context, content string, opts ChunkOptio
 	var currentChunk strings.Builder
 	startLine := 1
 	currentLine := 1
+	overlapLen := 0 // Bytes at the start of currentChunk copied from the previous chunk
 
 	for _, line := range lines {
 		// Check if adding this line would exceed max size
 		if currentChunk.Len()+len(line)+1 > maxSize && currentChunk.Len() > 0 {
 			// Save current chunk
-			chunks = append(chunks, types.Chunk{
-				Content:   strings.TrimSpace(currentChunk.String()),
-				StartLine: startLine,
-				EndLine:   currentLine - 1,
-				Type:      "text",
-			})
+			chunks = append(chunks, newLineChunk(currentChunk.String(), overlapLen, startLine, currentLine-1))
 
 			// Start new chunk with overlap
 			currentChunk.Reset()
+			overlapLen = 0
 			overlapStart := findOverlapStart(chunks[len(chunks)-1].Content, overlap)
 			if overlapStart != "" {
 				currentChunk.WriteString(overlapStart)
 				currentChunk.WriteString("\n")
+				overlapLen = currentChunk.Len()
 			}
 			startLine = currentLine
 		}
@@ -82,26 +81,48 @@ func (c *LineChunker) Chunk(ctx context.Context, content string, opts ChunkOptio
 
 	// Add final chunk if not empty
 	if currentChunk.Len() > 0 {
-		chunks = append(chunks, types.Chunk{
-			Content:   strings.TrimSpace(currentChunk.String()),
-			StartLine: startLine,
-			EndLine:   currentLine - 1,
-			Type:      "text",
-		})
+		chunks = append(chunks, newLineChunk(currentChunk.String(), overlapLen, startLine, currentLine-1))
 	}
 
 	return chunks, nil
 }
 
+// newLineChunk trims raw chunk text, adjusting the overlap prefix length for
+// any whitespace trimmed from the start
+func newLineChunk(raw string, overlapLen, startLine, endLine int) types.Chunk {
+	content := strings.TrimSpace(raw)
+	lead := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace))
+	overlapLen -= lead
+	if overlapLen < 0 {
+		overlapLen = 0
+	}
+	if overlapLen > len(content) {
+		overlapLen = len(content)
+	}
+	return types.Chunk{
+		Content:   content,
+		StartLine: startLine,
+		EndLine:   endLine,
+		Type:      "text",
+		Overlap:   overlapLen,
+	}
+}
+
 // ChunkFile reads and chunks a file
 func (c *LineChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk, error) {
-	content, err := os.ReadFile(path)
+	return c.ChunkFileWithLanguage(ctx, path, "")
+}
+
+// ChunkFileWithLanguage reads and chunks a file as the given language
+func (c *LineChunker) ChunkFileWithLanguage(ctx context.Context, path, language string) ([]types.Chunk, error) {
+	content, err := ReadTextFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	ext := strings.ToLower(filepath.Ext(path))
-	language := detectLanguage(ext)
+	if language == "" {
+		language = c.languageFor(path, []byte(content))
+	}
 
 	opts := ChunkOptions{
 		Language: language,
@@ -109,7 +130,7 @@ func (c *LineChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk
 		Overlap:  c.overlap,
 	}
 
-	chunks, err := c.Chunk(ctx, string(content), opts)
+	chunks, err := c.Chunk(ctx, content, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +143,24 @@ func (c *LineChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk
 	return chunks, nil
 }
 
+// SetLanguageOverrides maps additional file extensions to languages,
+// taking precedence over the built-in detection
+func (c *LineChunker) SetLanguageOverrides(overrides map[string]string) {
+	c.languages = make(map[string]string, len(overrides))
+	for ext, lang := range overrides {
+		c.languages[normalizeExt(ext)] = lang
+	}
+}
+
+// languageFor resolves the language of a file, honoring extension overrides
+func (c *LineChunker) languageFor(path string, firstBytes []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if lang, ok := c.languages[ext]; ok {
+		return lang
+	}
+	return detectLanguageFromFile(path, firstBytes)
+}
+
 // SupportedLanguages returns list of supported programming languages
 func (c *LineChunker) SupportedLanguages() []string {
 	return []string{"text", "go", "python", "javascript", "typescript", "rust", "java", "c", "cpp"}
@@ -148,7 +187,7 @@ func detectLanguage(ext string) string {
 		return "go"
 	case ".py":
 		return "python"
-	case ".js":
+	case ".js", ".jsx":
 		return "javascript"
 	case ".ts", ".tsx":
 		return "typescript"
@@ -187,6 +226,112 @@ func detectLanguage(ext string) string {
 	}
 }
 
+// detectLanguageFromFile detects a file's language from its name, extension,
+// and (for extensionless files) shebang line, so files like Dockerfile or
+// scripts still get an appropriate chunker
+func detectLanguageFromFile(path string, firstBytes []byte) string {
+	if lang := detectLanguageFromName(filepath.Base(path)); lang != "" {
+		return lang
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != "" {
+		return detectLanguage(ext)
+	}
+
+	if lang := detectLanguageFromShebang(firstBytes); lang != "" {
+		return lang
+	}
+
+	return "text"
+}
+
+// NamedLanguage returns the language of a file recognized without its
+// extension: by a well-known name such as Dockerfile or Makefile, or, for an
+// extensionless file, by the shebang in firstBytes. Other files get "".
+func NamedLanguage(path string, firstBytes []byte) string {
+	if lang := detectLanguageFromName(filepath.Base(path)); lang != "" {
+		return lang
+	}
+	if filepath.Ext(path) != "" {
+		return ""
+	}
+	return detectLanguageFromShebang(firstBytes)
+}
+
+// detectLanguageFromName recognizes well-known extensionless file names
+func detectLanguageFromName(name string) string {
+	switch {
+	case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile."):
+		return "dockerfile"
+	case name == "Makefile" || name == "makefile" || name == "GNUmakefile":
+		return "makefile"
+	case name == "CMakeLists.txt":
+		return "cmake"
+	default:
+		return ""
+	}
+}
+
+// detectLanguageFromShebang infers a script's language from its #! line
+func detectLanguageFromShebang(firstBytes []byte) string {
+	if len(firstBytes) < 2 || firstBytes[0] != '#' || firstBytes[1] != '!' {
+		return ""
+	}
+
+	line := string(firstBytes[2:])
+	if idx := strings.IndexByte(line, '\n'); idx != -1 {
+		line = line[:idx]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// "#!/usr/bin/env python3" names the interpreter in the second field
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return ""
+		}
+		interp = filepath.Base(args[0])
+	}
+
+	// Strip version suffixes like python3.11
+	interp = strings.TrimRight(interp, "0123456789.")
+
+	switch interp {
+	case "python":
+		return "python"
+	case "sh", "bash", "zsh", "ksh", "dash":
+		return "shell"
+	case "node", "nodejs", "deno", "bun":
+		return "javascript"
+	case "ruby":
+		return "ruby"
+	case "php":
+		return "php"
+	case "perl":
+		return "perl"
+	default:
+		return ""
+	}
+}
+
+// normalizeExt lowercases an extension and ensures it has a leading dot
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
 // CodeChunker implements code-aware chunking that respects function boundaries
 type CodeChunker struct {
 	lineChunker *LineChunker
@@ -251,15 +396,7 @@ func (c *CodeChunker) chunkGo(ctx context.Context, content string, opts ChunkOpt
 			startLine = lineNum
 			inFunc = true
 
-			// Extract function name
-			parts := strings.Fields(strings.TrimSpace(line))
-			if len(parts) >= 2 {
-				name := parts[1]
-				if idx := strings.Index(name, "("); idx != -1 {
-					name = name[:idx]
-				}
-				currentName = name
-			}
+			currentName = goFuncName(line)
 		}
 
 		currentChunk.WriteString(line)
@@ -310,6 +447,21 @@ func (c *CodeChunker) chunkGo(ctx context.Context, content string, opts ChunkOpt
 	return chunks, nil
 }
 
+// goFuncName returns the name declared by a "func" line, skipping a
+// method's receiver
+func goFuncName(line string) string {
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "func"))
+	if strings.HasPrefix(rest, "(") {
+		if idx := strings.Index(rest, ")"); idx != -1 {
+			rest = strings.TrimSpace(rest[idx+1:])
+		}
+	}
+	if idx := strings.IndexAny(rest, "([ "); idx != -1 {
+		rest = rest[:idx]
+	}
+	return rest
+}
+
 // chunkPython chunks Python code by function/class boundaries
 func (c *CodeChunker) chunkPython(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
 	var chunks []types.Chunk
@@ -406,22 +558,21 @@ func (c *CodeChunker) chunkPython(ctx context.Context, content string, opts Chun
 	return chunks, nil
 }
 
-// chunkJS chunks JavaScript/TypeScript code
-func (c *CodeChunker) chunkJS(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
-	// For now, use the Go chunker logic as JS is similar with braces
-	// TODO: Handle arrow functions, class methods, etc.
-	return c.chunkGo(ctx, content, opts)
-}
-
 // ChunkFile reads and chunks a file with code awareness
 func (c *CodeChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk, error) {
-	content, err := os.ReadFile(path)
+	return c.ChunkFileWithLanguage(ctx, path, "")
+}
+
+// ChunkFileWithLanguage reads and chunks a file as the given language
+func (c *CodeChunker) ChunkFileWithLanguage(ctx context.Context, path, language string) ([]types.Chunk, error) {
+	content, err := ReadTextFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	ext := strings.ToLower(filepath.Ext(path))
-	language := detectLanguage(ext)
+	if language == "" {
+		language = c.lineChunker.languageFor(path, []byte(content))
+	}
 
 	opts := ChunkOptions{
 		Language: language,
@@ -430,7 +581,12 @@ func (c *CodeChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk
 		Semantic: true,
 	}
 
-	return c.Chunk(ctx, string(content), opts)
+	return c.Chunk(ctx, content, opts)
+}
+
+// SetLanguageOverrides maps additional file extensions to languages
+func (c *CodeChunker) SetLanguageOverrides(overrides map[string]string) {
+	c.lineChunker.SetLanguageOverrides(overrides)
 }
 
 // SupportedLanguages returns list of supported programming languages
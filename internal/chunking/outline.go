@@ -0,0 +1,129 @@
+// Package chunking reduces code chunks to their declaration outlines
+package chunking
+
+import (
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// maxSignatureLines bounds how far a signature is followed looking for the
+// start of its body; past it, only the declaration's first line is kept
+const maxSignatureLines = 8
+
+// isDeclaration reports whether a chunker marked chunk as a named
+// function, method, or class
+func isDeclaration(chunk types.Chunk) bool {
+	switch chunk.Type {
+	case "function", "method", "class":
+		return chunk.Name != ""
+	}
+	return false
+}
+
+// HasDeclarations reports whether any chunk is a named declaration, which
+// only the semantic chunkers produce
+func HasDeclarations(chunks []types.Chunk) bool {
+	for _, c := range chunks {
+		if isDeclaration(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Outline replaces each declaration chunk of source with its leading
+// comments, signature, and Python docstring, dropping bodies and chunks
+// that aren't declarations. Line numbers refer to source.
+func Outline(source string, chunks []types.Chunk) []types.Chunk {
+	lines := strings.Split(source, "\n")
+	var out []types.Chunk
+	for _, chunk := range chunks {
+		if !isDeclaration(chunk) || chunk.StartLine < 1 || chunk.StartLine > len(lines) {
+			continue
+		}
+		last := min(chunk.EndLine, len(lines))
+
+		// Chunks may begin with the comments; the declaration follows them
+		decl := chunk.StartLine - 1
+		for decl < last && (isOutlineComment(lines[decl]) || strings.TrimSpace(lines[decl]) == "") {
+			decl++
+		}
+		if decl >= last {
+			continue
+		}
+
+		// Go and Python chunks start at the declaration, leaving its
+		// comments and decorators at the end of the previous chunk
+		first := decl
+		for first > 0 && isOutlineComment(lines[first-1]) {
+			first--
+		}
+
+		end := signatureEnd(lines[:last], decl)
+		end = docstringEnd(lines[:last], end)
+
+		out = append(out, types.Chunk{
+			Content:   strings.Join(lines[first:end+1], "\n"),
+			StartLine: first + 1,
+			EndLine:   end + 1,
+			Type:      chunk.Type,
+			Name:      chunk.Name,
+		})
+	}
+	return out
+}
+
+// isOutlineComment reports whether line is a comment or decorator that
+// documents the declaration below it
+func isOutlineComment(line string) bool {
+	t := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "/*", "*", "#", "@"} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureEnd returns the index of the line that opens the body of the
+// declaration starting at decl: the first with a brace, a Python colon, or
+// an arrow
+func signatureEnd(lines []string, decl int) int {
+	for i := decl; i < len(lines) && i < decl+maxSignatureLines; i++ {
+		t := strings.TrimSpace(lines[i])
+		if strings.Contains(t, "{") || strings.HasSuffix(t, ":") || strings.Contains(t, "=>") {
+			return i
+		}
+	}
+	return decl
+}
+
+// docstringEnd extends a Python signature ending at end through the
+// docstring that follows it, if any
+func docstringEnd(lines []string, end int) int {
+	next := end + 1
+	for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+		next++
+	}
+	if next >= len(lines) {
+		return end
+	}
+
+	t := strings.TrimLeft(strings.TrimSpace(lines[next]), "rbuRBU")
+	for _, quote := range []string{`"""`, `'''`} {
+		if !strings.HasPrefix(t, quote) {
+			continue
+		}
+		if strings.Contains(t[len(quote):], quote) {
+			return next
+		}
+		for i := next + 1; i < len(lines); i++ {
+			if strings.Contains(lines[i], quote) {
+				return i
+			}
+		}
+		return end
+	}
+	return end
+}
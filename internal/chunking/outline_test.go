@@ -0,0 +1,95 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestOutline_Go(t *testing.T) {
+	src := `package store
+
+import "context"
+
+// Get loads a memory by ID.
+// It returns ErrNotFound when missing.
+func (s *Store) Get(ctx context.Context, id string) (*Memory, error) {
+	row := s.db.QueryRowContext(ctx, query, id)
+	return scan(row)
+}
+
+func helper(
+	a int,
+	b int,
+) int {
+	return a + b
+}
+`
+	chunks, err := NewCodeChunker(1500, 0).Chunk(context.Background(), src, ChunkOptions{Language: "go", MaxSize: 1500, Semantic: true})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	outline := Outline(src, chunks)
+	if len(outline) != 2 {
+		t.Fatalf("expected the two functions only, got %+v", outline)
+	}
+
+	get := outline[0]
+	want := "// Get loads a memory by ID.\n// It returns ErrNotFound when missing.\nfunc (s *Store) Get(ctx context.Context, id string) (*Memory, error) {"
+	if get.Content != want || get.StartLine != 5 || get.EndLine != 7 {
+		t.Errorf("expected doc comment and signature on lines 5-7, got %d-%d %q", get.StartLine, get.EndLine, get.Content)
+	}
+	if h := outline[1]; !strings.HasSuffix(h.Content, ") int {") || strings.Contains(h.Content, "return") {
+		t.Errorf("expected the multi-line signature without its body, got %q", h.Content)
+	}
+}
+
+func TestOutline_PythonDocstring(t *testing.T) {
+	src := `import os
+
+@cache
+def load(path: str) -> dict[str, int]:
+    """Load counts from path.
+
+    Missing files give an empty dict.
+    """
+    if not os.path.exists(path):
+        return {}
+    return parse(path)
+`
+	chunks, err := NewCodeChunker(1500, 0).Chunk(context.Background(), src, ChunkOptions{Language: "python", MaxSize: 1500, Semantic: true})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	outline := Outline(src, chunks)
+	if len(outline) != 1 {
+		t.Fatalf("expected one function, got %+v", outline)
+	}
+	got := outline[0].Content
+	if !strings.HasPrefix(got, "@cache\ndef load") || !strings.HasSuffix(got, `    """`) || strings.Contains(got, "return") {
+		t.Errorf("expected decorator, signature, and docstring only, got %q", got)
+	}
+}
+
+func TestOutline_JSMethods(t *testing.T) {
+	src := `class Cache {
+  /** Reads a value */
+  get(key) {
+    return this.map.get(key);
+  }
+}
+`
+	chunks, err := NewCodeChunker(1500, 0).Chunk(context.Background(), src, ChunkOptions{Language: "javascript", MaxSize: 1500, Semantic: true})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	outline := Outline(src, chunks)
+	var names []string
+	for _, c := range outline {
+		names = append(names, c.Type+":"+strings.TrimSpace(c.Content))
+	}
+	got := strings.Join(names, "|")
+	if got != "class:class Cache {|method:/** Reads a value */\n  get(key) {" {
+		t.Errorf("unexpected outline %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+// Package chunking selects a chunker per language
+package chunking
+
+import (
+	"context"
+	"sort"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// Registry is a Chunker that detects each file's language and hands it to
+// the chunker registered for that language, or to a fallback
+type Registry struct {
+	fallback   Chunker
+	byLanguage map[string]Chunker
+	detector   *LineChunker // language detection and chunk sizes
+}
+
+// NewRegistry creates a registry that chunks unregistered languages with
+// fallback, using maxSize and overlap for every language
+func NewRegistry(fallback Chunker, maxSize, overlap int) *Registry {
+	return &Registry{
+		fallback:   fallback,
+		byLanguage: make(map[string]Chunker),
+		detector:   NewLineChunker(maxSize, overlap),
+	}
+}
+
+// DefaultRegistry chunks Go, Python, JavaScript, and TypeScript at function
+// boundaries and everything else by lines
+func DefaultRegistry(maxSize, overlap int) *Registry {
+	r := NewRegistry(NewLineChunker(maxSize, overlap), maxSize, overlap)
+	code := NewCodeChunker(maxSize, overlap)
+	for _, lang := range code.SupportedLanguages() {
+		r.Register(lang, code)
+	}
+	return r
+}
+
+// Register chunks language with c, replacing any earlier registration
+func (r *Registry) Register(language string, c Chunker) {
+	r.byLanguage[language] = c
+}
+
+// ChunkerFor returns the chunker used for language
+func (r *Registry) ChunkerFor(language string) Chunker {
+	if c, ok := r.byLanguage[language]; ok {
+		return c
+	}
+	return r.fallback
+}
+
+// Chunk splits content with the chunker for opts.Language
+func (r *Registry) Chunk(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
+	chunks, err := r.ChunkerFor(opts.Language).Chunk(ctx, content, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		if chunks[i].Type == "" {
+			chunks[i].Type = opts.Language
+		}
+	}
+	return chunks, nil
+}
+
+// ChunkFile reads and chunks a file, detecting language automatically
+func (r *Registry) ChunkFile(ctx context.Context, path string) ([]types.Chunk, error) {
+	return r.ChunkFileWithLanguage(ctx, path, "")
+}
+
+// ChunkFileWithLanguage reads and chunks a file as the given language
+func (r *Registry) ChunkFileWithLanguage(ctx context.Context, path, language string) ([]types.Chunk, error) {
+	content, err := ReadTextFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if language == "" {
+		language = r.detector.languageFor(path, []byte(content))
+	}
+
+	opts := ChunkOptions{
+		Language: language,
+		MaxSize:  r.detector.maxSize,
+		Overlap:  r.detector.overlap,
+		Semantic: true,
+	}
+	return r.Chunk(ctx, content, opts)
+}
+
+// SetLanguageOverrides maps additional file extensions to languages
+func (r *Registry) SetLanguageOverrides(overrides map[string]string) {
+	r.detector.SetLanguageOverrides(overrides)
+}
+
+// SupportedLanguages returns the languages with a registered chunker
+func (r *Registry) SupportedLanguages() []string {
+	languages := make([]string, 0, len(r.byLanguage))
+	for lang := range r.byLanguage {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
@@ -0,0 +1,62 @@
+package chunking
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// wholeChunker returns content as a single chunk without a type
+type wholeChunker struct{ LineChunker }
+
+func (c *wholeChunker) Chunk(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
+	return []types.Chunk{{Content: content, StartLine: 1, Name: "whole"}}, nil
+}
+
+func TestRegistry_SelectsChunkerByLanguage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	readme := write("README.md", "# Title\n\nSome text\n")
+	code := write("main.go", "package main\n\nfunc main() {\n}\n")
+
+	r := DefaultRegistry(1500, 100)
+	r.Register("markdown", &wholeChunker{})
+	ctx := context.Background()
+
+	chunks, err := r.ChunkFile(ctx, readme)
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Name != "whole" || chunks[0].Type != "markdown" {
+		t.Errorf("expected the registered markdown chunker, got %+v", chunks)
+	}
+
+	chunks, err = r.ChunkFile(ctx, code)
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	if len(chunks) != 2 || chunks[1].Name != "main" {
+		t.Errorf("expected function chunks from the code chunker, got %+v", chunks)
+	}
+
+	// An explicit language wins over detection
+	chunks, _ = r.ChunkFileWithLanguage(ctx, code, "markdown")
+	if len(chunks) != 1 || chunks[0].Name != "whole" {
+		t.Errorf("expected the markdown chunker for --lang markdown, got %+v", chunks)
+	}
+
+	want := []string{"go", "javascript", "markdown", "python", "typescript"}
+	if got := r.SupportedLanguages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
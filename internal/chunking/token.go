@@ -0,0 +1,119 @@
+// Package chunking caps chunks by their token count
+package chunking
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// DefaultMaxTokens fits the 512-token context of common embedding models
+// such as nomic-embed-text
+const DefaultMaxTokens = 512
+
+// TokenChunker chunks with another Chunker, then splits any chunk over the
+// token limit at line boundaries. The inner chunker still closes chunks at
+// MaxSize, so whichever limit is reached first ends a chunk. A single line
+// over the limit is kept whole.
+type TokenChunker struct {
+	inner     Chunker
+	maxTokens int
+	tokenizer tokenizer.Tokenizer
+}
+
+// NewTokenChunker caps the chunks of inner at maxTokens as counted by tok,
+// defaulting to DefaultMaxTokens and tokenizer.Heuristic
+func NewTokenChunker(inner Chunker, maxTokens int, tok tokenizer.Tokenizer) *TokenChunker {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	if tok == nil {
+		tok = tokenizer.Heuristic{}
+	}
+	return &TokenChunker{inner: inner, maxTokens: maxTokens, tokenizer: tok}
+}
+
+// Chunk splits content with the inner chunker and caps each chunk at
+// opts.MaxTokens as counted by opts.Tokenizer, or the chunker's own limit
+// and tokenizer where those are unset
+func (c *TokenChunker) Chunk(ctx context.Context, content string, opts ChunkOptions) ([]types.Chunk, error) {
+	chunks, err := c.inner.Chunk(ctx, content, opts)
+	if err != nil {
+		return nil, err
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = c.maxTokens
+	}
+	tok := opts.Tokenizer
+	if tok == nil {
+		tok = c.tokenizer
+	}
+	return split(chunks, maxTokens, tok), nil
+}
+
+// ChunkFile reads and chunks a file, detecting language automatically
+func (c *TokenChunker) ChunkFile(ctx context.Context, path string) ([]types.Chunk, error) {
+	return c.ChunkFileWithLanguage(ctx, path, "")
+}
+
+// ChunkFileWithLanguage reads and chunks a file as the given language
+func (c *TokenChunker) ChunkFileWithLanguage(ctx context.Context, path, language string) ([]types.Chunk, error) {
+	chunks, err := c.inner.ChunkFileWithLanguage(ctx, path, language)
+	if err != nil {
+		return nil, err
+	}
+	return split(chunks, c.maxTokens, c.tokenizer), nil
+}
+
+// SupportedLanguages returns the inner chunker's languages
+func (c *TokenChunker) SupportedLanguages() []string {
+	return c.inner.SupportedLanguages()
+}
+
+// split replaces every chunk over maxTokens with consecutive pieces of its
+// lines that each fit
+func split(chunks []types.Chunk, maxTokens int, tok tokenizer.Tokenizer) []types.Chunk {
+	out := make([]types.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if tok.Count(chunk.Content) <= maxTokens {
+			out = append(out, chunk)
+			continue
+		}
+
+		lines := strings.Split(chunk.Content, "\n")
+		first := len(out)
+		for start := 0; start < len(lines); {
+			end := start + 1
+			for end < len(lines) && tok.Count(strings.Join(lines[start:end+1], "\n")) <= maxTokens {
+				end++
+			}
+			// Number the piece by its first and last non-blank lines
+			from, to := start, end
+			for from < to && strings.TrimSpace(lines[from]) == "" {
+				from++
+			}
+			for to > from && strings.TrimSpace(lines[to-1]) == "" {
+				to--
+			}
+			if from < to {
+				out = append(out, types.Chunk{
+					Content:   strings.Join(lines[from:to], "\n"),
+					StartLine: chunk.StartLine + from,
+					EndLine:   chunk.StartLine + to - 1,
+					Type:      chunk.Type,
+					Name:      chunk.Name,
+				})
+			}
+			start = end
+		}
+
+		// Only the first piece can begin with the previous chunk's overlap
+		if first < len(out) && out[first].StartLine == chunk.StartLine {
+			out[first].Overlap = min(chunk.Overlap, len(out[first].Content))
+		}
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
+)
+
+// charTokenizer counts one token per byte, as for dense code
+type charTokenizer struct{}
+
+func (charTokenizer) Count(text string) int { return len(text) }
+
+func (charTokenizer) Encode(text string) []int {
+	ids := make([]int, len(text))
+	for i := range text {
+		ids[i] = int(text[i])
+	}
+	return ids
+}
+
+func TestTokenChunker_SplitsByTokens(t *testing.T) {
+	// Ten lines of ten words fit in one 1500-char chunk but not in 20 tokens
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, strings.TrimSpace(strings.Repeat("word ", 10)))
+	}
+	content := strings.Join(lines, "\n")
+
+	chunker := NewTokenChunker(NewLineChunker(1500, 0), 20, nil)
+	chunks, err := chunker.Chunk(context.Background(), content, ChunkOptions{MaxSize: 1500})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 chunks of two lines, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := (tokenizer.Heuristic{}).Count(c.Content); n > 20 {
+			t.Errorf("chunk %d has %d tokens, over the limit", i, n)
+		}
+		if c.StartLine != 2*i+1 || c.EndLine != 2*i+2 {
+			t.Errorf("expected chunk %d on lines %d-%d, got %d-%d", i, 2*i+1, 2*i+2, c.StartLine, c.EndLine)
+		}
+	}
+}
+
+func TestTokenChunker_MaxSizeFirst(t *testing.T) {
+	// Long words hit the character limit well before the token limit
+	content := strings.Repeat(strings.Repeat("x", 60)+"\n", 10)
+
+	chunker := NewTokenChunker(NewLineChunker(150, 0), 512, nil)
+	chunks, err := chunker.Chunk(context.Background(), content, ChunkOptions{MaxSize: 150, Overlap: 0})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	if len(chunks) != 5 {
+		t.Errorf("expected MaxSize to close 5 chunks, got %d", len(chunks))
+	}
+}
+
+func TestTokenChunker_CustomTokenizer(t *testing.T) {
+	content := "func a() {}\nfunc b() {}\nfunc c() {}"
+
+	chunker := NewTokenChunker(NewLineChunker(1500, 0), 1500, charTokenizer{})
+	chunks, err := chunker.Chunk(context.Background(), content, ChunkOptions{MaxSize: 1500, MaxTokens: 12})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected opts.MaxTokens to split every line, got %d chunks", len(chunks))
+	}
+	if chunks[2].Content != "func c() {}" || chunks[2].StartLine != 3 {
+		t.Errorf("expected the last function on line 3, got %+v", chunks[2])
+	}
+
+	// opts.Tokenizer overrides the chunker's
+	chunker = NewTokenChunker(NewLineChunker(1500, 0), 12, nil)
+	if chunks, _ := chunker.Chunk(context.Background(), content, ChunkOptions{MaxSize: 1500}); len(chunks) != 1 {
+		t.Errorf("expected the heuristic to fit all three lines in 12 tokens, got %d chunks", len(chunks))
+	}
+	chunks, _ = chunker.Chunk(context.Background(), content, ChunkOptions{MaxSize: 1500, Tokenizer: charTokenizer{}})
+	if len(chunks) != 3 {
+		t.Errorf("expected opts.Tokenizer to split every line, got %d chunks", len(chunks))
+	}
+}
+
+func TestTokenChunker_KeepsChunkMetadata(t *testing.T) {
+	src := "package main\n\n// Big does a lot\nfunc Big() {\n\ta := 1\n\n\tb := 2\n\treturn\n}\n"
+	chunker := NewTokenChunker(NewCodeChunker(1500, 0), 6, nil)
+	chunks, err := chunker.Chunk(context.Background(), src, ChunkOptions{Language: "go", MaxSize: 1500, Semantic: true})
+	if err != nil {
+		t.Fatalf("chunk failed: %v", err)
+	}
+
+	var pieces int
+	for _, c := range chunks {
+		if c.Name != "Big" {
+			continue
+		}
+		pieces++
+		if c.Type != "function" {
+			t.Errorf("expected split pieces to stay functions, got %q", c.Type)
+		}
+		if strings.HasPrefix(c.Content, "\n") || strings.HasSuffix(c.Content, "\n") {
+			t.Errorf("expected blank lines trimmed from %q", c.Content)
+		}
+	}
+	if pieces < 2 {
+		t.Errorf("expected Big split into pieces, got %d", pieces)
+	}
+}
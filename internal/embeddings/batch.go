@@ -0,0 +1,193 @@
+// Package embeddings provides shared helpers for embedder implementations
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatusError is returned when the embedding server answers a request with
+// an error status. A 4xx other than 404 or 429 is specific to the input
+// (e.g. a text longer than the model's context); 5xx, 429, and 404 mean the
+// server can't embed anything right now.
+type StatusError struct {
+	Server string // e.g. "Ollama"; empty reads as "embedding server"
+	Code   int
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	server := e.Server
+	if server == "" {
+		server = "embedding server"
+	}
+	return fmt.Sprintf("%s returned status %d: %s", server, e.Code, e.Body)
+}
+
+// BatchError reports the texts of a batch that could not be embedded. The
+// other embeddings are still returned; failed positions hold nil.
+type BatchError struct {
+	Failed map[int]error // Batch index to error
+}
+
+func (e *BatchError) Error() string {
+	indexes := e.Indexes()
+	return fmt.Sprintf("failed to embed %d text(s), first at index %d: %v", len(indexes), indexes[0], e.Failed[indexes[0]])
+}
+
+// Indexes returns the failed batch indexes in order
+func (e *BatchError) Indexes() []int {
+	indexes := make([]int, 0, len(e.Failed))
+	for i := range e.Failed {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// isInputError reports whether err is specific to one text, so the rest of
+// the batch can still be embedded. A server that is down, overloaded, or
+// missing the model fails the batch instead.
+func isInputError(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Code >= 400 && statusErr.Code < 500 && !isUnavailable(err)
+}
+
+// embedFunc generates an embedding for a single text
+type embedFunc func(ctx context.Context, text string) ([]float32, error)
+
+// embedBatchDedup embeds each unique text in the batch once and fans the
+// result back out to every position that held the same text.
+// Indexing batches often repeat boilerplate chunks, so this saves model calls
+// before the per-text cache is even consulted.
+//
+// A text the server rejects does not fail the batch: its position is left nil
+// and reported in a *BatchError alongside the other embeddings. Any other
+// error, such as the server being unreachable, fails the whole batch.
+//
+// When ctx has a deadline, no text is started once less time is left than
+// texts have taken so far on average. The embeddings done by then are
+// returned, the rest left nil, with an error wrapping
+// context.DeadlineExceeded.
+func embedBatchDedup(ctx context.Context, texts []string, embed embedFunc) ([][]float32, error) {
+	return embedBatchConcurrent(ctx, texts, nil, embed, 1)
+}
+
+// embedBatchConcurrent is embedBatchDedup with up to workers texts embedded
+// at once. Texts that lookup finds are filled in without taking a worker.
+// Embeddings keep their input order, and the first error that fails the
+// batch cancels the requests still running.
+func embedBatchConcurrent(ctx context.Context, texts []string, lookup func(string) ([]float32, bool), embed embedFunc, workers int) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	workers = max(workers, 1)
+
+	// Map each unique text to the first index it appears at, and queue the
+	// ones that need the model
+	firstIndex := make(map[string]int, len(texts))
+	var pending []int
+	for i, text := range texts {
+		if _, ok := firstIndex[text]; ok {
+			continue
+		}
+		firstIndex[text] = i
+		if lookup != nil {
+			if emb, ok := lookup(text); ok {
+				embeddings[i] = emb
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		failed  = make(map[int]error)
+		fatal   error // fails the whole batch
+		stopped error // ctx ended; what's done is returned
+		spent   time.Duration
+		calls   int
+	)
+	deadline, hasDeadline := ctx.Deadline()
+	slots := make(chan struct{}, workers)
+
+	for _, i := range pending {
+		slots <- struct{}{}
+
+		mu.Lock()
+		if fatal == nil && stopped == nil {
+			if err := ctx.Err(); err != nil {
+				stopped = fmt.Errorf("stopped before text %d of %d: %w", i, len(texts), err)
+			} else if hasDeadline && calls > 0 && time.Until(deadline) < spent/time.Duration(calls) {
+				stopped = fmt.Errorf("stopped before text %d of %d, too close to the deadline to finish it: %w", i, len(texts), context.DeadlineExceeded)
+			}
+		}
+		done := fatal != nil || stopped != nil
+		mu.Unlock()
+		if done {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			emb, err := embed(runCtx, texts[i])
+
+			mu.Lock()
+			defer mu.Unlock()
+			spent += time.Since(start)
+			calls++
+			switch {
+			case err == nil:
+				embeddings[i] = emb
+			case ctx.Err() != nil:
+				if stopped == nil {
+					stopped = fmt.Errorf("failed to embed text %d: %w", i, err)
+				}
+			case runCtx.Err() != nil:
+				// Cancelled because another text failed the batch
+			case isInputError(err):
+				failed[i] = err
+			default:
+				if fatal == nil {
+					fatal = fmt.Errorf("failed to embed text %d: %w", i, err)
+					cancel()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if fatal != nil {
+		return nil, fatal
+	}
+	for i, text := range texts {
+		if j := firstIndex[text]; j != i {
+			embeddings[i] = embeddings[j]
+			if err, ok := failed[j]; ok {
+				failed[i] = err
+			}
+		}
+	}
+	if stopped != nil {
+		return embeddings, stopped
+	}
+	if len(failed) > 0 {
+		return embeddings, &BatchError{Failed: failed}
+	}
+	return embeddings, nil
+}
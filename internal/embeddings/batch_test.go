@@ -0,0 +1,144 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEmbedBatchDedup_Duplicates(t *testing.T) {
+	calls := make(map[string]int)
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		calls[text]++
+		return []float32{float32(len(text))}, nil
+	}
+
+	texts := []string{"a", "bb", "a", "ccc", "bb", "a"}
+	embeddings, err := embedBatchDedup(context.Background(), texts, embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(calls) != 3 {
+		t.Errorf("expected 3 unique texts embedded, got %d", len(calls))
+	}
+	for text, n := range calls {
+		if n != 1 {
+			t.Errorf("text %q embedded %d times, want 1", text, n)
+		}
+	}
+
+	if len(embeddings) != len(texts) {
+		t.Fatalf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+	for i, text := range texts {
+		if embeddings[i][0] != float32(len(text)) {
+			t.Errorf("embedding %d mismatch: got %v, want %v", i, embeddings[i][0], len(text))
+		}
+	}
+}
+
+func TestEmbedBatchDedup_Empty(t *testing.T) {
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		t.Error("embed should not be called for empty batch")
+		return nil, nil
+	}
+
+	embeddings, err := embedBatchDedup(context.Background(), nil, embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embeddings) != 0 {
+		t.Errorf("expected no embeddings, got %d", len(embeddings))
+	}
+}
+
+func TestEmbedBatchDedup_Error(t *testing.T) {
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		if text == "bad" {
+			return nil, errors.New("boom")
+		}
+		return []float32{1}, nil
+	}
+
+	_, err := embedBatchDedup(context.Background(), []string{"ok", "bad"}, embed)
+	if err == nil {
+		t.Error("expected error from failing text")
+	}
+}
+
+func TestEmbedBatchDedup_IsolatesRejectedTexts(t *testing.T) {
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		if text == "huge" {
+			return nil, &StatusError{Code: 400, Body: "input exceeds context length"}
+		}
+		return []float32{1}, nil
+	}
+
+	embeddings, err := embedBatchDedup(context.Background(), []string{"ok", "huge", "fine", "huge"}, embed)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a *BatchError, got %v", err)
+	}
+	if got := batchErr.Indexes(); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("expected indexes [1 3] to fail, got %v", got)
+	}
+	if embeddings[0] == nil || embeddings[2] == nil || embeddings[1] != nil || embeddings[3] != nil {
+		t.Errorf("expected embeddings only for accepted texts, got %v", embeddings)
+	}
+}
+
+func TestEmbedBatchDedup_ServerErrorsFailBatch(t *testing.T) {
+	for _, code := range []int{503, 429, 404} {
+		embed := func(ctx context.Context, text string) ([]float32, error) {
+			if text == "b" {
+				return nil, &StatusError{Code: code}
+			}
+			return []float32{1}, nil
+		}
+		_, err := embedBatchDedup(context.Background(), []string{"a", "b", "c"}, embed)
+		var batchErr *BatchError
+		if err == nil || errors.As(err, &batchErr) {
+			t.Errorf("status %d: expected the whole batch to fail, got %v", code, err)
+		}
+	}
+}
+
+func TestEmbedBatchDedup_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	interrupted := 0
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return []float32{1}, nil
+		case <-ctx.Done():
+			interrupted++
+			return nil, ctx.Err()
+		}
+	}
+
+	texts := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	embeddings, err := embedBatchDedup(ctx, texts, embed)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if len(embeddings) != len(texts) {
+		t.Fatalf("expected %d positions, got %d", len(texts), len(embeddings))
+	}
+
+	done := 0
+	for _, e := range embeddings {
+		if e != nil {
+			done++
+		}
+	}
+	if done == 0 || done == len(texts) {
+		t.Errorf("expected some but not all texts embedded, got %d", done)
+	}
+	if interrupted != 0 {
+		t.Errorf("expected no text started without time to finish, %d were cut off", interrupted)
+	}
+}
@@ -0,0 +1,224 @@
+// Package embeddings provides a circuit breaker for failing embedders
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned without calling the embedder while the circuit
+// breaker is open, and by embedders that can't serve any request, such as
+// an ONNX model that isn't downloaded
+var ErrUnavailable = errors.New("embedder unavailable")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Calls pass through
+	BreakerOpen                         // Calls fail fast with ErrUnavailable
+	BreakerHalfOpen                     // One probe call tests recovery
+)
+
+// String returns the state name
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive failures that open the circuit
+	Cooldown         time.Duration // How long the circuit stays open before a probe
+}
+
+// DefaultBreakerConfig returns sensible defaults
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// CircuitBreaker wraps an Embedder and stops calling it after repeated
+// failures. After the cooldown a single probe is let through; success closes
+// the circuit and failure reopens it.
+type CircuitBreaker struct {
+	inner     Embedder
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker wraps inner with a circuit breaker
+func NewCircuitBreaker(inner Embedder, cfg BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaults.FailureThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaults.Cooldown
+	}
+
+	return &CircuitBreaker{
+		inner:     inner,
+		threshold: cfg.FailureThreshold,
+		cooldown:  cfg.Cooldown,
+		now:       time.Now,
+	}
+}
+
+// Embed generates an embedding unless the circuit is open
+func (b *CircuitBreaker) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	embedding, err := b.inner.Embed(ctx, text)
+	b.record(ctx, err)
+	return embedding, err
+}
+
+// EmbedQuery embeds a search query unless the circuit is open, falling back
+// to Embed for embedders without query support
+func (b *CircuitBreaker) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	var embedding []float32
+	var err error
+	if q, ok := b.inner.(QueryEmbedder); ok {
+		embedding, err = q.EmbedQuery(ctx, text)
+	} else {
+		embedding, err = b.inner.Embed(ctx, text)
+	}
+	b.record(ctx, err)
+	return embedding, err
+}
+
+// EmbedBatch generates embeddings unless the circuit is open
+func (b *CircuitBreaker) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	embeddings, err := b.inner.EmbedBatch(ctx, texts)
+	b.record(ctx, err)
+	return embeddings, err
+}
+
+// allow reports whether a call may proceed, moving an open circuit to
+// half-open once the cooldown has passed
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		wait := b.cooldown - b.now().Sub(b.openedAt)
+		if wait > 0 {
+			return fmt.Errorf("%w: circuit open, retrying in %s", ErrUnavailable, wait.Round(time.Second))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: circuit half-open, probe in progress", ErrUnavailable)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the circuit with a call's outcome. Calls abandoned by the
+// caller's context say nothing about the embedder and are not counted.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.state == BreakerHalfOpen
+	b.probing = false
+
+	// Texts the embedder rejected mean it is up
+	var batchErr *BatchError
+	if err == nil || errors.As(err, &batchErr) || isInputError(err) {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	b.failures++
+	if wasProbe || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// State returns the current circuit state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Dimensions returns the wrapped embedder's dimensions
+func (b *CircuitBreaker) Dimensions() int {
+	return b.inner.Dimensions()
+}
+
+// Model returns the wrapped embedder's model
+func (b *CircuitBreaker) Model() string {
+	return b.inner.Model()
+}
+
+// Close closes the wrapped embedder
+func (b *CircuitBreaker) Close() error {
+	return b.inner.Close()
+}
+
+// Stats forwards the wrapped embedder's statistics, if it reports them
+func (b *CircuitBreaker) Stats() (requests int64, avgLatencyMs float64, cacheHitRate float64) {
+	if s, ok := b.inner.(interface {
+		Stats() (int64, float64, float64)
+	}); ok {
+		return s.Stats()
+	}
+	return 0, 0, 0
+}
+
+// LatencyPercentiles forwards the wrapped embedder's p50, p95, and p99
+// latency, if it reports them
+func (b *CircuitBreaker) LatencyPercentiles() (p50, p95, p99 time.Duration) {
+	if l, ok := b.inner.(interface {
+		LatencyPercentiles() (time.Duration, time.Duration, time.Duration)
+	}); ok {
+		return l.LatencyPercentiles()
+	}
+	return 0, 0, 0
+}
+
+// CacheEnabled forwards the wrapped embedder's cache status. Embedders
+// without a cache report true so they are not shown as bypassed.
+func (b *CircuitBreaker) CacheEnabled() bool {
+	if c, ok := b.inner.(interface{ CacheEnabled() bool }); ok {
+		return c.CacheEnabled()
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyEmbedder fails while down is set
+type flakyEmbedder struct {
+	down  bool
+	calls int
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	if e.down {
+		return nil, errors.New("connection refused")
+	}
+	return []float32{1}, nil
+}
+
+func (e *flakyEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		emb, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = emb
+	}
+	return out, nil
+}
+
+func (e *flakyEmbedder) Dimensions() int { return 1 }
+func (e *flakyEmbedder) Model() string   { return "flaky" }
+func (e *flakyEmbedder) Close() error    { return nil }
+
+func TestCircuitBreaker(t *testing.T) {
+	inner := &flakyEmbedder{down: true}
+	b := NewCircuitBreaker(inner, BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Consecutive failures open the circuit
+	for i := 0; i < 3; i++ {
+		b.Embed(ctx, "x")
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected open after 3 failures, got %s", b.State())
+	}
+
+	// Open circuits fail fast without calling the embedder
+	calls := inner.calls
+	if _, err := b.Embed(ctx, "x"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+	if inner.calls != calls {
+		t.Error("open circuit should not call the embedder")
+	}
+
+	// After the cooldown a failed probe reopens the circuit
+	now = now.Add(time.Minute)
+	if _, err := b.Embed(ctx, "x"); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected the probe to reach the embedder, got %v", err)
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to reopen, got %s", b.State())
+	}
+
+	// A successful probe closes it
+	inner.down = false
+	now = now.Add(time.Minute)
+	if _, err := b.Embed(ctx, "x"); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("expected closed after a successful probe, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_IgnoresCanceledCalls(t *testing.T) {
+	inner := &flakyEmbedder{down: true}
+	b := NewCircuitBreaker(inner, BreakerConfig{FailureThreshold: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Embed(ctx, "x")
+
+	if b.State() != BreakerClosed {
+		t.Errorf("canceled calls should not open the circuit, got %s", b.State())
+	}
+}
+
+// rejectingEmbedder embeds nothing, rejecting every text individually
+type rejectingEmbedder struct{ flakyEmbedder }
+
+func (e *rejectingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return make([][]float32, len(texts)), &BatchError{Failed: map[int]error{0: &StatusError{Code: 400}}}
+}
+
+func TestCircuitBreaker_IgnoresRejectedTexts(t *testing.T) {
+	b := NewCircuitBreaker(&rejectingEmbedder{}, BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+
+	b.EmbedBatch(context.Background(), []string{"too long"})
+	if b.State() != BreakerClosed {
+		t.Errorf("rejected texts should not open the circuit, got %s", b.State())
+	}
+}
+
+// statusEmbedder fails every call with an HTTP status
+type statusEmbedder struct {
+	flakyEmbedder
+	code int
+}
+
+func (e *statusEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, &StatusError{Code: e.code}
+}
+
+func TestCircuitBreaker_IgnoresRejectedInput(t *testing.T) {
+	b := NewCircuitBreaker(&statusEmbedder{code: 413}, BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	for i := 0; i < 5; i++ {
+		b.Embed(context.Background(), "too long")
+		b.EmbedQuery(context.Background(), "too long")
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("rejected single texts should not open the circuit, got %s", b.State())
+	}
+
+	// Server errors still count
+	b = NewCircuitBreaker(&statusEmbedder{code: 503}, BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	b.Embed(context.Background(), "x")
+	b.Embed(context.Background(), "x")
+	if b.State() != BreakerOpen {
+		t.Errorf("expected server errors to open the circuit, got %s", b.State())
+	}
+}
@@ -20,3 +20,9 @@ type Embedder interface {
 	// Close releases any resources
 	Close() error
 }
+
+// QueryEmbedder is implemented by embedders that embed search queries
+// differently from stored content. Embed and EmbedBatch embed content.
+type QueryEmbedder interface {
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
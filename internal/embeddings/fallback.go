@@ -0,0 +1,269 @@
+// Package embeddings falls back through an ordered chain of embedders
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFallbackRecheck is how long FallbackEmbedder stays on a fallback
+// before trying the embedders ahead of it again
+const DefaultFallbackRecheck = 30 * time.Second
+
+// FallbackEmbedder tries an ordered list of embedders, moving to the next
+// when one is unavailable. It remembers the first that worked and goes
+// straight to it, so a dead provider costs one failed call per recheck
+// interval rather than one per call. Errors about the input, such as a
+// text the model rejects, are returned as is: the next embedder would
+// reject it too.
+//
+// Every embedder must produce embeddings of the same length, since they
+// share one store.
+type FallbackEmbedder struct {
+	embedders []Embedder
+	recheck   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	current  int       // index of the embedder that last worked
+	fellBack time.Time // when current moved past the first embedder
+	dims     int
+}
+
+// NewFallbackEmbedder chains embedders in order of preference. Embedders
+// reporting different non-zero dimensions are rejected. A zero recheck
+// uses DefaultFallbackRecheck.
+func NewFallbackEmbedder(embedders []Embedder, recheck time.Duration) (*FallbackEmbedder, error) {
+	if len(embedders) == 0 {
+		return nil, fmt.Errorf("fallback chain has no embedders")
+	}
+	if recheck <= 0 {
+		recheck = DefaultFallbackRecheck
+	}
+
+	f := &FallbackEmbedder{embedders: embedders, recheck: recheck, now: time.Now}
+	var first Embedder
+	for _, e := range embedders {
+		d := e.Dimensions()
+		if d == 0 {
+			continue
+		}
+		if f.dims == 0 {
+			f.dims, first = d, e
+		} else if d != f.dims {
+			return nil, fmt.Errorf("embedders in a fallback chain must share dimensions: %s has %d, %s has %d",
+				first.Model(), f.dims, e.Model(), d)
+		}
+	}
+	return f, nil
+}
+
+// isUnavailable reports whether err means the embedder can't serve any
+// request right now, as opposed to rejecting this one: it said so, was
+// unreachable or failing, or doesn't have the model (a 404)
+func isUnavailable(err error) bool {
+	if errors.Is(err, ErrUnavailable) || isTransient(err) {
+		return true
+	}
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound
+}
+
+// start returns the index to try first: the embedder that last worked,
+// or the first again once the recheck interval has passed
+func (f *FallbackEmbedder) start() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.current > 0 && f.now().Sub(f.fellBack) >= f.recheck {
+		f.current = 0
+	}
+	return f.current
+}
+
+// use records that embedder i worked
+func (f *FallbackEmbedder) use(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i != f.current {
+		f.current = i
+		f.fellBack = f.now()
+	}
+}
+
+// checkDimensions rejects embeddings of a length other than the chain's,
+// learning it from the first embedding if no embedder reported one
+func (f *FallbackEmbedder) checkDimensions(e Embedder, embeddings ...[]float32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, emb := range embeddings {
+		if emb == nil {
+			continue
+		}
+		if f.dims == 0 {
+			f.dims = len(emb)
+		}
+		if len(emb) != f.dims {
+			return fmt.Errorf("%s returned %d dimensions, expected %d like the rest of the fallback chain", e.Model(), len(emb), f.dims)
+		}
+	}
+	return nil
+}
+
+// try calls fn on each embedder from the current one on, until one is
+// available. Embedders before the current one are tried last.
+func (f *FallbackEmbedder) try(ctx context.Context, fn func(Embedder) error) error {
+	start := f.start()
+	var errs []string
+	for n := range f.embedders {
+		i := (start + n) % len(f.embedders)
+		e := f.embedders[i]
+		err := fn(e)
+		if err == nil || !isUnavailable(err) {
+			// Rejected input still means the embedder is up
+			f.use(i)
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", e.Model(), err))
+	}
+	return fmt.Errorf("%w: every embedder in the fallback chain failed (%s)", ErrUnavailable, strings.Join(errs, "; "))
+}
+
+// Embed generates an embedding with the first available embedder
+func (f *FallbackEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	var embedding []float32
+	err := f.try(ctx, func(e Embedder) error {
+		var err error
+		if embedding, err = e.Embed(ctx, text); err != nil {
+			return err
+		}
+		return f.checkDimensions(e, embedding)
+	})
+	return embedding, err
+}
+
+// EmbedQuery embeds a search query with the first available embedder,
+// using Embed for embedders without query support
+func (f *FallbackEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	var embedding []float32
+	err := f.try(ctx, func(e Embedder) error {
+		var err error
+		if q, ok := e.(QueryEmbedder); ok {
+			embedding, err = q.EmbedQuery(ctx, text)
+		} else {
+			embedding, err = e.Embed(ctx, text)
+		}
+		if err != nil {
+			return err
+		}
+		return f.checkDimensions(e, embedding)
+	})
+	return embedding, err
+}
+
+// EmbedBatch generates embeddings with the first available embedder. A
+// batch is never split across embedders.
+func (f *FallbackEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	err := f.try(ctx, func(e Embedder) error {
+		var err error
+		embeddings, err = e.EmbedBatch(ctx, texts)
+		if err != nil && isUnavailable(err) {
+			return err
+		}
+		// A *BatchError still carries the embeddings that worked
+		if dimErr := f.checkDimensions(e, embeddings...); dimErr != nil {
+			return dimErr
+		}
+		return err
+	})
+	return embeddings, err
+}
+
+// active returns the embedder calls currently go to first
+func (f *FallbackEmbedder) active() Embedder {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.embedders[f.current]
+}
+
+// Dimensions returns the embedding length every embedder shares, or 0
+// until one is known
+func (f *FallbackEmbedder) Dimensions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dims
+}
+
+// Model returns the model of the embedder currently in use
+func (f *FallbackEmbedder) Model() string {
+	return f.active().Model()
+}
+
+// Ping succeeds if any embedder in the chain is healthy
+func (f *FallbackEmbedder) Ping(ctx context.Context) error {
+	var errs []string
+	for _, e := range f.embedders {
+		p, ok := e.(interface{ Ping(context.Context) error })
+		if !ok {
+			continue
+		}
+		err := p.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("every embedder in the fallback chain failed: %s", strings.Join(errs, "; "))
+}
+
+// Close closes every embedder in the chain
+func (f *FallbackEmbedder) Close() error {
+	var first error
+	for _, e := range f.embedders {
+		if err := e.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stats forwards the statistics of the embedder currently in use, if it
+// reports them
+func (f *FallbackEmbedder) Stats() (requests int64, avgLatencyMs float64, cacheHitRate float64) {
+	if s, ok := f.active().(interface {
+		Stats() (int64, float64, float64)
+	}); ok {
+		return s.Stats()
+	}
+	return 0, 0, 0
+}
+
+// LatencyPercentiles forwards the p50, p95, and p99 latency of the embedder
+// currently in use, if it reports them
+func (f *FallbackEmbedder) LatencyPercentiles() (p50, p95, p99 time.Duration) {
+	if l, ok := f.active().(interface {
+		LatencyPercentiles() (time.Duration, time.Duration, time.Duration)
+	}); ok {
+		return l.LatencyPercentiles()
+	}
+	return 0, 0, 0
+}
+
+// CacheEnabled forwards the cache status of the embedder currently in use
+func (f *FallbackEmbedder) CacheEnabled() bool {
+	if c, ok := f.active().(interface{ CacheEnabled() bool }); ok {
+		return c.CacheEnabled()
+	}
+	return true
+}
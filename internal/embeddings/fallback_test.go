@@ -0,0 +1,110 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chainEmbedder fails with err, or returns an embedding of dims ones
+type chainEmbedder struct {
+	name  string
+	dims  int
+	err   error
+	calls int
+}
+
+func (e *chainEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	emb := make([]float32, e.dims)
+	for i := range emb {
+		emb[i] = 1
+	}
+	return emb, nil
+}
+
+func (e *chainEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatchDedup(ctx, texts, e.Embed)
+}
+
+func (e *chainEmbedder) Dimensions() int { return e.dims }
+func (e *chainEmbedder) Model() string   { return e.name }
+func (e *chainEmbedder) Close() error    { return nil }
+
+func TestFallbackEmbedder(t *testing.T) {
+	onnx := &chainEmbedder{name: "onnx", dims: 4, err: ErrUnavailable}
+	ollama := &chainEmbedder{name: "ollama", dims: 4}
+	f, err := NewFallbackEmbedder([]Embedder{onnx, ollama}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// An unavailable embedder falls through to the next
+	if emb, err := f.Embed(ctx, "x"); err != nil || len(emb) != 4 {
+		t.Fatalf("expected a fallback embedding, got %v, %v", emb, err)
+	}
+	if f.Model() != "ollama" {
+		t.Errorf("expected ollama in use, got %s", f.Model())
+	}
+
+	// The dead embedder is skipped until the recheck interval passes
+	f.EmbedBatch(ctx, []string{"a", "b"})
+	if onnx.calls != 1 {
+		t.Errorf("expected onnx to be skipped, got %d calls", onnx.calls)
+	}
+	now = now.Add(time.Minute)
+	onnx.err = nil
+	f.Embed(ctx, "x")
+	if onnx.calls != 2 || f.Model() != "onnx" {
+		t.Errorf("expected onnx to be rechecked and used, got %d calls, model %s", onnx.calls, f.Model())
+	}
+
+	// Input errors don't fall back
+	onnx.err = &StatusError{Server: "onnx", Code: 400, Body: "input too long"}
+	ollamaCalls := ollama.calls
+	if _, err := f.Embed(ctx, "x"); err == nil || ollama.calls != ollamaCalls {
+		t.Errorf("expected the input error without a fallback, got %v after %d calls", err, ollama.calls-ollamaCalls)
+	}
+
+	// Missing models and servers do
+	onnx.err = &StatusError{Server: "onnx", Code: 404, Body: "model not found"}
+	if _, err := f.Embed(ctx, "x"); err != nil {
+		t.Errorf("expected a fallback for a missing model, got %v", err)
+	}
+
+	ollama.err = &StatusError{Server: "ollama", Code: 503}
+	if _, err := f.Embed(ctx, "x"); !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "onnx") {
+		t.Errorf("expected ErrUnavailable naming every embedder, got %v", err)
+	}
+}
+
+func TestFallbackEmbedder_Dimensions(t *testing.T) {
+	_, err := NewFallbackEmbedder([]Embedder{
+		&chainEmbedder{name: "onnx", dims: 384},
+		&chainEmbedder{name: "ollama", dims: 768},
+	}, 0)
+	if err == nil {
+		t.Fatal("expected mismatched dimensions to be rejected")
+	}
+
+	// Embedders that learn their dimensions are checked as they answer
+	f, err := NewFallbackEmbedder([]Embedder{
+		&chainEmbedder{name: "onnx", dims: 3, err: ErrUnavailable},
+		&chainEmbedder{name: "ollama"},
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.embedders[1].(*chainEmbedder).dims = 5
+	if _, err := f.Embed(context.Background(), "x"); err == nil {
+		t.Error("expected an embedding of other dimensions to be rejected")
+	}
+}
@@ -0,0 +1,90 @@
+// Package embeddings provides a lightweight latency histogram for embedder stats
+package embeddings
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBuckets covers latencies up to 2^41 microseconds (~25 days)
+const histogramBuckets = 4 * 41
+
+// latencyHistogram records latencies in log-linear buckets (HDR-style):
+// each power-of-two range is split into 4 sub-buckets, bounding the
+// percentile error to 25% while staying lock-free and fixed-size
+type latencyHistogram struct {
+	counts [histogramBuckets]atomic.Int64
+}
+
+// Record adds a latency sample
+func (h *latencyHistogram) Record(d time.Duration) {
+	us := d.Microseconds()
+	if us < 0 {
+		us = 0
+	}
+	h.counts[bucketIndex(uint64(us))].Add(1)
+}
+
+// Count returns the total number of samples
+func (h *latencyHistogram) Count() int64 {
+	var total int64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+	}
+	return total
+}
+
+// Percentile returns the upper bound of the bucket holding the q-th quantile
+// (0 < q <= 1), or 0 if no samples have been recorded
+func (h *latencyHistogram) Percentile(q float64) time.Duration {
+	var snapshot [histogramBuckets]int64
+	var total int64
+	for i := range h.counts {
+		snapshot[i] = h.counts[i].Load()
+		total += snapshot[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative int64
+	for i, n := range snapshot {
+		cumulative += n
+		if cumulative >= rank {
+			return time.Duration(bucketUpperBound(i)) * time.Microsecond
+		}
+	}
+	return time.Duration(bucketUpperBound(histogramBuckets-1)) * time.Microsecond
+}
+
+// bucketIndex maps a value to its bucket
+func bucketIndex(v uint64) int {
+	if v < 4 {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	sub := int(v>>(exp-2)) - 4
+	idx := 4*(exp-1) + sub
+	if idx >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return idx
+}
+
+// bucketUpperBound returns the exclusive upper bound of a bucket
+func bucketUpperBound(idx int) uint64 {
+	if idx < 4 {
+		return uint64(idx + 1)
+	}
+	exp := idx/4 + 1
+	sub := uint64(idx % 4)
+	width := uint64(1) << (exp - 2)
+	return (4+sub)*width + width
+}
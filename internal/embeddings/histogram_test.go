@@ -0,0 +1,82 @@
+package embeddings
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketIndex_Monotonic(t *testing.T) {
+	prev := -1
+	for v := uint64(0); v < 100000; v++ {
+		idx := bucketIndex(v)
+		if idx < prev {
+			t.Fatalf("bucketIndex(%d) = %d, less than previous %d", v, idx, prev)
+		}
+		if v >= bucketUpperBound(idx) {
+			t.Fatalf("value %d not below upper bound %d of bucket %d", v, bucketUpperBound(idx), idx)
+		}
+		prev = idx
+	}
+}
+
+func TestLatencyHistogram_Empty(t *testing.T) {
+	var h latencyHistogram
+	if p := h.Percentile(0.5); p != 0 {
+		t.Errorf("expected 0 for empty histogram, got %v", p)
+	}
+}
+
+func TestLatencyHistogram_Percentiles(t *testing.T) {
+	var h latencyHistogram
+
+	// 90 fast samples, 9 slow, 1 very slow
+	for i := 0; i < 90; i++ {
+		h.Record(10 * time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		h.Record(200 * time.Millisecond)
+	}
+	h.Record(2 * time.Second)
+
+	if h.Count() != 100 {
+		t.Fatalf("expected 100 samples, got %d", h.Count())
+	}
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 10 * time.Millisecond},
+		{0.95, 200 * time.Millisecond},
+		{0.99, 200 * time.Millisecond},
+		{1.00, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		got := h.Percentile(tt.q)
+		// Upper bounds overestimate by at most 25%
+		if got < tt.want || got > tt.want*5/4 {
+			t.Errorf("Percentile(%v) = %v, want within 25%% above %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestLatencyHistogram_Concurrent(t *testing.T) {
+	var h latencyHistogram
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				h.Record(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if h.Count() != 8000 {
+		t.Errorf("expected 8000 samples, got %d", h.Count())
+	}
+}
@@ -0,0 +1,310 @@
+// Package embeddings provides embedding generation via a llama.cpp server
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/cache"
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
+)
+
+// LlamaCppClient embeds text with llama.cpp's server (llama-server
+// --embedding), which serves a single model from its /embedding endpoint
+type LlamaCppClient struct {
+	baseURL    string
+	model      string
+	dims       atomic.Int64 // 0 until discovered when not configured
+	timeout    time.Duration
+	httpClient *http.Client
+	cache      *cache.EmbeddingCache
+	template   *PromptTemplate
+	tokenizer  tokenizer.Tokenizer
+	maxTokens  int // Rendered texts are truncated to this many tokens
+	logf       func(format string, args ...any)
+
+	// Stats
+	requests  atomic.Int64
+	latency   atomic.Int64 // cumulative latency in microseconds
+	latencies latencyHistogram
+}
+
+// LlamaCppConfig configures the llama.cpp client
+type LlamaCppConfig struct {
+	BaseURL string
+
+	// Model names the served model for display and cache keys; the server
+	// decides which model actually runs
+	Model string
+
+	// Dimensions is the expected embedding length. Zero or less discovers it
+	// from the first embedding returned.
+	Dimensions int
+	CacheSize  int
+
+	// DisableCache bypasses the embedding cache, so every call reaches the server
+	DisableCache bool
+
+	// PromptTemplate formats queries and documents before embedding. Nil
+	// uses DefaultPromptTemplate for the model.
+	PromptTemplate *PromptTemplate
+
+	// MaxTokens truncates each text, after PromptTemplate, to this many
+	// tokens as counted by Tokenizer (nil for tokenizer.Heuristic). Zero
+	// sends text whole.
+	MaxTokens int
+	Tokenizer tokenizer.Tokenizer
+
+	// Timeout bounds each embed request
+	Timeout time.Duration
+
+	// Logf prints status messages, such as the detected dimensions. Nil
+	// writes them to stderr.
+	Logf func(format string, args ...any)
+}
+
+// DefaultLlamaCppConfig returns sensible defaults
+func DefaultLlamaCppConfig() LlamaCppConfig {
+	return LlamaCppConfig{
+		BaseURL:   getEnvOrDefault("LLAMACPP_HOST", "http://localhost:8080"),
+		Model:     getEnvOrDefault("EMBEDDING_MODEL", "llamacpp"),
+		CacheSize: 1000,
+		Timeout:   30 * time.Second,
+	}
+}
+
+// NewLlamaCppClient creates a new llama.cpp embeddings client
+func NewLlamaCppClient(cfg LlamaCppConfig) *LlamaCppClient {
+	defaults := DefaultLlamaCppConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaults.Model
+	}
+	if cfg.CacheSize == 0 {
+		cfg.CacheSize = defaults.CacheSize
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+
+	c := &LlamaCppClient{
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		timeout:    cfg.Timeout,
+		httpClient: &http.Client{},
+		template:   cfg.PromptTemplate,
+		tokenizer:  cfg.Tokenizer,
+		maxTokens:  cfg.MaxTokens,
+		logf:       cfg.Logf,
+	}
+	if c.logf == nil {
+		c.logf = stderrf
+	}
+	if c.template == nil {
+		c.template = DefaultPromptTemplate(cfg.Model)
+	}
+	if c.tokenizer == nil {
+		c.tokenizer = tokenizer.Heuristic{}
+	}
+	if !cfg.DisableCache {
+		c.cache = cache.NewEmbeddingCache(cfg.CacheSize)
+	}
+	if cfg.Dimensions > 0 {
+		c.dims.Store(int64(cfg.Dimensions))
+	}
+	return c
+}
+
+// llamaCppRequest is the request payload for the /embedding endpoint
+type llamaCppRequest struct {
+	Content string `json:"content"`
+}
+
+// Embed generates an embedding for content being stored
+func (c *LlamaCppClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return c.embed(ctx, RoleDocument, text)
+}
+
+// EmbedQuery generates an embedding for a search query
+func (c *LlamaCppClient) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return c.embed(ctx, RoleQuery, text)
+}
+
+// embed renders text for role and embeds it, caching by the rendered text
+func (c *LlamaCppClient) embed(ctx context.Context, role Role, text string) ([]float32, error) {
+	text, err := c.template.Render(role, text)
+	if err != nil {
+		return nil, err
+	}
+	text = tokenizer.Truncate(c.tokenizer, text, c.maxTokens)
+
+	if embedding, ok := c.cache.Get(text, c.model); ok {
+		return embedding, nil
+	}
+
+	start := time.Now()
+
+	jsonBody, err := json.Marshal(llamaCppRequest{Content: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/embedding", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call llama.cpp: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{Server: "llama.cpp", Code: resp.StatusCode, Body: string(body)}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	embedding, err := parseLlamaCppEmbedding(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := c.checkDimensions(embedding); err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+	c.requests.Add(1)
+	c.latency.Add(elapsed.Microseconds())
+	c.latencies.Record(elapsed)
+
+	c.cache.Put(text, c.model, embedding)
+
+	return embedding, nil
+}
+
+// parseLlamaCppEmbedding extracts the embedding from either response shape
+// llama.cpp has used:
+//
+//	{"embedding": [0.1, ...]}                    (older servers)
+//	[{"index": 0, "embedding": [[0.1, ...]]}]    (newer servers, pooled)
+func parseLlamaCppEmbedding(body []byte) ([]float32, error) {
+	var item struct {
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if len(body) > 0 && body[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no embeddings found in response")
+		}
+		body = items[0]
+	}
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, err
+	}
+	if len(item.Embedding) == 0 {
+		return nil, fmt.Errorf("no embeddings found in response")
+	}
+
+	var flat []float32
+	if err := json.Unmarshal(item.Embedding, &flat); err == nil {
+		return flat, nil
+	}
+
+	// Newer servers nest one vector per pooled sequence
+	var nested [][]float32
+	if err := json.Unmarshal(item.Embedding, &nested); err != nil {
+		return nil, err
+	}
+	if len(nested) != 1 {
+		return nil, fmt.Errorf("expected one pooled embedding, got %d (start llama-server with --pooling mean)", len(nested))
+	}
+	return nested[0], nil
+}
+
+// checkDimensions records the dimensions of the first embedding when they
+// were not configured, and rejects embeddings of any other length
+func (c *LlamaCppClient) checkDimensions(embedding []float32) error {
+	n := int64(len(embedding))
+	if n == 0 {
+		return fmt.Errorf("llama.cpp returned an empty embedding")
+	}
+	if c.dims.CompareAndSwap(0, n) {
+		c.logf("Detected %d embedding dimensions from llama.cpp\n", n)
+		return nil
+	}
+	if dims := c.dims.Load(); dims != n {
+		return fmt.Errorf("llama.cpp returned %d dimensions, expected %d", n, dims)
+	}
+	return nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+// Duplicate texts within the batch are embedded only once
+func (c *LlamaCppClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatchDedup(ctx, texts, c.Embed)
+}
+
+// Dimensions returns the embedding vector dimensions
+func (c *LlamaCppClient) Dimensions() int {
+	return int(c.dims.Load())
+}
+
+// Model returns the configured model name
+func (c *LlamaCppClient) Model() string {
+	return c.model
+}
+
+// Close releases resources
+func (c *LlamaCppClient) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// Ping checks that the llama.cpp server is reachable and serving embeddings
+func (c *LlamaCppClient) Ping(ctx context.Context) error {
+	if _, err := c.Embed(ctx, "test"); err != nil {
+		return fmt.Errorf("llama.cpp health check failed: %w", err)
+	}
+	return nil
+}
+
+// Stats returns client statistics
+func (c *LlamaCppClient) Stats() (requests int64, avgLatencyMs float64, cacheHitRate float64) {
+	requests = c.requests.Load()
+	if requests > 0 {
+		avgLatencyMs = float64(c.latency.Load()) / float64(requests) / 1000
+	}
+	_, _, cacheHitRate = c.cache.Stats()
+	return
+}
+
+// CacheEnabled reports whether embeddings are cached
+func (c *LlamaCppClient) CacheEnabled() bool {
+	return c.cache.Enabled()
+}
+
+// LatencyPercentiles returns the p50, p95, and p99 latency of uncached
+// embedding requests. Values are bucket upper bounds, accurate to within 25%.
+func (c *LlamaCppClient) LatencyPercentiles() (p50, p95, p99 time.Duration) {
+	return c.latencies.Percentile(0.50), c.latencies.Percentile(0.95), c.latencies.Percentile(0.99)
+}
@@ -0,0 +1,72 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLlamaCppClient_ResponseShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"flat object", `{"embedding":[0.1,0.2,0.3]}`},
+		{"pooled array", `[{"index":0,"embedding":[[0.1,0.2,0.3]]}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req llamaCppRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				if r.URL.Path != "/embedding" || req.Content != "hello" {
+					t.Errorf("unexpected request %s %+v", r.URL.Path, req)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewLlamaCppClient(LlamaCppConfig{BaseURL: srv.URL})
+			defer c.Close()
+
+			embedding, err := c.Embed(context.Background(), "hello")
+			if err != nil {
+				t.Fatalf("embed failed: %v", err)
+			}
+			if len(embedding) != 3 || embedding[2] != 0.3 || c.Dimensions() != 3 {
+				t.Errorf("unexpected embedding %v (dims %d)", embedding, c.Dimensions())
+			}
+
+			// Second call is served from the cache
+			c.Embed(context.Background(), "hello")
+			if requests, _, _ := c.Stats(); requests != 1 {
+				t.Errorf("expected 1 request, got %d", requests)
+			}
+		})
+	}
+}
+
+func TestLlamaCppClient_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "input is too large", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := NewLlamaCppClient(LlamaCppConfig{BaseURL: srv.URL, Dimensions: 3})
+	defer c.Close()
+
+	var statusErr *StatusError
+	if _, err := c.Embed(context.Background(), "x"); !errors.As(err, &statusErr) || statusErr.Server != "llama.cpp" {
+		t.Errorf("expected a llama.cpp *StatusError, got %v", err)
+	}
+}
+
+func TestParseLlamaCppEmbedding_Unpooled(t *testing.T) {
+	if _, err := parseLlamaCppEmbedding([]byte(`[{"index":0,"embedding":[[1,2],[3,4]]}]`)); err == nil {
+		t.Error("expected an error for per-token embeddings")
+	}
+}
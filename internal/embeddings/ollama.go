@@ -9,23 +9,40 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
 	"github.com/shivavenkatesh/moneta/internal/cache"
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
 )
 
 // OllamaClient handles communication with Ollama for embeddings
 type OllamaClient struct {
 	baseURL    string
 	model      string
-	dims       int
+	dims       atomic.Int64 // 0 until discovered when not configured
 	httpClient *http.Client
 	cache      *cache.EmbeddingCache
+	template   *PromptTemplate
+	workers    int // EmbedBatch requests in flight
+	tokenizer  tokenizer.Tokenizer
+	maxTokens  int // Rendered texts are truncated to this many tokens
+	logf       func(format string, args ...any)
+
+	// Transient failures are retried with exponential backoff
+	maxRetries     int
+	retryBaseDelay time.Duration
+
+	// Per-request deadlines; the first call may need to load the model
+	firstCallTimeout time.Duration
+	requestTimeout   time.Duration
+	warmed           atomic.Bool
 
 	// Stats
-	requests atomic.Int64
-	latency  atomic.Int64 // cumulative latency in microseconds
+	requests  atomic.Int64
+	latency   atomic.Int64 // cumulative latency in microseconds
+	latencies latencyHistogram
 }
 
 // ollamaRequest is the request payload for Ollama embed API
@@ -41,21 +58,74 @@ type ollamaResponse struct {
 
 // OllamaConfig configures the Ollama client
 type OllamaConfig struct {
-	BaseURL    string
-	Model      string
+	BaseURL string
+	Model   string
+
+	// Dimensions is the expected embedding length. Zero or less discovers it
+	// from the first embedding the model returns.
 	Dimensions int
 	CacheSize  int
-	Timeout    time.Duration
+
+	// DisableCache bypasses the embedding cache, so every call reaches Ollama
+	DisableCache bool
+
+	// PersistCache also keeps cached embeddings on disk in CacheDir
+	// (default ~/.moneta/cache), so they survive restarts
+	PersistCache bool
+	CacheDir     string
+
+	// PromptTemplate formats queries and documents before embedding. Nil
+	// uses DefaultPromptTemplate for the model.
+	PromptTemplate *PromptTemplate
+
+	// MaxTokens truncates each text, after PromptTemplate, to this many
+	// tokens as counted by Tokenizer (nil for tokenizer.Heuristic). Zero
+	// sends text whole.
+	MaxTokens int
+	Tokenizer tokenizer.Tokenizer
+
+	// FirstCallTimeout bounds the first embed request, which may include
+	// loading the model into memory
+	FirstCallTimeout time.Duration
+
+	// RequestTimeout bounds each embed request once the model is warm
+	RequestTimeout time.Duration
+
+	// Timeout is the default for FirstCallTimeout and RequestTimeout when
+	// they are unset
+	Timeout time.Duration
+
+	// Concurrency is how many requests EmbedBatch sends at once. Zero or
+	// less uses DefaultConcurrency.
+	Concurrency int
+
+	// MaxRetries is how many times a request that fails transiently (an
+	// unreachable server, a timeout, a 5xx or 429) is sent again, waiting
+	// RetryBaseDelay before the first retry and doubling it. Zero uses
+	// DefaultMaxRetries; negative disables retries.
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+
+	// Logf prints status messages, such as the detected dimensions or a
+	// persistent cache falling back to memory. Nil writes them to stderr.
+	Logf func(format string, args ...any)
 }
 
+// DefaultConcurrency keeps a local Ollama busy without queueing much on it
+const DefaultConcurrency = 4
+
 // DefaultOllamaConfig returns sensible defaults
 func DefaultOllamaConfig() OllamaConfig {
 	return OllamaConfig{
-		BaseURL:    getEnvOrDefault("OLLAMA_HOST", "http://localhost:11434"),
-		Model:      getEnvOrDefault("EMBEDDING_MODEL", "nomic-embed-text"),
-		Dimensions: 768, // nomic-embed-text dimensions
-		CacheSize:  1000,
-		Timeout:    30 * time.Second,
+		BaseURL:          getEnvOrDefault("OLLAMA_HOST", "http://localhost:11434"),
+		Model:            getEnvOrDefault("EMBEDDING_MODEL", "nomic-embed-text"),
+		Dimensions:       768, // nomic-embed-text dimensions
+		CacheSize:        1000,
+		FirstCallTimeout: 30 * time.Second,
+		RequestTimeout:   30 * time.Second,
+		Concurrency:      DefaultConcurrency,
+		MaxRetries:       DefaultMaxRetries,
+		RetryBaseDelay:   DefaultRetryBaseDelay,
 	}
 }
 
@@ -73,25 +143,136 @@ func NewOllamaClient(cfg OllamaConfig) *OllamaClient {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
+	if cfg.FirstCallTimeout == 0 {
+		cfg.FirstCallTimeout = cfg.Timeout
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = cfg.Timeout
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = DefaultRetryBaseDelay
+	}
+
+	// Deadlines are applied per request so warmup and steady-state can differ
+	c := &OllamaClient{
+		baseURL:          cfg.BaseURL,
+		model:            cfg.Model,
+		httpClient:       &http.Client{},
+		firstCallTimeout: cfg.FirstCallTimeout,
+		requestTimeout:   cfg.RequestTimeout,
+		workers:          cfg.Concurrency,
+		maxRetries:       cfg.MaxRetries,
+		retryBaseDelay:   cfg.RetryBaseDelay,
+		template:         cfg.PromptTemplate,
+		tokenizer:        cfg.Tokenizer,
+		maxTokens:        cfg.MaxTokens,
+		logf:             cfg.Logf,
+	}
+	if c.logf == nil {
+		c.logf = stderrf
+	}
+	if c.template == nil {
+		c.template = DefaultPromptTemplate(cfg.Model)
+	}
+	if c.tokenizer == nil {
+		c.tokenizer = tokenizer.Heuristic{}
+	}
+	if !cfg.DisableCache {
+		c.cache = newCache(cfg.CacheSize, cfg.PersistCache, cfg.CacheDir, c.logf)
+	}
+	if cfg.Dimensions > 0 {
+		c.dims.Store(int64(cfg.Dimensions))
+	}
+	return c
+}
+
+// newCache creates an embedding cache of size entries, backed by dir when
+// persist is set. A persistent cache that can't be opened falls back to
+// memory only, since caching never changes results.
+func newCache(size int, persist bool, dir string, logf func(string, ...any)) *cache.EmbeddingCache {
+	if !persist {
+		return cache.NewEmbeddingCache(size)
+	}
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			logf("Warning: failed to get home directory, caching embeddings in memory only: %v\n", err)
+			return cache.NewEmbeddingCache(size)
+		}
+		dir = filepath.Join(home, ".moneta", "cache")
+	}
+	c, err := cache.NewPersistentEmbeddingCache(dir, size)
+	if err != nil {
+		logf("Warning: caching embeddings in memory only: %v\n", err)
+		return cache.NewEmbeddingCache(size)
+	}
+	return c
+}
+
+// DiscoverDimensions returns the embedding dimensions, probing the model
+// once if they were not configured
+func (c *OllamaClient) DiscoverDimensions(ctx context.Context) (int, error) {
+	if dims := c.Dimensions(); dims > 0 {
+		return dims, nil
+	}
+	if _, err := c.Embed(ctx, "dimension probe"); err != nil {
+		return 0, fmt.Errorf("failed to probe embedding dimensions: %w", err)
+	}
+	return c.Dimensions(), nil
+}
 
-	return &OllamaClient{
-		baseURL: cfg.BaseURL,
-		model:   cfg.Model,
-		dims:    cfg.Dimensions,
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-		},
-		cache: cache.NewEmbeddingCache(cfg.CacheSize),
+// checkDimensions records the dimensions of the first embedding when they
+// were not configured, and rejects embeddings of any other length
+func (c *OllamaClient) checkDimensions(embedding []float32) error {
+	n := int64(len(embedding))
+	if n == 0 {
+		return fmt.Errorf("model %s returned an empty embedding", c.model)
+	}
+	if c.dims.CompareAndSwap(0, n) {
+		c.logf("Detected %d embedding dimensions for model %s\n", n, c.model)
+		return nil
+	}
+	if dims := c.dims.Load(); dims != n {
+		return fmt.Errorf("model %s returned %d dimensions, expected %d", c.model, n, dims)
 	}
+	return nil
 }
 
-// Embed generates an embedding for the given text
+// Embed generates an embedding for content being stored
 func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return c.embed(ctx, RoleDocument, text)
+}
+
+// EmbedQuery generates an embedding for a search query
+func (c *OllamaClient) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return c.embed(ctx, RoleQuery, text)
+}
+
+// embed renders text for role and embeds it. The cache is keyed by the
+// rendered text, so queries and documents never share entries.
+func (c *OllamaClient) embed(ctx context.Context, role Role, text string) ([]float32, error) {
+	text, err := c.template.Render(role, text)
+	if err != nil {
+		return nil, err
+	}
+	text = tokenizer.Truncate(c.tokenizer, text, c.maxTokens)
+
 	// Check cache first
-	if embedding, ok := c.cache.Get(text); ok {
+	if embedding, ok := c.cache.Get(text, c.model); ok {
 		return embedding, nil
 	}
+	return c.fetch(ctx, text)
+}
 
+// fetch requests the embedding of already rendered text from Ollama,
+// retrying transient failures, and caches it. It is safe for concurrent use.
+func (c *OllamaClient) fetch(ctx context.Context, text string) ([]float32, error) {
 	start := time.Now()
 
 	reqBody := ollamaRequest{
@@ -104,7 +285,43 @@ func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float32, error
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(jsonBody))
+	var embedding []float32
+	err = retryTransient(ctx, c.maxRetries, c.retryBaseDelay, func() error {
+		var err error
+		embedding, err = c.post(ctx, jsonBody)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDimensions(embedding); err != nil {
+		return nil, err
+	}
+
+	c.warmed.Store(true)
+
+	// Update stats
+	elapsed := time.Since(start)
+	c.requests.Add(1)
+	c.latency.Add(elapsed.Microseconds())
+	c.latencies.Record(elapsed)
+
+	// Cache the result
+	c.cache.Put(text, c.model, embedding)
+
+	return embedding, nil
+}
+
+// post sends one embed request, bounded by the per-request timeout
+func (c *OllamaClient) post(ctx context.Context, body []byte) ([]float32, error) {
+	timeout := c.requestTimeout
+	if !c.warmed.Load() {
+		timeout = c.firstCallTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -118,7 +335,7 @@ func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float32, error
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &StatusError{Server: "Ollama", Code: resp.StatusCode, Body: string(body)}
 	}
 
 	// Use streaming parser for better performance
@@ -126,14 +343,6 @@ func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float32, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-
-	// Update stats
-	c.requests.Add(1)
-	c.latency.Add(time.Since(start).Microseconds())
-
-	// Cache the result
-	c.cache.Put(text, embedding)
-
 	return embedding, nil
 }
 
@@ -163,7 +372,7 @@ func (c *OllamaClient) parseEmbeddingStream(r io.Reader) ([]float32, error) {
 			}
 
 			// Pre-allocate with expected dimensions
-			embedding := make([]float32, 0, c.dims)
+			embedding := make([]float32, 0, c.dims.Load())
 
 			// Read floats until closing bracket
 			for dec.More() {
@@ -180,27 +389,29 @@ func (c *OllamaClient) parseEmbeddingStream(r io.Reader) ([]float32, error) {
 	return nil, fmt.Errorf("no embeddings found in response")
 }
 
-// EmbedBatch generates embeddings for multiple texts
-// Uses concurrent requests for better throughput
+// EmbedBatch generates embeddings for multiple texts, sending up to
+// Concurrency requests at once. Duplicate texts within the batch are
+// embedded only once, and cached ones without a request.
 func (c *OllamaClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
-
-	// For now, process sequentially (Ollama doesn't batch well)
-	// TODO: Add concurrent processing with semaphore
+	rendered := make([]string, len(texts))
 	for i, text := range texts {
-		emb, err := c.Embed(ctx, text)
+		r, err := c.template.Render(RoleDocument, text)
 		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+			return nil, err
 		}
-		embeddings[i] = emb
+		rendered[i] = tokenizer.Truncate(c.tokenizer, r, c.maxTokens)
 	}
 
-	return embeddings, nil
+	var lookup func(string) ([]float32, bool)
+	if c.cache.Enabled() {
+		lookup = func(text string) ([]float32, bool) { return c.cache.Get(text, c.model) }
+	}
+	return embedBatchConcurrent(ctx, rendered, lookup, c.fetch, c.workers)
 }
 
 // Dimensions returns the embedding vector dimensions
 func (c *OllamaClient) Dimensions() int {
-	return c.dims
+	return int(c.dims.Load())
 }
 
 // Model returns the current embedding model name
@@ -211,7 +422,7 @@ func (c *OllamaClient) Model() string {
 // Close releases resources
 func (c *OllamaClient) Close() error {
 	c.httpClient.CloseIdleConnections()
-	return nil
+	return c.cache.Close()
 }
 
 // Ping checks if Ollama is reachable and the model is available
@@ -234,6 +445,22 @@ func (c *OllamaClient) Stats() (requests int64, avgLatencyMs float64, cacheHitRa
 	return
 }
 
+// CacheEnabled reports whether embeddings are cached
+func (c *OllamaClient) CacheEnabled() bool {
+	return c.cache.Enabled()
+}
+
+// LatencyPercentiles returns the p50, p95, and p99 latency of uncached
+// embedding requests. Values are bucket upper bounds, accurate to within 25%.
+func (c *OllamaClient) LatencyPercentiles() (p50, p95, p99 time.Duration) {
+	return c.latencies.Percentile(0.50), c.latencies.Percentile(0.95), c.latencies.Percentile(0.99)
+}
+
+// stderrf is the default Logf
+func stderrf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
 func getEnvOrDefault(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -0,0 +1,316 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_FirstCallTimeout(t *testing.T) {
+	delay := 50 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	c := NewOllamaClient(OllamaConfig{
+		BaseURL:          srv.URL,
+		Dimensions:       3,
+		FirstCallTimeout: 5 * time.Second,
+		RequestTimeout:   10 * time.Millisecond,
+		MaxRetries:       -1,
+	})
+	defer c.Close()
+
+	ctx := context.Background()
+
+	// The slow warmup call gets the generous deadline
+	if _, err := c.Embed(ctx, "first"); err != nil {
+		t.Fatalf("first call should use FirstCallTimeout: %v", err)
+	}
+
+	// Steady-state calls get the tight deadline
+	if _, err := c.Embed(ctx, "second"); err == nil {
+		t.Error("expected second call to exceed RequestTimeout")
+	}
+}
+
+func TestOllamaClient_DiscoverDimensions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	// Zero dimensions are discovered from the model
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL})
+	defer c.Close()
+	dims, err := c.DiscoverDimensions(ctx)
+	if err != nil {
+		t.Fatalf("discover failed: %v", err)
+	}
+	if dims != 3 || c.Dimensions() != 3 {
+		t.Errorf("expected 3 dimensions, got %d (Dimensions() = %d)", dims, c.Dimensions())
+	}
+
+	// Configured dimensions that don't match the model are an error
+	mismatched := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Dimensions: 768})
+	defer mismatched.Close()
+	if _, err := mismatched.Embed(ctx, "text"); err == nil {
+		t.Error("expected an error for mismatched dimensions")
+	}
+}
+
+func TestOllamaClient_DisableCache(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Dimensions: 3, DisableCache: true})
+	defer c.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := c.Embed(ctx, "same text"); err != nil {
+			t.Fatalf("embed failed: %v", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected every call to reach the server, got %d requests", calls)
+	}
+	if c.CacheEnabled() {
+		t.Error("expected cache to report disabled")
+	}
+	if _, _, hitRate := c.Stats(); hitRate != 0 {
+		t.Errorf("expected 0 hit rate, got %v", hitRate)
+	}
+}
+
+// lengthServer embeds each input as its length after delay, counting the
+// requests it receives and the most it handled at once
+type lengthServer struct {
+	*httptest.Server
+	calls, inFlight, peak atomic.Int64
+}
+
+func newLengthServer(t testing.TB, delay time.Duration) *lengthServer {
+	s := &lengthServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls.Add(1)
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for p := s.peak.Load(); n > p && !s.peak.CompareAndSwap(p, n); p = s.peak.Load() {
+		}
+
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Input, "boom") {
+			// Drop the connection, which fails the whole batch
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprintf(w, `{"embeddings":[[%d]]}`, len(req.Input))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestOllamaClient_EmbedBatchConcurrent(t *testing.T) {
+	srv := newLengthServer(t, 20*time.Millisecond)
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "test", Dimensions: 1, Concurrency: 3})
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.Embed(ctx, "cached"); err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+
+	var texts []string
+	for i := 1; i <= 10; i++ {
+		texts = append(texts, strings.Repeat("x", i))
+	}
+	texts = append(texts, "xx", "cached")
+
+	embeddings, err := c.EmbedBatch(ctx, texts)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	for i, text := range texts {
+		if len(embeddings[i]) != 1 || int(embeddings[i][0]) != len(text) {
+			t.Errorf("expected text %d embedded in order as %d, got %v", i, len(text), embeddings[i])
+		}
+	}
+
+	// One request per unique uncached text, and never more than 3 at once
+	if got := srv.calls.Load(); got != 11 {
+		t.Errorf("expected 11 requests (1 + 10 unique), got %d", got)
+	}
+	if p := srv.peak.Load(); p > 3 || p < 2 {
+		t.Errorf("expected up to 3 concurrent requests, peak was %d", p)
+	}
+	if requests, _, _ := c.Stats(); requests != 11 {
+		t.Errorf("expected stats to count 11 requests, got %d", requests)
+	}
+	if n := c.latencies.Count(); n != 11 {
+		t.Errorf("expected 11 latency samples, got %d", n)
+	}
+}
+
+func TestOllamaClient_MaxTokens(t *testing.T) {
+	srv := newLengthServer(t, 0)
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "test", Dimensions: 1, MaxTokens: 2})
+	defer c.Close()
+	ctx := context.Background()
+
+	// "alpha" is two heuristic tokens, so only "alpha " is sent
+	embedding, err := c.Embed(ctx, "alpha beta gamma")
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if int(embedding[0]) != len("alpha ") {
+		t.Errorf("expected the text truncated to 2 tokens, server saw %v bytes", embedding[0])
+	}
+
+	embeddings, err := c.EmbedBatch(ctx, []string{"beta gamma delta", "pi"})
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	// Truncation can cut a word between tokens: "gamm" is one, "a" another
+	if int(embeddings[0][0]) != len("beta gamm") || int(embeddings[1][0]) != len("pi") {
+		t.Errorf("expected batch texts truncated to 2 tokens, server saw %v", embeddings)
+	}
+}
+
+func TestOllamaClient_EmbedBatchCancelsOnError(t *testing.T) {
+	srv := newLengthServer(t, 5*time.Second)
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "test", Dimensions: 1, Concurrency: 4, MaxRetries: -1})
+	defer c.Close()
+
+	start := time.Now()
+	embeddings, err := c.EmbedBatch(context.Background(), []string{"slow 1", "slow 2", "boom", "slow 3"})
+	if err == nil || embeddings != nil {
+		t.Fatalf("expected the dropped connection to fail the batch, got %v, %v", embeddings, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the slow requests to be cancelled, took %s", elapsed)
+	}
+}
+
+func TestOllamaClient_RetriesTransientFailures(t *testing.T) {
+	var calls, status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "model is loading", int(status.Load()))
+			return
+		}
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	newClient := func() *OllamaClient {
+		return NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Dimensions: 3, DisableCache: true, RetryBaseDelay: time.Millisecond})
+	}
+	ctx := context.Background()
+
+	// Two 503s, then success
+	if _, err := newClient().Embed(ctx, "text"); err != nil {
+		t.Fatalf("expected the third attempt to succeed: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	// A 400 is the request's fault and fails at once
+	calls.Store(0)
+	status.Store(http.StatusBadRequest)
+	if _, err := newClient().Embed(ctx, "text"); err == nil {
+		t.Fatal("expected a 400 to fail")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected no retries after a 400, got %d requests", n)
+	}
+
+	// No retry is started that the deadline would cut short
+	calls.Store(0)
+	status.Store(http.StatusServiceUnavailable)
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Dimensions: 3, DisableCache: true, RetryBaseDelay: time.Second})
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Embed(ctx, "text"); err == nil {
+		t.Fatal("expected the 503 to be returned")
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond || calls.Load() != 1 {
+		t.Errorf("expected to give up before the deadline, took %s and %d requests", elapsed, calls.Load())
+	}
+}
+
+func TestOllamaClient_PersistCache(t *testing.T) {
+	srv := newLengthServer(t, 0)
+	cfg := OllamaConfig{BaseURL: srv.URL, Model: "test", Dimensions: 1, PersistCache: true, CacheDir: t.TempDir()}
+	ctx := context.Background()
+
+	c := NewOllamaClient(cfg)
+	want, err := c.EmbedBatch(ctx, []string{"alpha", "beta"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	c.Close()
+
+	// A restarted client finds both embeddings on disk
+	c = NewOllamaClient(cfg)
+	defer c.Close()
+	got, err := c.EmbedBatch(ctx, []string{"alpha", "beta"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if n := srv.calls.Load(); n != 2 {
+		t.Errorf("expected the restarted client to embed nothing, got %d requests in all", n)
+	}
+	if got[0][0] != want[0][0] || got[1][0] != want[1][0] {
+		t.Errorf("expected the stored embeddings %v, got %v", want, got)
+	}
+}
+
+func BenchmarkOllamaClient_EmbedBatch(b *testing.B) {
+	srv := newLengthServer(b, 2*time.Millisecond)
+	texts := make([]string, 200)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	for _, workers := range []int{1, DefaultConcurrency} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "test", Dimensions: 1, DisableCache: true, Concurrency: workers})
+			defer c.Close()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.EmbedBatch(context.Background(), texts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
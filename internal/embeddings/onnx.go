@@ -35,6 +35,9 @@ type ONNXConfig struct {
 	ModelPath  string // Path to .onnx model file
 	Dimensions int    // Embedding dimensions
 	CacheSize  int    // LRU cache size
+
+	// DisableCache bypasses the embedding cache
+	DisableCache bool
 }
 
 // DefaultONNXConfig returns config for all-MiniLM-L6-v2
@@ -54,17 +57,20 @@ func NewONNXClient(cfg ONNXConfig) (*ONNXClient, error) {
 		cfg.CacheSize = 1000
 	}
 
-	return &ONNXClient{
+	c := &ONNXClient{
 		modelPath: cfg.ModelPath,
 		dims:      cfg.Dimensions,
-		cache:     cache.NewEmbeddingCache(cfg.CacheSize),
-	}, nil
+	}
+	if !cfg.DisableCache {
+		c.cache = cache.NewEmbeddingCache(cfg.CacheSize)
+	}
+	return c, nil
 }
 
 // Embed generates an embedding for the given text
 func (c *ONNXClient) Embed(ctx context.Context, text string) ([]float32, error) {
 	// Check cache first
-	if embedding, ok := c.cache.Get(text); ok {
+	if embedding, ok := c.cache.Get(text, c.Model()); ok {
 		return embedding, nil
 	}
 
@@ -85,20 +91,13 @@ func (c *ONNXClient) Embed(ctx context.Context, text string) ([]float32, error)
 	// 2. Run inference through the ONNX model
 	// 3. Return the embedding vector
 
-	return nil, fmt.Errorf("ONNX support not yet implemented - use Ollama for now")
+	return nil, fmt.Errorf("%w: ONNX support not yet implemented - use Ollama for now", ErrUnavailable)
 }
 
 // EmbedBatch generates embeddings for multiple texts
+// Duplicate texts within the batch are embedded only once
 func (c *ONNXClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
-	for i, text := range texts {
-		emb, err := c.Embed(ctx, text)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
-		}
-		embeddings[i] = emb
-	}
-	return embeddings, nil
+	return embedBatchDedup(ctx, texts, c.Embed)
 }
 
 // Dimensions returns the embedding dimensions
@@ -111,6 +110,14 @@ func (c *ONNXClient) Model() string {
 	return filepath.Base(c.modelPath)
 }
 
+// Ping checks that the model can embed
+func (c *ONNXClient) Ping(ctx context.Context) error {
+	if _, err := c.Embed(ctx, "test"); err != nil {
+		return fmt.Errorf("ONNX health check failed: %w", err)
+	}
+	return nil
+}
+
 // Close releases resources
 func (c *ONNXClient) Close() error {
 	// Close ONNX session if initialized
@@ -121,11 +128,11 @@ func (c *ONNXClient) Close() error {
 func (c *ONNXClient) initialize() error {
 	// Check if model file exists
 	if _, err := os.Stat(c.modelPath); os.IsNotExist(err) {
-		return fmt.Errorf("model file not found: %s\n\nTo use ONNX embeddings, download a model:\n"+
+		return fmt.Errorf("%w: model file not found: %s\n\nTo use ONNX embeddings, download a model:\n"+
 			"  mkdir -p ~/.moneta/models\n"+
 			"  curl -L -o ~/.moneta/models/all-MiniLM-L6-v2.onnx \\\n"+
 			"    https://huggingface.co/sentence-transformers/all-MiniLM-L6-v2/resolve/main/onnx/model.onnx",
-			c.modelPath)
+			ErrUnavailable, c.modelPath)
 	}
 
 	// TODO: Initialize ONNX runtime session
@@ -0,0 +1,57 @@
+// Package embeddings retries embedding requests that fail transiently
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Defaults for OllamaConfig's retries
+const (
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = 200 * time.Millisecond
+)
+
+// isTransient reports whether a request that failed with err may succeed
+// if sent again: the server was unreachable or timed out, returned a 5xx,
+// or asked to slow down with a 429. Other statuses are permanent.
+func isTransient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+	// A per-request timeout, as opposed to the caller's context ending
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryTransient runs fn, retrying it up to retries more times while it
+// fails transiently. The wait starts at base and doubles each attempt,
+// with jitter so concurrent callers don't retry in lockstep. It gives up
+// once ctx ends or its deadline would pass before the next attempt.
+func retryTransient(ctx context.Context, retries int, base time.Duration, fn func() error) error {
+	delay := base
+	err := fn()
+	for attempt := 0; attempt < retries && err != nil && ctx.Err() == nil && isTransient(err); attempt++ {
+		// Wait between half and all of delay
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+		err = fn()
+	}
+	return err
+}
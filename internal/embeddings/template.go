@@ -0,0 +1,64 @@
+// Package embeddings provides prompt templates for instruction-tuned models
+package embeddings
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Role is what a text is embedded for
+type Role string
+
+const (
+	RoleQuery    Role = "query"    // A search query
+	RoleDocument Role = "document" // Content being stored
+)
+
+// NomicTemplate applies nomic-embed-text's task prefixes
+const NomicTemplate = `{{if eq .Role "query"}}search_query: {{else}}search_document: {{end}}{{.Text}}`
+
+// PlainTemplate embeds text unchanged, overriding a model's default template
+const PlainTemplate = `{{.Text}}`
+
+// PromptTemplate formats text for a role before it is embedded, for models
+// trained with task instructions (e.g. "query: " and "passage: " for e5).
+// Templates see .Text and .Role ("query" or "document").
+type PromptTemplate struct {
+	tmpl *template.Template
+}
+
+// NewPromptTemplate parses a text/template
+func NewPromptTemplate(src string) (*PromptTemplate, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	return &PromptTemplate{tmpl: tmpl}, nil
+}
+
+// DefaultPromptTemplate returns the template for a known instruction-tuned
+// model, or nil to embed text unchanged
+func DefaultPromptTemplate(model string) *PromptTemplate {
+	if strings.Contains(strings.ToLower(model), "nomic-embed-text") {
+		t, _ := NewPromptTemplate(NomicTemplate)
+		return t
+	}
+	return nil
+}
+
+// Render formats text for role. A nil template returns text unchanged.
+func (p *PromptTemplate) Render(role Role, text string) (string, error) {
+	if p == nil {
+		return text, nil
+	}
+	var b strings.Builder
+	err := p.tmpl.Execute(&b, struct {
+		Role Role
+		Text string
+	}{role, text})
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return b.String(), nil
+}
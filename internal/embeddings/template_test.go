@@ -0,0 +1,62 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPromptTemplate_Render(t *testing.T) {
+	nomic := DefaultPromptTemplate("nomic-embed-text")
+	if nomic == nil {
+		t.Fatal("expected a default template for nomic-embed-text")
+	}
+	if got, _ := nomic.Render(RoleQuery, "auth"); got != "search_query: auth" {
+		t.Errorf("query rendered as %q", got)
+	}
+	if got, _ := nomic.Render(RoleDocument, "auth"); got != "search_document: auth" {
+		t.Errorf("document rendered as %q", got)
+	}
+
+	if DefaultPromptTemplate("all-minilm") != nil {
+		t.Error("expected no default template for all-minilm")
+	}
+	var none *PromptTemplate
+	if got, _ := none.Render(RoleQuery, "auth"); got != "auth" {
+		t.Errorf("nil template rendered as %q", got)
+	}
+
+	if _, err := NewPromptTemplate("{{.Text"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestOllamaClient_PromptTemplate(t *testing.T) {
+	var inputs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		inputs = append(inputs, req.Input)
+		w.Write([]byte(`{"embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	tmpl, err := NewPromptTemplate(`{{if eq .Role "query"}}query: {{else}}passage: {{end}}{{.Text}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Dimensions: 3, CacheSize: 10, PromptTemplate: tmpl})
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Embed(ctx, "auth")
+	c.EmbedQuery(ctx, "auth")
+	c.Embed(ctx, "auth") // cached
+
+	// The query must not be served from the document's cache entry
+	if len(inputs) != 2 || inputs[0] != "passage: auth" || inputs[1] != "query: auth" {
+		t.Errorf("unexpected inputs sent: %q", inputs)
+	}
+}
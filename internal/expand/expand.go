@@ -0,0 +1,77 @@
+// Package expand rewrites search queries into related queries, so short
+// queries also match memories that use different words for the same idea
+package expand
+
+import (
+	"context"
+	"strings"
+)
+
+// Expander produces alternative phrasings of a query
+type Expander interface {
+	// Expand returns queries related to query, not including query itself
+	Expand(ctx context.Context, query string) ([]string, error)
+}
+
+// synonymGroups are sets of interchangeable code terms
+var synonymGroups = [][]string{
+	{"db", "database", "persistence", "storage"},
+	{"auth", "authentication", "authorization", "login"},
+	{"config", "configuration", "settings"},
+	{"err", "error", "failure"},
+	{"repo", "repository"},
+	{"req", "request"},
+	{"resp", "response"},
+	{"env", "environment"},
+	{"msg", "message"},
+	{"init", "initialize", "setup"},
+	{"deps", "dependencies"},
+	{"util", "utils", "helper", "helpers"},
+	{"impl", "implementation"},
+	{"func", "function", "method"},
+	{"api", "endpoint", "route"},
+	{"delete", "remove"},
+}
+
+// SynonymExpander substitutes common code terms with their synonyms, one
+// word at a time. It needs no model.
+type SynonymExpander struct {
+	synonyms    map[string][]string
+	maxVariants int
+}
+
+// NewSynonymExpander creates an expander using the built-in synonym groups
+// that returns at most maxVariants queries (default 4)
+func NewSynonymExpander(maxVariants int) *SynonymExpander {
+	if maxVariants <= 0 {
+		maxVariants = 4
+	}
+	synonyms := make(map[string][]string)
+	for _, group := range synonymGroups {
+		for _, word := range group {
+			for _, other := range group {
+				if other != word {
+					synonyms[word] = append(synonyms[word], other)
+				}
+			}
+		}
+	}
+	return &SynonymExpander{synonyms: synonyms, maxVariants: maxVariants}
+}
+
+// Expand returns the query with one known term replaced by each synonym
+func (e *SynonymExpander) Expand(ctx context.Context, query string) ([]string, error) {
+	words := strings.Fields(query)
+	var variants []string
+	for i, word := range words {
+		for _, synonym := range e.synonyms[strings.ToLower(word)] {
+			if len(variants) == e.maxVariants {
+				return variants, nil
+			}
+			replaced := append([]string(nil), words...)
+			replaced[i] = synonym
+			variants = append(variants, strings.Join(replaced, " "))
+		}
+	}
+	return variants, nil
+}
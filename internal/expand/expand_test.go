@@ -0,0 +1,45 @@
+package expand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSynonymExpander_Expand(t *testing.T) {
+	e := NewSynonymExpander(0)
+	got, _ := e.Expand(context.Background(), "DB migrations")
+	want := []string{"database migrations", "persistence migrations", "storage migrations"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got, _ := e.Expand(context.Background(), "retry loop"); len(got) != 0 {
+		t.Errorf("expected no variants for unknown terms, got %q", got)
+	}
+
+	limited, _ := NewSynonymExpander(2).Expand(context.Background(), "auth config")
+	if len(limited) != 2 {
+		t.Errorf("expected variants capped at 2, got %q", limited)
+	}
+}
+
+func TestOllamaExpander_Expand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(generateResponse{Response: "1. database connection\n- \"db\"\n\n2) persistence layer\n3. storage setup\n4. extra"})
+	}))
+	defer srv.Close()
+
+	e := NewOllamaExpander(OllamaConfig{BaseURL: srv.URL, Model: "test", Variants: 3})
+	got, err := e.Expand(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("expand failed: %v", err)
+	}
+	want := []string{"database connection", "persistence layer", "storage setup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
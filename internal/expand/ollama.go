@@ -0,0 +1,146 @@
+// Package expand provides an Ollama-backed query expander
+package expand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OllamaExpander asks a generate model for paraphrases of the query
+type OllamaExpander struct {
+	baseURL    string
+	model      string
+	variants   int
+	httpClient *http.Client
+}
+
+// OllamaConfig configures the Ollama expander
+type OllamaConfig struct {
+	BaseURL  string
+	Model    string
+	Variants int           // Paraphrases to request
+	Timeout  time.Duration // Per query
+}
+
+// DefaultOllamaConfig returns sensible defaults
+func DefaultOllamaConfig() OllamaConfig {
+	return OllamaConfig{
+		BaseURL:  getEnvOrDefault("OLLAMA_HOST", "http://localhost:11434"),
+		Model:    getEnvOrDefault("EXPAND_MODEL", "llama3.2:1b"),
+		Variants: 3,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// NewOllamaExpander creates a new Ollama expander
+func NewOllamaExpander(cfg OllamaConfig) *OllamaExpander {
+	defaults := DefaultOllamaConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaults.Model
+	}
+	if cfg.Variants <= 0 {
+		cfg.Variants = defaults.Variants
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	return &OllamaExpander{
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		variants:   cfg.Variants,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// generateRequest is the request payload for Ollama generate API
+type generateRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// generateResponse is the response from Ollama generate API
+type generateResponse struct {
+	Response string `json:"response"`
+}
+
+// expandPrompt asks for paraphrases one per line
+const expandPrompt = `Rewrite this search query over a codebase %d different ways, using
+other words a developer might have written (e.g. "db" -> "database").
+Reply with one query per line and nothing else.
+
+Query: %s`
+
+// Expand returns up to the configured number of paraphrases
+func (e *OllamaExpander) Expand(ctx context.Context, query string) ([]string, error) {
+	jsonBody, err := json.Marshal(generateRequest{
+		Model:  e.model,
+		Prompt: fmt.Sprintf(expandPrompt, e.variants, query),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/generate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return parseVariants(out.Response, query, e.variants), nil
+}
+
+// parseVariants extracts distinct queries from a reply, dropping list
+// markers, quotes, and the original query
+func parseVariants(reply, query string, max int) []string {
+	seen := map[string]bool{strings.ToLower(query): true}
+	var variants []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•0123456789.) ")
+		line = strings.Trim(line, `"'`)
+		if line == "" || seen[strings.ToLower(line)] {
+			continue
+		}
+		seen[strings.ToLower(line)] = true
+		variants = append(variants, line)
+		if len(variants) == max {
+			break
+		}
+	}
+	return variants
+}
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
@@ -0,0 +1,45 @@
+// Package hash is the one place Moneta hashes content. Identity hashes
+// decide whether two contents are the same and are persisted, so they keep
+// the full SHA-256 digest. Cache keys only find cached values, so they are
+// truncated to 128 bits, but they are persisted too: the embedding cache
+// writes them to embeddings.db.
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Algorithm names the digest behind Identity. Identity hashes are stored
+// with memories and files, so changing it means rehashing all of them.
+const Algorithm = "sha256"
+
+// IdentityLen is the length of an Identity hash in hex characters
+const IdentityLen = 2 * sha256.Size
+
+// cacheKeyBytes is how much of the digest CacheKey keeps. At 128 bits a
+// collision is out of reach for any cache size, and the worst it could do
+// is return one cached value for another input.
+const cacheKeyBytes = 16
+
+// Identity returns the hex SHA-256 digest of content. Use it wherever a
+// hash stands for the content itself: duplicate detection, deduplicated
+// storage, and IDs derived from content.
+func Identity(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheKey returns a 128-bit hex key for parts. Every part is length
+// prefixed, so no two different lists of parts share an encoding. Don't use
+// it to decide that two contents are equal; use Identity. The keys are an
+// on-disk format: changing them turns every persisted cache entry into a
+// miss.
+func CacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:cacheKeyBytes])
+}
@@ -0,0 +1,41 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// Identity hashes are persisted and compared for deduplication, so they
+// must be the full SHA-256 digest, never a truncation
+func TestIdentity_FullDigest(t *testing.T) {
+	content := "func main() {}"
+	sum := sha256.Sum256([]byte(content))
+	want := hex.EncodeToString(sum[:])
+
+	got := Identity(content)
+	if got != want {
+		t.Errorf("expected the full SHA-256 digest %s, got %s", want, got)
+	}
+	if len(got) != IdentityLen || IdentityLen != 64 {
+		t.Errorf("expected a 256-bit (64 hex character) hash, got %d characters", len(got))
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	key := CacheKey("model", "content")
+	if len(key) != 32 {
+		t.Errorf("expected a 128-bit (32 hex character) key, got %d characters", len(key))
+	}
+	if key != CacheKey("model", "content") {
+		t.Error("expected the same parts to give the same key")
+	}
+	if key == Identity("content")[:32] {
+		t.Error("expected cache keys to differ from identity hashes")
+	}
+
+	// Moving bytes between parts changes the key
+	if CacheKey("ab", "c") == CacheKey("a", "bc") || CacheKey("ab") == CacheKey("a", "b") {
+		t.Error("expected part boundaries to affect the key")
+	}
+}
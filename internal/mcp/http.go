@@ -0,0 +1,94 @@
+// Package mcp serves MCP over the Streamable HTTP transport
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxMessageSize bounds the body of a POSTed message
+const maxMessageSize = 4 << 20
+
+// ServeHTTP implements the Streamable HTTP transport at a single endpoint.
+// Clients POST JSON-RPC messages; responses come back as an SSE stream
+// when the client accepts one, and as a JSON body otherwise. The server
+// never starts a conversation, so it keeps no sessions and does not offer
+// the optional GET stream.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		s.stream(w, r, data)
+		return
+	}
+
+	var replies []*response
+	batch := s.handle(r.Context(), data, func(resp *response) {
+		replies = append(replies, resp)
+	})
+	if len(replies) == 0 {
+		// Only notifications or responses
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	body, err := encode(replies, batch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// stream answers a message with an SSE stream, one event per response as
+// each is ready. A message with nothing to answer gets 202 and no stream.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, data []byte) {
+	flusher, _ := w.(http.Flusher)
+	started := false
+	s.handle(r.Context(), data, func(resp *response) {
+		event, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			started = true
+		}
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if !started {
+		// Only notifications or responses
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// acceptsEventStream reports whether the request's Accept header lists
+// text/event-stream
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, _ := strings.Cut(part, ";")
+			if strings.TrimSpace(mediaType) == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+// Package mcp serves moneta's memory tools over the Model Context
+// Protocol. Server dispatches JSON-RPC messages independently of how they
+// arrive, so every transport shares the same tools.
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+)
+
+// protocolVersions lists the MCP revisions Server speaks, newest first
+var protocolVersions = []string{"2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Config configures a Server
+type Config struct {
+	// Version is reported to clients as the server version
+	Version string
+
+	// MaxSearchLimit caps the results a search_memory call may request
+	// (0 leaves it uncapped)
+	MaxSearchLimit int
+}
+
+// Server answers MCP requests with the memory service
+type Server struct {
+	svc    memory.Service
+	config Config
+}
+
+// New creates an MCP server backed by svc
+func New(svc memory.Service, cfg Config) *Server {
+	if cfg.Version == "" {
+		cfg.Version = "dev"
+	}
+	return &Server{svc: svc, config: cfg}
+}
+
+// request is a JSON-RPC request, or a notification when ID is absent
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response carrying either Result or Error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+// handle processes one JSON-RPC message or batch, passing each response
+// to send as soon as it is ready. Notifications and client responses get
+// none. It reports whether data was a batch, whose replies go back as an
+// array.
+func (s *Server) handle(ctx context.Context, data []byte, send func(*response)) (batch bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var msgs []json.RawMessage
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			send(errorResponse(nil, codeParseError, "invalid JSON"))
+			return false
+		}
+		if len(msgs) == 0 {
+			send(errorResponse(nil, codeInvalidRequest, "empty batch"))
+			return false
+		}
+		for _, msg := range msgs {
+			if resp := s.handleOne(ctx, msg); resp != nil {
+				send(resp)
+			}
+		}
+		return true
+	}
+
+	if resp := s.handleOne(ctx, data); resp != nil {
+		send(resp)
+	}
+	return false
+}
+
+// encode marshals the replies to a message as one JSON value: an array
+// for a batch
+func encode(replies []*response, batch bool) ([]byte, error) {
+	if batch {
+		return json.Marshal(replies)
+	}
+	return json.Marshal(replies[0])
+}
+
+func (s *Server) handleOne(ctx context.Context, msg json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return errorResponse(nil, codeParseError, "invalid JSON")
+	}
+	// Responses to requests we never send, and notifications, need no reply
+	if req.Method == "" {
+		if req.ID == nil {
+			return errorResponse(nil, codeInvalidRequest, "missing method")
+		}
+		return nil
+	}
+	if req.JSONRPC != "2.0" {
+		return errorResponse(req.ID, codeInvalidRequest, "jsonrpc must be \"2.0\"")
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if req.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, &rpcError{codeInvalidParams, err.Error()}
+			}
+		}
+		return map[string]any{
+			"protocolVersion": negotiateVersion(params.ProtocolVersion),
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "moneta", "version": s.config.Version},
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		return map[string]any{"tools": toolList()}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		t, ok := findTool(params.Name)
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		return s.callTool(ctx, t, params.Arguments), nil
+	}
+
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+}
+
+// negotiateVersion answers a client's protocol version with the same one
+// when supported, or the newest this server speaks
+func negotiateVersion(requested string) string {
+	for _, v := range protocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return protocolVersions[0]
+}
@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// fakeService answers searches with a memory holding the query and limit
+// it was given
+type fakeService struct {
+	memory.Service
+}
+
+func (fakeService) Search(ctx context.Context, req types.SearchRequest) (*types.SearchResponse, error) {
+	content := fmt.Sprintf("%s, limit %d", req.Query, req.Limit)
+	return &types.SearchResponse{Results: []types.SearchResult{{Memory: types.Memory{Content: content}}}}, nil
+}
+
+func (fakeService) Get(ctx context.Context, id string) (*types.Memory, error) {
+	return nil, fmt.Errorf("memory not found: %s", id)
+}
+
+func post(t *testing.T, srv *httptest.Server, accept, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeResponse(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var msg map[string]any
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("invalid response %q: %v", body, err)
+	}
+	return msg
+}
+
+func TestServeHTTP_JSON(t *testing.T) {
+	srv := httptest.NewServer(New(fakeService{}, Config{Version: "1.2.3", MaxSearchLimit: 5}))
+	defer srv.Close()
+
+	resp := post(t, srv, "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	body, _ := io.ReadAll(resp.Body)
+	result := decodeResponse(t, body)["result"].(map[string]any)
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Errorf("expected the client's supported version back, got %v", result["protocolVersion"])
+	}
+	if info := result["serverInfo"].(map[string]any); info["version"] != "1.2.3" {
+		t.Errorf("expected the configured version, got %v", info)
+	}
+
+	// Notifications are accepted without a body
+	resp = post(t, srv, "application/json", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	// A batch is answered with an array in order, skipping notifications
+	resp = post(t, srv, "application/json", `[
+		{"jsonrpc":"2.0","id":"a","method":"tools/list"},
+		{"jsonrpc":"2.0","method":"notifications/cancelled"},
+		{"jsonrpc":"2.0","id":"b","method":"nope"}
+	]`)
+	body, _ = io.ReadAll(resp.Body)
+	var batch []map[string]any
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("expected 2 responses, got %s", body)
+	}
+	tools := batch[0]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != len(toolList()) || tools[0].(map[string]any)["name"] != "search_memory" {
+		t.Errorf("unexpected tools: %v", tools)
+	}
+	if code := batch[1]["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("expected method not found, got %v", batch[1])
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to be refused, got %d", resp.StatusCode)
+	}
+}
+
+func TestServeHTTP_SSE(t *testing.T) {
+	srv := httptest.NewServer(New(fakeService{}, Config{MaxSearchLimit: 5}))
+	defer srv.Close()
+
+	resp := post(t, srv, "application/json, text/event-stream",
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"search_memory","arguments":{"query":"retries","limit":50}}}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	data, ok := strings.CutPrefix(strings.TrimSpace(string(body)), "event: message\ndata: ")
+	if !ok {
+		t.Fatalf("expected one message event, got %q", body)
+	}
+
+	result := decodeResponse(t, []byte(data))["result"].(map[string]any)
+	if result["isError"] != false {
+		t.Fatalf("expected the call to succeed, got %v", result)
+	}
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	var search types.SearchResponse
+	if err := json.Unmarshal([]byte(text), &search); err != nil {
+		t.Fatalf("expected a search response, got %q", text)
+	}
+	if len(search.Results) != 1 || search.Results[0].Memory.Content != "retries, limit 5" {
+		t.Errorf("expected the limit capped at 5, got %+v", search)
+	}
+}
+
+func TestServer_ToolErrors(t *testing.T) {
+	s := New(fakeService{}, Config{})
+	call := func(params string) *response {
+		var got *response
+		s.handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+params+`}`), func(r *response) { got = r })
+		return got
+	}
+
+	// A failing tool reports the error to the model in its result
+	resp := call(`{"name":"get_memory","arguments":{"id":"missing"}}`)
+	result := resp.Result.(map[string]any)
+	if resp.Error != nil || result["isError"] != true {
+		t.Errorf("expected a tool error result, got %+v", resp)
+	}
+
+	// An unknown tool is a protocol error
+	if resp := call(`{"name":"drop_tables"}`); resp.Error == nil || resp.Error.Code != codeInvalidParams {
+		t.Errorf("expected invalid params, got %+v", resp)
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	s := New(fakeService{}, Config{Version: "1.2.3", MaxSearchLimit: 5})
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search_memory","arguments":{"query":"retries","limit":50}}}`,
+		`[{"jsonrpc":"2.0","id":3,"method":"ping"},{"jsonrpc":"2.0","id":4,"method":"nope"}]`,
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	if err := s.ServeStdio(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	// One line per reply; the notification and blank line get none
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 reply lines, got %d: %q", len(lines), out.String())
+	}
+
+	init := decodeResponse(t, []byte(lines[0]))
+	result, _ := init["result"].(map[string]any)
+	if init["id"] != float64(1) || result["protocolVersion"] != "2024-11-05" {
+		t.Errorf("unexpected initialize reply: %v", init)
+	}
+
+	call := decodeResponse(t, []byte(lines[1]))
+	if call["id"] != float64(2) || !strings.Contains(lines[1], "retries, limit 5") {
+		t.Errorf("expected the search to run with the capped limit, got %s", lines[1])
+	}
+
+	var batch []map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 replies, got %s", lines[2])
+	}
+	if batch[1]["error"] == nil {
+		t.Errorf("expected an error for an unknown method, got %v", batch[1])
+	}
+}
+
+// searchRecorder keeps the last search request it was given
+type searchRecorder struct {
+	memory.Service
+	got types.SearchRequest
+}
+
+func (r *searchRecorder) Search(ctx context.Context, req types.SearchRequest) (*types.SearchResponse, error) {
+	r.got = req
+	return &types.SearchResponse{}, nil
+}
+
+func TestSearchMemory_Window(t *testing.T) {
+	svc := &searchRecorder{}
+	s := New(svc, Config{MaxSearchLimit: 5})
+	ctx := context.Background()
+
+	if _, err := s.searchMemory(ctx, json.RawMessage(`{"query":"q","offset":3,"limit":50,"include_neighbors":100}`)); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if svc.got.Limit != 2 || svc.got.IncludeNeighbors != types.MaxIncludeNeighbors {
+		t.Errorf("expected limit 2 and %d neighbors, got %+v", types.MaxIncludeNeighbors, svc.got)
+	}
+	for _, args := range []string{`{"query":"q","offset":5}`, `{"query":"q","offset":-1}`} {
+		if _, err := s.searchMemory(ctx, json.RawMessage(args)); err == nil {
+			t.Errorf("%s: expected an offset past the cap to be refused", args)
+		}
+	}
+}
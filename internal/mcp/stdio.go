@@ -0,0 +1,49 @@
+// Package mcp serves MCP over the stdio transport
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// ServeStdio implements the stdio transport: it reads newline-delimited
+// JSON-RPC messages from r and writes the reply to each as one line to w,
+// until r ends or ctx is done. Messages are handled one at a time, in the
+// order they arrive. Nothing but replies is written to w.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var replies []*response
+		batch := s.handle(ctx, line, func(resp *response) {
+			replies = append(replies, resp)
+		})
+		if len(replies) == 0 {
+			// Only notifications or responses
+			continue
+		}
+		out, err := encode(replies, batch)
+		if err != nil {
+			return fmt.Errorf("failed to encode reply: %w", err)
+		}
+		if _, err := w.Write(append(out, '\n')); err != nil {
+			return fmt.Errorf("failed to write reply: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	return nil
+}
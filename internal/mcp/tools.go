@@ -0,0 +1,190 @@
+// Package mcp defines the memory tools MCP clients can call
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shivavenkatesh/moneta/internal/schema"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// tool is an MCP tool: its advertised definition and the function that
+// runs it with the call's JSON arguments
+type tool struct {
+	name        string
+	description string
+	input       schema.Object
+	run         func(s *Server, ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// memoryTypes are the values the type arguments accept
+var memoryTypes = []string{
+	string(types.TypeArchitecture), string(types.TypePattern), string(types.TypeDecision),
+	string(types.TypeGotcha), string(types.TypeContext), string(types.TypePreference),
+}
+
+// tools is the single table both tools/list and tools/call read
+var tools = []tool{
+	{
+		name:        "search_memory",
+		description: "Search stored memories and indexed code by meaning. Returns the most relevant memories with similarity scores.",
+		input: object([]string{"query"}, schema.Object{
+			"query":     schema.Object{"type": "string", "description": "What to look for, in natural language"},
+			"project":   schema.Object{"type": "string", "description": "Only search this project"},
+			"type":      schema.Object{"type": "string", "enum": memoryTypes, "description": "Only return memories of this type"},
+			"limit":     schema.Object{"type": "integer", "minimum": 1, "description": "Maximum results"},
+			"threshold": schema.Object{"type": "number", "description": "Minimum similarity score"},
+		}),
+		run: (*Server).searchMemory,
+	},
+	{
+		name:        "add_memory",
+		description: "Store a memory, such as a decision, pattern, or gotcha, so later searches can find it.",
+		input: object([]string{"content"}, schema.Object{
+			"content":   schema.Object{"type": "string", "description": "The memory text"},
+			"project":   schema.Object{"type": "string", "description": "Project to store it in"},
+			"type":      schema.Object{"type": "string", "enum": memoryTypes, "description": "Kind of memory"},
+			"file_path": schema.Object{"type": "string", "description": "File the memory is about"},
+			"metadata":  schema.Object{"type": "object", "additionalProperties": schema.Object{"type": "string"}},
+		}),
+		run: (*Server).addMemory,
+	},
+	{
+		name:        "get_memory",
+		description: "Fetch a memory by ID.",
+		input: object([]string{"id"}, schema.Object{
+			"id": schema.Object{"type": "string"},
+		}),
+		run: (*Server).getMemory,
+	},
+	{
+		name:        "delete_memory",
+		description: "Delete a memory by ID.",
+		input: object([]string{"id"}, schema.Object{
+			"id": schema.Object{"type": "string"},
+		}),
+		run: (*Server).deleteMemory,
+	},
+	{
+		name:        "list_projects",
+		description: "List projects and how many memories each holds.",
+		input:       object(nil, schema.Object{}),
+		run:         (*Server).listProjects,
+	},
+}
+
+func object(required []string, properties schema.Object) schema.Object {
+	o := schema.Object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		o["required"] = required
+	}
+	return o
+}
+
+func toolList() []schema.Object {
+	list := make([]schema.Object, len(tools))
+	for i, t := range tools {
+		list[i] = schema.Object{"name": t.name, "description": t.description, "inputSchema": t.input}
+	}
+	return list
+}
+
+func findTool(name string) (tool, bool) {
+	for _, t := range tools {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+// callTool runs t and wraps its outcome as a tools/call result. Failures
+// are reported in the result, not as protocol errors, so the model sees them.
+func (s *Server) callTool(ctx context.Context, t tool, args json.RawMessage) schema.Object {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	out, err := t.run(s, ctx, args)
+	if err != nil {
+		return toolResult(err.Error(), true)
+	}
+	text, err := json.Marshal(out)
+	if err != nil {
+		return toolResult(fmt.Sprintf("failed to encode result: %v", err), true)
+	}
+	return toolResult(string(text), false)
+}
+
+func toolResult(text string, isError bool) schema.Object {
+	return schema.Object{
+		"content": []schema.Object{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func (s *Server) searchMemory(ctx context.Context, args json.RawMessage) (any, error) {
+	var req types.SearchRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if max := s.config.MaxSearchLimit; max > 0 {
+		// Earlier pages are ranked too, so the cap covers offset plus limit
+		if req.Offset < 0 || req.Offset >= max {
+			return nil, fmt.Errorf("offset must be between 0 and %d", max-1)
+		}
+		if req.Limit < 0 || req.Limit > max-req.Offset {
+			req.Limit = max - req.Offset
+		}
+	}
+	req.IncludeNeighbors = min(req.IncludeNeighbors, types.MaxIncludeNeighbors)
+	return s.svc.Search(ctx, req)
+}
+
+func (s *Server) addMemory(ctx context.Context, args json.RawMessage) (any, error) {
+	var req types.AddMemoryRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return s.svc.Add(ctx, req)
+}
+
+// idArgs are the arguments of the tools that take a memory ID
+type idArgs struct {
+	ID string `json:"id"`
+}
+
+func parseID(args json.RawMessage) (string, error) {
+	var a idArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if a.ID == "" {
+		return "", fmt.Errorf("id is required")
+	}
+	return a.ID, nil
+}
+
+func (s *Server) getMemory(ctx context.Context, args json.RawMessage) (any, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.svc.Get(ctx, id)
+}
+
+func (s *Server) deleteMemory(ctx context.Context, args json.RawMessage) (any, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.svc.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return types.DeleteResponse{Deleted: true}, nil
+}
+
+func (s *Server) listProjects(ctx context.Context, args json.RawMessage) (any, error) {
+	return s.svc.ListProjects(ctx)
+}
@@ -0,0 +1,73 @@
+// Package memory assembles search results into prompt-ready context
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/tokenizer"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// FormatContext renders results as markdown code fences headed by language
+// and file:lines, best first. Results that would push the total past
+// maxTokens, as counted by tok (nil for tokenizer.Heuristic), are left out;
+// maxTokens <= 0 includes everything.
+func FormatContext(results []types.SearchResult, maxTokens int, tok tokenizer.Tokenizer) string {
+	if tok == nil {
+		tok = tokenizer.Heuristic{}
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range results {
+		block := contextBlock(r.Memory)
+		cost := tok.Count(block)
+		if maxTokens > 0 && used+cost > maxTokens {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(block)
+		used += cost
+	}
+	return b.String()
+}
+
+// contextBlock fences one memory. The fence is longer than any backtick run
+// in the content so embedded fences don't close it.
+func contextBlock(m types.Memory) string {
+	lang := m.Language
+	if lang == "" {
+		lang = "text"
+	}
+	info := strings.TrimSpace(lang + " " + string(m.Type))
+	if m.FilePath != "" {
+		info = lang + " " + m.FilePath
+		if start, end := m.Metadata["start_line"], m.Metadata["end_line"]; start != "" && end != "" {
+			info += ":" + start + "-" + end
+		}
+	}
+
+	fence := strings.Repeat("`", longestRun(m.Content, '`')+1)
+	if len(fence) < 3 {
+		fence = "```"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, info, strings.TrimRight(m.Content, "\n"), fence)
+}
+
+// longestRun returns the length of the longest run of c in s
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
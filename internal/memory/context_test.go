@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestFormatContext(t *testing.T) {
+	results := []types.SearchResult{
+		{Memory: types.Memory{Content: "func retry() {}", Language: "go", Type: types.TypeContext, FilePath: "net/retry.go",
+			Metadata: map[string]string{"start_line": "10", "end_line": "12"}}},
+		{Memory: types.Memory{Content: "Use ```go fences``` in docs", Type: types.TypeDecision}},
+		{Memory: types.Memory{Content: strings.Repeat("x", 400), Language: "text"}},
+	}
+
+	got := FormatContext(results, 0, nil)
+	want := "```go net/retry.go:10-12\nfunc retry() {}\n```\n\n" +
+		"````text decision\nUse ```go fences``` in docs\n````\n\n" +
+		"```text\n" + strings.Repeat("x", 400) + "\n```\n"
+	if got != want {
+		t.Errorf("unexpected context:\n%s", got)
+	}
+
+	// The long third result doesn't fit the budget; the others still do
+	budgeted := FormatContext(results, 40, nil)
+	if strings.Contains(budgeted, "xxxx") || !strings.Contains(budgeted, "decision") {
+		t.Errorf("expected only the results within budget, got:\n%s", budgeted)
+	}
+}
@@ -0,0 +1,188 @@
+// Package memory previews the effect of reindexing a path
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/chunking"
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// IndexDiff compares the chunks a path would produce with the chunks already
+// stored for it. Chunks match on file and content; a remaining chunk with the
+// same file and chunk name as a stored one counts as changed. Stored files
+// that no longer exist under the path are reported as removed.
+func (s *serviceImpl) IndexDiff(ctx context.Context, req types.IndexRequest) (*types.IndexDiff, error) {
+	if req.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	project := req.Project
+	if project == "" {
+		project = s.config.DefaultProject
+	}
+
+	path := expandHome(req.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = s.collectFiles(path, req.FollowSymlinks); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := s.storedChunks(ctx, project, path)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &types.IndexDiff{
+		Added:   []types.ChunkChange{},
+		Removed: []types.ChunkChange{},
+		Changed: []types.ChunkChange{},
+	}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunks, err := s.chunker.ChunkFileWithLanguage(ctx, file, req.Language)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to chunk %s: %v\n", file, err)
+		}
+		if req.OutlineOnly && chunking.HasDeclarations(chunks) {
+			if chunks, err = s.outlineFile(file, chunks); err != nil {
+				return nil, err
+			}
+		}
+		s.redactChunks(chunks) // Indexing compares redacted content
+		diffFile(diff, file, chunks, stored[file])
+		delete(stored, file)
+	}
+
+	// Whatever is left belongs to files that are gone or no longer indexed
+	for _, memories := range stored {
+		for _, m := range memories {
+			diff.Removed = append(diff.Removed, storedChange(m))
+		}
+	}
+
+	for _, changes := range [][]types.ChunkChange{diff.Added, diff.Removed, diff.Changed} {
+		sortChanges(changes)
+	}
+	return diff, nil
+}
+
+// storedChunks returns the indexed chunks stored under path, by file path.
+// Memories added by hand and directory summaries carry no chunk_name and are
+// left out.
+func (s *serviceImpl) storedChunks(ctx context.Context, project, path string) (map[string][]*types.Memory, error) {
+	root := filepath.Clean(path)
+	prefix := root
+	if prefix == "." {
+		prefix = ""
+	}
+
+	memories, err := s.store.List(ctx, store.ListOptions{
+		Project:        project,
+		FilePathPrefix: prefix,
+		Limit:          store.NoLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored chunks: %w", err)
+	}
+
+	byFile := make(map[string][]*types.Memory)
+	for _, m := range memories {
+		if _, ok := m.Metadata["chunk_name"]; !ok || !underRoot(root, m.FilePath) {
+			continue
+		}
+		byFile[m.FilePath] = append(byFile[m.FilePath], m)
+	}
+	return byFile, nil
+}
+
+// underRoot reports whether path is root or inside it
+func underRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// diffFile adds one file's changes to diff
+func diffFile(diff *types.IndexDiff, file string, chunks []types.Chunk, stored []*types.Memory) {
+	// Identical content is unchanged, whatever its position
+	remaining := make(map[string][]*types.Memory)
+	for _, m := range stored {
+		remaining[m.Content] = append(remaining[m.Content], m)
+	}
+	matched := make(map[string]bool)
+
+	var fresh []types.Chunk
+	for _, chunk := range chunks {
+		if ms := remaining[chunk.Content]; len(ms) > 0 {
+			matched[ms[0].ID] = true
+			remaining[chunk.Content] = ms[1:]
+			diff.Unchanged++
+			continue
+		}
+		fresh = append(fresh, chunk)
+	}
+
+	byName := make(map[string][]*types.Memory)
+	for _, m := range stored {
+		if name := m.Metadata["chunk_name"]; !matched[m.ID] && name != "" {
+			byName[name] = append(byName[name], m)
+		}
+	}
+
+	for _, chunk := range fresh {
+		change := types.ChunkChange{FilePath: file, Name: chunk.Name, StartLine: chunk.StartLine, EndLine: chunk.EndLine}
+		if ms := byName[chunk.Name]; chunk.Name != "" && len(ms) > 0 {
+			matched[ms[0].ID] = true
+			byName[chunk.Name] = ms[1:]
+			change.ID = ms[0].ID
+			diff.Changed = append(diff.Changed, change)
+			continue
+		}
+		diff.Added = append(diff.Added, change)
+	}
+
+	for _, m := range stored {
+		if !matched[m.ID] {
+			diff.Removed = append(diff.Removed, storedChange(m))
+		}
+	}
+}
+
+// storedChange describes a stored chunk
+func storedChange(m *types.Memory) types.ChunkChange {
+	start, _ := strconv.Atoi(m.Metadata["start_line"])
+	end, _ := strconv.Atoi(m.Metadata["end_line"])
+	return types.ChunkChange{
+		FilePath:  m.FilePath,
+		Name:      m.Metadata["chunk_name"],
+		StartLine: start,
+		EndLine:   end,
+		ID:        m.ID,
+	}
+}
+
+// sortChanges orders changes by file and line
+func sortChanges(changes []types.ChunkChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].FilePath != changes[j].FilePath {
+			return changes[i].FilePath < changes[j].FilePath
+		}
+		return changes[i].StartLine < changes[j].StartLine
+	})
+}
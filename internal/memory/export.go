@@ -0,0 +1,284 @@
+// Package memory provides NDJSON export and import of memories, and
+// columnar CSV and Parquet exports
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/shivavenkatesh/moneta/internal/parquet"
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// maxImportLine bounds a single NDJSON line (content plus encoded embedding)
+const maxImportLine = 64 * 1024 * 1024
+
+// exportRecord is the NDJSON line format used by Export and Import
+type exportRecord struct {
+	*types.Memory
+	Embedding string `json:"embedding,omitempty"` // base64 little-endian float32
+}
+
+// Export writes memories as NDJSON in stable ID order
+func (s *serviceImpl) Export(ctx context.Context, w io.Writer, opts store.ExportOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	var count int
+
+	err := s.store.Export(ctx, opts, func(m *types.Memory) error {
+		rec := exportRecord{Memory: m}
+		if !opts.NoEmbeddings {
+			rec.Embedding = encodeEmbedding(m.Embedding)
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("failed to write memory %s: %w", m.ID, err)
+		}
+		count++
+		return nil
+	})
+
+	return count, err
+}
+
+// tableColumns are the columns written by ExportTable, in order
+var tableColumns = []parquet.Column{
+	{Name: "id", Type: parquet.String},
+	{Name: "content", Type: parquet.String},
+	{Name: "project", Type: parquet.String},
+	{Name: "type", Type: parquet.String},
+	{Name: "embedding", Type: parquet.FloatList},
+}
+
+// ExportTable writes one row per memory in stable ID order
+func (s *serviceImpl) ExportTable(ctx context.Context, w io.Writer, format TableFormat, opts store.ExportOptions) (int, error) {
+	var write func(m *types.Memory) error
+	var finish func() error
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(tableColumns))
+		for i, col := range tableColumns {
+			header[i] = col.Name
+		}
+		if err := cw.Write(header); err != nil {
+			return 0, fmt.Errorf("failed to write header: %w", err)
+		}
+		write = func(m *types.Memory) error {
+			return cw.Write([]string{m.ID, m.Content, m.Project, string(m.Type), encodeEmbedding(m.Embedding)})
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatParquet:
+		pw := parquet.NewWriter(w, tableColumns)
+		write = func(m *types.Memory) error {
+			return pw.Write(m.ID, m.Content, m.Project, string(m.Type), m.Embedding)
+		}
+		finish = pw.Close
+	default:
+		return 0, fmt.Errorf("unknown table format %q (want csv or parquet)", format)
+	}
+
+	var count int
+	err := s.store.Export(ctx, opts, func(m *types.Memory) error {
+		if err := write(m); err != nil {
+			return fmt.Errorf("failed to write memory %s: %w", m.ID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := finish(); err != nil {
+		return count, fmt.Errorf("failed to finish %s export: %w", format, err)
+	}
+	return count, nil
+}
+
+// Import reads NDJSON memories, skipping IDs that already exist. Records
+// exported without embeddings are embedded again.
+func (s *serviceImpl) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if _, err := store.ParseConflictPolicy(string(opts.OnConflict)); err != nil {
+		return nil, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	result := &ImportResult{}
+	batch := make([]*types.Memory, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		embedded, err := s.embedImported(ctx, batch, opts.OnConflict)
+		if err != nil {
+			return err
+		}
+		result.Embedded += embedded
+		n, err := s.store.Import(ctx, batch, store.ImportOptions{OnConflict: opts.OnConflict})
+		s.searches.invalidate(opts.Project)
+		if err != nil {
+			return err
+		}
+		result.Imported += n.Inserted
+		result.Skipped += n.Skipped
+		result.Merged += n.Merged
+		result.Replaced += n.Replaced
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		rec := exportRecord{Memory: &types.Memory{}}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return result, fmt.Errorf("line %d: invalid record: %w", line, err)
+		}
+		if rec.ID == "" {
+			return result, fmt.Errorf("line %d: id is required", line)
+		}
+
+		embedding, err := decodeEmbedding(rec.Embedding)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		rec.Memory.Embedding = embedding
+		if rec.Type == "" {
+			rec.Type = types.TypeContext
+		}
+		if err := rec.Memory.Validate(); err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if opts.Project != "" {
+			rec.Project = opts.Project
+		}
+		if rec.Project == "" {
+			rec.Project = s.config.DefaultProject
+		}
+
+		batch = append(batch, rec.Memory)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// embedImported embeds the memories of an import batch that have no
+// embedding and returns how many it embedded. Unless duplicates are
+// replaced, memories whose ID already exists are left alone: the store
+// won't use their embedding, so re-running an import costs nothing.
+func (s *serviceImpl) embedImported(ctx context.Context, batch []*types.Memory, policy store.ConflictPolicy) (int, error) {
+	var missing []*types.Memory
+	for _, m := range batch {
+		if len(m.Embedding) == 0 {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	if policy != store.ConflictReplace {
+		ids := make([]string, len(missing))
+		for i, m := range missing {
+			ids[i] = m.ID
+		}
+		existing, err := s.store.GetBatch(ctx, ids)
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up imported memories: %w", err)
+		}
+		fresh := missing[:0]
+		for i, m := range missing {
+			if existing[i] == nil {
+				fresh = append(fresh, m)
+			}
+		}
+		missing = fresh
+	}
+
+	for i := 0; i < len(missing); i += s.config.EmbedBatchSize {
+		end := i + s.config.EmbedBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		texts := make([]string, end-i)
+		for j, m := range missing[i:end] {
+			texts[j] = m.Content
+		}
+
+		s.metrics.embeddings.Add(int64(len(texts)))
+		embeddings, err := s.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return i, fmt.Errorf("failed to embed imported memories: %w", err)
+		}
+		for j, m := range missing[i:end] {
+			m.Embedding = embeddings[j]
+		}
+	}
+	return len(missing), nil
+}
+
+// encodeEmbedding encodes a vector as base64 little-endian float32
+func encodeEmbedding(v []float32) string {
+	if len(v) == 0 {
+		return ""
+	}
+	b := make([]byte, len(v)*4)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// decodeEmbedding reverses encodeEmbedding
+func decodeEmbedding(s string) ([]float32, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedding encoding: %w", err)
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding length: %d bytes", len(b))
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestService_ExportImport_RoundTrip(t *testing.T) {
+	src, _ := createTestService(t, DefaultConfig())
+	ctx := context.Background()
+
+	for _, content := range []string{"first memory", "second memory", "third memory"} {
+		if _, err := src.Add(ctx, types.AddMemoryRequest{Content: content, Project: "p"}); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := src.Export(ctx, &buf, store.ExportOptions{Project: "p"})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 exported, got %d", n)
+	}
+
+	dst, emb := createTestService(t, DefaultConfig())
+	result, err := dst.Import(ctx, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 3 || result.Skipped != 0 {
+		t.Errorf("expected 3 imported, got %+v", result)
+	}
+	if emb.Calls() != 0 {
+		t.Errorf("import should not re-embed, got %d calls", emb.Calls())
+	}
+
+	want, _ := src.List(ctx, store.ListOptions{Project: "p"})
+	for _, w := range want {
+		got, err := dst.Get(ctx, w.ID)
+		if err != nil {
+			t.Fatalf("imported memory %s missing: %v", w.ID, err)
+		}
+		if got.Content != w.Content || !got.CreatedAt.Equal(w.CreatedAt) || !got.UpdatedAt.Equal(w.UpdatedAt) {
+			t.Errorf("memory %s not preserved", w.ID)
+		}
+		if len(got.Embedding) != len(w.Embedding) {
+			t.Fatalf("embedding length mismatch for %s", w.ID)
+		}
+		for i := range w.Embedding {
+			if got.Embedding[i] != w.Embedding[i] {
+				t.Fatalf("embedding mismatch for %s at %d", w.ID, i)
+			}
+		}
+	}
+
+	// Re-running the import skips everything
+	result, err = dst.Import(ctx, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 3 {
+		t.Errorf("expected all skipped on re-import, got %+v", result)
+	}
+}
+
+func TestService_ExportImport_NoEmbeddings(t *testing.T) {
+	src, _ := createTestService(t, DefaultConfig())
+	ctx := context.Background()
+
+	for _, content := range []string{"first memory", "second memory"} {
+		if _, err := src.Add(ctx, types.AddMemoryRequest{Content: content, Project: "p"}); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := src.Export(ctx, &buf, store.ExportOptions{Project: "p", NoEmbeddings: true}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if strings.Contains(buf.String(), `"embedding"`) {
+		t.Fatalf("expected no embeddings in the export, got %s", buf.String())
+	}
+
+	// The import embeds each memory with its own model
+	dst, emb := createTestService(t, DefaultConfig())
+	result, err := dst.Import(ctx, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 2 || result.Embedded != 2 || emb.Calls() != 2 {
+		t.Errorf("expected 2 memories imported and embedded, got %+v after %d calls", result, emb.Calls())
+	}
+	memories, _ := dst.List(ctx, store.ListOptions{Project: "p"})
+	for _, m := range memories {
+		if len(m.Embedding) != 64 {
+			t.Errorf("expected memory %s embedded, got %d dimensions", m.ID, len(m.Embedding))
+		}
+	}
+
+	// Existing memories aren't embedded again
+	result, err = dst.Import(ctx, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	if result.Skipped != 2 || result.Embedded != 0 || emb.Calls() != 2 {
+		t.Errorf("expected a re-import to skip without embedding, got %+v after %d calls", result, emb.Calls())
+	}
+}
+
+func TestService_Export_Resume(t *testing.T) {
+	svc, _ := createTestService(t, DefaultConfig())
+	ctx := context.Background()
+
+	for _, content := range []string{"a", "b", "c"} {
+		if _, err := svc.Add(ctx, types.AddMemoryRequest{Content: content, Project: "p"}); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	var full bytes.Buffer
+	if _, err := svc.Export(ctx, &full, store.ExportOptions{Project: "p"}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(full.String()), "\n")
+
+	// Resume after the first line and check we get the remainder
+	first := lines[0][strings.Index(lines[0], `"id":"`)+6:]
+	afterID := first[:strings.Index(first, `"`)]
+
+	var rest bytes.Buffer
+	n, err := svc.Export(ctx, &rest, store.ExportOptions{Project: "p", AfterID: afterID})
+	if err != nil {
+		t.Fatalf("resumed export failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 remaining, got %d", n)
+	}
+	if lines[0]+"\n"+rest.String() != full.String() {
+		t.Error("resumed export does not continue the original")
+	}
+}
+
+func TestService_Import_InvalidLine(t *testing.T) {
+	svc, _ := createTestService(t, DefaultConfig())
+
+	_, err := svc.Import(context.Background(), strings.NewReader("{not json}\n"), ImportOptions{})
+	if err == nil {
+		t.Error("expected error for invalid line")
+	}
+}
+
+func TestService_ExportTable(t *testing.T) {
+	svc, _ := createTestService(t, DefaultConfig())
+	ctx := context.Background()
+
+	added, err := svc.Add(ctx, types.AddMemoryRequest{Content: "quoted, \"multi-line\"\ncontent", Project: "p"})
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := svc.ExportTable(ctx, &buf, FormatCSV, store.ExportOptions{Project: "p"})
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 exported, got %d, %v", n, err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != "id,content,project,type,embedding" {
+		t.Fatalf("expected a header and one row, got %q", records)
+	}
+	row := records[1]
+	if row[0] != added.ID || row[1] != added.Content || row[2] != "p" {
+		t.Errorf("expected the memory's fields, got %q", row)
+	}
+	if emb, err := decodeEmbedding(row[4]); err != nil || len(emb) != len(added.Embedding) || emb[0] != added.Embedding[0] {
+		t.Errorf("expected the base64 embedding to decode, got %v (%v)", emb, err)
+	}
+
+	buf.Reset()
+	if n, err := svc.ExportTable(ctx, &buf, FormatParquet, store.ExportOptions{}); err != nil || n != 1 {
+		t.Fatalf("expected 1 exported to parquet, got %d, %v", n, err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PAR1")) || !bytes.HasSuffix(buf.Bytes(), []byte("PAR1")) {
+		t.Error("expected a Parquet file")
+	}
+
+	if _, err := svc.ExportTable(ctx, &buf, "xml", store.ExportOptions{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -3,14 +3,22 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/shivavenkatesh/moneta/internal/chunking"
 	"github.com/shivavenkatesh/moneta/internal/embeddings"
+	"github.com/shivavenkatesh/moneta/internal/hash"
+	"github.com/shivavenkatesh/moneta/internal/redact"
+	"github.com/shivavenkatesh/moneta/internal/simd"
 	"github.com/shivavenkatesh/moneta/internal/store"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 
@@ -23,6 +31,10 @@ type serviceImpl struct {
 	embedder embeddings.Embedder
 	chunker  chunking.Chunker
 	config   Config
+
+	indexable map[string]bool // file extensions eligible for indexing
+	searches  *searchCache    // nil when search caching is disabled
+	metrics   metrics
 }
 
 // NewService creates a new memory service
@@ -31,7 +43,11 @@ func NewService(st store.Store, emb embeddings.Embedder, ch chunking.Chunker, cf
 		cfg.DefaultSearchLimit = 10
 	}
 	if cfg.DefaultSearchThreshold <= 0 {
-		cfg.DefaultSearchThreshold = 0.5
+		threshold := FallbackThreshold
+		if emb != nil {
+			threshold, _ = DefaultThreshold(emb.Model())
+		}
+		cfg.DefaultSearchThreshold = cfg.ScoreScale.fromCosine(threshold)
 	}
 	if cfg.DefaultProject == "" {
 		cfg.DefaultProject = "default"
@@ -39,19 +55,37 @@ func NewService(st store.Store, emb embeddings.Embedder, ch chunking.Chunker, cf
 	if cfg.EmbedBatchSize <= 0 {
 		cfg.EmbedBatchSize = 50
 	}
+	if cfg.RedactSecrets && cfg.Redactor == nil {
+		// The default patterns are known to compile
+		cfg.Redactor, _ = redact.New(redact.DefaultConfig())
+	}
+
+	if len(cfg.ChunkerByLanguage) > 0 {
+		registry, ok := ch.(*chunking.Registry)
+		if !ok {
+			opts := chunking.DefaultChunkOptions()
+			registry = chunking.NewRegistry(ch, opts.MaxSize, opts.Overlap)
+		}
+		for lang, c := range cfg.ChunkerByLanguage {
+			registry.Register(lang, c)
+		}
+		ch = registry
+	}
 
 	return &serviceImpl{
-		store:    st,
-		embedder: emb,
-		chunker:  ch,
-		config:   cfg,
+		store:     st,
+		embedder:  emb,
+		chunker:   ch,
+		config:    cfg,
+		indexable: buildIndexExtensions(cfg.IndexExtensions),
+		searches:  newSearchCache(cfg.SearchCacheSize),
 	}
 }
 
 // Add creates a new memory with automatic embedding generation
 func (s *serviceImpl) Add(ctx context.Context, req types.AddMemoryRequest) (*types.Memory, error) {
-	if req.Content == "" {
-		return nil, fmt.Errorf("content is required")
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
 	project := req.Project
@@ -65,6 +99,7 @@ func (s *serviceImpl) Add(ctx context.Context, req types.AddMemoryRequest) (*typ
 	}
 
 	// Generate embedding
+	s.metrics.embeddings.Add(1)
 	embedding, err := s.embedder.Embed(ctx, req.Content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
@@ -86,58 +121,443 @@ func (s *serviceImpl) Add(ctx context.Context, req types.AddMemoryRequest) (*typ
 	if err := s.store.Add(ctx, memory); err != nil {
 		return nil, fmt.Errorf("failed to store memory: %w", err)
 	}
+	s.searches.invalidate(memory.Project)
+	s.metrics.adds.Add(1)
+
+	return memory, nil
+}
+
+// Update changes a memory, re-embedding only when its content changed
+func (s *serviceImpl) Update(ctx context.Context, id string, req types.UpdateMemoryRequest) (*types.Memory, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	memory, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only content, path, and language changes need a full row write
+	fullWrite := false
+	if req.Type != nil {
+		memory.Type = *req.Type
+	}
+	if req.FilePath != nil && *req.FilePath != memory.FilePath {
+		memory.FilePath = *req.FilePath
+		fullWrite = true
+	}
+	if req.Language != nil && *req.Language != memory.Language {
+		memory.Language = *req.Language
+		fullWrite = true
+	}
+	if len(req.Metadata) > 0 {
+		if memory.Metadata == nil {
+			memory.Metadata = make(map[string]string)
+		}
+		for k, v := range req.Metadata {
+			if v == nil {
+				delete(memory.Metadata, k)
+			} else {
+				memory.Metadata[k] = *v
+			}
+		}
+	}
+
+	if req.Content != nil && *req.Content != memory.Content {
+		s.metrics.embeddings.Add(1)
+		embedding, err := s.embedder.Embed(ctx, *req.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		}
+		memory.Content = *req.Content
+		memory.Embedding = embedding
+		delete(memory.Metadata, "chunk_summary")
+		fullWrite = true
+	}
 
+	if fullWrite {
+		err = s.store.Update(ctx, memory)
+	} else {
+		err = s.store.UpdateMetadata(ctx, memory)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update memory: %w", err)
+	}
+	s.searches.invalidate(memory.Project)
 	return memory, nil
 }
 
 // Search finds relevant memories using semantic search
 func (s *serviceImpl) Search(ctx context.Context, req types.SearchRequest) (*types.SearchResponse, error) {
+	return s.SearchEmbedded(ctx, req, nil)
+}
+
+// EmbedQuery embeds the query of req ahead of SearchEmbedded
+func (s *serviceImpl) EmbedQuery(ctx context.Context, req types.SearchRequest) ([]float32, error) {
+	if req.Query == "" || s.isShortQuery(req) {
+		return nil, nil
+	}
+	if _, ok := s.searches.get(s.searches.key(req)); ok {
+		return nil, nil
+	}
+	embedding, err := s.embedQuery(ctx, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return embedding, nil
+}
+
+// SearchEmbedded finds relevant memories, using embedding for the query
+// when it is given
+func (s *serviceImpl) SearchEmbedded(ctx context.Context, req types.SearchRequest, embedding []float32) (*types.SearchResponse, error) {
 	start := time.Now()
 
 	if req.Query == "" {
 		return nil, fmt.Errorf("query is required")
 	}
 
-	// Generate query embedding
-	queryEmbedding, err := s.embedder.Embed(ctx, req.Query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	cacheKey := s.searches.key(req)
+	if resp, ok := s.searches.get(cacheKey); ok {
+		resp.Timing = time.Since(start).Milliseconds()
+		s.metrics.cacheHits.Add(1)
+		s.metrics.searched(start)
+		return resp, nil
+	}
+	if s.searches != nil {
+		s.metrics.cacheMisses.Add(1)
 	}
 
 	limit := req.Limit
 	if limit <= 0 {
 		limit = s.config.DefaultSearchLimit
 	}
+	if req.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+
+	// Thresholds are on the configured scale; the store compares cosine
+	scale, err := ParseScoreScale(string(s.config.ScoreScale))
+	if err != nil {
+		return nil, err
+	}
+	threshold := scale.toCosine(s.searchThreshold(req))
+
+	useReranker := req.Rerank && s.config.Reranker != nil
 
-	threshold := req.Threshold
-	if threshold <= 0 {
-		threshold = s.config.DefaultSearchThreshold
+	// Boosting, reranking, and per-file caps can reorder or drop results,
+	// so fetch extra candidates to rerank. Earlier pages are fetched too so
+	// every page is cut from the same ranking.
+	fetchLimit := req.Offset + limit
+	if len(req.TypeBoosts) > 0 || req.MaxPerFile > 0 || useReranker {
+		fetchLimit *= candidateFactor
 	}
 
 	opts := store.SearchOptions{
-		Project:   req.Project,
-		Limit:     limit,
-		Threshold: threshold,
+		Project:         req.Project,
+		Limit:           fetchLimit,
+		Threshold:       threshold,
+		FilePaths:       req.FilePaths,
+		ExactFilePaths:  req.ExactFilePaths,
+		MetadataFilters: req.Metadata,
+		Fuzzy:           req.Fuzzy,
 	}
 
 	if req.Type != "" {
 		opts.Types = []types.MemoryType{req.Type}
 	}
 
-	results, err := s.store.Search(ctx, queryEmbedding, opts)
+	mode := types.SearchModeSemantic
+	if s.isShortQuery(req) {
+		mode = types.SearchModeKeyword
+	}
+
+	var results []types.SearchResult
+	var total int
+	if mode == types.SearchModeKeyword {
+		results, total, err = s.store.KeywordSearch(ctx, req.Query, opts)
+	} else {
+		results, total, err = s.semanticSearch(ctx, req, embedding, opts, scale, threshold)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+		return nil, err
 	}
 
-	return &types.SearchResponse{
-		Results: results,
-		Total:   len(results),
-		Timing:  time.Since(start).Milliseconds(),
-	}, nil
+	if len(req.TypeBoosts) > 0 {
+		results = applyTypeBoosts(results, req.TypeBoosts, fetchLimit)
+	}
+	if useReranker && mode == types.SearchModeSemantic && len(results) > 1 {
+		reranked, err := s.config.Reranker.Rerank(ctx, req.Query, results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: reranking failed, using vector order: %v\n", err)
+		} else {
+			results = reranked
+		}
+	}
+	if req.MaxPerFile > 0 {
+		results = capPerFile(results, req.MaxPerFile)
+	}
+	results = page(results, req.Offset, limit)
+	if req.IncludeNeighbors > 0 {
+		if err := s.attachNeighbors(ctx, results, req.IncludeNeighbors); err != nil {
+			return nil, err
+		}
+	}
+
+	// Total counts matches before per-file caps, and only estimates the
+	// merged count of an expanded search
+	if total < req.Offset+len(results) {
+		total = req.Offset + len(results)
+	}
+
+	resp := &types.SearchResponse{
+		Results:    results,
+		Total:      total,
+		Limit:      limit,
+		Offset:     req.Offset,
+		HasMore:    req.Offset+len(results) < total,
+		Timing:     time.Since(start).Milliseconds(),
+		ScoreScale: string(scale),
+		Mode:       mode,
+	}
+	s.searches.put(cacheKey, resp)
+	s.metrics.searched(start)
+	return resp, nil
+}
+
+// isShortQuery reports whether req's query is below the length that is
+// embedded, so it should be matched by keyword instead
+func (s *serviceImpl) isShortQuery(req types.SearchRequest) bool {
+	minLen := req.MinSemanticLen
+	if minLen <= 0 {
+		minLen = s.config.MinSemanticLen
+	}
+	return minLen > 0 && utf8.RuneCountInString(strings.TrimSpace(req.Query)) < minLen
+}
+
+// semanticSearch searches with opts for queryEmbedding, embedding the query
+// of req when it is nil, and expands the query when requested.
+// Similarities are returned on scale.
+func (s *serviceImpl) semanticSearch(ctx context.Context, req types.SearchRequest, queryEmbedding []float32, opts store.SearchOptions, scale ScoreScale, threshold float32) ([]types.SearchResult, int, error) {
+	if queryEmbedding == nil {
+		var err error
+		if queryEmbedding, err = s.embedQuery(ctx, req.Query); err != nil {
+			return nil, 0, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+	}
+
+	results, total, err := s.store.Search(ctx, queryEmbedding, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search failed: %w", err)
+	}
+	if req.Expand && s.config.Expander != nil {
+		if results, total, err = s.searchExpanded(ctx, req.Query, opts, results, total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// The store ignores thresholds at or below 0, which unit thresholds
+	// under 0.5 map to, so apply those here
+	kept := results[:0]
+	for _, r := range results {
+		if scale == ScoreUnit && r.Similarity < threshold {
+			continue
+		}
+		r.Similarity = scale.fromCosine(r.Similarity)
+		kept = append(kept, r)
+	}
+	total -= len(results) - len(kept)
+	return kept, total, nil
+}
+
+// searchExpanded searches for each expansion of query and merges the
+// results into results, keeping each memory's highest similarity. total is
+// raised to the largest match count of any variant. A failed expansion is
+// reported and leaves results unchanged.
+func (s *serviceImpl) searchExpanded(ctx context.Context, query string, opts store.SearchOptions, results []types.SearchResult, total int) ([]types.SearchResult, int, error) {
+	variants, err := s.config.Expander.Expand(ctx, query)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		fmt.Fprintf(os.Stderr, "Warning: query expansion failed, searching the query only: %v\n", err)
+		return results, total, nil
+	}
+
+	best := make(map[string]int, len(results))
+	for i, r := range results {
+		best[r.Memory.ID] = i
+	}
+	for _, variant := range variants {
+		embedding, err := s.embedQuery(ctx, variant)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to generate expanded query embedding: %w", err)
+		}
+		more, matched, err := s.store.Search(ctx, embedding, opts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("search failed: %w", err)
+		}
+		if matched > total {
+			total = matched
+		}
+		for _, r := range more {
+			if i, ok := best[r.Memory.ID]; ok {
+				if r.Similarity > results[i].Similarity {
+					results[i].Similarity = r.Similarity
+				}
+				continue
+			}
+			best[r.Memory.ID] = len(results)
+			results = append(results, r)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	if len(results) > total {
+		total = len(results)
+	}
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, total, nil
+}
+
+// page returns the results after offset, up to limit
+func page(results []types.SearchResult, offset, limit int) []types.SearchResult {
+	if offset >= len(results) {
+		return results[:0]
+	}
+	results = results[offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// embedQuery embeds a search query, formatted as a query when the embedder
+// distinguishes queries from content
+func (s *serviceImpl) embedQuery(ctx context.Context, query string) ([]float32, error) {
+	s.metrics.embeddings.Add(1)
+	if q, ok := s.embedder.(embeddings.QueryEmbedder); ok {
+		return q.EmbedQuery(ctx, query)
+	}
+	return s.embedder.Embed(ctx, query)
+}
+
+// candidateFactor is how many candidates per requested result are fetched
+// when type boosts or per-file caps may change the ranking
+const candidateFactor = 3
+
+// applyTypeBoosts scales similarities by per-type multipliers, re-sorts, and
+// trims to limit. The threshold has already been applied to raw similarity.
+func applyTypeBoosts(results []types.SearchResult, boosts map[types.MemoryType]float32, limit int) []types.SearchResult {
+	for i := range results {
+		if boost, ok := boosts[results[i].Memory.Type]; ok && boost > 0 {
+			results[i].Similarity *= boost
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// attachNeighbors sets the Neighbors of each result to the n chunks on
+// either side of it in its file
+func (s *serviceImpl) attachNeighbors(ctx context.Context, results []types.SearchResult, n int) error {
+	for i := range results {
+		neighbors, err := s.store.NeighborChunks(ctx, &results[i].Memory, n)
+		if err != nil {
+			return fmt.Errorf("failed to get neighbors: %w", err)
+		}
+		for _, m := range neighbors {
+			results[i].Neighbors = append(results[i].Neighbors, *m)
+		}
+	}
+	return nil
+}
+
+// capPerFile keeps at most max results per file path, preserving rank order.
+// Memories without a file path are not capped.
+func capPerFile(results []types.SearchResult, max int) []types.SearchResult {
+	perFile := make(map[string]int)
+	kept := results[:0]
+	for _, r := range results {
+		if path := r.Memory.FilePath; path != "" {
+			if perFile[path] >= max {
+				continue
+			}
+			perFile[path]++
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// searchThreshold picks the similarity threshold for a search: an explicit
+// request threshold wins, then a per-type default, then the global default
+func (s *serviceImpl) searchThreshold(req types.SearchRequest) float32 {
+	if req.Threshold > 0 {
+		return req.Threshold
+	}
+	if req.Type != "" {
+		if t, ok := s.config.ThresholdByType[req.Type]; ok && t > 0 {
+			return t
+		}
+	}
+	return s.config.DefaultSearchThreshold
 }
 
 // Index processes a file or directory and stores as memories
 func (s *serviceImpl) Index(ctx context.Context, req types.IndexRequest) (int, error) {
+	return s.IndexWithProgress(ctx, req, nil)
+}
+
+// progressReporter serializes progress callbacks across indexing workers
+type progressReporter struct {
+	mu       sync.Mutex
+	callback func(types.IndexProgress)
+	state    types.IndexProgress
+}
+
+func newProgressReporter(total int, callback func(types.IndexProgress)) *progressReporter {
+	return &progressReporter{callback: callback, state: types.IndexProgress{FilesTotal: total}}
+}
+
+// fileDone records a processed file and reports the updated totals
+func (p *progressReporter) fileDone(path string, chunks int) {
+	if p.callback == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.FilesDone++
+	p.state.Path = path
+	p.state.Chunks += chunks
+	p.callback(p.state)
+}
+
+// fileSkipped records a file left as it was last indexed
+func (p *progressReporter) fileSkipped(path string) {
+	if p.callback == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.FilesDone++
+	p.state.FilesSkipped++
+	p.state.Path = path
+	p.callback(p.state)
+}
+
+// IndexWithProgress processes a file or directory, reporting progress per file
+func (s *serviceImpl) IndexWithProgress(ctx context.Context, req types.IndexRequest, progress func(types.IndexProgress)) (int, error) {
 	if req.Path == "" {
 		return 0, fmt.Errorf("path is required")
 	}
@@ -147,84 +567,411 @@ func (s *serviceImpl) Index(ctx context.Context, req types.IndexRequest) (int, e
 		project = s.config.DefaultProject
 	}
 
-	// Expand ~ to home directory
-	path := req.Path
-	if strings.HasPrefix(path, "~/") {
-		home, _ := os.UserHomeDir()
-		path = filepath.Join(home, path[2:])
-	}
+	// Drop cached searches even if indexing stops partway
+	defer s.searches.invalidate(project)
+	s.metrics.indexes.Add(1)
 
+	path := expandHome(req.Path)
 	info, err := os.Stat(path)
 	if err != nil {
 		return 0, fmt.Errorf("failed to access path: %w", err)
 	}
 
-	var count int
-	if info.IsDir() {
-		count, err = s.indexDirectory(ctx, path, project)
-	} else {
-		count, err = s.indexFile(ctx, path, project)
+	if !info.IsDir() {
+		chunks, err := s.indexFile(ctx, filepath.Dir(path), path, project, req, nil)
+		if errors.Is(err, errFileUnchanged) {
+			newProgressReporter(1, progress).fileSkipped(path)
+			return 0, nil
+		}
+		if err == nil {
+			newProgressReporter(1, progress).fileDone(path, len(chunks))
+		}
+		return len(chunks), err
 	}
 
-	return count, err
+	return s.indexDirectory(ctx, path, project, req, progress)
+}
+
+// expandHome expands a leading ~/ to the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, path[2:])
+	}
+	return path
 }
 
 // indexDirectory recursively indexes all files in a directory
-func (s *serviceImpl) indexDirectory(ctx context.Context, dir, project string) (int, error) {
+func (s *serviceImpl) indexDirectory(ctx context.Context, dir, project string, req types.IndexRequest, progress func(types.IndexProgress)) (int, error) {
+	files, err := s.collectFiles(dir, req.FollowSymlinks)
+	if err != nil {
+		return 0, err
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		root = dir
+	}
+	journal, err := openIndexJournal(s.config.DataDir, project, root, req.Resume)
+	if err != nil {
+		return 0, err
+	}
+
 	var count int
+	reporter := newProgressReporter(len(files), progress)
+
+	// Indexed files and their declarations, grouped by directory
+	summaries := make(map[string][]fileSummary)
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			journal.close()
+			return count, err
+		}
+
+		chunks, err := s.indexFile(ctx, dir, path, project, req, journal)
+		if errors.Is(err, errFileUnchanged) {
+			reporter.fileSkipped(path)
+			if req.GenerateSummaries {
+				// Still list the file in its directory's summary
+				if chunks, err := s.chunker.ChunkFileWithLanguage(ctx, path, req.Language); err == nil && len(chunks) > 0 {
+					d := filepath.Dir(path)
+					summaries[d] = append(summaries[d], newFileSummary(path, chunks))
+				}
+			}
+			continue
+		}
+		if err != nil {
+			// Log error but continue indexing other files
+			fmt.Fprintf(os.Stderr, "Warning: failed to index %s: %v\n", path, err)
+		}
+		count += len(chunks)
+		reporter.fileDone(path, len(chunks))
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if req.GenerateSummaries && len(chunks) > 0 {
+			d := filepath.Dir(path)
+			summaries[d] = append(summaries[d], newFileSummary(path, chunks))
+		}
+	}
+
+	if req.GenerateSummaries {
+		n, err := s.storeDirectorySummaries(ctx, project, summaries)
+		count += n
 		if err != nil {
-			return nil // Skip files we can't access
+			journal.close()
+			return count, err
 		}
+	}
+
+	return count, journal.clear()
+}
+
+// collectFiles walks dir and returns the indexable files in walk order
+func (s *serviceImpl) collectFiles(dir string, followSymlinks bool) ([]string, error) {
+	var files []string
 
-		// Skip ignored patterns
-		for _, pattern := range s.config.IndexIgnore {
-			if matched, _ := filepath.Match(pattern, info.Name()); matched {
-				if info.IsDir() {
-					return filepath.SkipDir
+	// Resolved directories already walked, so symlink cycles terminate
+	visited := make(map[string]bool)
+
+	// walk indexes root, reporting paths under logical so files reached
+	// through a symlinked directory keep their in-tree paths
+	var walk func(root, logical string) error
+	walk = func(root, logical string) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip files we can't access
+			}
+			if root != logical {
+				rel, _ := filepath.Rel(root, path)
+				path = filepath.Join(logical, rel)
+			}
+
+			// Skip ignored patterns
+			for _, pattern := range s.config.IndexIgnore {
+				if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+
+			if followSymlinks && info.Mode()&os.ModeSymlink != 0 {
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return nil // Dangling link
+				}
+				if ti, err := os.Stat(target); err == nil && ti.IsDir() {
+					return walk(target, path)
+				}
+			}
+
+			if info.IsDir() {
+				if followSymlinks {
+					resolved, err := resolveDir(path)
+					if err != nil || visited[resolved] {
+						return filepath.SkipDir
+					}
+					visited[resolved] = true
 				}
 				return nil
 			}
-		}
 
-		if info.IsDir() {
+			// Only index known file types, including Dockerfiles, Makefiles,
+			// and extensionless scripts the chunker recognizes
+			ext := strings.ToLower(filepath.Ext(path))
+			if !s.isIndexableFile(ext) && !isNamedFile(path) {
+				return nil
+			}
+			if !s.isIncluded(dir, path) {
+				return nil
+			}
+
+			files = append(files, path)
 			return nil
+		})
+	}
+
+	if err := walk(dir, dir); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// isIncluded reports whether path matches IndexInclude, trying each pattern
+// against the base name and the path relative to root.
+// An empty IndexInclude includes everything.
+func (s *serviceImpl) isIncluded(root, path string) bool {
+	if len(s.config.IndexInclude) == 0 {
+		return true
+	}
+
+	base := filepath.Base(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+
+	for _, pattern := range s.config.IndexInclude {
+		pattern = filepath.FromSlash(pattern)
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
 		}
+	}
+	return false
+}
 
-		// Only index known file types
-		ext := strings.ToLower(filepath.Ext(path))
-		if !isIndexableFile(ext) {
-			return nil
+// resolveDir returns the absolute, symlink-free form of a directory path
+func resolveDir(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// embeddingText returns the text embedded for a chunk. With EmbedWithPath the
+// relative file path and chunk name are prepended so path keywords carry
+// signal. With EmbedCoreOnly the overlap shared with the previous chunk is
+// left out. The stored content is always the raw chunk.
+func (s *serviceImpl) embeddingText(relPath string, chunk types.Chunk) string {
+	text := chunk.Content
+	if s.config.EmbedCoreOnly && chunk.Overlap > 0 && chunk.Overlap < len(text) {
+		text = strings.TrimSpace(text[chunk.Overlap:])
+	}
+
+	if !s.config.EmbedWithPath {
+		return text
+	}
+	header := filepath.ToSlash(relPath)
+	if chunk.Name != "" {
+		header += " " + chunk.Name
+	}
+	return header + "\n" + text
+}
+
+// fileSummary records an indexed file and its top-level declarations
+type fileSummary struct {
+	name  string
+	decls []string
+}
+
+// newFileSummary collects the unique chunk names of an indexed file
+func newFileSummary(path string, chunks []types.Chunk) fileSummary {
+	summary := fileSummary{name: filepath.Base(path)}
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		if chunk.Name == "" || seen[chunk.Name] {
+			continue
 		}
+		seen[chunk.Name] = true
+		summary.decls = append(summary.decls, chunk.Name)
+	}
+	return summary
+}
 
-		n, err := s.indexFile(ctx, path, project)
-		if err != nil {
-			// Log error but continue indexing other files
-			fmt.Fprintf(os.Stderr, "Warning: failed to index %s: %v\n", path, err)
-			return nil
+// storeDirectorySummaries embeds and stores one navigational memory per directory
+func (s *serviceImpl) storeDirectorySummaries(ctx context.Context, project string, summaries map[string][]fileSummary) (int, error) {
+	if len(summaries) == 0 {
+		return 0, nil
+	}
+
+	dirs := make([]string, 0, len(summaries))
+	for dir := range summaries {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	texts := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		files := summaries[dir]
+		var b strings.Builder
+		fmt.Fprintf(&b, "Directory %s contains:\n", dir)
+		for _, f := range files {
+			if len(f.decls) > 0 {
+				fmt.Fprintf(&b, "- %s: %s\n", f.name, strings.Join(f.decls, ", "))
+			} else {
+				fmt.Fprintf(&b, "- %s\n", f.name)
+			}
 		}
-		count += n
+		texts = append(texts, strings.TrimSpace(b.String()))
+	}
 
-		return nil
-	})
+	s.metrics.embeddings.Add(int64(len(texts)))
+	embeddings, err := s.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate summary embeddings: %w", err)
+	}
+
+	memories := make([]*types.Memory, len(texts))
+	for i, text := range texts {
+		memories[i] = &types.Memory{
+			ID:       uuid.New().String(),
+			Content:  text,
+			Project:  project,
+			Type:     types.TypeContext,
+			FilePath: dirs[i],
+			Metadata: map[string]string{
+				"summary": "directory",
+				"files":   fmt.Sprintf("%d", len(summaries[dirs[i]])),
+			},
+			Embedding: embeddings[i],
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
 
-	return count, err
+	// Replace the summaries of earlier runs rather than adding copies
+	if err := s.deleteDirectorySummaries(ctx, project, dirs); err != nil {
+		return 0, err
+	}
+	if err := s.store.AddBatch(ctx, memories, store.AddOptions{}); err != nil {
+		return 0, fmt.Errorf("failed to store directory summaries: %w", err)
+	}
+
+	return len(memories), nil
+}
+
+// deleteDirectorySummaries deletes the stored summaries of dirs
+func (s *serviceImpl) deleteDirectorySummaries(ctx context.Context, project string, dirs []string) error {
+	var ids []string
+	for _, dir := range dirs {
+		existing, err := s.store.List(ctx, store.ListOptions{
+			Project:         project,
+			FilePathPrefix:  dir,
+			Limit:           store.NoLimit,
+			MetadataFilters: map[string]string{"summary": "directory"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list directory summaries: %w", err)
+		}
+		for _, m := range existing {
+			if m.FilePath == dir {
+				ids = append(ids, m.ID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := s.store.DeleteBatch(ctx, ids); err != nil {
+		return fmt.Errorf("failed to delete old directory summaries: %w", err)
+	}
+	return nil
 }
 
-// indexFile indexes a single file
-func (s *serviceImpl) indexFile(ctx context.Context, path, project string) (int, error) {
-	chunks, err := s.chunker.ChunkFile(ctx, path)
+// errFileUnchanged is returned by indexFile for a file whose content is
+// the same as when it was last indexed
+var errFileUnchanged = errors.New("file unchanged since last indexed")
+
+// indexFile indexes a single file and returns the chunks that were stored,
+// replacing the chunks of its previous version. Unless req.Force is set, a
+// file whose content hasn't changed is left alone and errFileUnchanged
+// returned; so is a file the resumed run in journal already finished. root
+// is the directory being indexed, used to relativize the file path. A
+// non-empty req.Language forces the chunker instead of detecting from the
+// file.
+func (s *serviceImpl) indexFile(ctx context.Context, root, path, project string, req types.IndexRequest, journal *indexJournal) ([]types.Chunk, error) {
+	record, err := s.fileRecord(project, path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to chunk file: %w", err)
+		return nil, err
+	}
+	if journal.finished(record.Path, record.Hash) {
+		return nil, errFileUnchanged
+	}
+	if !req.Force {
+		previous, err := s.store.GetIndexedFile(ctx, project, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up indexed file: %w", err)
+		}
+		if previous != nil && previous.Hash == record.Hash {
+			return nil, errFileUnchanged
+		}
+	}
+
+	chunks, err := s.chunker.ChunkFileWithLanguage(ctx, path, req.Language)
+	if errors.Is(err, chunking.ErrUnknownEncoding) {
+		// Indexing undecodable bytes would only store and embed mojibake
+		fmt.Fprintf(os.Stderr, "Warning: skipped %v\n", err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk file: %w", err)
+	}
+	outline := req.OutlineOnly && chunking.HasDeclarations(chunks)
+	if outline {
+		if chunks, err = s.outlineFile(path, chunks); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(chunks) == 0 {
-		return 0, nil
+		// The file is empty now, so its old chunks are stale
+		if err := s.replaceFile(ctx, record, nil); err != nil {
+			return nil, err
+		}
+		return nil, s.recordFile(ctx, record, 0, journal)
+	}
+	redacted := s.redactChunks(chunks)
+
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		relPath = path
+	}
+
+	fileHash, err := s.storeFileContent(ctx, project, path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate embeddings in batches
 	memories := make([]*types.Memory, 0, len(chunks))
+	stored := make([]types.Chunk, 0, len(chunks))
+	total := fmt.Sprintf("%d", len(chunks))
+	skipped := 0
 
 	for i := 0; i < len(chunks); i += s.config.EmbedBatchSize {
 		end := i + s.config.EmbedBatchSize
@@ -235,15 +982,24 @@ func (s *serviceImpl) indexFile(ctx context.Context, path, project string) (int,
 		batch := chunks[i:end]
 		texts := make([]string, len(batch))
 		for j, chunk := range batch {
-			texts[j] = chunk.Content
+			texts[j] = s.embeddingText(relPath, chunk)
 		}
 
+		var batchErr *embeddings.BatchError
+		s.metrics.embeddings.Add(int64(len(texts)))
 		embeddings, err := s.embedder.EmbedBatch(ctx, texts)
-		if err != nil {
-			return len(memories), fmt.Errorf("failed to generate embeddings: %w", err)
+		if err != nil && !errors.As(err, &batchErr) {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 		}
 
 		for j, chunk := range batch {
+			// Store the rest of the file without chunks the model rejected
+			if batchErr != nil && batchErr.Failed[j] != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipped chunk %s:%d-%d: %v\n", relPath, chunk.StartLine, chunk.EndLine, batchErr.Failed[j])
+				skipped++
+				continue
+			}
+			// chunk_index and total_chunks let consumers detect adjacent chunks
 			memory := &types.Memory{
 				ID:       uuid.New().String(),
 				Content:  chunk.Content,
@@ -252,24 +1008,172 @@ func (s *serviceImpl) indexFile(ctx context.Context, path, project string) (int,
 				FilePath: path,
 				Language: chunk.Type,
 				Metadata: map[string]string{
-					"start_line": fmt.Sprintf("%d", chunk.StartLine),
-					"end_line":   fmt.Sprintf("%d", chunk.EndLine),
-					"chunk_name": chunk.Name,
+					"start_line":   fmt.Sprintf("%d", chunk.StartLine),
+					"end_line":     fmt.Sprintf("%d", chunk.EndLine),
+					"chunk_name":   chunk.Name,
+					"chunk_index":  fmt.Sprintf("%d", i+j),
+					"total_chunks": total,
 				},
 				Embedding: embeddings[j],
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
+			if chunk.Overlap > 0 {
+				memory.Metadata["overlap"] = fmt.Sprintf("%d", chunk.Overlap)
+			}
+			if fileHash != "" {
+				memory.Metadata["file_hash"] = fileHash
+			}
+			if outline {
+				memory.Metadata["outline"] = "true"
+			}
+			if redacted != nil && len(redacted[i+j]) > 0 {
+				memory.Metadata[redactedKey] = strings.Join(redacted[i+j], ",")
+			}
 			memories = append(memories, memory)
+			stored = append(stored, chunk)
 		}
 	}
 
-	// Batch add to store
-	if err := s.store.AddBatch(ctx, memories); err != nil {
-		return 0, fmt.Errorf("failed to store memories: %w", err)
+	if len(memories) == 0 {
+		// Keep the previous version searchable rather than nothing
+		return nil, fmt.Errorf("failed to embed all %d chunks", skipped)
 	}
 
-	return len(memories), nil
+	if req.SummarizeChunks && s.config.Summarizer != nil {
+		if err := s.summarizeChunks(ctx, memories); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.replaceFile(ctx, record, memories); err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		// Leave the file unrecorded so the next run retries the skipped chunks
+		return stored, nil
+	}
+	return stored, s.recordFile(ctx, record, len(memories), journal)
+}
+
+// fileRecord hashes a file for its index record
+func (s *serviceImpl) fileRecord(project, path string) (store.IndexedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return store.IndexedFile{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return store.IndexedFile{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return store.IndexedFile{
+		Project: project,
+		Path:    path,
+		Hash:    hash.Identity(string(data)),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// replaceFile swaps the stored chunks of a file for memories
+func (s *serviceImpl) replaceFile(ctx context.Context, record store.IndexedFile, memories []*types.Memory) error {
+	if _, err := s.store.DeleteByFilePath(ctx, record.Project, record.Path); err != nil {
+		return fmt.Errorf("failed to delete old chunks: %w", err)
+	}
+	if len(memories) > 0 {
+		if err := s.store.AddBatch(ctx, memories, store.AddOptions{}); err != nil {
+			return fmt.Errorf("failed to store memories: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordFile records the version of a file indexed with count chunks, in
+// the store and the journal, so later runs skip it while it is unchanged.
+// It is called only once every chunk is stored.
+func (s *serviceImpl) recordFile(ctx context.Context, record store.IndexedFile, count int, journal *indexJournal) error {
+	record.ChunkCount = count
+	if err := s.store.PutIndexedFile(ctx, record); err != nil {
+		return err
+	}
+	journal.record(record.Path, record.Hash)
+	return nil
+}
+
+// outlineFile reduces the declaration chunks of a file to their outlines
+func (s *serviceImpl) outlineFile(path string, chunks []types.Chunk) ([]types.Chunk, error) {
+	content, err := chunking.ReadTextFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return chunking.Outline(content, chunks), nil
+}
+
+// storeFileContent copies a file into the store when StoreFileContent is
+// set, returning its hash. Files over the size limit are skipped.
+func (s *serviceImpl) storeFileContent(ctx context.Context, project, path string) (string, error) {
+	if !s.config.StoreFileContent {
+		return "", nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	limit := s.config.MaxFileContentSize
+	if limit <= 0 {
+		limit = DefaultMaxFileContentSize
+	}
+	if info.Size() > limit {
+		return "", nil
+	}
+
+	content, err := chunking.ReadTextFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	hash, err := s.store.PutFileContent(ctx, project, path, s.redactText(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to store file content: %w", err)
+	}
+	return hash, nil
+}
+
+// summarizeChunks stores a summary of each memory in its metadata and embeds
+// it as the memory's summary embedding. Chunks the summarizer fails on are
+// indexed without a summary.
+func (s *serviceImpl) summarizeChunks(ctx context.Context, memories []*types.Memory) error {
+	var summarized []*types.Memory
+	var summaries []string
+	for _, memory := range memories {
+		summary, err := s.config.Summarizer.Summarize(ctx, memory.Content, memory.Language)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "Warning: failed to summarize %s:%s: %v\n", memory.FilePath, memory.Metadata["start_line"], err)
+			continue
+		}
+		memory.Metadata["chunk_summary"] = summary
+		summarized = append(summarized, memory)
+		summaries = append(summaries, summary)
+	}
+
+	for i := 0; i < len(summaries); i += s.config.EmbedBatchSize {
+		end := i + s.config.EmbedBatchSize
+		if end > len(summaries) {
+			end = len(summaries)
+		}
+		var batchErr *embeddings.BatchError
+		s.metrics.embeddings.Add(int64(end - i))
+		embeddings, err := s.embedder.EmbedBatch(ctx, summaries[i:end])
+		if err != nil && !errors.As(err, &batchErr) {
+			return fmt.Errorf("failed to generate summary embeddings: %w", err)
+		}
+		for j, embedding := range embeddings {
+			summarized[i+j].SummaryEmbedding = embedding // nil if it failed
+		}
+	}
+	return nil
 }
 
 // Get retrieves a single memory by ID
@@ -277,21 +1181,191 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (*types.Memory, error)
 	return s.store.Get(ctx, id)
 }
 
+// GetBatch retrieves memories by ID in the order given
+func (s *serviceImpl) GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error) {
+	return s.store.GetBatch(ctx, ids)
+}
+
 // Delete removes a memory by ID
 func (s *serviceImpl) Delete(ctx context.Context, id string) error {
+	// The project isn't known without a lookup, so drop every cached search
+	defer s.searches.invalidate("")
 	return s.store.Delete(ctx, id)
 }
 
 // DeleteByProject removes all memories for a project
 func (s *serviceImpl) DeleteByProject(ctx context.Context, project string) error {
+	defer s.searches.invalidate(project)
 	return s.store.DeleteByProject(ctx, project)
 }
 
+// UpdateWhere applies a bulk edit to the memories matching filter
+func (s *serviceImpl) UpdateWhere(ctx context.Context, filter store.UpdateFilter, changes store.UpdateChanges) (int, error) {
+	if !changes.DryRun {
+		defer s.searches.invalidate(filter.Project)
+	}
+	return s.store.UpdateWhere(ctx, filter, changes)
+}
+
+// DeleteBatch removes memories by ID in one transaction
+func (s *serviceImpl) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	defer s.searches.invalidate("")
+	return s.store.DeleteBatch(ctx, ids)
+}
+
 // List returns memories with filtering
 func (s *serviceImpl) List(ctx context.Context, opts store.ListOptions) ([]*types.Memory, error) {
 	return s.store.List(ctx, opts)
 }
 
+// CountList returns how many memories match the filters of opts
+func (s *serviceImpl) CountList(ctx context.Context, opts store.ListOptions) (int, error) {
+	return s.store.CountList(ctx, opts)
+}
+
+// Project metadata keys
+const (
+	projectNameKey        = "name"
+	projectDescriptionKey = "description"
+)
+
+// ListProjects returns all projects with their display metadata
+func (s *serviceImpl) ListProjects(ctx context.Context) ([]types.Project, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		meta, err := s.store.GetProjectMeta(ctx, projects[i].Key)
+		if err != nil {
+			return nil, err
+		}
+		projects[i].Name = meta[projectNameKey]
+		projects[i].Description = meta[projectDescriptionKey]
+	}
+
+	return projects, nil
+}
+
+// GetProject returns a project's display metadata and memory count
+func (s *serviceImpl) GetProject(ctx context.Context, key string) (*types.Project, error) {
+	meta, err := s.store.GetProjectMeta(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.store.Count(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Project{
+		Key:         key,
+		Name:        meta[projectNameKey],
+		Description: meta[projectDescriptionKey],
+		MemoryCount: count,
+	}, nil
+}
+
+// SetProjectInfo sets a project's display name and description
+func (s *serviceImpl) SetProjectInfo(ctx context.Context, key string, name, description *string) error {
+	if key == "" {
+		return fmt.Errorf("project key is required")
+	}
+
+	meta := make(map[string]string)
+	if name != nil {
+		meta[projectNameKey] = strings.TrimSpace(*name)
+	}
+	if description != nil {
+		meta[projectDescriptionKey] = strings.TrimSpace(*description)
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+
+	return s.store.SetProjectMeta(ctx, key, meta)
+}
+
+// Prune finds and removes duplicate or near-duplicate memories.
+// Memories are considered newest first, so the newest copy is always kept.
+func (s *serviceImpl) Prune(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
+	if !opts.Duplicates && opts.SimilarityThreshold <= 0 {
+		return nil, fmt.Errorf("no prune criteria specified")
+	}
+
+	var cutoff time.Time
+	if opts.OlderThan > 0 {
+		cutoff = time.Now().Add(-opts.OlderThan)
+	}
+
+	result := &PruneResult{}
+	seen := make(map[string]bool) // project + content of kept memories
+	var kept []*types.Memory      // candidates for near-duplicate comparison
+
+	err := s.store.Iterate(ctx, store.ListOptions{
+		Project:    opts.Project,
+		OrderBy:    "updated_at",
+		Descending: true,
+	}, func(m *types.Memory) error {
+		eligible := cutoff.IsZero() || m.UpdatedAt.Before(cutoff)
+
+		if opts.Duplicates {
+			key := m.Project + "\x00" + m.Content
+			if seen[key] && eligible {
+				result.Duplicates = append(result.Duplicates, m)
+				return nil
+			}
+			seen[key] = true
+		}
+
+		if opts.SimilarityThreshold > 0 && len(m.Embedding) > 0 {
+			if eligible && hasNearDuplicate(m, kept, opts.SimilarityThreshold) {
+				result.NearDuplicates = append(result.NearDuplicates, m)
+				return nil
+			}
+			kept = append(kept, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	ids := make([]string, 0, len(result.Duplicates)+len(result.NearDuplicates))
+	for _, group := range [][]*types.Memory{result.Duplicates, result.NearDuplicates} {
+		for _, m := range group {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	defer s.searches.invalidate(opts.Project)
+	result.Deleted, err = s.store.DeleteBatch(ctx, ids)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete pruned memories: %w", err)
+	}
+
+	return result, nil
+}
+
+// hasNearDuplicate reports whether m is at least threshold-similar to any kept memory
+func hasNearDuplicate(m *types.Memory, kept []*types.Memory, threshold float32) bool {
+	for _, k := range kept {
+		if k.Project != m.Project {
+			continue
+		}
+		if simd.CosineSimilarity(m.Embedding, k.Embedding) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
 // Stats returns system statistics
 func (s *serviceImpl) Stats(ctx context.Context) (*types.StatsResponse, error) {
 	stats, err := s.store.Stats(ctx)
@@ -299,9 +1373,80 @@ func (s *serviceImpl) Stats(ctx context.Context) (*types.StatsResponse, error) {
 		return nil, err
 	}
 	stats.EmbeddingModel = s.embedder.Model()
+	es := s.EmbedderStats()
+	stats.EmbedRequests = es.Requests
+	stats.CacheHitRate = es.CacheHitRate
+	stats.EmbedP50LatencyMs = es.P50LatencyMs
+	stats.EmbedP95LatencyMs = es.P95LatencyMs
+	stats.EmbedP99LatencyMs = es.P99LatencyMs
+	if c, ok := s.embedder.(embedderCache); ok {
+		stats.CacheDisabled = !c.CacheEnabled()
+	}
+	if b, ok := s.embedder.(embedderCircuit); ok {
+		stats.EmbedderCircuit = b.State().String()
+	}
 	return stats, nil
 }
 
+// EmbedderInfo describes the embedder and whether it is accepting calls
+func (s *serviceImpl) EmbedderInfo() (model string, dims int, healthy bool) {
+	healthy = true
+	if b, ok := s.embedder.(embedderCircuit); ok {
+		healthy = b.State() != embeddings.BreakerOpen
+	}
+	return s.embedder.Model(), s.embedder.Dimensions(), healthy
+}
+
+// EmbedderStats returns the embedder's request statistics, or zeros
+func (s *serviceImpl) EmbedderStats() types.EmbedderStats {
+	var stats types.EmbedderStats
+	if r, ok := s.embedder.(embedderStats); ok {
+		stats.Requests, stats.AvgLatencyMs, stats.CacheHitRate = r.Stats()
+	}
+	if l, ok := s.embedder.(embedderLatency); ok {
+		p50, p95, p99 := l.LatencyPercentiles()
+		stats.P50LatencyMs = durationMs(p50)
+		stats.P95LatencyMs = durationMs(p95)
+		stats.P99LatencyMs = durationMs(p99)
+	}
+	return stats
+}
+
+// durationMs converts d to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// embedderStats is implemented by embedders that track request statistics
+type embedderStats interface {
+	Stats() (requests int64, avgLatencyMs float64, cacheHitRate float64)
+}
+
+// embedderLatency is implemented by embedders that track latency percentiles
+type embedderLatency interface {
+	LatencyPercentiles() (p50, p95, p99 time.Duration)
+}
+
+// embedderCache is implemented by embedders whose cache can be disabled
+type embedderCache interface {
+	CacheEnabled() bool
+}
+
+// embedderCircuit is implemented by embedders behind a circuit breaker
+type embedderCircuit interface {
+	State() embeddings.BreakerState
+}
+
+// Ping verifies the underlying store is healthy
+func (s *serviceImpl) Ping(ctx context.Context) error {
+	return s.store.Ping(ctx)
+}
+
+// Checkpoint flushes the store's write-ahead log
+func (s *serviceImpl) Checkpoint(ctx context.Context, mode string) error {
+	return s.store.Checkpoint(ctx, mode)
+}
+
 // Close releases resources
 func (s *serviceImpl) Close() error {
 	if err := s.embedder.Close(); err != nil {
@@ -310,34 +1455,52 @@ func (s *serviceImpl) Close() error {
 	return s.store.Close()
 }
 
+// defaultIndexExtensions lists the file extensions indexed out of the box
+var defaultIndexExtensions = []string{
+	".go", ".py", ".js", ".ts", ".tsx", ".jsx", ".rs", ".java",
+	".c", ".cpp", ".h", ".hpp", ".rb", ".php", ".swift", ".kt", ".cs",
+	".md", ".txt", ".yaml", ".yml", ".toml", ".json", ".sql", ".sh",
+}
+
+// buildIndexExtensions merges the built-in extensions with configured extras
+func buildIndexExtensions(extra []string) map[string]bool {
+	indexable := make(map[string]bool, len(defaultIndexExtensions)+len(extra))
+	for _, ext := range defaultIndexExtensions {
+		indexable[ext] = true
+	}
+	for _, ext := range extra {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		indexable[ext] = true
+	}
+	return indexable
+}
+
+// isNamedFile reports whether the chunker recognizes path by its name or,
+// without an extension, by its shebang
+func isNamedFile(path string) bool {
+	if chunking.NamedLanguage(path, nil) != "" {
+		return true
+	}
+	if filepath.Ext(path) != "" {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 256)
+	n, _ := io.ReadFull(f, head)
+	return chunking.NamedLanguage(path, head[:n]) != ""
+}
+
 // isIndexableFile returns true if the file extension is indexable
-func isIndexableFile(ext string) bool {
-	indexable := map[string]bool{
-		".go":    true,
-		".py":    true,
-		".js":    true,
-		".ts":    true,
-		".tsx":   true,
-		".jsx":   true,
-		".rs":    true,
-		".java":  true,
-		".c":     true,
-		".cpp":   true,
-		".h":     true,
-		".hpp":   true,
-		".rb":    true,
-		".php":   true,
-		".swift": true,
-		".kt":    true,
-		".cs":    true,
-		".md":    true,
-		".txt":   true,
-		".yaml":  true,
-		".yml":   true,
-		".toml":  true,
-		".json":  true,
-		".sql":   true,
-		".sh":    true,
-	}
-	return indexable[ext]
+func (s *serviceImpl) isIndexableFile(ext string) bool {
+	return s.indexable[ext]
 }
@@ -0,0 +1,127 @@
+// Package memory journals the files a directory index has finished, so an
+// interrupted run can resume where it stopped
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shivavenkatesh/moneta/internal/hash"
+)
+
+// journalEntry is one line of an index journal: a file stored in full
+type journalEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// indexJournal appends each file a directory index finishes to a JSON
+// lines file under the data directory. A nil journal records nothing.
+type indexJournal struct {
+	path string
+	file *os.File
+	done map[string]string // path to content hash, from the run resumed
+}
+
+// journalPath returns where the journal for indexing root into project
+// lives, so runs over different directories don't share one
+func journalPath(dataDir, project, root string) string {
+	return filepath.Join(expandHome(dataDir), "journals", "index-"+hash.CacheKey(project, root)+".jsonl")
+}
+
+// openIndexJournal starts the journal for indexing root into project. With
+// resume, the files finished by an interrupted run are kept and reported by
+// finished; otherwise any old journal is discarded. Without a data
+// directory there is no journal.
+func openIndexJournal(dataDir, project, root string, resume bool) (*indexJournal, error) {
+	if dataDir == "" {
+		return nil, nil
+	}
+	j := &indexJournal{path: journalPath(dataDir, project, root), done: make(map[string]string)}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		if err := j.load(); err != nil {
+			return nil, err
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(j.path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index journal: %w", err)
+	}
+	j.file = file
+	return j, nil
+}
+
+// load reads the entries of an earlier run. A line cut short by a crash is
+// ignored; its file is simply indexed again.
+func (j *indexJournal) load() error {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index journal: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Path != "" {
+			j.done[entry.Path] = entry.Hash
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read index journal: %w", err)
+	}
+	return nil
+}
+
+// finished reports whether the resumed run already stored path at this
+// content hash
+func (j *indexJournal) finished(path, contentHash string) bool {
+	if j == nil {
+		return false
+	}
+	done, ok := j.done[path]
+	return ok && done == contentHash
+}
+
+// record appends a finished file. A failed write only costs redoing the
+// file on resume, so it is a warning.
+func (j *indexJournal) record(path, contentHash string) {
+	if j == nil {
+		return
+	}
+	line, _ := json.Marshal(journalEntry{Path: path, Hash: contentHash})
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write index journal: %v\n", err)
+	}
+}
+
+// close closes the journal, keeping it for a later resume
+func (j *indexJournal) close() {
+	if j != nil {
+		j.file.Close()
+	}
+}
+
+// clear closes and removes the journal once its run has completed
+func (j *indexJournal) clear() error {
+	if j == nil {
+		return nil
+	}
+	j.file.Close()
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove index journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package memory counts service activity for Service.Metrics
+package memory
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// metrics holds the counters behind Service.Metrics. They are updated
+// with atomics, so recording never blocks a request.
+type metrics struct {
+	adds        atomic.Int64
+	searches    atomic.Int64
+	searchNanos atomic.Int64
+	indexes     atomic.Int64
+	embeddings  atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+}
+
+// searched records a search that took since start
+func (m *metrics) searched(start time.Time) {
+	m.searches.Add(1)
+	m.searchNanos.Add(int64(time.Since(start)))
+}
+
+// snapshot returns the current counts
+func (m *metrics) snapshot() types.Metrics {
+	out := types.Metrics{
+		Adds:        m.adds.Load(),
+		Searches:    m.searches.Load(),
+		Indexes:     m.indexes.Load(),
+		Embeddings:  m.embeddings.Load(),
+		CacheHits:   m.cacheHits.Load(),
+		CacheMisses: m.cacheMisses.Load(),
+	}
+	if out.Searches > 0 {
+		out.AvgSearchLatencyMs = float64(m.searchNanos.Load()) / float64(out.Searches) / float64(time.Millisecond)
+	}
+	return out
+}
+
+// Metrics returns counts of the service's activity since it was created
+func (s *serviceImpl) Metrics() types.Metrics {
+	return s.metrics.snapshot()
+}
@@ -0,0 +1,50 @@
+// Package memory strips secrets from indexed content
+package memory
+
+import (
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// redactedKey is the metadata key listing the detectors that redacted
+// secrets from a memory's content
+const redactedKey = "redacted"
+
+// redactChunks replaces secrets in chunks in place when RedactSecrets is
+// set, and returns the detectors that matched each chunk
+func (s *serviceImpl) redactChunks(chunks []types.Chunk) [][]string {
+	if !s.config.RedactSecrets {
+		return nil
+	}
+
+	found := make([][]string, len(chunks))
+	for i := range chunks {
+		c := &chunks[i]
+		content, names := s.config.Redactor.Redact(c.Content)
+
+		// Keep the overlap boundary on the redacted text. A secret spanning
+		// it redacts differently in the overlap alone, so drop the overlap.
+		if c.Overlap > 0 && c.Overlap <= len(c.Content) {
+			prefix, _ := s.config.Redactor.Redact(c.Content[:c.Overlap])
+			if strings.HasPrefix(content, prefix) {
+				c.Overlap = len(prefix)
+			} else {
+				c.Overlap = 0
+			}
+		}
+
+		c.Content = content
+		found[i] = names
+	}
+	return found
+}
+
+// redactText replaces secrets in text when RedactSecrets is set
+func (s *serviceImpl) redactText(text string) string {
+	if !s.config.RedactSecrets {
+		return text
+	}
+	text, _ = s.config.Redactor.Redact(text)
+	return text
+}
@@ -0,0 +1,93 @@
+// Package memory caches search responses between writes
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/shivavenkatesh/moneta/internal/cache"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// searchCache caches search responses. Keys include a generation counter
+// for the searched project, so a write to a project makes its cached
+// searches unreachable without scanning the cache; they age out of the LRU.
+// A nil *searchCache caches nothing.
+type searchCache struct {
+	lru *cache.LRU[string, *types.SearchResponse]
+
+	mu    sync.Mutex
+	epoch uint64            // Bumped by writes to an unknown project
+	all   uint64            // Bumped by every write; keys unscoped searches
+	gens  map[string]uint64 // Bumped by writes to each project
+}
+
+func newSearchCache(size int) *searchCache {
+	if size <= 0 {
+		return nil
+	}
+	return &searchCache{
+		lru:  cache.NewLRU[string, *types.SearchResponse](size),
+		gens: make(map[string]uint64),
+	}
+}
+
+// key identifies req at the current generation. Compute it before searching
+// so a write during the search stores the response under a stale key.
+func (c *searchCache) key(req types.SearchRequest) string {
+	if c == nil {
+		return ""
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	gen := c.all
+	if req.Project != "" {
+		gen = c.gens[req.Project]
+	}
+	return fmt.Sprintf("%d/%d/%s", c.epoch, gen, body)
+}
+
+// get returns a copy of the response cached under key
+func (c *searchCache) get(key string) (*types.SearchResponse, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	cached, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	resp := *cached
+	resp.Results = append([]types.SearchResult(nil), cached.Results...)
+	return &resp, true
+}
+
+func (c *searchCache) put(key string, resp *types.SearchResponse) {
+	if c == nil || key == "" {
+		return
+	}
+	stored := *resp
+	stored.Results = append([]types.SearchResult(nil), resp.Results...)
+	c.lru.Put(key, &stored)
+}
+
+// invalidate drops cached searches of project and unscoped searches. An
+// empty project drops every cached search.
+func (c *searchCache) invalidate(project string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if project == "" {
+		c.epoch++
+		return
+	}
+	c.gens[project]++
+	c.all++
+}
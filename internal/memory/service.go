@@ -3,8 +3,16 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"time"
 
+	"github.com/shivavenkatesh/moneta/internal/chunking"
+	"github.com/shivavenkatesh/moneta/internal/expand"
+	"github.com/shivavenkatesh/moneta/internal/redact"
+	"github.com/shivavenkatesh/moneta/internal/rerank"
 	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/internal/summarize"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 )
 
@@ -16,48 +24,267 @@ type Service interface {
 	// Search finds relevant memories using semantic search
 	Search(ctx context.Context, req types.SearchRequest) (*types.SearchResponse, error)
 
+	// EmbedQuery embeds req's query as Search would, so a caller can embed
+	// the next query while the current one searches. It returns nil when
+	// Search would not embed req: keyword queries and cached searches.
+	EmbedQuery(ctx context.Context, req types.SearchRequest) ([]float32, error)
+
+	// SearchEmbedded is Search using an embedding from EmbedQuery. A nil
+	// embedding is computed as usual.
+	SearchEmbedded(ctx context.Context, req types.SearchRequest, embedding []float32) (*types.SearchResponse, error)
+
 	// Index processes a file or directory and stores as memories
 	Index(ctx context.Context, req types.IndexRequest) (int, error)
 
+	// IndexWithProgress is Index with a callback invoked after each file.
+	// Calls are serialized, so the callback needs no locking of its own.
+	IndexWithProgress(ctx context.Context, req types.IndexRequest, progress func(types.IndexProgress)) (int, error)
+
+	// IndexDiff compares the chunks a path would produce with the chunks
+	// already stored for it, without embedding or writing anything
+	IndexDiff(ctx context.Context, req types.IndexRequest) (*types.IndexDiff, error)
+
+	// Update applies the non-nil fields of req to a memory. Only a content
+	// change is re-embedded; other changes skip the embedder.
+	Update(ctx context.Context, id string, req types.UpdateMemoryRequest) (*types.Memory, error)
+
 	// Get retrieves a single memory by ID
 	Get(ctx context.Context, id string) (*types.Memory, error)
 
+	// GetBatch retrieves memories by ID in one query, in the order given,
+	// with nil entries for unknown IDs
+	GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error)
+
 	// Delete removes a memory by ID
 	Delete(ctx context.Context, id string) error
 
+	// UpdateWhere retypes or tags every memory matching filter without
+	// re-embedding, and returns how many memories changed
+	UpdateWhere(ctx context.Context, filter store.UpdateFilter, changes store.UpdateChanges) (int, error)
+
+	// DeleteBatch removes memories by ID in one transaction and returns how
+	// many existed
+	DeleteBatch(ctx context.Context, ids []string) (int, error)
+
 	// DeleteByProject removes all memories for a project
 	DeleteByProject(ctx context.Context, project string) error
 
 	// List returns memories with filtering
 	List(ctx context.Context, opts store.ListOptions) ([]*types.Memory, error)
 
+	// CountList returns how many memories match the filters of opts,
+	// ignoring its limit and offset
+	CountList(ctx context.Context, opts store.ListOptions) (int, error)
+
+	// ListProjects returns all projects with their display metadata
+	ListProjects(ctx context.Context) ([]types.Project, error)
+
+	// GetProject returns a project's display metadata and memory count
+	GetProject(ctx context.Context, key string) (*types.Project, error)
+
+	// SetProjectInfo sets a project's display name and description.
+	// Nil fields are left unchanged; empty strings clear them.
+	SetProjectInfo(ctx context.Context, key string, name, description *string) error
+
 	// Stats returns system statistics
 	Stats(ctx context.Context) (*types.StatsResponse, error)
 
+	// EmbedderInfo describes the embedder. It is unhealthy while its circuit
+	// breaker is open; embedders without one are always reported healthy.
+	EmbedderInfo() (model string, dims int, healthy bool)
+
+	// EmbedderStats returns the embedder's request statistics
+	EmbedderStats() types.EmbedderStats
+
+	// Metrics returns counts of the service's activity since it was created
+	Metrics() types.Metrics
+
+	// Ping verifies the underlying store is healthy
+	Ping(ctx context.Context) error
+
+	// Checkpoint flushes the store's write-ahead log (see store.Store)
+	Checkpoint(ctx context.Context, mode string) error
+
+	// Prune finds and removes duplicate or near-duplicate memories
+	Prune(ctx context.Context, opts PruneOptions) (*PruneResult, error)
+
+	// Export writes memories as NDJSON in stable ID order, returning the count written
+	Export(ctx context.Context, w io.Writer, opts store.ExportOptions) (int, error)
+
+	// ExportTable writes the id, content, project, type, and embedding of
+	// each memory as CSV or Parquet, for analysis or other vector stores
+	ExportTable(ctx context.Context, w io.Writer, format TableFormat, opts store.ExportOptions) (int, error)
+
+	// Import reads NDJSON memories. Memories already present, by ID or by
+	// content within their project, are handled by opts.OnConflict.
+	Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error)
+
 	// Close releases resources
 	Close() error
 }
 
+// TableFormat is a columnar layout for ExportTable
+type TableFormat string
+
+const (
+	FormatCSV     TableFormat = "csv"     // embeddings as base64 little-endian float32
+	FormatParquet TableFormat = "parquet" // embeddings as a list<float> column
+)
+
+// ImportOptions configures NDJSON import
+type ImportOptions struct {
+	Project    string               // Override the project of every imported memory
+	BatchSize  int                  // Memories per insert transaction (default 500)
+	OnConflict store.ConflictPolicy // What to do with duplicates (default skip)
+}
+
+// ImportResult reports the outcome of an import
+type ImportResult struct {
+	Imported int // Newly inserted memories
+	Skipped  int // Duplicates left unchanged
+	Merged   int // Duplicates whose metadata was merged
+	Replaced int // Duplicates overwritten
+	Embedded int // Memories exported without embeddings, embedded again
+}
+
+// PruneOptions configures which memories Prune removes
+type PruneOptions struct {
+	Project             string
+	Duplicates          bool          // Remove exact content duplicates, keeping the newest
+	SimilarityThreshold float32       // Remove memories at least this similar to a newer one (0 disables)
+	OlderThan           time.Duration // Only consider memories not updated within this window (0 = all)
+	DryRun              bool          // Report candidates without deleting
+}
+
+// PruneResult reports the memories selected by Prune
+type PruneResult struct {
+	Duplicates     []*types.Memory // Exact content duplicates
+	NearDuplicates []*types.Memory // Embedding near-duplicates
+	Deleted        int             // Number actually deleted (0 on dry run)
+}
+
+// ScoreScale selects the range search similarities and thresholds use
+type ScoreScale string
+
+const (
+	// ScoreCosine reports cosine similarity, with negative scores shown as 0
+	ScoreCosine ScoreScale = "cosine"
+
+	// ScoreUnit rescales cosine similarity from [-1, 1] to [0, 1] as
+	// (sim + 1) / 2, so a threshold of 0.5 admits every non-negative score
+	ScoreUnit ScoreScale = "unit"
+)
+
+// ParseScoreScale parses a score scale name; empty selects ScoreCosine
+func ParseScoreScale(name string) (ScoreScale, error) {
+	switch scale := ScoreScale(name); scale {
+	case "":
+		return ScoreCosine, nil
+	case ScoreCosine, ScoreUnit:
+		return scale, nil
+	default:
+		return "", fmt.Errorf("unknown score scale %q (want %s or %s)", name, ScoreCosine, ScoreUnit)
+	}
+}
+
+// toCosine converts a score on this scale to cosine similarity
+func (s ScoreScale) toCosine(score float32) float32 {
+	if s == ScoreUnit {
+		return score*2 - 1
+	}
+	return score
+}
+
+// fromCosine converts cosine similarity to this scale
+func (s ScoreScale) fromCosine(sim float32) float32 {
+	if s == ScoreUnit {
+		return (sim + 1) / 2
+	}
+	if sim < 0 {
+		return 0
+	}
+	return sim
+}
+
 // Config configures the memory service
 type Config struct {
-	DataDir        string   // Directory for data storage
-	EmbedBatchSize int      // Batch size for embedding generation
-	IndexIgnore    []string // Glob patterns to ignore during indexing
-	DefaultProject string   // Default project name if not specified
+	DataDir         string   // Directory for data storage
+	EmbedBatchSize  int      // Batch size for embedding generation
+	IndexIgnore     []string // Glob patterns to ignore during indexing
+	IndexInclude    []string // If set, only index files matching these globs
+	IndexExtensions []string // Extra file extensions to index (e.g. ".proto")
+	DefaultProject  string   // Default project name if not specified
+
+	// EmbedWithPath prepends the relative file path and chunk name to the
+	// text embedded during indexing. Stored content is unchanged.
+	EmbedWithPath bool
 
-	// Search defaults
+	// EmbedCoreOnly embeds chunks without the overlap repeated from the
+	// previous chunk, so adjacent chunks don't match the same query. The
+	// full text, overlap included, is still stored for display.
+	EmbedCoreOnly bool
+
+	// Search defaults. A zero DefaultSearchThreshold uses the embedding
+	// model's recommended threshold (see DefaultThreshold).
 	DefaultSearchLimit     int
 	DefaultSearchThreshold float32
+
+	// MinSemanticLen routes queries shorter than this many characters to
+	// keyword search when a request doesn't set its own (0 always embeds)
+	MinSemanticLen int
+
+	// ThresholdByType overrides DefaultSearchThreshold for type-filtered searches
+	ThresholdByType map[types.MemoryType]float32
+
+	// ScoreScale is the range of search similarities and of every threshold
+	// above. Empty means ScoreCosine.
+	ScoreScale ScoreScale
+
+	// Reranker reorders candidates for searches that request it (optional)
+	Reranker rerank.Reranker
+
+	// Expander rephrases queries for searches that request expansion (optional)
+	Expander expand.Expander
+
+	// ChunkerByLanguage overrides the chunker used for a language (as
+	// detected, or set with --lang). Languages not listed keep the
+	// service's chunker.
+	ChunkerByLanguage map[string]chunking.Chunker
+
+	// SearchCacheSize is how many search responses to cache (0 disables).
+	// Writes through the service invalidate the affected project's entries;
+	// writes by other processes sharing the database do not.
+	SearchCacheSize int
+
+	// Summarizer describes chunks for indexing requests that ask for
+	// chunk summaries (optional)
+	Summarizer summarize.Summarizer
+
+	// StoreFileContent keeps a copy of each indexed file in the store (see
+	// Store.GetFileContent), and records its hash on the file's chunks as
+	// the "file_hash" metadata key. Files larger than MaxFileContentSize
+	// (default DefaultMaxFileContentSize) are not copied.
+	StoreFileContent   bool
+	MaxFileContentSize int64
+
+	// RedactSecrets replaces secrets in indexed chunks, and in stored file
+	// copies, before they are embedded or stored. Chunks that had secrets
+	// list the matching detectors in the "redacted" metadata key. Redactor
+	// chooses the detectors; nil uses redact.DefaultConfig.
+	RedactSecrets bool
+	Redactor      *redact.Redactor
 }
 
+// DefaultMaxFileContentSize is the largest file StoreFileContent copies
+const DefaultMaxFileContentSize = 1 << 20
+
 // DefaultConfig returns sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		DataDir:                "~/.moneta",
-		EmbedBatchSize:         50,
-		IndexIgnore:            []string{".git", "node_modules", "vendor", "__pycache__", ".venv"},
-		DefaultProject:         "default",
-		DefaultSearchLimit:     10,
-		DefaultSearchThreshold: 0.5,
+		DataDir:            "~/.moneta",
+		EmbedBatchSize:     50,
+		IndexIgnore:        []string{".git", "node_modules", "vendor", "__pycache__", ".venv"},
+		DefaultProject:     "default",
+		DefaultSearchLimit: 10,
 	}
 }
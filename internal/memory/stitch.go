@@ -0,0 +1,106 @@
+// Package memory merges adjacent chunk results back into contiguous snippets
+package memory
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// StitchAdjacent merges results that are consecutive chunks of the same file
+// (by the chunk_index metadata recorded at index time) into one result per
+// contiguous run. A run takes the rank and highest similarity of its best
+// member; repeated overlap between chunks is dropped. Results without chunk
+// metadata pass through unchanged.
+func StitchAdjacent(results []types.SearchResult) []types.SearchResult {
+	type chunkRef struct {
+		file  string
+		index int
+	}
+
+	// Position of each chunk in results
+	byChunk := make(map[chunkRef]int)
+	for i, r := range results {
+		if idx, ok := chunkIndex(r.Memory); ok {
+			byChunk[chunkRef{r.Memory.FilePath, idx}] = i
+		}
+	}
+
+	merged := make([]bool, len(results))
+	var stitched []types.SearchResult
+	for i, r := range results {
+		if merged[i] {
+			continue
+		}
+		idx, ok := chunkIndex(r.Memory)
+		if !ok {
+			stitched = append(stitched, r)
+			continue
+		}
+
+		// Walk back to the start of the run, then forward to its end
+		first := idx
+		for {
+			j, ok := byChunk[chunkRef{r.Memory.FilePath, first - 1}]
+			if !ok || merged[j] {
+				break
+			}
+			first--
+		}
+
+		run := r
+		run.Memory.Metadata = copyMetadata(r.Memory.Metadata)
+		var content strings.Builder
+		count := 0
+		for k := first; ; k++ {
+			j, ok := byChunk[chunkRef{r.Memory.FilePath, k}]
+			if !ok || merged[j] {
+				break
+			}
+			merged[j] = true
+			m := results[j].Memory
+
+			text := m.Content
+			if k > first {
+				if overlap, _ := strconv.Atoi(m.Metadata["overlap"]); overlap > 0 && overlap < len(text) {
+					text = strings.TrimLeft(text[overlap:], "\n")
+				}
+				content.WriteString("\n")
+			} else {
+				run.Memory.Metadata["start_line"] = m.Metadata["start_line"]
+				run.Memory.Metadata["chunk_index"] = m.Metadata["chunk_index"]
+			}
+			content.WriteString(text)
+
+			run.Memory.Metadata["end_line"] = m.Metadata["end_line"]
+			if results[j].Similarity > run.Similarity {
+				run.Similarity = results[j].Similarity
+			}
+			count++
+		}
+		run.Memory.Content = content.String()
+		if count > 1 {
+			run.Memory.Metadata["stitched_chunks"] = strconv.Itoa(count)
+		}
+		stitched = append(stitched, run)
+	}
+	return stitched
+}
+
+// chunkIndex returns a memory's position within its file, if recorded
+func chunkIndex(m types.Memory) (int, bool) {
+	if m.FilePath == "" {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(m.Metadata["chunk_index"])
+	return idx, err == nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+// Package memory picks default search thresholds for known embedding models
+package memory
+
+import "strings"
+
+// FallbackThreshold is the default cosine threshold for unknown models
+const FallbackThreshold float32 = 0.5
+
+// modelThresholds are recommended cosine thresholds, keyed by a substring of
+// the model name. Models differ in how similar unrelated text scores: MiniLM
+// spreads scores widely, while BGE packs even weak matches above 0.5.
+var modelThresholds = []struct {
+	model     string
+	threshold float32
+}{
+	{"nomic-embed-text", 0.45},
+	{"bge-small", 0.6},
+	{"all-minilm", 0.3},
+}
+
+// DefaultThreshold returns the recommended cosine threshold for an embedding
+// model, matching names case-insensitively and ignoring tags and
+// organization prefixes (e.g. "nomic-embed-text:latest",
+// "sentence-transformers/all-MiniLM-L6-v2"). Unknown models get
+// FallbackThreshold and known is false.
+func DefaultThreshold(model string) (threshold float32, known bool) {
+	name := strings.ToLower(model)
+	for _, m := range modelThresholds {
+		if strings.Contains(name, m.model) {
+			return m.threshold, true
+		}
+	}
+	return FallbackThreshold, false
+}
@@ -0,0 +1,12 @@
+# Reads a Parquet file with pyarrow and prints its row group count and rows
+# as JSON, so writer_test.go can check the file opens in a real reader.
+import json
+import sys
+
+import pyarrow.parquet as pq
+
+f = pq.ParquetFile(sys.argv[1])
+print(json.dumps({
+    "row_groups": f.metadata.num_row_groups,
+    "rows": f.read().to_pylist(),
+}))
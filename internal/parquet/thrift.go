@@ -0,0 +1,98 @@
+// Package parquet encodes Parquet metadata with the Thrift compact protocol
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol type codes
+const (
+	typeI32    = 5
+	typeI64    = 6
+	typeBinary = 8
+	typeList   = 9
+	typeStruct = 12
+)
+
+// compact writes a Thrift struct in the compact protocol. Field headers
+// hold the delta from the previous field ID, so nested structs keep a stack
+// of the IDs they interrupted.
+type compact struct {
+	buf   []byte
+	last  int16
+	outer []int16
+}
+
+func (c *compact) varint(v uint64) {
+	c.buf = binary.AppendUvarint(c.buf, v)
+}
+
+func (c *compact) zigzag(v int64) {
+	c.varint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (c *compact) field(id int16, typ byte) {
+	if delta := id - c.last; delta > 0 && delta <= 15 {
+		c.buf = append(c.buf, byte(delta)<<4|typ)
+	} else {
+		c.buf = append(c.buf, typ)
+		c.zigzag(int64(id))
+	}
+	c.last = id
+}
+
+func (c *compact) i32(id int16, v int32) {
+	c.field(id, typeI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compact) i64(id int16, v int64) {
+	c.field(id, typeI64)
+	c.zigzag(v)
+}
+
+func (c *compact) str(id int16, s string) {
+	c.field(id, typeBinary)
+	c.varint(uint64(len(s)))
+	c.buf = append(c.buf, s...)
+}
+
+// list starts a list field of n elements of type elem, which the caller
+// then writes with the *Elem methods or begin/end
+func (c *compact) list(id int16, elem byte, n int) {
+	c.field(id, typeList)
+	if n < 15 {
+		c.buf = append(c.buf, byte(n)<<4|elem)
+		return
+	}
+	c.buf = append(c.buf, 0xf0|elem)
+	c.varint(uint64(n))
+}
+
+func (c *compact) i32Elem(v int32) {
+	c.zigzag(int64(v))
+}
+
+func (c *compact) strElem(s string) {
+	c.varint(uint64(len(s)))
+	c.buf = append(c.buf, s...)
+}
+
+// structField starts a struct-valued field; close it with end
+func (c *compact) structField(id int16) {
+	c.field(id, typeStruct)
+	c.begin()
+}
+
+// begin starts a struct, either a list element or after structField
+func (c *compact) begin() {
+	c.outer = append(c.outer, c.last)
+	c.last = 0
+}
+
+// end writes the stop byte closing the current struct
+func (c *compact) end() {
+	c.buf = append(c.buf, 0)
+	if n := len(c.outer); n > 0 {
+		c.last = c.outer[n-1]
+		c.outer = c.outer[:n-1]
+	}
+}
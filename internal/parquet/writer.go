@@ -0,0 +1,345 @@
+// Package parquet writes the small subset of Apache Parquet needed to
+// export memories: required string columns and float lists, uncompressed
+// and PLAIN-encoded, buffered one row group at a time.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ColumnType is the kind of values a column holds
+type ColumnType int
+
+const (
+	String    ColumnType = iota // UTF-8 string
+	FloatList                   // list of float32, such as an embedding
+)
+
+// Column names a column and its type
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// DefaultRowGroupSize bounds how many rows a Writer buffers, about 3 MB of
+// 768-dimension embeddings
+const DefaultRowGroupSize = 1000
+
+var magic = []byte("PAR1")
+
+// Parquet enum values, from parquet.thrift
+const (
+	physicalFloat     = 4
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionRepeated = 2
+
+	convertedUTF8 = 0
+	convertedList = 3
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageData = 0
+)
+
+// Writer streams rows to a Parquet file. Rows are buffered until a row
+// group fills, then written out; Close writes the footer.
+type Writer struct {
+	w       io.Writer
+	offset  int64
+	columns []Column
+	groupSz int
+
+	strings   [][]string    // buffered values per String column
+	lists     [][][]float32 // buffered values per FloatList column
+	buffered  int
+	rows      int64
+	rowGroups []rowGroup
+	err       error
+}
+
+type rowGroup struct {
+	chunks []columnChunk
+	rows   int64
+	bytes  int64
+}
+
+type columnChunk struct {
+	column    Column
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// NewWriter writes a file with columns to w, starting with the magic bytes
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	pw := &Writer{
+		w:       w,
+		columns: columns,
+		groupSz: DefaultRowGroupSize,
+		strings: make([][]string, len(columns)),
+		lists:   make([][][]float32, len(columns)),
+	}
+	pw.write(magic)
+	return pw
+}
+
+// Write adds a row holding one value per column: a string for String
+// columns and a []float32 for FloatList columns
+func (w *Writer) Write(row ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(row))
+	}
+	for i, col := range w.columns {
+		switch col.Type {
+		case String:
+			s, ok := row[i].(string)
+			if !ok {
+				return fmt.Errorf("column %s: expected string, got %T", col.Name, row[i])
+			}
+			w.strings[i] = append(w.strings[i], s)
+		case FloatList:
+			v, ok := row[i].([]float32)
+			if !ok {
+				return fmt.Errorf("column %s: expected []float32, got %T", col.Name, row[i])
+			}
+			w.lists[i] = append(w.lists[i], v)
+		}
+	}
+	w.buffered++
+	if w.buffered >= w.groupSz {
+		w.flush()
+	}
+	return w.err
+}
+
+// Close writes any buffered rows and the file footer. It does not close
+// the underlying writer.
+func (w *Writer) Close() error {
+	if w.buffered > 0 {
+		w.flush()
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	footer := w.fileMetadata()
+	w.write(footer)
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(footer)))
+	w.write(n[:])
+	w.write(magic)
+	return w.err
+}
+
+func (w *Writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	if err != nil {
+		w.err = fmt.Errorf("failed to write parquet: %w", err)
+	}
+}
+
+// flush writes the buffered rows as a row group of one page per column
+func (w *Writer) flush() {
+	group := rowGroup{rows: int64(w.buffered)}
+	for i, col := range w.columns {
+		var body []byte
+		var numValues int
+		switch col.Type {
+		case String:
+			body, numValues = plainStrings(w.strings[i]), len(w.strings[i])
+			w.strings[i] = w.strings[i][:0]
+		case FloatList:
+			body, numValues = floatListPage(w.lists[i])
+			w.lists[i] = w.lists[i][:0]
+		}
+
+		header := pageHeader(len(body), numValues)
+		chunk := columnChunk{
+			column:    col,
+			offset:    w.offset,
+			size:      int64(len(header) + len(body)),
+			numValues: int64(numValues),
+		}
+		w.write(header)
+		w.write(body)
+		group.chunks = append(group.chunks, chunk)
+		group.bytes += chunk.size
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows += int64(w.buffered)
+	w.buffered = 0
+}
+
+// plainStrings encodes strings as PLAIN byte arrays, each prefixed with
+// its length
+func plainStrings(values []string) []byte {
+	var b []byte
+	for _, s := range values {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+// floatListPage encodes lists as a data page body: repetition levels,
+// definition levels, then the floats. Each list contributes one level
+// entry per element, or a single entry with definition level 0 when empty.
+func floatListPage(lists [][]float32) ([]byte, int) {
+	var reps, defs []byte
+	var values []byte
+	for _, list := range lists {
+		if len(list) == 0 {
+			reps = append(reps, 0)
+			defs = append(defs, 0)
+			continue
+		}
+		for j, f := range list {
+			reps = append(reps, byte(min(j, 1)))
+			defs = append(defs, 1)
+			values = binary.LittleEndian.AppendUint32(values, math.Float32bits(f))
+		}
+	}
+
+	var b []byte
+	b = appendLevels(b, reps)
+	b = appendLevels(b, defs)
+	b = append(b, values...)
+	return b, len(reps)
+}
+
+// appendLevels writes 1-bit levels as length-prefixed RLE runs
+func appendLevels(b []byte, levels []byte) []byte {
+	var runs []byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		runs = binary.AppendUvarint(runs, uint64(j-i)<<1)
+		runs = append(runs, levels[i])
+		i = j
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(runs)))
+	return append(b, runs...)
+}
+
+// pageHeader describes an uncompressed data page of size bytes
+func pageHeader(size, numValues int) []byte {
+	var c compact
+	c.i32(1, pageData)
+	c.i32(2, int32(size))
+	c.i32(3, int32(size))
+	c.structField(5)
+	c.i32(1, int32(numValues))
+	c.i32(2, encodingPlain)
+	c.i32(3, encodingRLE)
+	c.i32(4, encodingRLE)
+	c.end()
+	c.end()
+	return c.buf
+}
+
+// fileMetadata encodes the footer: schema, row groups, and column chunks
+func (w *Writer) fileMetadata() []byte {
+	var c compact
+	c.i32(1, 1)
+
+	// The schema is flattened depth-first, each group followed by its children
+	elements := 1
+	for _, col := range w.columns {
+		elements++
+		if col.Type == FloatList {
+			elements += 2
+		}
+	}
+	c.list(2, typeStruct, elements)
+	c.begin()
+	c.str(4, "schema")
+	c.i32(5, int32(len(w.columns)))
+	c.end()
+	for _, col := range w.columns {
+		switch col.Type {
+		case String:
+			c.begin()
+			c.i32(1, physicalByteArray)
+			c.i32(3, repetitionRequired)
+			c.str(4, col.Name)
+			c.i32(6, convertedUTF8)
+			c.end()
+		case FloatList:
+			c.begin()
+			c.i32(3, repetitionRequired)
+			c.str(4, col.Name)
+			c.i32(5, 1)
+			c.i32(6, convertedList)
+			c.end()
+			c.begin()
+			c.i32(3, repetitionRepeated)
+			c.str(4, "list")
+			c.i32(5, 1)
+			c.end()
+			c.begin()
+			c.i32(1, physicalFloat)
+			c.i32(3, repetitionRequired)
+			c.str(4, "element")
+			c.end()
+		}
+	}
+
+	c.i64(3, w.rows)
+	c.list(4, typeStruct, len(w.rowGroups))
+	for _, g := range w.rowGroups {
+		c.begin()
+		c.list(1, typeStruct, len(g.chunks))
+		for _, ch := range g.chunks {
+			c.begin()
+			c.i64(2, ch.offset)
+			c.structField(3)
+			writeColumnMetadata(&c, ch)
+			c.end()
+			c.end()
+		}
+		c.i64(2, g.bytes)
+		c.i64(3, g.rows)
+		c.end()
+	}
+	c.str(6, "moneta")
+	c.end()
+	return c.buf
+}
+
+func writeColumnMetadata(c *compact, ch columnChunk) {
+	path := []string{ch.column.Name}
+	physical := int32(physicalByteArray)
+	if ch.column.Type == FloatList {
+		path = append(path, "list", "element")
+		physical = physicalFloat
+	}
+
+	c.i32(1, physical)
+	c.list(2, typeI32, 2)
+	c.i32Elem(encodingPlain)
+	c.i32Elem(encodingRLE)
+	c.list(3, typeBinary, len(path))
+	for _, p := range path {
+		c.strElem(p)
+	}
+	c.i32(4, 0) // uncompressed
+	c.i64(5, ch.numValues)
+	c.i64(6, ch.size)
+	c.i64(7, ch.size)
+	c.i64(9, ch.offset)
+}
@@ -0,0 +1,249 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden.parquet")
+
+// thriftValue is a decoded compact-protocol value: an int, a string, a
+// list, or a struct keyed by field ID
+type thriftValue any
+
+// readCompact decodes a compact-protocol struct, enough to check footers
+func readCompact(t *testing.T, b []byte) (map[int16]thriftValue, []byte) {
+	t.Helper()
+	fields := make(map[int16]thriftValue)
+	var last int16
+	for {
+		if len(b) == 0 {
+			t.Fatal("struct ran past the end of the buffer")
+		}
+		h := b[0]
+		b = b[1:]
+		if h == 0 {
+			return fields, b
+		}
+		typ := h & 0x0f
+		if delta := int16(h >> 4); delta != 0 {
+			last += delta
+		} else {
+			var id int64
+			id, b = readZigzag(b)
+			last = int16(id)
+		}
+		fields[last], b = readValue(t, typ, b)
+	}
+}
+
+func readValue(t *testing.T, typ byte, b []byte) (thriftValue, []byte) {
+	switch typ {
+	case typeI32, typeI64:
+		return readZigzag(b)
+	case typeBinary:
+		n, k := binary.Uvarint(b)
+		b = b[k:]
+		return string(b[:n]), b[n:]
+	case typeList:
+		n, elem := int(b[0]>>4), b[0]&0x0f
+		b = b[1:]
+		if n == 15 {
+			u, k := binary.Uvarint(b)
+			n, b = int(u), b[k:]
+		}
+		list := make([]thriftValue, n)
+		for i := range list {
+			list[i], b = readValue(t, elem, b)
+		}
+		return list, b
+	case typeStruct:
+		return readCompact(t, b)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil, nil
+}
+
+func readZigzag(b []byte) (int64, []byte) {
+	u, k := binary.Uvarint(b)
+	return int64(u>>1) ^ -int64(u&1), b[k:]
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{"id", String}, {"embedding", FloatList}})
+	w.groupSz = 2
+
+	rows := []struct {
+		id  string
+		emb []float32
+	}{
+		{"a", []float32{1, 2, 3}},
+		{"b", nil},
+		{"c", []float32{-0.5}},
+	}
+	for _, r := range rows {
+		if err := w.Write(r.id, r.emb); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := w.Write("d"); err == nil {
+		t.Error("expected an error for a short row")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, magic) || !bytes.HasSuffix(data, magic) {
+		t.Fatal("expected PAR1 at both ends")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-size : len(data)-8]
+	meta, rest := readCompact(t, footer)
+	if len(rest) != 0 {
+		t.Errorf("expected the footer length to cover exactly the metadata, %d bytes left", len(rest))
+	}
+
+	if meta[3] != int64(3) {
+		t.Errorf("expected 3 rows, got %v", meta[3])
+	}
+	if schema := meta[2].([]thriftValue); len(schema) != 5 {
+		t.Errorf("expected root, id, and the 3-level list in the schema, got %d elements", len(schema))
+	}
+	groups := meta[4].([]thriftValue)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 row groups of at most 2 rows, got %d", len(groups))
+	}
+
+	// The first group's embedding page holds levels for 1,2,3 and the empty list
+	columns := groups[0].(map[int16]thriftValue)[1].([]thriftValue)
+	emb := columns[1].(map[int16]thriftValue)[3].(map[int16]thriftValue)
+	if emb[5] != int64(4) {
+		t.Errorf("expected 4 level entries, got %v", emb[5])
+	}
+	offset := emb[9].(int64)
+	header, body := readCompact(t, data[offset:])
+	if header[1] != int64(pageData) {
+		t.Fatalf("expected a data page at the column offset, got %v", header)
+	}
+	body = body[:header[2].(int64)]
+
+	// Skip the length-prefixed repetition and definition levels
+	for i := 0; i < 2; i++ {
+		n := binary.LittleEndian.Uint32(body)
+		body = body[4+n:]
+	}
+	var got []float32
+	for ; len(body) > 0; body = body[4:] {
+		got = append(got, math.Float32frombits(binary.LittleEndian.Uint32(body)))
+	}
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("expected the floats 1, 2, 3, got %v", got)
+	}
+}
+
+func TestAppendLevels(t *testing.T) {
+	got := appendLevels(nil, []byte{0, 1, 1, 0})
+	want := []byte{6, 0, 0, 0, 2, 0, 4, 1, 2, 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected runs %v, got %v", want, got)
+	}
+}
+
+// goldenRows fill three row groups of two rows, with empty, single, and
+// multi-element lists
+var goldenRows = []struct {
+	ID        string    `json:"id"`
+	Embedding []float32 `json:"embedding"`
+}{
+	{"a", []float32{1, 2, 3}},
+	{"b", []float32{}},
+	{"c", []float32{-0.5}},
+	{"déjà vu", []float32{0, 0.25, 0.5, 0.75, 1, 1.25, 1.5, 1.75, 2, 2.25, 2.5, 2.75, 3, 3.25, 3.5, 3.75, 4}},
+	{"", []float32{}},
+}
+
+func writeGolden(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{"id", String}, {"embedding", FloatList}})
+	w.groupSz = 2
+	for _, r := range goldenRows {
+		if err := w.Write(r.ID, r.Embedding); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestWriter_Golden pins the writer's output to testdata/golden.parquet.
+// After an intended format change, regenerate it with -update and confirm
+// the new file still opens with TestWriter_PyArrow.
+func TestWriter_Golden(t *testing.T) {
+	path := filepath.Join("testdata", "golden.parquet")
+	got := writeGolden(t)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (%d bytes, want %d)", path, len(got), len(want))
+	}
+}
+
+// TestWriter_PyArrow reads the golden file with pyarrow, when it is installed
+func TestWriter_PyArrow(t *testing.T) {
+	if exec.Command("python3", "-c", "import pyarrow").Run() != nil {
+		t.Skip("pyarrow not installed")
+	}
+	out, err := exec.Command("python3", filepath.Join("testdata", "read.py"), filepath.Join("testdata", "golden.parquet")).Output()
+	if err != nil {
+		t.Fatalf("pyarrow failed to read the file: %v", err)
+	}
+
+	var got struct {
+		RowGroups int `json:"row_groups"`
+		Rows      []struct {
+			ID        string    `json:"id"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("failed to parse reader output %q: %v", out, err)
+	}
+	if got.RowGroups != 3 {
+		t.Errorf("expected 3 row groups, got %d", got.RowGroups)
+	}
+	if len(got.Rows) != len(goldenRows) {
+		t.Fatalf("expected %d rows, got %d", len(goldenRows), len(got.Rows))
+	}
+	for i, want := range goldenRows {
+		row := got.Rows[i]
+		if row.ID != want.ID || len(row.Embedding) != len(want.Embedding) {
+			t.Errorf("row %d: expected %q with %d floats, got %q with %d", i, want.ID, len(want.Embedding), row.ID, len(row.Embedding))
+			continue
+		}
+		for j := range want.Embedding {
+			if row.Embedding[j] != want.Embedding[j] {
+				t.Errorf("row %d: expected %v, got %v", i, want.Embedding, row.Embedding)
+				break
+			}
+		}
+	}
+}
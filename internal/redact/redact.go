@@ -0,0 +1,194 @@
+// Package redact finds secrets such as API keys and tokens in text and
+// replaces them, so indexed code doesn't leak credentials into the store
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pattern is a named regular expression matching a secret. When the
+// expression has a group named "secret", only that group is redacted, so
+// `API_KEY=abc123` keeps its `API_KEY=`. The default assignment pattern
+// skips values followed by "(" so calls like `token := getToken()` survive.
+type Pattern struct {
+	Name   string
+	Regexp string
+}
+
+// DefaultPatterns detect common credential formats
+var DefaultPatterns = []Pattern{
+	{"aws-access-key", `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{"aws-secret-key", `(?i)aws_?secret_?access_?key\s*(?::=|[:=])\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`},
+	{"private-key", `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{"github-token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+	{"slack-token", `\bxox[abprs]-[A-Za-z0-9-]{10,}`},
+	{"jwt", `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`},
+	{"assignment", `(?i)\b[A-Z0-9_.-]*(?:token|secret|passwd|password|api_?key|access_?key|auth_?key|credentials?)[A-Z0-9_.-]*["']?\s*(?::=|[:=])\s*["']?(?P<secret>[A-Za-z0-9/+=_.~-]{8,})(?:[^A-Za-z0-9/+=_.~(-]|$)`},
+}
+
+// Config configures a Redactor
+type Config struct {
+	// Patterns are the secret formats to detect
+	Patterns []Pattern
+
+	// EntropyThreshold redacts unmatched tokens of at least EntropyMinLength
+	// characters that mix letters and digits and carry more than this many
+	// bits of entropy per character. Hex strings such as hashes and UUIDs
+	// stay under 4. Negative disables the check.
+	EntropyThreshold float64
+	EntropyMinLength int
+}
+
+// DefaultConfig returns the default detectors: DefaultPatterns plus
+// high-entropy strings of 24 or more characters
+func DefaultConfig() Config {
+	return Config{
+		Patterns:         DefaultPatterns,
+		EntropyThreshold: 4.0,
+		EntropyMinLength: 24,
+	}
+}
+
+// Redactor replaces secrets in text
+type Redactor struct {
+	detectors        []detector
+	entropyThreshold float64
+	entropyMinLength int
+}
+
+type detector struct {
+	name   string
+	re     *regexp.Regexp
+	secret int // Submatch index of the "secret" group, or 0 for the whole match
+}
+
+// entropyToken matches candidate strings for the entropy check
+var entropyToken = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+
+// New compiles the patterns of cfg
+func New(cfg Config) (*Redactor, error) {
+	if cfg.EntropyMinLength <= 0 {
+		cfg.EntropyMinLength = DefaultConfig().EntropyMinLength
+	}
+
+	r := &Redactor{
+		entropyThreshold: cfg.EntropyThreshold,
+		entropyMinLength: cfg.EntropyMinLength,
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", p.Name, err)
+		}
+		d := detector{name: p.Name, re: re}
+		if i := re.SubexpIndex("secret"); i > 0 {
+			d.secret = i * 2
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	return r, nil
+}
+
+// ParsePattern parses a "name=regexp" pattern spec
+func ParsePattern(spec string) (Pattern, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || expr == "" {
+		return Pattern{}, fmt.Errorf("invalid secret pattern %q (want name=regexp)", spec)
+	}
+	return Pattern{Name: name, Regexp: expr}, nil
+}
+
+// Placeholder returns the text that replaces a secret found by detector name
+func Placeholder(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+// Redact replaces every secret in text with its Placeholder and returns the
+// result along with the sorted names of the detectors that matched
+func (r *Redactor) Redact(text string) (string, []string) {
+	found := make(map[string]bool)
+
+	for _, d := range r.detectors {
+		text = replaceMatches(text, d.re, d.secret, func(string) string {
+			found[d.name] = true
+			return Placeholder(d.name)
+		})
+	}
+
+	if r.entropyThreshold >= 0 {
+		text = entropyToken.ReplaceAllStringFunc(text, func(token string) string {
+			if len(token) < r.entropyMinLength || !hasLetterAndDigit(token) || entropy(token) <= r.entropyThreshold {
+				return token
+			}
+			found["high-entropy"] = true
+			return Placeholder("high-entropy")
+		})
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return text, names
+}
+
+// replaceMatches replaces submatch group of every match of re (the whole
+// match when group is 0) with repl of its text
+func replaceMatches(text string, re *regexp.Regexp, group int, repl func(string) string) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[group], m[group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(repl(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// hasLetterAndDigit reports whether s contains both an ASCII letter and a
+// digit, which identifiers and words rarely do at length
+func hasLetterAndDigit(s string) bool {
+	var letter, digit bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var bits float64
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(s))
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
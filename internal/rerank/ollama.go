@@ -0,0 +1,173 @@
+// Package rerank provides an Ollama-backed reranker
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// OllamaReranker scores each candidate with a prompt sent to Ollama's
+// generate API. Any instruction-following model works.
+type OllamaReranker struct {
+	baseURL    string
+	model      string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// OllamaConfig configures the Ollama reranker
+type OllamaConfig struct {
+	BaseURL string
+	Model   string
+
+	// Timeout bounds a whole Rerank call, across all candidates
+	Timeout time.Duration
+}
+
+// DefaultOllamaConfig returns sensible defaults
+func DefaultOllamaConfig() OllamaConfig {
+	return OllamaConfig{
+		BaseURL: getEnvOrDefault("OLLAMA_HOST", "http://localhost:11434"),
+		Model:   getEnvOrDefault("RERANK_MODEL", "llama3.2:1b"),
+		Timeout: 30 * time.Second,
+	}
+}
+
+// NewOllamaReranker creates a new Ollama reranker
+func NewOllamaReranker(cfg OllamaConfig) *OllamaReranker {
+	defaults := DefaultOllamaConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaults.Model
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+
+	return &OllamaReranker{
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		timeout:    cfg.Timeout,
+		httpClient: &http.Client{},
+	}
+}
+
+// generateRequest is the request payload for Ollama generate API
+type generateRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// generateResponse is the response from Ollama generate API
+type generateResponse struct {
+	Response string `json:"response"`
+}
+
+// scorePrompt asks the model for a single relevance score
+const scorePrompt = `Rate how relevant the document is to the query on a scale from 0 (irrelevant) to 10 (directly answers it).
+Reply with only the number.
+
+Query: %s
+
+Document:
+%s
+
+Score:`
+
+// Rerank scores every candidate and sorts by score, keeping vector order
+// among equal scores
+func (r *OllamaReranker) Rerank(ctx context.Context, query string, results []types.SearchResult) ([]types.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		score, err := r.score(ctx, query, result.Memory.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to score candidate %d: %w", i, err)
+		}
+		scores[i] = score
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	reranked := make([]types.SearchResult, len(results))
+	for i, idx := range order {
+		reranked[i] = results[idx]
+	}
+	return reranked, nil
+}
+
+// score returns the model's relevance score for one document
+func (r *OllamaReranker) score(ctx context.Context, query, document string) (float64, error) {
+	jsonBody, err := json.Marshal(generateRequest{
+		Model:   r.model,
+		Prompt:  fmt.Sprintf(scorePrompt, query, document),
+		Options: map[string]interface{}{"temperature": 0},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/api/generate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parseScore(out.Response)
+}
+
+var numberPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// parseScore extracts the first number from a model reply
+func parseScore(reply string) (float64, error) {
+	match := numberPattern.FindString(reply)
+	if match == "" {
+		return 0, fmt.Errorf("no score in reply %q", reply)
+	}
+	return strconv.ParseFloat(match, 64)
+}
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
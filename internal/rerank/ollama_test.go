@@ -0,0 +1,81 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestOllamaReranker_Rerank(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req generateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		score := "2"
+		if strings.Contains(req.Prompt, "refresh tokens") {
+			score = "Score: 9"
+		}
+		json.NewEncoder(w).Encode(generateResponse{Response: score})
+	}))
+	defer srv.Close()
+
+	r := NewOllamaReranker(OllamaConfig{BaseURL: srv.URL, Model: "test"})
+	results := []types.SearchResult{
+		{Memory: types.Memory{ID: "a", Content: "login form layout"}, Similarity: 0.9},
+		{Memory: types.Memory{ID: "b", Content: "how we refresh tokens"}, Similarity: 0.8},
+		{Memory: types.Memory{ID: "c", Content: "logout button"}, Similarity: 0.7},
+	}
+
+	reranked, err := r.Rerank(context.Background(), "token refresh", results)
+	if err != nil {
+		t.Fatalf("rerank failed: %v", err)
+	}
+
+	got := []string{reranked[0].Memory.ID, reranked[1].Memory.ID, reranked[2].Memory.ID}
+	if strings.Join(got, ",") != "b,a,c" {
+		t.Errorf("expected b,a,c (ties keep vector order), got %v", got)
+	}
+}
+
+func TestOllamaReranker_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	r := NewOllamaReranker(OllamaConfig{BaseURL: srv.URL, Model: "test", Timeout: 10 * time.Millisecond})
+	results := []types.SearchResult{{Memory: types.Memory{Content: "x"}}}
+
+	if _, err := r.Rerank(context.Background(), "q", results); err == nil {
+		t.Error("expected an error when the reranker times out")
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  float64
+		ok    bool
+	}{
+		{"7", 7, true},
+		{" 8.5\n", 8.5, true},
+		{"Score: 3/10", 3, true},
+		{"not relevant", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseScore(tt.reply)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseScore(%q) = %v, %v; want %v, ok=%v", tt.reply, got, err, tt.want, tt.ok)
+		}
+	}
+}
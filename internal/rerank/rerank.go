@@ -0,0 +1,17 @@
+// Package rerank reorders search candidates with a model that scores each
+// candidate against the query
+package rerank
+
+import (
+	"context"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// Reranker reorders search results by relevance to a query
+type Reranker interface {
+	// Rerank returns results sorted by relevance to query, best first. It may
+	// return an error instead of a partial ordering; callers keep the vector
+	// order in that case.
+	Rerank(ctx context.Context, query string, results []types.SearchResult) ([]types.SearchResult, error)
+}
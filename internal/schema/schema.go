@@ -0,0 +1,245 @@
+// Package schema generates JSON Schema and OpenAPI documents from Go types
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object is a JSON object in a generated document
+type Object = map[string]interface{}
+
+// Route describes an HTTP endpoint for OpenAPI generation
+type Route struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  interface{} // Zero value of the request body type, or nil
+	Response interface{} // Zero value of the success response type
+	Status   int         // Success status code
+	Stream   bool        // Also supports application/x-ndjson responses
+	Query    []string    // Optional string query parameters
+}
+
+// Generator converts Go types to JSON Schema. Named struct types are
+// emitted once as definitions and referenced with $ref.
+type Generator struct {
+	refPrefix string
+	defs      map[string]Object
+	enums     map[reflect.Type][]string
+}
+
+// NewGenerator creates a generator whose $refs point at refPrefix
+// (e.g. "#/$defs/" or "#/components/schemas/")
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{
+		refPrefix: refPrefix,
+		defs:      make(map[string]Object),
+		enums:     make(map[reflect.Type][]string),
+	}
+}
+
+// Enum restricts a named string type to the given values
+func (g *Generator) Enum(v interface{}, values ...string) {
+	g.enums[reflect.TypeOf(v)] = values
+}
+
+// Definitions returns the named types collected so far
+func (g *Generator) Definitions() map[string]Object {
+	return g.defs
+}
+
+// Schema returns the schema for v's type
+func (g *Generator) Schema(v interface{}) Object {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *Generator) schemaFor(t reflect.Type) Object {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if values, ok := g.enums[t]; ok {
+		return Object{"type": "string", "enum": values}
+	}
+
+	switch {
+	case t == timeType:
+		return Object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // Reserve to stop recursion
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return Object{"$ref": g.refPrefix + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.String:
+		return Object{"type": "string"}
+	case reflect.Bool:
+		return Object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Object{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return Object{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	default:
+		return Object{}
+	}
+}
+
+// structSchema builds an object schema from exported fields and json tags.
+// Fields without omitempty are required.
+func (g *Generator) structSchema(t reflect.Type) Object {
+	props := Object{}
+	var required []string
+	g.addFields(t, props, &required)
+
+	s := Object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *Generator) addFields(t reflect.Type, props Object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened, as encoding/json does
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = g.schemaFor(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document defining the
+// given named types
+func JSONSchema(g *Generator, values ...interface{}) Object {
+	for _, v := range values {
+		g.Schema(v)
+	}
+	return Object{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs":   g.Definitions(),
+	}
+}
+
+// OpenAPI returns a minimal OpenAPI 3.0 document for the given routes.
+// errorBody is the zero value of the body returned with error statuses.
+// Path parameters are written as {name}.
+func OpenAPI(g *Generator, title, version string, routes []Route, errorBody interface{}) Object {
+	paths := Object{}
+	for _, r := range routes {
+		op := Object{"summary": r.Summary}
+
+		var params []Object
+		for _, seg := range strings.Split(r.Path, "/") {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				params = append(params, Object{
+					"name":     strings.Trim(seg, "{}"),
+					"in":       "path",
+					"required": true,
+					"schema":   Object{"type": "string"},
+				})
+			}
+		}
+		for _, q := range r.Query {
+			params = append(params, Object{
+				"name":   q,
+				"in":     "query",
+				"schema": Object{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if r.Request != nil {
+			op["requestBody"] = Object{
+				"required": true,
+				"content": Object{
+					"application/json": Object{"schema": g.Schema(r.Request)},
+				},
+			}
+		}
+
+		status := r.Status
+		if status == 0 {
+			status = 200
+		}
+		content := Object{}
+		if r.Response != nil {
+			content["application/json"] = Object{"schema": g.Schema(r.Response)}
+		}
+		if r.Stream {
+			content["application/x-ndjson"] = Object{"schema": Object{"type": "string"}}
+		}
+		resp := Object{"description": "Success"}
+		if len(content) > 0 {
+			resp["content"] = content
+		}
+		op["responses"] = Object{
+			strconv.Itoa(status): resp,
+			"default": Object{
+				"description": "Error",
+				"content": Object{
+					"application/json": Object{"schema": g.Schema(errorBody)},
+				},
+			},
+		}
+
+		item, _ := paths[r.Path].(Object)
+		if item == nil {
+			item = Object{}
+			paths[r.Path] = item
+		}
+		item[strings.ToLower(r.Method)] = op
+	}
+
+	return Object{
+		"openapi": "3.0.3",
+		"info":    Object{"title": title, "version": version},
+		"paths":   paths,
+		"components": Object{
+			"schemas": g.Definitions(),
+		},
+	}
+}
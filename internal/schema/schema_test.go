@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Note string `json:"note"`
+}
+
+type sample struct {
+	inner
+	Name    string            `json:"name"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]int    `json:"labels,omitempty"`
+	When    time.Time         `json:"when"`
+	Kind    kind              `json:"kind,omitempty"`
+	Child   *child            `json:"child,omitempty"`
+	Skipped string            `json:"-"`
+	Extra   map[string]string `json:"extra"`
+}
+
+type child struct {
+	Score float32 `json:"score"`
+}
+
+type kind string
+
+func TestGenerator_Struct(t *testing.T) {
+	g := NewGenerator("#/$defs/")
+	g.Enum(kind(""), "a", "b")
+
+	ref := g.Schema(sample{})
+	if ref["$ref"] != "#/$defs/sample" {
+		t.Fatalf("expected $ref to sample, got %v", ref)
+	}
+
+	s := g.Definitions()["sample"]
+	props := s["properties"].(Object)
+
+	if _, ok := props["Skipped"]; ok {
+		t.Error("fields tagged json:\"-\" must be skipped")
+	}
+	if _, ok := props["note"]; !ok {
+		t.Error("embedded struct fields should be flattened")
+	}
+	if props["when"].(Object)["format"] != "date-time" {
+		t.Errorf("expected time.Time as date-time, got %v", props["when"])
+	}
+	if !reflect.DeepEqual(props["kind"].(Object)["enum"], []string{"a", "b"}) {
+		t.Errorf("expected enum values, got %v", props["kind"])
+	}
+	if props["child"].(Object)["$ref"] != "#/$defs/child" {
+		t.Errorf("expected pointer to named struct as $ref, got %v", props["child"])
+	}
+
+	want := []string{"extra", "name", "note", "when"}
+	if !reflect.DeepEqual(s["required"], want) {
+		t.Errorf("expected required %v, got %v", want, s["required"])
+	}
+}
+
+func TestOpenAPI_PathParams(t *testing.T) {
+	g := NewGenerator("#/components/schemas/")
+	doc := OpenAPI(g, "test", "1", []Route{
+		{Method: "GET", Path: "/things/{id}", Summary: "Get", Response: child{}},
+	}, struct {
+		Error string `json:"error"`
+	}{})
+
+	op := doc["paths"].(Object)["/things/{id}"].(Object)["get"].(Object)
+	params := op["parameters"].([]Object)
+	if len(params) != 1 || params[0]["name"] != "id" || params[0]["in"] != "path" {
+		t.Errorf("unexpected parameters: %v", params)
+	}
+	if _, ok := op["responses"].(Object)["200"]; !ok {
+		t.Errorf("expected default 200 response, got %v", op["responses"])
+	}
+}
@@ -0,0 +1,119 @@
+// Package server deduplicates retried adds using Idempotency-Key headers
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/cache"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// idempotencyHeader is the request header clients set to make adds retry-safe
+const idempotencyHeader = "Idempotency-Key"
+
+const (
+	idempotencyCapacity = 4096           // Keys remembered at most
+	idempotencyTTL      = 24 * time.Hour // How long a key is honored
+)
+
+// errIdempotencyBusy is returned while another request holds the same key
+var errIdempotencyBusy = errors.New("a request with this idempotency key is in progress")
+
+// addOnce adds a memory unless key was already used within the TTL, in
+// which case the memory created then is returned with replayed=true. An
+// empty key always adds.
+func (s *Server) addOnce(ctx context.Context, key string, req types.AddMemoryRequest) (*types.Memory, bool, error) {
+	if key == "" {
+		memory, err := s.svc.Add(ctx, req)
+		return memory, false, err
+	}
+
+	id, ok, busy := s.idempotency.begin(key)
+	if busy {
+		return nil, false, errIdempotencyBusy
+	}
+	if !ok {
+		if memory, err := s.svc.Get(ctx, id); err == nil {
+			return memory, true, nil
+		}
+		// The original memory is gone, so create it again
+		s.idempotency.forget(key)
+		if _, ok, _ = s.idempotency.begin(key); !ok {
+			return nil, false, errIdempotencyBusy
+		}
+	}
+
+	memory, err := s.svc.Add(ctx, req)
+	if err != nil {
+		s.idempotency.finish(key, "")
+		return nil, false, err
+	}
+	s.idempotency.finish(key, memory.ID)
+	return memory, false, nil
+}
+
+// idempotencyEntry records the memory created for a key
+type idempotencyEntry struct {
+	memoryID  string
+	createdAt time.Time
+}
+
+// idempotencyCache maps recently seen keys to the memories they created.
+// Keys being processed are tracked so a concurrent retry cannot slip past.
+type idempotencyCache struct {
+	mu       sync.Mutex
+	entries  *cache.LRU[string, idempotencyEntry]
+	inFlight map[string]bool
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func newIdempotencyCache(capacity int, ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		entries:  cache.NewLRU[string, idempotencyEntry](capacity),
+		inFlight: make(map[string]bool),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// begin looks up key. It returns the memory ID created by an earlier request,
+// or reserves the key and returns ok=true if the caller should create it.
+// busy is true while another request with the same key is in progress.
+func (c *idempotencyCache) begin(key string) (memoryID string, ok, busy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight[key] {
+		return "", false, true
+	}
+	if e, found := c.entries.Get(key); found {
+		if c.now().Sub(e.createdAt) < c.ttl {
+			return e.memoryID, false, false
+		}
+		c.entries.Delete(key)
+	}
+
+	c.inFlight[key] = true
+	return "", true, false
+}
+
+// finish releases a key reserved by begin, remembering memoryID if the add
+// succeeded (non-empty)
+func (c *idempotencyCache) finish(key, memoryID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.inFlight, key)
+	if memoryID != "" {
+		c.entries.Put(key, idempotencyEntry{memoryID: memoryID, createdAt: c.now()})
+	}
+}
+
+// forget drops a key whose memory no longer exists
+func (c *idempotencyCache) forget(key string) {
+	c.entries.Delete(key)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	c := newIdempotencyCache(10, time.Hour)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	if _, ok, busy := c.begin("k"); !ok || busy {
+		t.Fatalf("expected a new key to be reserved, got ok=%v busy=%v", ok, busy)
+	}
+	if _, ok, busy := c.begin("k"); ok || !busy {
+		t.Fatalf("expected a concurrent retry to be busy, got ok=%v busy=%v", ok, busy)
+	}
+	c.finish("k", "mem-1")
+
+	if id, ok, busy := c.begin("k"); ok || busy || id != "mem-1" {
+		t.Fatalf("expected replay of mem-1, got id=%q ok=%v busy=%v", id, ok, busy)
+	}
+
+	// Keys expire after the TTL
+	now = now.Add(2 * time.Hour)
+	if _, ok, _ := c.begin("k"); !ok {
+		t.Fatal("expected an expired key to be reserved again")
+	}
+	c.finish("k", "")
+
+	// Failed adds are not remembered
+	if _, ok, _ := c.begin("k"); !ok {
+		t.Fatal("expected a failed key to be retryable")
+	}
+}
+
+// addService stores added memories in a map. With release set, each Add
+// signals started and waits for release.
+type addService struct {
+	memory.Service
+	mu       sync.Mutex
+	memories map[string]*types.Memory
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (s *addService) Add(ctx context.Context, req types.AddMemoryRequest) (*types.Memory, error) {
+	if s.release != nil {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memories == nil {
+		s.memories = make(map[string]*types.Memory)
+	}
+	m := &types.Memory{ID: fmt.Sprintf("mem-%d", len(s.memories)+1), Content: req.Content}
+	s.memories[m.ID] = m
+	return m, nil
+}
+
+func (s *addService) Get(ctx context.Context, id string) (*types.Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.memories[id]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("memory not found: %s", id)
+}
+
+func (s *addService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.memories)
+}
+
+// postAdd sends POST /memory with an optional Idempotency-Key
+func postAdd(h *Server, key string) (int, types.Memory) {
+	r := httptest.NewRequest("POST", "/memory", strings.NewReader(`{"content":"Retries back off exponentially"}`))
+	if key != "" {
+		r.Header.Set(idempotencyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.Handler().ServeHTTP(w, r)
+	var m types.Memory
+	json.Unmarshal(w.Body.Bytes(), &m)
+	return w.Code, m
+}
+
+func TestHandleAdd_IdempotencyKeyReplays(t *testing.T) {
+	svc := &addService{}
+	s := New(svc, Config{})
+
+	code, first := postAdd(s, "retry-1")
+	if code != 201 || first.ID == "" {
+		t.Fatalf("expected 201 with a memory, got %d %+v", code, first)
+	}
+	code, replay := postAdd(s, "retry-1")
+	if code != 200 || replay.ID != first.ID {
+		t.Errorf("expected the retry to replay %s with 200, got %d %s", first.ID, code, replay.ID)
+	}
+	if n := svc.count(); n != 1 {
+		t.Errorf("expected one insert, got %d", n)
+	}
+
+	// Another key is another memory
+	if code, m := postAdd(s, "retry-2"); code != 201 || m.ID == first.ID {
+		t.Errorf("expected a new memory for a new key, got %d %s", code, m.ID)
+	}
+}
+
+func TestHandleAdd_IdempotencyKeyConcurrent(t *testing.T) {
+	svc := &addService{started: make(chan struct{}), release: make(chan struct{})}
+	s := New(svc, Config{})
+
+	done := make(chan int)
+	go func() {
+		code, _ := postAdd(s, "retry-1")
+		done <- code
+	}()
+	<-svc.started
+
+	// The first request holds the key until its add finishes
+	if code, _ := postAdd(s, "retry-1"); code != 409 {
+		t.Errorf("expected 409 while the first request is in progress, got %d", code)
+	}
+	close(svc.release)
+	if code := <-done; code != 201 {
+		t.Errorf("expected the first request to create the memory, got %d", code)
+	}
+	if n := svc.count(); n != 1 {
+		t.Errorf("expected one insert, got %d", n)
+	}
+}
+
+func TestHandleAdd_WithoutIdempotencyKey(t *testing.T) {
+	svc := &addService{}
+	s := New(svc, Config{})
+
+	_, first := postAdd(s, "")
+	code, second := postAdd(s, "")
+	if code != 201 || second.ID == first.ID {
+		t.Errorf("expected each request without a key to add, got %d %s after %s", code, second.ID, first.ID)
+	}
+	if n := svc.count(); n != 2 {
+		t.Errorf("expected two inserts, got %d", n)
+	}
+}
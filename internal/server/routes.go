@@ -0,0 +1,114 @@
+// Package server describes its HTTP routes for API documentation
+package server
+
+import (
+	"net/http"
+
+	"github.com/shivavenkatesh/moneta/internal/schema"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// apiVersion is the version of the HTTP API
+const apiVersion = "0.1.0"
+
+// route binds a ServeMux pattern to its handler and documents the
+// operations it serves
+type route struct {
+	pattern string
+	handle  func(*Server, http.ResponseWriter, *http.Request)
+	docs    []schema.Route
+}
+
+// routeTable is the single source for both the mux and the OpenAPI spec
+func routeTable() []route {
+	return []route{
+		{"/memory", (*Server).handleMemory, []schema.Route{
+			{Method: http.MethodPost, Path: "/memory", Summary: "Add a memory",
+				Request: types.AddMemoryRequest{}, Response: types.Memory{}, Status: http.StatusCreated},
+			{Method: http.MethodGet, Path: "/memory", Summary: "List memories",
+				Response: types.ListResponse{}, Stream: true, Query: []string{"project", "type", "language", "path", "limit", "offset"}},
+		}},
+		{"/memory/", (*Server).handleMemoryByID, []schema.Route{
+			{Method: http.MethodGet, Path: "/memory/{id}", Summary: "Get a memory",
+				Response: types.Memory{}},
+			{Method: http.MethodDelete, Path: "/memory/{id}", Summary: "Delete a memory",
+				Response: types.DeleteResponse{}},
+			{Method: http.MethodPut, Path: "/memory/{id}", Summary: "Update a memory, re-embedding changed content",
+				Request: types.UpdateMemoryRequest{}, Response: types.Memory{}},
+			{Method: http.MethodPatch, Path: "/memory/{id}", Summary: "Update type and metadata without re-embedding",
+				Request: types.UpdateMemoryRequest{}, Response: types.Memory{}},
+		}},
+		{"/memory/batch", (*Server).handleGetBatch, []schema.Route{
+			{Method: http.MethodPost, Path: "/memory/batch", Summary: "Get memories by ID",
+				Request: types.GetBatchRequest{}, Response: types.GetBatchResponse{}},
+		}},
+		{"/search", (*Server).handleSearch, []schema.Route{
+			{Method: http.MethodPost, Path: "/search", Summary: "Semantic search",
+				Request: types.SearchRequest{}, Response: types.SearchResponse{}, Stream: true},
+		}},
+		{"/index", (*Server).handleIndex, []schema.Route{
+			{Method: http.MethodPost, Path: "/index", Summary: "Index a file or directory",
+				Request: types.IndexRequest{}, Response: types.IndexResponse{}},
+		}},
+		{"/stats", (*Server).handleStats, []schema.Route{
+			{Method: http.MethodGet, Path: "/stats", Summary: "Storage statistics",
+				Response: types.StatsResponse{}},
+		}},
+		{"/projects", (*Server).handleProjects, []schema.Route{
+			{Method: http.MethodGet, Path: "/projects", Summary: "List projects",
+				Response: types.ProjectsResponse{}},
+		}},
+		{"/health", (*Server).handleHealth, []schema.Route{
+			{Method: http.MethodGet, Path: "/health", Summary: "Health check",
+				Response: types.StatusResponse{}},
+		}},
+		{"/ready", (*Server).handleReady, []schema.Route{
+			{Method: http.MethodGet, Path: "/ready", Summary: "Readiness check",
+				Response: types.StatusResponse{}},
+		}},
+		{"/mcp", (*Server).handleMCP, []schema.Route{
+			{Method: http.MethodPost, Path: "/mcp", Summary: "MCP Streamable HTTP endpoint (when enabled)"},
+		}},
+		{"/openapi.json", (*Server).handleOpenAPI, []schema.Route{
+			{Method: http.MethodGet, Path: "/openapi.json", Summary: "OpenAPI document (when enabled)"},
+		}},
+	}
+}
+
+// Routes describes every endpoint served by the API
+func Routes() []schema.Route {
+	var routes []schema.Route
+	for _, rt := range routeTable() {
+		routes = append(routes, rt.docs...)
+	}
+	return routes
+}
+
+// NewSchemaGenerator returns a schema generator that knows the API's enums
+func NewSchemaGenerator(refPrefix string) *schema.Generator {
+	g := schema.NewGenerator(refPrefix)
+	g.Enum(types.MemoryType(""),
+		string(types.TypeArchitecture), string(types.TypePattern), string(types.TypeDecision),
+		string(types.TypeGotcha), string(types.TypeContext), string(types.TypePreference))
+	return g
+}
+
+// OpenAPISpec returns the OpenAPI document for the API
+func OpenAPISpec() schema.Object {
+	g := NewSchemaGenerator("#/components/schemas/")
+	return schema.OpenAPI(g, "Moneta API", apiVersion, Routes(), types.ErrorResponse{})
+}
+
+// handleOpenAPI handles GET /openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.config.OpenAPI {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, r, OpenAPISpec(), http.StatusOK)
+}
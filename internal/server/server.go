@@ -3,12 +3,17 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/shivavenkatesh/moneta/internal/mcp"
 	"github.com/shivavenkatesh/moneta/internal/memory"
 	"github.com/shivavenkatesh/moneta/internal/store"
 	"github.com/shivavenkatesh/moneta/pkg/types"
@@ -16,44 +21,68 @@ import (
 
 // Server is the HTTP API server
 type Server struct {
-	svc    memory.Service
-	config Config
-	server *http.Server
+	svc         memory.Service
+	config      Config
+	server      *http.Server
+	idempotency *idempotencyCache
+	mcp         *mcp.Server // nil unless Config.MCP
 }
 
 // Config configures the server
 type Config struct {
 	Host string
 	Port int
+
+	// MaxSearchLimit caps the number of results a client can request from
+	// /search and GET /memory (default 200)
+	MaxSearchLimit int
+
+	// OpenAPI serves the API's OpenAPI document at GET /openapi.json
+	OpenAPI bool
+
+	// AllowedOrigins lists origins allowed to make cross-origin requests.
+	// "*" allows any origin. Empty allows only localhost origins.
+	AllowedOrigins []string
+
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	// cross-origin. Credentialed requests can't use a wildcard origin, so
+	// "*" in AllowedOrigins echoes the request's origin instead.
+	AllowCredentials bool
+
+	// MCP serves the memory tools over MCP's Streamable HTTP transport at
+	// /mcp. Version is reported to MCP clients.
+	MCP     bool
+	Version string
+
+	// APIKey, when set, is required as "Authorization: Bearer <key>" on
+	// every route except /health
+	APIKey string
 }
 
+// defaultMaxSearchLimit is used when Config.MaxSearchLimit is unset
+const defaultMaxSearchLimit = 200
+
 // New creates a new server
 func New(svc memory.Service, cfg Config) *Server {
-	return &Server{
-		svc:    svc,
-		config: cfg,
+	if cfg.MaxSearchLimit <= 0 {
+		cfg.MaxSearchLimit = defaultMaxSearchLimit
+	}
+	s := &Server{
+		svc:         svc,
+		config:      cfg,
+		idempotency: newIdempotencyCache(idempotencyCapacity, idempotencyTTL),
 	}
+	if cfg.MCP {
+		s.mcp = mcp.New(svc, mcp.Config{Version: cfg.Version, MaxSearchLimit: cfg.MaxSearchLimit})
+	}
+	return s
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-
-	// API routes
-	mux.HandleFunc("/memory", s.handleMemory)
-	mux.HandleFunc("/memory/", s.handleMemoryByID)
-	mux.HandleFunc("/search", s.handleSearch)
-	mux.HandleFunc("/index", s.handleIndex)
-	mux.HandleFunc("/stats", s.handleStats)
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/projects", s.handleProjects)
-
-	// CORS middleware for Claude Code integration
-	handler := corsMiddleware(mux)
-
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.config.Host, s.config.Port),
-		Handler:      handler,
+		Handler:      s.Handler(),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -62,6 +91,22 @@ func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// Handler returns the API handler with middleware applied
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// API routes, registered from the same table the OpenAPI spec uses
+	for _, rt := range routeTable() {
+		handle := rt.handle
+		mux.HandleFunc(rt.pattern, func(w http.ResponseWriter, r *http.Request) {
+			handle(s, w, r)
+		})
+	}
+
+	// CORS wraps auth so preflights and 401s still carry CORS headers
+	return corsMiddleware(authMiddleware(mux, s.config.APIKey), s.config.AllowedOrigins, s.config.AllowCredentials)
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown() error {
 	if s.server == nil {
@@ -72,12 +117,30 @@ func (s *Server) Shutdown() error {
 	return s.server.Shutdown(ctx)
 }
 
-// corsMiddleware adds CORS headers for Claude Code integration
-func corsMiddleware(next http.Handler) http.Handler {
+// corsMiddleware adds CORS headers for allowed origins
+func corsMiddleware(next http.Handler, origins []string, credentials bool) http.Handler {
+	// Only a bare wildcard answers every origin the same way
+	wildcard := allowedOrigin("", origins) == "*" && !credentials
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
+		allow := allowedOrigin(origin, origins)
+		if allow == "*" && credentials {
+			allow = origin
+		}
+		if allow != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allow)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, Mcp-Protocol-Version")
+			if credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+		if !wildcard {
+			// The response depends on the request's Origin, so caches must
+			// not serve it to another
+			w.Header().Add("Vary", "Origin")
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -88,8 +151,75 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// handleMemory handles POST /memory (add memory)
+// authMiddleware rejects requests without the bearer token key. An empty
+// key disables authentication.
+func authMiddleware(next http.Handler, key string) http.Handler {
+	if key == "" {
+		return next
+	}
+	want := []byte("Bearer " + key)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="moneta"`)
+			writeError(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for origin,
+// or "" if it is not allowed
+func allowedOrigin(origin string, origins []string) string {
+	for _, o := range origins {
+		if o == "*" {
+			return "*"
+		}
+	}
+	if origin == "" {
+		return ""
+	}
+
+	if len(origins) == 0 {
+		if isLocalOrigin(origin) {
+			return origin
+		}
+		return ""
+	}
+
+	for _, o := range origins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// isLocalOrigin reports whether origin is served from this machine
+func isLocalOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// handleMemory handles POST /memory (add memory) and GET /memory (list)
 func (s *Server) handleMemory(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.handleList(w, r)
+		return
+	}
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -100,17 +230,103 @@ func (s *Server) handleMemory(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if err := req.Validate(); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	memory, replayed, err := s.addOnce(r.Context(), r.Header.Get(idempotencyHeader), req)
+	if errors.Is(err, errIdempotencyBusy) {
+		writeError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if replayed {
+		writeJSON(w, r, memory, http.StatusOK)
+		return
+	}
+	writeJSON(w, r, memory, http.StatusCreated)
+}
+
+// handleList handles GET /memory?project=&type=&limit=&offset=
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
 
-	memory, err := s.svc.Add(r.Context(), req)
+	limit, err := queryInt(q.Get("limit"))
+	if err != nil {
+		writeError(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	offset, err := queryInt(q.Get("offset"))
+	if err != nil || offset < 0 {
+		writeError(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	// Never allow an unbounded load over HTTP
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	limit = s.clampLimit(limit)
+
+	opts := store.ListOptions{
+		Project:        q.Get("project"),
+		Type:           types.MemoryType(q.Get("type")),
+		Language:       q.Get("language"),
+		FilePathPrefix: q.Get("path"),
+		Limit:          limit,
+		Offset:         offset,
+		OrderBy:        "created_at",
+		Descending:     true,
+	}
+	memories, err := s.svc.List(r.Context(), opts)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if memories == nil {
+		memories = []*types.Memory{}
+	}
+	total, err := s.svc.CountList(r.Context(), opts)
 	if err != nil {
 		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	writeJSON(w, memory, http.StatusCreated)
+	writeItems(w, r, memories, types.ListResponse{
+		Memories: memories,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+		HasMore:  offset+len(memories) < total,
+	})
+}
+
+// defaultListLimit is the page size for GET /memory without a limit
+const defaultListLimit = 100
+
+// clampLimit bounds a client-requested limit to the configured maximum;
+// negative (unlimited) requests get the maximum
+func (s *Server) clampLimit(limit int) int {
+	if limit < 0 || limit > s.config.MaxSearchLimit {
+		return s.config.MaxSearchLimit
+	}
+	return limit
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
 }
 
-// handleMemoryByID handles GET/DELETE /memory/:id
+// handleMemoryByID handles GET/PUT/PATCH/DELETE /memory/:id
 func (s *Server) handleMemoryByID(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/memory/")
 	if id == "" {
@@ -125,20 +341,70 @@ func (s *Server) handleMemoryByID(w http.ResponseWriter, r *http.Request) {
 			writeError(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		writeJSON(w, memory, http.StatusOK)
+		writeJSON(w, r, memory, http.StatusOK)
 
 	case http.MethodDelete:
 		if err := s.svc.Delete(r.Context(), id); err != nil {
 			writeError(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		writeJSON(w, map[string]bool{"deleted": true}, http.StatusOK)
+		writeJSON(w, r, types.DeleteResponse{Deleted: true}, http.StatusOK)
+
+	case http.MethodPut, http.MethodPatch:
+		var req types.UpdateMemoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		// PATCH only touches type and metadata, so it never re-embeds. PUT
+		// may change content, re-embedding it when it differs.
+		if r.Method == http.MethodPatch && req.Content != nil {
+			writeError(w, "PATCH cannot change content", http.StatusBadRequest)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := s.svc.Get(r.Context(), id); err != nil {
+			writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		memory, err := s.svc.Update(r.Context(), id, req)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, r, memory, http.StatusOK)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// handleGetBatch handles POST /memory/batch
+func (s *Server) handleGetBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req types.GetBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	memories, err := s.svc.GetBatch(r.Context(), req.IDs)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, types.GetBatchResponse{Memories: memories}, http.StatusOK)
+}
+
 // handleSearch handles POST /search
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -151,6 +417,15 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if req.Offset < 0 || req.Offset >= s.config.MaxSearchLimit {
+		writeError(w, fmt.Sprintf("offset must be between 0 and %d", s.config.MaxSearchLimit-1), http.StatusBadRequest)
+		return
+	}
+	// Earlier pages are ranked too, so the cap covers offset plus limit
+	if req.Limit != 0 {
+		req.Limit = min(s.clampLimit(req.Limit), s.config.MaxSearchLimit-req.Offset)
+	}
+	req.IncludeNeighbors = min(req.IncludeNeighbors, types.MaxIncludeNeighbors)
 
 	resp, err := s.svc.Search(r.Context(), req)
 	if err != nil {
@@ -158,7 +433,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, resp, http.StatusOK)
+	writeItems(w, r, resp.Results, resp)
 }
 
 // handleIndex handles POST /index
@@ -180,7 +455,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, map[string]int{"indexed": count}, http.StatusOK)
+	writeJSON(w, r, types.IndexResponse{Indexed: count}, http.StatusOK)
 }
 
 // handleStats handles GET /stats
@@ -195,13 +470,34 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	metrics := s.svc.Metrics()
+	stats.Runtime = &metrics
 
-	writeJSON(w, stats, http.StatusOK)
+	writeJSON(w, r, stats, http.StatusOK)
 }
 
 // handleHealth handles GET /health
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, map[string]string{"status": "ok", "version": "0.1.0"}, http.StatusOK)
+	writeJSON(w, r, types.StatusResponse{Status: "ok", Version: apiVersion}, http.StatusOK)
+}
+
+// handleReady handles GET /ready, reporting whether the store and embedder are usable
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.svc.Ping(r.Context()); err != nil {
+		writeError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if model, _, healthy := s.svc.EmbedderInfo(); !healthy {
+		writeError(w, fmt.Sprintf("embedder %s unavailable", model), http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, r, types.StatusResponse{Status: "ready"}, http.StatusOK)
 }
 
 // handleProjects handles GET /projects (list projects)
@@ -211,40 +507,89 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get list of projects from stats
-	// This is a simplified implementation - could be optimized
-	memories, err := s.svc.List(r.Context(), store.ListOptions{
-		Limit: 1000,
-	})
+	list, err := s.svc.ListProjects(r.Context())
 	if err != nil {
 		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Extract unique projects
-	projectSet := make(map[string]bool)
-	for _, m := range memories {
-		projectSet[m.Project] = true
+	// Keys are kept under "projects" for existing clients
+	projects := make([]string, 0, len(list))
+	for _, p := range list {
+		projects = append(projects, p.Key)
+	}
+
+	writeJSON(w, r, types.ProjectsResponse{Projects: projects, Details: list}, http.StatusOK)
+}
+
+// handleMCP handles /mcp when MCP is enabled
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if s.mcp == nil {
+		http.NotFound(w, r)
+		return
 	}
 
-	projects := make([]string, 0, len(projectSet))
-	for p := range projectSet {
-		projects = append(projects, p)
+	// Unlike the REST API, MCP clients act on what they read, so a page
+	// from a disallowed origin, e.g. via DNS rebinding, is refused outright
+	if origin := r.Header.Get("Origin"); origin != "" && allowedOrigin(origin, s.config.AllowedOrigins) == "" {
+		writeError(w, "Origin not allowed", http.StatusForbidden)
+		return
 	}
 
-	writeJSON(w, map[string][]string{"projects": projects}, http.StatusOK)
+	s.mcp.ServeHTTP(w, r)
 }
 
-// writeJSON writes a JSON response
-func writeJSON(w http.ResponseWriter, data interface{}, status int) {
+// writeJSON writes a JSON response, indented when the request has ?pretty
+func writeJSON(w http.ResponseWriter, r *http.Request, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	enc := json.NewEncoder(w)
+	if wantsPretty(r) {
+		enc.SetIndent("", "  ")
+	}
+	enc.Encode(data)
+}
+
+// wantsPretty reports whether ?pretty is present and not false. Compact
+// output stays the default.
+func wantsPretty(r *http.Request) bool {
+	q := r.URL.Query()
+	if !q.Has("pretty") {
+		return false
+	}
+	pretty, err := strconv.ParseBool(q.Get("pretty"))
+	return q.Get("pretty") == "" || (err == nil && pretty)
+}
+
+// ndjsonContentType is the media type for newline-delimited JSON
+const ndjsonContentType = "application/x-ndjson"
+
+// writeItems writes items one JSON object per line when the client accepts
+// NDJSON, flushing as it goes; otherwise it writes envelope as regular JSON
+func writeItems[T any](w http.ResponseWriter, r *http.Request, items []T, envelope interface{}) {
+	if !strings.Contains(r.Header.Get("Accept"), ndjsonContentType) {
+		writeJSON(w, r, envelope, http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return // Client went away
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
 }
 
 // writeError writes an error response
 func writeError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(types.ErrorResponse{Error: message})
 }
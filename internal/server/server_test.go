@@ -0,0 +1,330 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/memory"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestWriteItems_NDJSON(t *testing.T) {
+	items := []map[string]int{{"n": 1}, {"n": 2}}
+
+	r := httptest.NewRequest("GET", "/memory", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	writeItems(w, r, items, map[string]interface{}{"items": items})
+
+	if ct := w.Header().Get("Content-Type"); ct != ndjsonContentType {
+		t.Errorf("expected %s, got %s", ndjsonContentType, ct)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || lines[0] != `{"n":1}` || lines[1] != `{"n":2}` {
+		t.Errorf("unexpected NDJSON body: %q", w.Body.String())
+	}
+}
+
+func TestWriteItems_DefaultJSON(t *testing.T) {
+	items := []map[string]int{{"n": 1}}
+
+	r := httptest.NewRequest("GET", "/memory", nil)
+	w := httptest.NewRecorder()
+	writeItems(w, r, items, map[string]interface{}{"items": items})
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %s", ct)
+	}
+	var body map[string][]map[string]int
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body["items"]) != 1 {
+		t.Errorf("unexpected body: %v", body)
+	}
+}
+
+func TestWriteJSON_Pretty(t *testing.T) {
+	tests := []struct {
+		url    string
+		pretty bool
+	}{
+		{"/health", false},
+		{"/health?pretty", true},
+		{"/health?pretty=true", true},
+		{"/health?pretty=1", true},
+		{"/health?pretty=false", false},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		writeJSON(w, httptest.NewRequest("GET", tt.url, nil), map[string]string{"status": "ok"}, 200)
+
+		indented := strings.Contains(w.Body.String(), "\n  ")
+		if indented != tt.pretty {
+			t.Errorf("%s: indented = %v, want %v (body %q)", tt.url, indented, tt.pretty, w.Body.String())
+		}
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	s := New(nil, Config{MaxSearchLimit: 50})
+
+	tests := []struct {
+		in, want int
+	}{
+		{10, 10},
+		{50, 50},
+		{1000000, 50},
+		{-1, 50},
+	}
+	for _, tt := range tests {
+		if got := s.clampLimit(tt.in); got != tt.want {
+			t.Errorf("clampLimit(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	if s := New(nil, Config{}); s.config.MaxSearchLimit != defaultMaxSearchLimit {
+		t.Errorf("expected default max limit %d, got %d", defaultMaxSearchLimit, s.config.MaxSearchLimit)
+	}
+}
+
+func TestHandler_OpenAPI(t *testing.T) {
+	get := func(cfg Config) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		New(nil, cfg).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+		return w
+	}
+
+	if w := get(Config{}); w.Code != 404 {
+		t.Errorf("expected 404 when disabled, got %d", w.Code)
+	}
+
+	w := get(Config{OpenAPI: true})
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var doc struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, rt := range routeTable() {
+		for _, d := range rt.docs {
+			if _, ok := doc.Paths[d.Path][strings.ToLower(d.Method)]; !ok {
+				t.Errorf("spec is missing %s %s", d.Method, d.Path)
+			}
+		}
+	}
+}
+
+func TestCORS_AllowedOrigins(t *testing.T) {
+	tests := []struct {
+		origins []string
+		origin  string
+		want    string
+	}{
+		{nil, "http://localhost:3000", "http://localhost:3000"},
+		{nil, "http://127.0.0.1:8080", "http://127.0.0.1:8080"},
+		{nil, "https://evil.example", ""},
+		{[]string{"https://claude.ai"}, "https://claude.ai", "https://claude.ai"},
+		{[]string{"https://claude.ai"}, "http://localhost:3000", ""},
+		{[]string{"*"}, "https://evil.example", "*"},
+	}
+
+	for _, tt := range tests {
+		h := New(nil, Config{AllowedOrigins: tt.origins}).Handler()
+		r := httptest.NewRequest("OPTIONS", "/search", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("origins %v, origin %q: expected %q, got %q", tt.origins, tt.origin, tt.want, got)
+		}
+	}
+}
+
+func TestCORS_Credentials(t *testing.T) {
+	tests := []struct {
+		origins     []string
+		credentials bool
+		origin      string
+		wantAllow   string
+		wantCreds   string
+		wantVary    bool
+	}{
+		{[]string{"*"}, false, "https://app.example", "*", "", false},
+		{[]string{"*"}, true, "https://app.example", "https://app.example", "true", true},
+		{[]string{"https://app.example"}, true, "https://app.example", "https://app.example", "true", true},
+		{[]string{"https://app.example"}, true, "https://evil.example", "", "", true},
+		{nil, false, "http://localhost:3000", "http://localhost:3000", "", true},
+	}
+
+	for _, tt := range tests {
+		h := New(nil, Config{AllowedOrigins: tt.origins, AllowCredentials: tt.credentials}).Handler()
+		r := httptest.NewRequest("OPTIONS", "/search", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+			t.Errorf("origins %v, credentials %v, origin %q: expected allow %q, got %q", tt.origins, tt.credentials, tt.origin, tt.wantAllow, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+			t.Errorf("origins %v, credentials %v, origin %q: expected credentials %q, got %q", tt.origins, tt.credentials, tt.origin, tt.wantCreds, got)
+		}
+		if got := w.Header().Get("Vary") == "Origin"; got != tt.wantVary {
+			t.Errorf("origins %v, credentials %v, origin %q: expected Vary: Origin %v, got %q", tt.origins, tt.credentials, tt.origin, tt.wantVary, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestHandleMCP_Origin(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	serve := func(s *Server, origin string) int {
+		r := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(New(nil, Config{}), ""); code != 404 {
+		t.Errorf("expected 404 with MCP disabled, got %d", code)
+	}
+
+	s := New(nil, Config{MCP: true})
+	if code := serve(s, ""); code != 200 {
+		t.Errorf("expected a client without an Origin to be served, got %d", code)
+	}
+	if code := serve(s, "http://localhost:5173"); code != 200 {
+		t.Errorf("expected a local origin to be served, got %d", code)
+	}
+	if code := serve(s, "https://evil.example"); code != 403 {
+		t.Errorf("expected a foreign origin to be refused, got %d", code)
+	}
+}
+
+// batchService serves GetBatch from a map
+type batchService struct {
+	memory.Service
+	memories map[string]*types.Memory
+}
+
+func (s *batchService) GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error) {
+	found := make([]*types.Memory, len(ids))
+	for i, id := range ids {
+		found[i] = s.memories[id]
+	}
+	return found, nil
+}
+
+func TestHandleGetBatch(t *testing.T) {
+	s := New(&batchService{memories: map[string]*types.Memory{
+		"a": {ID: "a", Content: "first"},
+		"b": {ID: "b", Content: "second"},
+	}}, Config{})
+	post := func(body string) (int, types.GetBatchResponse) {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/memory/batch", strings.NewReader(body)))
+		var resp types.GetBatchResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := post(`{"ids":["b","missing","a"]}`)
+	if code != 200 {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(resp.Memories) != 3 || resp.Memories[0].ID != "b" || resp.Memories[1] != nil || resp.Memories[2].ID != "a" {
+		t.Errorf("expected b, null, a, got %+v", resp.Memories)
+	}
+
+	if code, resp := post(`{"ids":[]}`); code != 200 || len(resp.Memories) != 0 {
+		t.Errorf("expected an empty batch, got %d %+v", code, resp.Memories)
+	}
+	if code, _ := post(`not json`); code != 400 {
+		t.Errorf("expected 400 for a bad body, got %d", code)
+	}
+}
+
+func TestAuth_APIKey(t *testing.T) {
+	h := New(&batchService{}, Config{APIKey: "secret"}).Handler()
+	serve := func(method, path, auth string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(`{"ids":[]}`))
+		r.Header.Set("Origin", "http://localhost:3000")
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name, method, path, auth string
+		want                     int
+	}{
+		{"missing key", "POST", "/memory/batch", "", 401},
+		{"wrong key", "POST", "/memory/batch", "Bearer wrong", 401},
+		{"wrong scheme", "POST", "/memory/batch", "Basic secret", 401},
+		{"correct key", "POST", "/memory/batch", "Bearer secret", 200},
+		{"health", "GET", "/health", "", 200},
+		{"preflight", "OPTIONS", "/memory/batch", "", 200},
+	}
+	for _, tt := range tests {
+		w := serve(tt.method, tt.path, tt.auth)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+			t.Errorf("%s: expected CORS headers, got origin %q", tt.name, got)
+		}
+	}
+
+	if w := serve("POST", "/memory/batch", ""); w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected a WWW-Authenticate challenge with the 401")
+	}
+}
+
+// searchRecorder keeps the last search request it was given
+type searchRecorder struct {
+	memory.Service
+	got types.SearchRequest
+}
+
+func (s *searchRecorder) Search(ctx context.Context, req types.SearchRequest) (*types.SearchResponse, error) {
+	s.got = req
+	return &types.SearchResponse{}, nil
+}
+
+func TestHandleSearch_Window(t *testing.T) {
+	svc := &searchRecorder{}
+	h := New(svc, Config{MaxSearchLimit: 20}).Handler()
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/search", strings.NewReader(body)))
+		return w.Code
+	}
+
+	if code := post(`{"query":"q","offset":15,"limit":50,"include_neighbors":100}`); code != 200 {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if svc.got.Limit != 5 || svc.got.IncludeNeighbors != types.MaxIncludeNeighbors {
+		t.Errorf("expected limit 5 and %d neighbors, got %+v", types.MaxIncludeNeighbors, svc.got)
+	}
+	if code := post(`{"query":"q","offset":20}`); code != 400 {
+		t.Errorf("expected an offset at the cap to be refused, got %d", code)
+	}
+	if code := post(`{"query":"q","offset":-1}`); code != 400 {
+		t.Errorf("expected a negative offset to be refused, got %d", code)
+	}
+}
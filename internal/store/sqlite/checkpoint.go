@@ -0,0 +1,63 @@
+// Package sqlite checkpoints the write-ahead log
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultCheckpointEvery is how many rows AddBatch writes between automatic
+// checkpoints
+const DefaultCheckpointEvery = 10000
+
+// checkpointModes are the modes PRAGMA wal_checkpoint accepts
+var checkpointModes = map[string]bool{"PASSIVE": true, "FULL": true, "RESTART": true, "TRUNCATE": true}
+
+// Checkpoint copies the WAL into the database. mode is PASSIVE, FULL,
+// RESTART, or TRUNCATE (the default, which also empties the -wal file).
+func (s *Store) Checkpoint(ctx context.Context, mode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoint(ctx, mode)
+}
+
+// checkpoint runs the checkpoint. Callers hold s.mu.
+func (s *Store) checkpoint(ctx context.Context, mode string) error {
+	name := strings.ToUpper(mode)
+	if name == "" {
+		name = "TRUNCATE"
+	}
+	if !checkpointModes[name] {
+		return fmt.Errorf("unknown checkpoint mode %q (want passive, full, restart, or truncate)", mode)
+	}
+	mode = name
+
+	var busy, logFrames, checkpointed int
+	err := s.db.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+mode+")").Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	if busy != 0 && mode != "PASSIVE" {
+		return fmt.Errorf("checkpoint incomplete: %d of %d frames copied while the database was busy", checkpointed, logFrames)
+	}
+	return nil
+}
+
+// countWrites records rows written by AddBatch and checkpoints once
+// checkpointEvery rows have accumulated, so a long index doesn't grow the
+// WAL without bound. Callers hold s.mu.
+func (s *Store) countWrites(ctx context.Context, n int) {
+	if s.checkpointEvery <= 0 {
+		return
+	}
+	s.sinceCheckpoint += n
+	if s.sinceCheckpoint < s.checkpointEvery {
+		return
+	}
+	// A busy checkpoint is retried after the next batch
+	if err := s.checkpoint(ctx, "TRUNCATE"); err == nil {
+		s.sinceCheckpoint = 0
+	}
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func walSize(t *testing.T, s *Store) int64 {
+	t.Helper()
+	info, err := os.Stat(s.path + "-wal")
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func TestStore_Checkpoint(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		m := &types.Memory{ID: fmt.Sprintf("m%d", i), Content: "x", Project: "p", Type: types.TypeContext, Embedding: generateTestEmbedding(768)}
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+	if walSize(t, s) == 0 {
+		t.Fatal("expected writes to grow the WAL")
+	}
+
+	if err := s.Checkpoint(ctx, ""); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+	if size := walSize(t, s); size != 0 {
+		t.Errorf("expected TRUNCATE to empty the WAL, got %d bytes", size)
+	}
+
+	if err := s.Checkpoint(ctx, "sideways"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestStore_AddBatch_AutoCheckpoint(t *testing.T) {
+	s, err := New(Config{Path: filepath.Join(t.TempDir(), "test.db"), Dimensions: 768, CheckpointEvery: 10})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	batch := func(prefix string, n int) []*types.Memory {
+		var memories []*types.Memory
+		for i := 0; i < n; i++ {
+			memories = append(memories, &types.Memory{ID: fmt.Sprintf("%s%d", prefix, i), Content: "x", Project: "p", Type: types.TypeContext, Embedding: generateTestEmbedding(768)})
+		}
+		return memories
+	}
+
+	if err := s.AddBatch(ctx, batch("a", 5), store.AddOptions{}); err != nil {
+		t.Fatalf("add batch failed: %v", err)
+	}
+	if walSize(t, s) == 0 {
+		t.Fatal("expected no checkpoint below the threshold")
+	}
+	if err := s.AddBatch(ctx, batch("b", 5), store.AddOptions{}); err != nil {
+		t.Fatalf("add batch failed: %v", err)
+	}
+	if size := walSize(t, s); size != 0 {
+		t.Errorf("expected an automatic checkpoint, WAL is %d bytes", size)
+	}
+}
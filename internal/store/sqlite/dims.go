@@ -0,0 +1,78 @@
+// Package sqlite records the embedding dimensions a database holds
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrUnknownDimensions is returned by New when Config.Dimensions is zero
+// and the database holds no embeddings to take them from, so the caller has
+// to find the embedder's dimensions
+var ErrUnknownDimensions = errors.New("embedding dimensions unknown")
+
+// ErrDimensionMismatch is returned by New when Config.Dimensions differs
+// from the dimensions of the stored embeddings. Migrate converts the store.
+var ErrDimensionMismatch = errors.New("embedding dimensions mismatch")
+
+// dimensionsKey is the store_meta key holding the embedding dimensions
+const dimensionsKey = "dimensions"
+
+// Dimensions returns the embedding length of the store
+func (s *Store) Dimensions() int {
+	return s.dims
+}
+
+// resolveDimensions returns the store's dimensions and saves them. Once the
+// store holds embeddings their length is fixed and want must match it (or
+// be zero). An empty store takes want, so switching models before anything
+// is stored needs no migration.
+func (s *Store) resolveDimensions(want int) (int, error) {
+	var size sql.NullInt64
+	err := s.db.QueryRow("SELECT length(embedding) FROM memories WHERE length(embedding) > 0 LIMIT 1").Scan(&size)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read embedding dimensions: %w", err)
+	}
+
+	if size.Int64 == 0 {
+		if want == 0 {
+			return 0, ErrUnknownDimensions
+		}
+		return want, s.saveDimensions(want)
+	}
+
+	var value string
+	err = s.db.QueryRow("SELECT value FROM store_meta WHERE key = ?", dimensionsKey).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read embedding dimensions: %w", err)
+	}
+	// Databases from before dimensions were saved go by their embeddings
+	saved := int(size.Int64 / 4)
+	if value != "" {
+		if saved, err = strconv.Atoi(value); err != nil {
+			return 0, fmt.Errorf("invalid saved embedding dimensions %q: %w", value, err)
+		}
+	}
+
+	if want != 0 && want != saved {
+		return 0, fmt.Errorf("%w: store has %d-dimension embeddings, not %d", ErrDimensionMismatch, saved, want)
+	}
+	if value == "" {
+		return saved, s.saveDimensions(saved)
+	}
+	return saved, nil
+}
+
+// saveDimensions records the store's embedding dimensions
+func (s *Store) saveDimensions(dims int) error {
+	_, err := s.db.Exec(`
+		INSERT INTO store_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, dimensionsKey, strconv.Itoa(dims))
+	if err != nil {
+		return fmt.Errorf("failed to save embedding dimensions: %w", err)
+	}
+	return nil
+}
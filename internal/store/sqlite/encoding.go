@@ -63,6 +63,16 @@ func bytesToFloat32Alloc(b []byte) []float32 {
 	return f
 }
 
+// ranksBefore reports whether a ranks ahead of b: higher similarity first,
+// then lower ID, so equal scores order the same way in every strategy and
+// pages cut from rankings of different lengths line up
+func ranksBefore(a, b *types.SearchResult) bool {
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	return a.Memory.ID < b.Memory.ID
+}
+
 // sortBySimilarity sorts search results by similarity in descending order
 // Uses optimized sorting for typical result sizes
 func sortBySimilarity(results []types.SearchResult) {
@@ -79,7 +89,7 @@ func sortBySimilarity(results []types.SearchResult) {
 
 	// For larger slices, use Go's optimized sort (pdqsort)
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Similarity > results[j].Similarity
+		return ranksBefore(&results[i], &results[j])
 	})
 }
 
@@ -91,7 +101,7 @@ func insertionSortResults(results []types.SearchResult) {
 		j := i - 1
 
 		// Move elements that are smaller than key to one position ahead
-		for j >= 0 && results[j].Similarity < key.Similarity {
+		for j >= 0 && ranksBefore(&key, &results[j]) {
 			results[j+1] = results[j]
 			j--
 		}
@@ -99,18 +109,20 @@ func insertionSortResults(results []types.SearchResult) {
 	}
 }
 
-// topKResults extracts top K results efficiently using partial sort
-// This is faster than full sort when K << N
-func topKResults(results []types.SearchResult, k int) []types.SearchResult {
+// topKResults extracts the top k results, best first, using the strategy
+// cfg picks for this k and input size
+func topKResults(results []types.SearchResult, k int, cfg TopKConfig) []types.SearchResult {
 	n := len(results)
-	if k >= n {
+	if k >= n || float64(k) >= cfg.SortRatio*float64(n) {
 		sortBySimilarity(results)
+		if k < n {
+			results = results[:k]
+		}
 		return results
 	}
 
-	// Use selection algorithm for top-k
 	// For very small k, simple selection is faster
-	if k <= 5 {
+	if k <= cfg.SelectMaxK {
 		return selectTopK(results, k)
 	}
 
@@ -125,7 +137,7 @@ func selectTopK(results []types.SearchResult, k int) []types.SearchResult {
 	for i := 0; i < k; i++ {
 		maxIdx := i
 		for j := i + 1; j < len(results); j++ {
-			if results[j].Similarity > results[maxIdx].Similarity {
+			if ranksBefore(&results[j], &results[maxIdx]) {
 				maxIdx = j
 			}
 		}
@@ -149,7 +161,7 @@ func heapTopK(results []types.SearchResult, k int) []types.SearchResult {
 		if len(heap) < k {
 			heap = append(heap, r)
 			heapifyUp(heap, len(heap)-1)
-		} else if r.Similarity > heap[0].Similarity {
+		} else if ranksBefore(&r, &heap[0]) {
 			heap[0] = r
 			heapifyDown(heap, 0)
 		}
@@ -163,7 +175,7 @@ func heapTopK(results []types.SearchResult, k int) []types.SearchResult {
 func heapifyUp(heap []types.SearchResult, i int) {
 	for i > 0 {
 		parent := (i - 1) / 2
-		if heap[parent].Similarity <= heap[i].Similarity {
+		if !ranksBefore(&heap[parent], &heap[i]) {
 			break
 		}
 		heap[parent], heap[i] = heap[i], heap[parent]
@@ -178,10 +190,10 @@ func heapifyDown(heap []types.SearchResult, i int) {
 		left := 2*i + 1
 		right := 2*i + 2
 
-		if left < n && heap[left].Similarity < heap[smallest].Similarity {
+		if left < n && ranksBefore(&heap[smallest], &heap[left]) {
 			smallest = left
 		}
-		if right < n && heap[right].Similarity < heap[smallest].Similarity {
+		if right < n && ranksBefore(&heap[smallest], &heap[right]) {
 			smallest = right
 		}
 
@@ -158,7 +158,7 @@ func TestTopKResults(t *testing.T) {
 		{Memory: types.Memory{ID: "5"}, Similarity: 0.3},
 	}
 
-	top := topKResults(results, 3)
+	top := topKResults(results, 3, DefaultTopKConfig())
 
 	if len(top) != 3 {
 		t.Errorf("expected 3 results, got %d", len(top))
@@ -176,7 +176,7 @@ func TestTopKResults_KGreaterThanN(t *testing.T) {
 		{Memory: types.Memory{ID: "2"}, Similarity: 0.3},
 	}
 
-	top := topKResults(results, 10)
+	top := topKResults(results, 10, DefaultTopKConfig())
 
 	if len(top) != 2 {
 		t.Errorf("expected 2 results, got %d", len(top))
@@ -389,7 +389,7 @@ func BenchmarkTopKResults(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		resultsCopy := make([]types.SearchResult, len(results))
 		copy(resultsCopy, results)
-		topKResults(resultsCopy, 10)
+		topKResults(resultsCopy, 10, DefaultTopKConfig())
 	}
 }
 
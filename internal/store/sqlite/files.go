@@ -0,0 +1,87 @@
+// Package sqlite stores the full content of indexed files
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/hash"
+)
+
+// ErrHashCollision is returned when different contents share a hash
+var ErrHashCollision = errors.New("content hash collision")
+
+// PutFileContent stores a file's content under its SHA-256 hash and points
+// project/path at it. The previous version's content is removed once no
+// file references it. Different content already stored under the same hash
+// is reported as ErrHashCollision rather than silently shared.
+func (s *Store) PutFileContent(ctx context.Context, project, path, content string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := hash.Identity(content)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM files WHERE project = ? AND path = ?", project, path).Scan(&previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to look up file: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO file_blobs (hash, content) VALUES (?, ?)", sum, content); err != nil {
+		return "", fmt.Errorf("failed to store file content: %w", err)
+	}
+	var same bool
+	if err := tx.QueryRowContext(ctx, "SELECT content = ? FROM file_blobs WHERE hash = ?", content, sum).Scan(&same); err != nil {
+		return "", fmt.Errorf("failed to verify file content: %w", err)
+	}
+	if !same {
+		return "", fmt.Errorf("%w: %s", ErrHashCollision, sum)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO files (project, path, hash, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(project, path) DO UPDATE SET hash = excluded.hash, updated_at = excluded.updated_at
+	`, project, path, sum, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if previous != "" && previous != sum {
+		_, err = tx.ExecContext(ctx, "DELETE FROM file_blobs WHERE hash = ? AND NOT EXISTS (SELECT 1 FROM files WHERE hash = ?)", previous, previous)
+		if err != nil {
+			return "", fmt.Errorf("failed to delete old file content: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sum, nil
+}
+
+// GetFileContent returns the content stored for project/path
+func (s *Store) GetFileContent(ctx context.Context, project, path string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var content string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT b.content FROM files f JOIN file_blobs b ON b.hash = f.hash
+		WHERE f.project = ? AND f.path = ?
+	`, project, path).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("file not found: %s", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get file content: %w", err)
+	}
+	return content, nil
+}
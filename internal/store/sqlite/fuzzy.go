@@ -0,0 +1,128 @@
+// Package sqlite matches keywords despite typos
+package sqlite
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTypos is how many edits a fuzzy match of term may need. Short words
+// match exactly; at one typo per four characters, "pars" already finds
+// "bars" and "part".
+func maxTypos(term string) int {
+	n := utf8.RuneCountInString(term)
+	if n < 4 {
+		return 0
+	}
+	return min(n/4, 3)
+}
+
+// trigrams returns the distinct three-character substrings of term
+func trigrams(term string) []string {
+	runes := []rune(term)
+	seen := make(map[string]bool)
+	var grams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		g := string(runes[i : i+3])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
+
+// wordStarts returns the lowercase rest of text from the start of every
+// word and every camelCase or snake_case part, so "parseEmbeddingStream"
+// gives "parseembeddingstream", "embeddingstream", and "stream"
+func wordStarts(text string) []string {
+	runes := []rune(text)
+	var starts []string
+	seen := make(map[string]bool)
+	isWord := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	for i := 0; i < len(runes); {
+		if !isWord(runes[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && isWord(runes[end]) {
+			end++
+		}
+		for j := i; j < end; j++ {
+			r := runes[j]
+			part := j == i ||
+				unicode.IsUpper(r) && (unicode.IsLower(runes[j-1]) || j+1 < end && unicode.IsLower(runes[j+1])) ||
+				runes[j-1] == '_' && r != '_'
+			if !part {
+				continue
+			}
+			if w := strings.ToLower(string(runes[j:end])); !seen[w] {
+				seen[w] = true
+				starts = append(starts, w)
+			}
+		}
+		i = end
+	}
+	return starts
+}
+
+// fuzzyScore scores term against the best of words within maxTypos edits
+// of a prefix of the word. A match scores below an exact one, less for
+// every edit, and 0 means no word was close enough.
+func fuzzyScore(term string, words []string) float32 {
+	limit := maxTypos(term)
+	if limit == 0 {
+		return 0
+	}
+	best := limit + 1
+	for _, w := range words {
+		if d := prefixDistance(term, w, best-1); d < best {
+			best = d
+		}
+	}
+	if best > limit {
+		return 0
+	}
+	return 0.5 * (1 - float32(best)/float32(limit+1))
+}
+
+// prefixDistance returns the Levenshtein distance between term and the
+// closest prefix of word, or limit+1 when it is more than limit
+func prefixDistance(term, word string, limit int) int {
+	a, b := []rune(term), []rune(word)
+	if len(b) > len(a)+limit {
+		b = b[:len(a)+limit]
+	}
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	// Any prefix of word will do, so take the best column of the last row
+	best := prev[0]
+	for _, d := range prev {
+		best = min(best, d)
+	}
+	return min(best, limit+1)
+}
@@ -0,0 +1,76 @@
+// Package sqlite records indexed files so reindexing skips unchanged ones
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+)
+
+// GetIndexedFile returns the record of project/path's last indexing, or
+// nil if it was never indexed
+func (s *Store) GetIndexedFile(ctx context.Context, project, path string) (*store.IndexedFile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file := &store.IndexedFile{Project: project, Path: path}
+	var modTime sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		"SELECT hash, mod_time, chunk_count FROM indexed_files WHERE project = ? AND path = ?",
+		project, path,
+	).Scan(&file.Hash, &modTime, &file.ChunkCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indexed file: %w", err)
+	}
+	file.ModTime = modTime.Time
+	return file, nil
+}
+
+// PutIndexedFile records that a file was indexed
+func (s *Store) PutIndexedFile(ctx context.Context, file store.IndexedFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO indexed_files (project, path, hash, mod_time, chunk_count, indexed_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project, path) DO UPDATE SET
+			hash = excluded.hash, mod_time = excluded.mod_time,
+			chunk_count = excluded.chunk_count, indexed_at = excluded.indexed_at
+	`, file.Project, file.Path, file.Hash, file.ModTime, file.ChunkCount, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record indexed file: %w", err)
+	}
+	return nil
+}
+
+// DeleteByFilePath removes the chunks indexing stored for project/path,
+// which carry a chunk_index in their metadata. Memories added by hand with
+// the file path have none and are kept.
+func (s *Store) DeleteByFilePath(ctx context.Context, project, path string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	err := s.retryBusy(ctx, func() error {
+		result, err := s.db.ExecContext(ctx, `
+			DELETE FROM memories
+			WHERE project = ? AND file_path = ? AND json_extract(metadata, '$.chunk_index') IS NOT NULL
+		`, project, path)
+		if err != nil {
+			return err
+		}
+		deleted, _ = result.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete chunks of %s: %w", path, err)
+	}
+	return int(deleted), nil
+}
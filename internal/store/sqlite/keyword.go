@@ -0,0 +1,180 @@
+// Package sqlite matches memories by keyword, without embeddings
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// KeywordSearch finds memories whose content or file path contains every
+// word of query, ignoring ASCII case. A word counts fully when it starts a
+// word of the memory, so a half-typed word still ranks its completions
+// first, and half when it only appears inside one. Similarity is the
+// average over the query's words, each weighted by how rare it is among
+// the memories opts selects (see termWeights), so a distinctive
+// identifier outweighs a word most memories contain. The threshold of
+// opts is ignored.
+//
+// With opts.Fuzzy, a word of four or more characters also matches the
+// start of a word or camelCase part within a few typos (see fuzzyScore).
+func (s *Store) KeywordSearch(ctx context.Context, query string, opts store.SearchOptions) ([]types.SearchResult, int, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conditions, args := searchConditions(opts)
+	weights, err := s.termWeights(ctx, terms, conditions, args)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, term := range terms {
+		// A typo leaves some of a word's trigrams intact, so candidates for
+		// a fuzzy match contain at least one of them
+		patterns := []string{term}
+		if opts.Fuzzy && maxTypos(term) > 0 {
+			patterns = trigrams(term)
+		}
+		var matches []string
+		for _, p := range patterns {
+			matches = append(matches, `content LIKE ? ESCAPE '\' OR file_path LIKE ? ESCAPE '\'`)
+			pattern := "%" + escapeLike(p) + "%"
+			args = append(args, pattern, pattern)
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// Embeddings aren't needed to rank, so skip reading them
+	query = fmt.Sprintf(`
+		SELECT id, content, project, type, file_path, language, metadata, NULL, created_at, updated_at
+		FROM memories
+		WHERE %s
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query memories: %w", err)
+	}
+	defer rows.Close()
+
+	var results []types.SearchResult
+	for rows.Next() {
+		memory, err := s.scanMemoryFromRows(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		score := keywordScore(terms, weights, memory, opts.Fuzzy)
+		if score == 0 {
+			// A trigram matched, but no word was close enough
+			continue
+		}
+		results = append(results, types.SearchResult{
+			Memory:     *memory,
+			Similarity: score,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read memories: %w", err)
+	}
+
+	total := len(results)
+	return topKResults(results, limit, s.topk), total, nil
+}
+
+// termWeights returns the inverse document frequency of each term among
+// the memories matching conditions, as BM25 computes it. A term in nearly
+// every memory, such as func or return, weighs close to 0; one in a
+// single memory of thousands weighs about 8.
+func (s *Store) termWeights(ctx context.Context, terms []string, conditions []string, args []interface{}) ([]float32, error) {
+	counts := make([]string, len(terms))
+	countArgs := make([]interface{}, 0, 2*len(terms)+len(args))
+	for i, term := range terms {
+		counts[i] = `COALESCE(SUM(content LIKE ? ESCAPE '\' OR file_path LIKE ? ESCAPE '\'), 0)`
+		pattern := "%" + escapeLike(term) + "%"
+		countArgs = append(countArgs, pattern, pattern)
+	}
+	countArgs = append(countArgs, args...)
+
+	query := fmt.Sprintf(`SELECT COUNT(*), %s FROM memories WHERE %s`,
+		strings.Join(counts, ", "), strings.Join(conditions, " AND "))
+	freqs := make([]int, len(terms)+1)
+	dest := make([]interface{}, len(freqs))
+	for i := range freqs {
+		dest[i] = &freqs[i]
+	}
+	if err := s.db.QueryRowContext(ctx, query, countArgs...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to count term frequencies: %w", err)
+	}
+
+	n := float64(freqs[0])
+	weights := make([]float32, len(terms))
+	for i := range terms {
+		df := float64(freqs[i+1])
+		weights[i] = float32(math.Log(1 + (n-df+0.5)/(df+0.5)))
+	}
+	return weights, nil
+}
+
+// keywordScore scores how well a memory matches lowercase terms, averaging
+// each term's score by weights. It is 0 when a term doesn't match at all.
+func keywordScore(terms []string, weights []float32, memory *types.Memory, fuzzy bool) float32 {
+	content := strings.ToLower(memory.Content)
+	path := strings.ToLower(memory.FilePath)
+
+	var words []string
+	var score, total float32
+	for i, term := range terms {
+		var s float32
+		switch {
+		case startsWord(content, term) || startsWord(path, term):
+			s = 1
+		case strings.Contains(content, term) || strings.Contains(path, term):
+			s = 0.5
+		case fuzzy:
+			if words == nil {
+				words = append(wordStarts(memory.Content), wordStarts(memory.FilePath)...)
+			}
+			if s = fuzzyScore(term, words); s == 0 {
+				return 0
+			}
+		default:
+			return 0
+		}
+		score += weights[i] * s
+		total += weights[i]
+	}
+	return score / total
+}
+
+// startsWord reports whether term occurs in text at the start of a word
+func startsWord(text, term string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		i += offset
+		if i == 0 || !isWordByte(text[i-1]) {
+			return true
+		}
+		offset = i + 1
+	}
+}
+
+// isWordByte reports whether c can be part of an identifier
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
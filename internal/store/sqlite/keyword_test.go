@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestStore_KeywordSearch(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	memories := []*types.Memory{
+		{ID: "a", Content: "func retryWithBackoff() {} // escapes jitter", FilePath: "net/retry.go"},
+		{ID: "b", Content: "// Retries are capped at five", FilePath: "net/client.go"},
+		{ID: "c", Content: "parse the config file", FilePath: "config/load.go"},
+		{ID: "d", Content: "100% of requests_total", FilePath: "metrics.go"},
+		{ID: "e", Content: "retry in another project", Project: "other"},
+	}
+	for _, m := range memories {
+		if m.Project == "" {
+			m.Project = "p"
+		}
+		m.Type = types.TypeContext
+		m.Embedding = generateTestEmbedding(768)
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	ids := func(query string, opts store.SearchOptions) []string {
+		t.Helper()
+		results, total, err := s.KeywordSearch(ctx, query, opts)
+		if err != nil {
+			t.Fatalf("keyword search %q failed: %v", query, err)
+		}
+		if total < len(results) {
+			t.Errorf("total %d below %d results", total, len(results))
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Memory.ID)
+		}
+		return got
+	}
+
+	// Word-start matches rank above matches inside a word, case-insensitively
+	if got := ids("CAP", store.SearchOptions{Project: "p"}); len(got) != 2 || got[0] != "b" || got[1] != "a" {
+		t.Errorf("expected [b a], got %v", got)
+	}
+	// Every word must match, in the content or the file path
+	if got := ids("retry net", store.SearchOptions{Project: "p"}); len(got) != 1 || got[0] != "a" {
+		t.Errorf("expected [a], got %v", got)
+	}
+	// LIKE wildcards match literally
+	if got := ids("0%", store.SearchOptions{Project: "p"}); len(got) != 1 || got[0] != "d" {
+		t.Errorf("expected [d], got %v", got)
+	}
+	if got := ids("s_t", store.SearchOptions{Project: "p"}); len(got) != 1 || got[0] != "d" {
+		t.Errorf("expected [d], got %v", got)
+	}
+	if got := ids("retry", store.SearchOptions{Limit: 1}); len(got) != 1 {
+		t.Errorf("expected the limit to apply, got %v", got)
+	}
+	if got := ids("   ", store.SearchOptions{}); len(got) != 0 {
+		t.Errorf("expected no results for a blank query, got %v", got)
+	}
+}
+
+func TestStore_KeywordSearch_WeightsRareTerms(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	memories := []*types.Memory{
+		// func starts a word, the identifier is only inside one
+		{ID: "common", Content: "func init() { registerHandleWebhook() }"},
+		// The identifier starts a word, func is only inside one
+		{ID: "distinctive", Content: "handleWebhook is a callback of type WebhookFunc"},
+	}
+	for i := 0; i < 8; i++ {
+		memories = append(memories, &types.Memory{ID: fmt.Sprintf("filler-%d", i), Content: fmt.Sprintf("func helper%d() error", i)})
+	}
+	for _, m := range memories {
+		m.Project = "p"
+		m.Type = types.TypeContext
+		m.Embedding = generateTestEmbedding(768)
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	// Unweighted, both would score 0.75; func is in every memory, so the
+	// identifier decides
+	results, _, err := s.KeywordSearch(ctx, "func handleWebhook", store.SearchOptions{Project: "p"})
+	if err != nil {
+		t.Fatalf("keyword search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Memory.ID != "distinctive" {
+		t.Fatalf("expected the distinctive match first, got %v", results)
+	}
+	if sim := results[0].Similarity; sim <= 0.75 || sim > 1 {
+		t.Errorf("expected a weighted score in (0.75, 1], got %f", sim)
+	}
+}
+
+func TestStore_KeywordSearch_Fuzzy(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	memories := []*types.Memory{
+		{ID: "stream", Content: "func (c *OllamaClient) parseEmbeddingStream(r io.Reader)", FilePath: "internal/embeddings/ollama.go"},
+		{ID: "retry", Content: "func retryWithBackoff(ctx context.Context) error", FilePath: "net/retry.go"},
+		{ID: "config", Content: "load_config_file reads settings", FilePath: "config/load.go"},
+	}
+	for _, m := range memories {
+		m.Project = "p"
+		m.Type = types.TypeContext
+		m.Embedding = generateTestEmbedding(768)
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	search := func(query string, fuzzy bool) []types.SearchResult {
+		t.Helper()
+		results, _, err := s.KeywordSearch(ctx, query, store.SearchOptions{Project: "p", Fuzzy: fuzzy})
+		if err != nil {
+			t.Fatalf("keyword search %q failed: %v", query, err)
+		}
+		return results
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"parseEmbeddng", "stream"},  // Dropped letter
+		{"parseEmbedidng", "stream"}, // Swapped letters
+		{"embeding", "stream"},       // camelCase part
+		{"retyrWith", "retry"},
+		{"bakoff", "retry"},
+		{"confg settings", "config"}, // snake_case part, with an exact word
+		{"olama", "stream"},          // File path
+	}
+	for _, tt := range tests {
+		if got := search(tt.query, false); len(got) != 0 {
+			t.Errorf("%q: expected no exact matches, got %d", tt.query, len(got))
+		}
+		got := search(tt.query, true)
+		if len(got) != 1 || got[0].Memory.ID != tt.want {
+			t.Errorf("%q: expected [%s], got %v", tt.query, tt.want, got)
+			continue
+		}
+		if sim := got[0].Similarity; sim <= 0 || sim >= 1 {
+			t.Errorf("%q: expected a fuzzy score below an exact match, got %f", tt.query, sim)
+		}
+	}
+
+	// Exact matches outrank fuzzy ones, and words too far off don't match
+	exact := search("parseEmbeddingStream", true)
+	fuzzy := search("parseEmbeddngStream", true)
+	if len(exact) != 1 || len(fuzzy) != 1 || fuzzy[0].Similarity >= exact[0].Similarity {
+		t.Errorf("expected the exact match to score higher, got %v and %v", exact, fuzzy)
+	}
+	if got := search("xyzEmbqqqqq", true); len(got) != 0 {
+		t.Errorf("expected no match for a distant word, got %v", got)
+	}
+	if got := search("cfg", true); len(got) != 0 {
+		t.Errorf("expected short words to match exactly, got %v", got)
+	}
+}
+
+func TestWordStarts(t *testing.T) {
+	got := wordStarts("parseEmbeddingStream(HTTPServer, max_size)")
+	want := []string{"parseembeddingstream", "embeddingstream", "stream", "httpserver", "server", "max_size", "size"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,143 @@
+// Package sqlite migrates stored embeddings to new dimensions
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrateBatch is how many memories Migrate re-embeds per transaction
+const migrateBatch = 100
+
+// Migrate re-embeds every memory with reembed and switches the store to
+// newDims, for moving to an embedding model of another size. It runs
+// online: the store stays usable, and the lock is only held to write each
+// batch, not while reembed runs. Memories already at newDims are skipped,
+// so an interrupted migration resumes where it stopped. Secondary vectors
+// such as chunk summaries can't be rebuilt from content and are dropped
+// when their size differs; reindex to restore them.
+//
+// Until Migrate returns, searches only compare the embeddings that match
+// the store's old dimensions. Memories written with the old model while it
+// runs fail the migration; run it again to pick them up.
+func (s *Store) Migrate(ctx context.Context, newDims int, reembed func(content string) ([]float32, error)) error {
+	if newDims <= 0 {
+		return fmt.Errorf("embedding dimensions must be positive, got %d", newDims)
+	}
+	size := newDims * 4
+
+	for after := ""; ; {
+		ids, contents, err := s.staleEmbeddings(ctx, after, size)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		after = ids[len(ids)-1]
+
+		embeddings := make([][]byte, len(ids))
+		for i, content := range contents {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			embedding, err := reembed(content)
+			if err != nil {
+				return fmt.Errorf("failed to re-embed memory %s: %w", ids[i], err)
+			}
+			if len(embedding) != newDims {
+				return fmt.Errorf("failed to re-embed memory %s: got %d dimensions, want %d", ids[i], len(embedding), newDims)
+			}
+			embeddings[i] = float32ToBytesAlloc(embedding)
+		}
+
+		s.mu.Lock()
+		err = s.retryBusy(ctx, func() error { return s.writeEmbeddings(ctx, ids, contents, embeddings) })
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var remaining int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM memories WHERE embedding IS NULL OR length(embedding) != ?", size).Scan(&remaining)
+	if err != nil {
+		return fmt.Errorf("failed to check migrated embeddings: %w", err)
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%d memories changed during the migration; run it again", remaining)
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM memory_vectors WHERE length(embedding) != ?", size); err != nil {
+		return fmt.Errorf("failed to drop old vectors: %w", err)
+	}
+	if err := s.saveDimensions(newDims); err != nil {
+		return err
+	}
+	s.dims = newDims
+	if s.vec {
+		return s.initVec(ctx)
+	}
+	return nil
+}
+
+// staleEmbeddings returns the next batch of memories after the given ID
+// whose embedding isn't size bytes long
+func (s *Store) staleEmbeddings(ctx context.Context, after string, size int) (ids, contents []string, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, content FROM memories
+		WHERE id > ? AND (embedding IS NULL OR length(embedding) != ?)
+		ORDER BY id
+		LIMIT ?
+	`, after, size, migrateBatch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to select memories to migrate: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		ids = append(ids, id)
+		contents = append(contents, content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to select memories to migrate: %w", err)
+	}
+	return ids, contents, nil
+}
+
+// writeEmbeddings stores re-embedded memories. A memory whose content
+// changed since it was read keeps its embedding. Callers hold s.mu.
+func (s *Store) writeEmbeddings(ctx context.Context, ids, contents []string, embeddings [][]byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE memories SET embedding = ? WHERE id = ? AND content = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, id := range ids {
+		if _, err := stmt.ExecContext(ctx, embeddings[i], id, contents[i]); err != nil {
+			return fmt.Errorf("failed to update embedding of memory %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embeddings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package sqlite looks up the chunks surrounding a memory in its file
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// neighborsQuery selects up to n indexed chunks on each side of a start
+// line in one file, nearest first on each side, and returns them in line
+// order. Blank chunks between declarations are skipped.
+const neighborsQuery = `
+	SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at
+	FROM (
+		SELECT * FROM (
+			SELECT *, CAST(json_extract(metadata, '$.start_line') AS INTEGER) AS line FROM memories
+			WHERE project = ? AND file_path = ? AND id != ?
+			  AND json_extract(metadata, '$.chunk_index') IS NOT NULL AND trim(content) != ''
+			  AND CAST(json_extract(metadata, '$.start_line') AS INTEGER) < ?
+			ORDER BY line DESC LIMIT ?
+		)
+		UNION ALL
+		SELECT * FROM (
+			SELECT *, CAST(json_extract(metadata, '$.start_line') AS INTEGER) AS line FROM memories
+			WHERE project = ? AND file_path = ? AND id != ?
+			  AND json_extract(metadata, '$.chunk_index') IS NOT NULL AND trim(content) != ''
+			  AND CAST(json_extract(metadata, '$.start_line') AS INTEGER) > ?
+			ORDER BY line ASC LIMIT ?
+		)
+	)
+	ORDER BY line
+`
+
+// NeighborChunks returns up to n indexed chunks before and n after memory
+// in its file, ordered by start line, skipping blank ones. A memory
+// without a file path or start line has none.
+func (s *Store) NeighborChunks(ctx context.Context, memory *types.Memory, n int) ([]*types.Memory, error) {
+	line, err := strconv.Atoi(memory.Metadata["start_line"])
+	if memory.FilePath == "" || err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, neighborsQuery,
+		memory.Project, memory.FilePath, memory.ID, line, n,
+		memory.Project, memory.FilePath, memory.ID, line, n,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get neighbor chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var neighbors []*types.Memory
+	for rows.Next() {
+		m, err := s.scanMemoryFromRows(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		neighbors = append(neighbors, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get neighbor chunks: %w", err)
+	}
+	return neighbors, nil
+}
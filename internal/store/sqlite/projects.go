@@ -0,0 +1,873 @@
+// Package sqlite keeps each project in its own database file
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// ProjectStores implements store.Store with one database file per project,
+// <dir>/<project>.db, so a project can be backed up or removed on its own.
+// Operations on a single project touch only its file; the rest fan out
+// across every file and merge the results. All files share one embedding
+// size.
+type ProjectStores struct {
+	dir string
+	cfg Config // template for each file; Path is unused
+
+	mu     sync.Mutex
+	stores map[string]*Store // open files by project
+	vecErr error
+}
+
+// NewProjectStores opens the project files in the directory cfg.Path,
+// creating it if missing. With zero Dimensions, they are read from the
+// first file holding embeddings, or ErrUnknownDimensions is returned if
+// none does.
+func NewProjectStores(cfg Config) (*ProjectStores, error) {
+	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create projects directory: %w", err)
+	}
+	p := &ProjectStores{dir: cfg.Path, cfg: cfg, stores: make(map[string]*Store)}
+
+	projects, err := p.projectFiles()
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.Dimensions == 0 {
+		for _, project := range projects {
+			if _, err := p.open(project); err == nil {
+				break
+			} else if !errors.Is(err, ErrUnknownDimensions) {
+				p.Close()
+				return nil, err
+			}
+		}
+		if p.cfg.Dimensions == 0 {
+			return nil, ErrUnknownDimensions
+		}
+	}
+
+	// Open the rest now so a mismatched file fails here, not mid-search
+	if _, err := p.all(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// path returns the file of project. Escaping keeps slashes and other
+// separators in project names from leaving the directory.
+func (p *ProjectStores) path(project string) string {
+	return filepath.Join(p.dir, url.PathEscape(project)+".db")
+}
+
+// projectFiles lists the projects that have a file in the directory
+func (p *ProjectStores) projectFiles() ([]string, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project databases: %w", err)
+	}
+	var projects []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".db")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if project, err := url.PathUnescape(name); err == nil {
+			projects = append(projects, project)
+		}
+	}
+	return projects, nil
+}
+
+// open returns the store for project, creating its file if needed
+func (p *ProjectStores) open(project string) (*Store, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if s, ok := p.stores[project]; ok {
+		return s, nil
+	}
+	cfg := p.cfg
+	cfg.Path = p.path(project)
+	s, err := New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database for project %s: %w", project, err)
+	}
+	if p.cfg.Dimensions == 0 {
+		p.cfg.Dimensions = s.Dimensions()
+	}
+	// Files created later follow the template the existing ones were
+	// embedded with
+	if t := s.PromptTemplate(); t != "" {
+		p.cfg.PromptTemplate = t
+	}
+	if p.vecErr == nil {
+		p.vecErr = s.VectorBackendError()
+	}
+	p.stores[project] = s
+	return s, nil
+}
+
+// lookup returns the store for project, or nil if it has no file
+func (p *ProjectStores) lookup(project string) (*Store, error) {
+	p.mu.Lock()
+	s, ok := p.stores[project]
+	p.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	if _, err := os.Stat(p.path(project)); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return p.open(project)
+}
+
+// all returns a store per project file, including files other processes
+// created since the last call
+func (p *ProjectStores) all() ([]*Store, error) {
+	projects, err := p.projectFiles()
+	if err != nil {
+		return nil, err
+	}
+	stores := make([]*Store, 0, len(projects))
+	for _, project := range projects {
+		s, err := p.open(project)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, s)
+	}
+	return stores, nil
+}
+
+// scope returns the stores an operation filtered to project reads: its
+// own, or every one when project is empty
+func (p *ProjectStores) scope(project string) ([]*Store, error) {
+	if project == "" {
+		return p.all()
+	}
+	s, err := p.lookup(project)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	return []*Store{s}, nil
+}
+
+// byProject splits memories by project, keeping each memory's index in
+// the original slice
+func byProject(memories []*types.Memory) (projects []string, groups map[string][]*types.Memory, indexes map[string][]int) {
+	groups = make(map[string][]*types.Memory)
+	indexes = make(map[string][]int)
+	for i, m := range memories {
+		if _, ok := groups[m.Project]; !ok {
+			projects = append(projects, m.Project)
+		}
+		groups[m.Project] = append(groups[m.Project], m)
+		indexes[m.Project] = append(indexes[m.Project], i)
+	}
+	return projects, groups, indexes
+}
+
+// Dimensions returns the embedding length shared by every project file
+func (p *ProjectStores) Dimensions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg.Dimensions
+}
+
+// PromptTemplate returns the prompt template the project files were
+// embedded with. If they differ, the last one opened wins.
+func (p *ProjectStores) PromptTemplate() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg.PromptTemplate
+}
+
+// VectorBackend reports how Search finds similar memories
+func (p *ProjectStores) VectorBackend() string {
+	if p.cfg.VecExtension == "" || p.VectorBackendError() != nil {
+		return BackendGo
+	}
+	return BackendVec
+}
+
+// VectorBackendError returns why Config.VecExtension couldn't be used by
+// the first file that failed to load it
+func (p *ProjectStores) VectorBackendError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.vecErr
+}
+
+// Add creates a memory in its project's file
+func (p *ProjectStores) Add(ctx context.Context, memory *types.Memory) error {
+	s, err := p.open(memory.Project)
+	if err != nil {
+		return err
+	}
+	return s.Add(ctx, memory)
+}
+
+// Get finds a memory by ID in any project
+func (p *ProjectStores) Get(ctx context.Context, id string) (*types.Memory, error) {
+	stores, err := p.all()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stores {
+		m, err := s.Get(ctx, id)
+		if !errors.Is(err, ErrNotFound) {
+			return m, err
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetBatch finds memories by ID across every project, in the order given
+func (p *ProjectStores) GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error) {
+	memories := make([]*types.Memory, len(ids))
+	stores, err := p.all()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each file is asked only for the IDs still missing
+	missing := ids
+	for _, s := range stores {
+		if len(missing) == 0 {
+			break
+		}
+		found, err := s.GetBatch(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*types.Memory, len(found))
+		for _, m := range found {
+			if m != nil {
+				byID[m.ID] = m
+			}
+		}
+		missing = nil
+		for i, id := range ids {
+			if memories[i] == nil {
+				if m, ok := byID[id]; ok {
+					memories[i] = m
+				} else {
+					missing = append(missing, id)
+				}
+			}
+		}
+	}
+	return memories, nil
+}
+
+// Update modifies a memory in its project's file
+func (p *ProjectStores) Update(ctx context.Context, memory *types.Memory) error {
+	s, err := p.lookup(memory.Project)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, memory.ID)
+	}
+	return s.Update(ctx, memory)
+}
+
+// UpdateMetadata writes a memory's type and metadata in its project's file
+func (p *ProjectStores) UpdateMetadata(ctx context.Context, memory *types.Memory) error {
+	s, err := p.lookup(memory.Project)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, memory.ID)
+	}
+	return s.UpdateMetadata(ctx, memory)
+}
+
+// UpdateWhere applies changes in every project filter matches
+func (p *ProjectStores) UpdateWhere(ctx context.Context, filter store.UpdateFilter, changes store.UpdateChanges) (int, error) {
+	stores, err := p.scope(filter.Project)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, s := range stores {
+		n, err := s.UpdateWhere(ctx, filter, changes)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Delete removes a memory by ID from whichever project holds it
+func (p *ProjectStores) Delete(ctx context.Context, id string) error {
+	stores, err := p.all()
+	if err != nil {
+		return err
+	}
+	for _, s := range stores {
+		if err := s.Delete(ctx, id); !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// DeleteBatch removes memories by ID from every project. Each project's
+// deletions are one transaction; together they are not.
+func (p *ProjectStores) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	stores, err := p.all()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, s := range stores {
+		n, err := s.DeleteBatch(ctx, ids)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// AddBatch adds memories to their projects' files, one transaction per
+// project. A failure leaves earlier projects' memories written; its
+// *BatchError indexes memories as given.
+func (p *ProjectStores) AddBatch(ctx context.Context, memories []*types.Memory, opts store.AddOptions) error {
+	projects, groups, indexes := byProject(memories)
+	for _, project := range projects {
+		s, err := p.open(project)
+		if err != nil {
+			return err
+		}
+		if err := s.AddBatch(ctx, groups[project], opts); err != nil {
+			var batchErr *store.BatchError
+			if errors.As(err, &batchErr) {
+				batchErr.Index = indexes[project][batchErr.Index]
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteByProject removes a project's memories. Its file stays, holding
+// any project metadata, and can be deleted by hand.
+func (p *ProjectStores) DeleteByProject(ctx context.Context, project string) error {
+	s, err := p.lookup(project)
+	if s == nil || err != nil {
+		return err
+	}
+	return s.DeleteByProject(ctx, project)
+}
+
+// Search finds similar memories in the project of opts, or across every
+// project at once
+func (p *ProjectStores) Search(ctx context.Context, embedding []float32, opts store.SearchOptions) ([]types.SearchResult, int, error) {
+	return p.searchAll(opts, func(s *Store) ([]types.SearchResult, int, error) {
+		return s.Search(ctx, embedding, opts)
+	})
+}
+
+// KeywordSearch finds memories containing every word of query, in the
+// project of opts or across every project at once
+func (p *ProjectStores) KeywordSearch(ctx context.Context, query string, opts store.SearchOptions) ([]types.SearchResult, int, error) {
+	return p.searchAll(opts, func(s *Store) ([]types.SearchResult, int, error) {
+		return s.KeywordSearch(ctx, query, opts)
+	})
+}
+
+// searchAll runs search on each store in scope concurrently, and merges
+// their results into the best opts.Limit
+func (p *ProjectStores) searchAll(opts store.SearchOptions, search func(*Store) ([]types.SearchResult, int, error)) ([]types.SearchResult, int, error) {
+	stores, err := p.scope(opts.Project)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(stores) == 1 {
+		return search(stores[0])
+	}
+
+	results := make([][]types.SearchResult, len(stores))
+	totals := make([]int, len(stores))
+	errs := make([]error, len(stores))
+	var wg sync.WaitGroup
+	for i, s := range stores {
+		wg.Add(1)
+		go func(i int, s *Store) {
+			defer wg.Done()
+			results[i], totals[i], errs[i] = search(s)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var merged []types.SearchResult
+	total := 0
+	for i := range stores {
+		if errs[i] != nil {
+			return nil, 0, errs[i]
+		}
+		merged = append(merged, results[i]...)
+		total += totals[i]
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Similarity != merged[j].Similarity {
+			return merged[i].Similarity > merged[j].Similarity
+		}
+		return merged[i].Memory.ID < merged[j].Memory.ID
+	})
+	if opts.Limit > 0 && len(merged) > opts.Limit {
+		merged = merged[:opts.Limit]
+	}
+	return merged, total, nil
+}
+
+// errPageFull stops a merged listing once its page is full
+var errPageFull = errors.New("page full")
+
+// List returns a page of memories from the project of opts, or from every
+// project merged in the order of opts
+func (p *ProjectStores) List(ctx context.Context, opts store.ListOptions) ([]*types.Memory, error) {
+	stores, err := p.scope(opts.Project)
+	if err != nil || len(stores) == 0 {
+		return nil, err
+	}
+	if len(stores) == 1 {
+		return stores[0].List(ctx, opts)
+	}
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	skip := opts.Offset
+	var memories []*types.Memory
+	err = mergeOrdered(ctx, stores, func(ctx context.Context, s *Store, fn func(*types.Memory) error) error {
+		return s.Iterate(ctx, opts, fn)
+	}, listLess(opts), func(m *types.Memory) error {
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		memories = append(memories, m)
+		if limit > 0 && len(memories) >= limit {
+			return errPageFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return nil, err
+	}
+	return memories, nil
+}
+
+// listLess orders memories as Iterate does for opts: by creation or update
+// time, then by ID
+func listLess(opts store.ListOptions) func(a, b *types.Memory) bool {
+	key := func(m *types.Memory) time.Time { return m.CreatedAt }
+	if opts.OrderBy == "updated_at" {
+		key = func(m *types.Memory) time.Time { return m.UpdatedAt }
+	}
+	return func(a, b *types.Memory) bool {
+		if ka, kb := key(a), key(b); !ka.Equal(kb) {
+			return ka.Before(kb) != opts.Descending
+		}
+		return a.ID < b.ID
+	}
+}
+
+// mergeOrdered runs run on every store concurrently, each yielding
+// memories in the order of less, and calls fn with them as one ordered
+// stream. It stops at the first error from fn or a store.
+func mergeOrdered(ctx context.Context, stores []*Store, run func(context.Context, *Store, func(*types.Memory) error) error, less func(a, b *types.Memory) bool, fn func(*types.Memory) error) error {
+	if len(stores) == 1 {
+		return run(ctx, stores[0], fn)
+	}
+
+	type stream struct {
+		memories chan *types.Memory
+		err      chan error
+		head     *types.Memory
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streams := make([]*stream, len(stores))
+	for i, s := range stores {
+		st := &stream{memories: make(chan *types.Memory, 64), err: make(chan error, 1)}
+		streams[i] = st
+		wg.Add(1)
+		go func(s *Store) {
+			defer wg.Done()
+			defer close(st.memories)
+			st.err <- run(ctx, s, func(m *types.Memory) error {
+				select {
+				case st.memories <- m:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}(s)
+	}
+
+	// next moves st to its following memory, leaving head nil at the end
+	next := func(st *stream) error {
+		m, ok := <-st.memories
+		st.head = m
+		if !ok {
+			return <-st.err
+		}
+		return nil
+	}
+	for _, st := range streams {
+		if err := next(st); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var min *stream
+		for _, st := range streams {
+			if st.head != nil && (min == nil || less(st.head, min.head)) {
+				min = st
+			}
+		}
+		if min == nil {
+			return nil
+		}
+		if err := fn(min.head); err != nil {
+			return err
+		}
+		if err := next(min); err != nil {
+			return err
+		}
+	}
+}
+
+// CountList counts the memories matching opts across the projects in scope
+func (p *ProjectStores) CountList(ctx context.Context, opts store.ListOptions) (int, error) {
+	stores, err := p.scope(opts.Project)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, s := range stores {
+		n, err := s.CountList(ctx, opts)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Count returns the number of memories in project, or in all of them
+func (p *ProjectStores) Count(ctx context.Context, project string) (int, error) {
+	stores, err := p.scope(project)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, s := range stores {
+		n, err := s.Count(ctx, project)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// ListProjects returns the projects of every file
+func (p *ProjectStores) ListProjects(ctx context.Context) ([]types.Project, error) {
+	stores, err := p.all()
+	if err != nil {
+		return nil, err
+	}
+	var projects []types.Project
+	for _, s := range stores {
+		list, err := s.ListProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, list...)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Key < projects[j].Key })
+	return projects, nil
+}
+
+// GetProjectMeta returns the metadata stored in project's file
+func (p *ProjectStores) GetProjectMeta(ctx context.Context, project string) (map[string]string, error) {
+	s, err := p.lookup(project)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return map[string]string{}, nil
+	}
+	return s.GetProjectMeta(ctx, project)
+}
+
+// SetProjectMeta upserts metadata in project's file
+func (p *ProjectStores) SetProjectMeta(ctx context.Context, project string, meta map[string]string) error {
+	s, err := p.open(project)
+	if err != nil {
+		return err
+	}
+	return s.SetProjectMeta(ctx, project, meta)
+}
+
+// PutFileContent stores an indexed file in its project's file
+func (p *ProjectStores) PutFileContent(ctx context.Context, project, path, content string) (string, error) {
+	s, err := p.open(project)
+	if err != nil {
+		return "", err
+	}
+	return s.PutFileContent(ctx, project, path, content)
+}
+
+// GetFileContent returns an indexed file from its project's file
+func (p *ProjectStores) GetFileContent(ctx context.Context, project, path string) (string, error) {
+	s, err := p.lookup(project)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		return "", fmt.Errorf("file not found: %s", path)
+	}
+	return s.GetFileContent(ctx, project, path)
+}
+
+// NeighborChunks returns the chunks around memory from its project's file
+func (p *ProjectStores) NeighborChunks(ctx context.Context, memory *types.Memory, n int) ([]*types.Memory, error) {
+	s, err := p.lookup(memory.Project)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	return s.NeighborChunks(ctx, memory, n)
+}
+
+// GetIndexedFile returns the index record from project's file
+func (p *ProjectStores) GetIndexedFile(ctx context.Context, project, path string) (*store.IndexedFile, error) {
+	s, err := p.lookup(project)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	return s.GetIndexedFile(ctx, project, path)
+}
+
+// PutIndexedFile records an indexed file in its project's file
+func (p *ProjectStores) PutIndexedFile(ctx context.Context, file store.IndexedFile) error {
+	s, err := p.open(file.Project)
+	if err != nil {
+		return err
+	}
+	return s.PutIndexedFile(ctx, file)
+}
+
+// DeleteByFilePath removes a file's indexed chunks from its project's file
+func (p *ProjectStores) DeleteByFilePath(ctx context.Context, project, path string) (int, error) {
+	s, err := p.lookup(project)
+	if s == nil || err != nil {
+		return 0, err
+	}
+	return s.DeleteByFilePath(ctx, project, path)
+}
+
+// Stats sums the statistics of every file
+func (p *ProjectStores) Stats(ctx context.Context) (*types.StatsResponse, error) {
+	stores, err := p.all()
+	if err != nil {
+		return nil, err
+	}
+	stats := &types.StatsResponse{
+		MemoriesByType:     make(map[string]int),
+		MemoriesByLanguage: make(map[string]int),
+	}
+	for _, s := range stores {
+		st, err := s.Stats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stats.TotalMemories += st.TotalMemories
+		stats.ProjectCount += st.ProjectCount
+		stats.StorageBytes += st.StorageBytes
+		for k, n := range st.MemoriesByType {
+			stats.MemoriesByType[k] += n
+		}
+		for k, n := range st.MemoriesByLanguage {
+			stats.MemoriesByLanguage[k] += n
+		}
+	}
+	return stats, nil
+}
+
+// Ping checks every open file
+func (p *ProjectStores) Ping(ctx context.Context) error {
+	if _, err := os.Stat(p.dir); err != nil {
+		return fmt.Errorf("failed to read projects directory: %w", err)
+	}
+	for _, s := range p.opened() {
+		if err := s.Ping(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// opened returns the stores opened so far
+func (p *ProjectStores) opened() []*Store {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stores := make([]*Store, 0, len(p.stores))
+	for _, s := range p.stores {
+		stores = append(stores, s)
+	}
+	return stores
+}
+
+// Close closes every open file
+func (p *ProjectStores) Close() error {
+	var first error
+	for _, s := range p.opened() {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Compact vacuums every file
+func (p *ProjectStores) Compact(ctx context.Context) error {
+	stores, err := p.all()
+	if err != nil {
+		return err
+	}
+	for _, s := range stores {
+		if err := s.Compact(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checkpoint flushes the write-ahead log of every file
+func (p *ProjectStores) Checkpoint(ctx context.Context, mode string) error {
+	stores, err := p.all()
+	if err != nil {
+		return err
+	}
+	for _, s := range stores {
+		if err := s.Checkpoint(ctx, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export streams memories in ID order across the projects in scope
+func (p *ProjectStores) Export(ctx context.Context, opts store.ExportOptions, fn func(*types.Memory) error) error {
+	stores, err := p.scope(opts.Project)
+	if err != nil || len(stores) == 0 {
+		return err
+	}
+	return mergeOrdered(ctx, stores, func(ctx context.Context, s *Store, fn func(*types.Memory) error) error {
+		return s.Export(ctx, opts, fn)
+	}, func(a, b *types.Memory) bool { return a.ID < b.ID }, fn)
+}
+
+// Iterate calls fn for the memories matching opts across the projects in
+// scope, merged in the order of opts
+func (p *ProjectStores) Iterate(ctx context.Context, opts store.ListOptions, fn func(*types.Memory) error) error {
+	stores, err := p.scope(opts.Project)
+	if err != nil || len(stores) == 0 {
+		return err
+	}
+	return mergeOrdered(ctx, stores, func(ctx context.Context, s *Store, fn func(*types.Memory) error) error {
+		return s.Iterate(ctx, opts, fn)
+	}, listLess(opts), fn)
+}
+
+// Import inserts memories into their projects' files. Duplicates are
+// found within a project's file, so an ID already used by another project
+// is not one.
+func (p *ProjectStores) Import(ctx context.Context, memories []*types.Memory, opts store.ImportOptions) (*store.ImportResult, error) {
+	total := &store.ImportResult{}
+	projects, groups, _ := byProject(memories)
+	for _, project := range projects {
+		s, err := p.open(project)
+		if err != nil {
+			return total, err
+		}
+		r, err := s.Import(ctx, groups[project], opts)
+		if r != nil {
+			total.Inserted += r.Inserted
+			total.Skipped += r.Skipped
+			total.Merged += r.Merged
+			total.Replaced += r.Replaced
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// MigrateProjects runs Store.Migrate on every project file in the
+// directory cfg.Path, one at a time. Each file is opened at the dimensions
+// it holds, so a migration interrupted between files resumes. Files whose
+// embeddings all change size are recorded as embedded with
+// cfg.PromptTemplate.
+func MigrateProjects(ctx context.Context, cfg Config, newDims int, reembed func(content string) ([]float32, error)) error {
+	p := &ProjectStores{dir: cfg.Path}
+	projects, err := p.projectFiles()
+	if err != nil {
+		return err
+	}
+	for _, project := range projects {
+		fileCfg := cfg
+		fileCfg.Path = p.path(project)
+		fileCfg.Dimensions = 0
+		s, err := New(fileCfg)
+		if errors.Is(err, ErrUnknownDimensions) {
+			// Nothing embedded yet; it takes newDims from its first memory
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open database for project %s: %w", project, err)
+		}
+		from := s.Dimensions()
+		err = s.Migrate(ctx, newDims, reembed)
+		if err == nil && from != newDims && cfg.PromptTemplate != "" {
+			// Every embedding was remade, with the current template
+			err = s.SetPromptTemplate(cfg.PromptTemplate)
+		}
+		s.Close()
+		if err != nil {
+			return fmt.Errorf("failed to migrate project %s: %w", project, err)
+		}
+	}
+	return nil
+}
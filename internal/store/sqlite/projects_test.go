@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// projectMemory returns a memory whose embedding points along axis
+func projectMemory(id, project string, axis int, created time.Time) *types.Memory {
+	embedding := make([]float32, 4)
+	embedding[axis] = 1
+	return &types.Memory{
+		ID:        id,
+		Content:   "memory " + id,
+		Project:   project,
+		Type:      types.TypeContext,
+		Embedding: embedding,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func createTestProjectStores(t *testing.T) (*ProjectStores, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "projects")
+	p, err := NewProjectStores(Config{Path: dir, Dimensions: 4})
+	if err != nil {
+		t.Fatalf("failed to create project stores: %v", err)
+	}
+	return p, dir
+}
+
+func TestProjectStores_RoutesByProject(t *testing.T) {
+	p, dir := createTestProjectStores(t)
+	defer p.Close()
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := p.Add(ctx, projectMemory("a1", "alpha", 0, now)); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	err := p.AddBatch(ctx, []*types.Memory{
+		projectMemory("b1", "team/beta", 1, now),
+		projectMemory("a2", "alpha", 1, now),
+	}, store.AddOptions{})
+	if err != nil {
+		t.Fatalf("failed to add batch: %v", err)
+	}
+
+	// Slashes in a project name stay inside the directory
+	for _, name := range []string{"alpha.db", "team%2Fbeta.db"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+	if n, _ := p.Count(ctx, "alpha"); n != 2 {
+		t.Errorf("expected 2 memories in alpha, got %d", n)
+	}
+	if n, _ := p.Count(ctx, ""); n != 3 {
+		t.Errorf("expected 3 memories in total, got %d", n)
+	}
+	if n, _ := p.Count(ctx, "gamma"); n != 0 {
+		t.Errorf("expected no memories in a missing project, got %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gamma.db")); !os.IsNotExist(err) {
+		t.Error("expected reading a missing project not to create its file")
+	}
+
+	// Get and Delete find a memory in whichever file holds it
+	m, err := p.Get(ctx, "b1")
+	if err != nil || m.Project != "team/beta" {
+		t.Fatalf("expected b1 from team/beta, got %v, %v", m, err)
+	}
+	batch, err := p.GetBatch(ctx, []string{"b1", "gone", "a1"})
+	if err != nil || len(batch) != 3 || batch[0].ID != "b1" || batch[1] != nil || batch[2].ID != "a1" {
+		t.Fatalf("expected b1, nil, a1 across projects, got %v, %v", batch, err)
+	}
+	if err := p.Delete(ctx, "b1"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if _, err := p.Get(ctx, "b1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := p.Delete(ctx, "b1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// A conflict reports its index in the batch as given
+	err = p.AddBatch(ctx, []*types.Memory{
+		projectMemory("c1", "gamma", 0, now),
+		projectMemory("a1", "alpha", 0, now),
+	}, store.AddOptions{})
+	var batchErr *store.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Errorf("expected a conflict at index 1, got %v", err)
+	}
+}
+
+func TestProjectStores_SearchAcrossProjects(t *testing.T) {
+	p, _ := createTestProjectStores(t)
+	defer p.Close()
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, m := range []*types.Memory{
+		projectMemory("a1", "alpha", 0, now),
+		projectMemory("a2", "alpha", 2, now),
+		projectMemory("b1", "beta", 0, now),
+		projectMemory("b2", "beta", 1, now),
+	} {
+		if err := p.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add: %v", err)
+		}
+	}
+
+	query := []float32{1, 0.5, 0, 0}
+	results, total, err := p.Search(ctx, query, store.SearchOptions{Limit: 3})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if total != 4 || len(results) != 3 {
+		t.Fatalf("expected 3 of 4 results, got %d of %d", len(results), total)
+	}
+	for i, want := range []string{"a1", "b1", "b2"} {
+		if results[i].Memory.ID != want {
+			t.Errorf("result %d: expected %s, got %s", i, want, results[i].Memory.ID)
+		}
+	}
+
+	results, _, err = p.Search(ctx, query, store.SearchOptions{Project: "beta", Limit: 10})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Memory.Project != "beta" || results[1].Memory.Project != "beta" {
+		t.Errorf("expected only beta's memories, got %+v", results)
+	}
+}
+
+func TestProjectStores_ListOrder(t *testing.T) {
+	p, _ := createTestProjectStores(t)
+	defer p.Close()
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour)
+	ids := []string{"m0", "m1", "m2", "m3", "m4"}
+	projects := []string{"alpha", "beta", "alpha", "gamma", "beta"}
+	for i, id := range ids {
+		if err := p.Add(ctx, projectMemory(id, projects[i], 0, start.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatalf("failed to add: %v", err)
+		}
+	}
+
+	page, err := p.List(ctx, store.ListOptions{Limit: 2, Offset: 1, Descending: true})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != "m3" || page[1].ID != "m2" {
+		t.Errorf("expected m3, m2, got %v", page)
+	}
+
+	var exported []string
+	err = p.Export(ctx, store.ExportOptions{}, func(m *types.Memory) error {
+		exported = append(exported, m.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(exported) != len(ids) {
+		t.Fatalf("expected %d memories, got %v", len(ids), exported)
+	}
+	for i, id := range ids {
+		if exported[i] != id {
+			t.Errorf("expected export in ID order, got %v", exported)
+			break
+		}
+	}
+}
+
+func TestProjectStores_LearnsDimensions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "projects")
+	if _, err := NewProjectStores(Config{Path: dir}); !errors.Is(err, ErrUnknownDimensions) {
+		t.Fatalf("expected ErrUnknownDimensions for an empty directory, got %v", err)
+	}
+
+	p, err := NewProjectStores(Config{Path: dir, Dimensions: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Add(context.Background(), projectMemory("a1", "alpha", 0, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	p, err = NewProjectStores(Config{Path: dir})
+	if err != nil {
+		t.Fatalf("failed to reopen: %v", err)
+	}
+	defer p.Close()
+	if d := p.Dimensions(); d != 4 {
+		t.Errorf("expected 4 dimensions from the existing file, got %d", d)
+	}
+
+	if _, err := NewProjectStores(Config{Path: dir, Dimensions: 8}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Package sqlite retries writes that fail on a busy database
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Defaults for Config's busy handling
+const (
+	DefaultBusyTimeout = 5 * time.Second
+	DefaultBusyRetries = 3
+	DefaultBusyBackoff = 50 * time.Millisecond
+)
+
+// isBusy reports whether err is SQLITE_BUSY or SQLITE_LOCKED, which can
+// outlast busy_timeout while another connection checkpoints the WAL.
+// Anything else is treated as permanent.
+func isBusy(err error) bool {
+	var se sqlite3.Error
+	if !errors.As(err, &se) {
+		return false
+	}
+	return se.Code == sqlite3.ErrBusy || se.Code == sqlite3.ErrLocked
+}
+
+// retryBusy runs fn, retrying it with exponential backoff while it fails
+// with a busy error. fn must be safe to run again, e.g. a whole transaction.
+func (s *Store) retryBusy(ctx context.Context, fn func() error) error {
+	backoff := s.busyBackoff
+	err := fn()
+	for attempt := 0; attempt < s.busyRetries && isBusy(err); attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
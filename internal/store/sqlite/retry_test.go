@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// lockDatabase holds the write lock on path from another connection until
+// the returned function is called
+func lockDatabase(t *testing.T, path string) func() {
+	t.Helper()
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open second connection: %v", err)
+	}
+	tx, err := other.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO project_meta (project, key, value) VALUES ('lock', 'k', 'v')"); err != nil {
+		t.Fatalf("failed to take write lock: %v", err)
+	}
+	return func() {
+		tx.Rollback()
+		other.Close()
+	}
+}
+
+func TestStore_RetriesBusyWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	newStore := func(retries int) *Store {
+		s, err := New(Config{
+			Path:        path,
+			Dimensions:  768,
+			BusyTimeout: time.Millisecond,
+			BusyRetries: retries,
+			BusyBackoff: 20 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	ctx := context.Background()
+	memory := func(id string) *types.Memory {
+		return &types.Memory{ID: id, Content: id, Project: "p", Type: types.TypeContext, Embedding: generateTestEmbedding(768)}
+	}
+
+	// Without retries the write fails while another connection holds the lock
+	noRetry := newStore(-1)
+	unlock := lockDatabase(t, path)
+	err := noRetry.Add(ctx, memory("a"))
+	unlock()
+	if !isBusy(err) {
+		t.Fatalf("expected a busy error, got %v", err)
+	}
+
+	// With retries it succeeds once the lock is released
+	s := newStore(5)
+	unlock = lockDatabase(t, path)
+	time.AfterFunc(100*time.Millisecond, unlock)
+	if err := s.Add(ctx, memory("b")); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if _, err := s.Get(ctx, "b"); err != nil {
+		t.Errorf("memory not stored: %v", err)
+	}
+}
+
+func TestIsBusy(t *testing.T) {
+	if !isBusy(sqlite3.Error{Code: sqlite3.ErrBusy}) || !isBusy(sqlite3.Error{Code: sqlite3.ErrLocked}) {
+		t.Error("expected SQLITE_BUSY and SQLITE_LOCKED to be transient")
+	}
+	if isBusy(sqlite3.Error{Code: sqlite3.ErrConstraint}) || isBusy(errors.New("busy")) {
+		t.Error("expected other errors to be permanent")
+	}
+}
@@ -5,45 +5,128 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/shivavenkatesh/moneta/internal/hash"
 	"github.com/shivavenkatesh/moneta/internal/store"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrNotFound is returned for an ID that matches no memory
+var ErrNotFound = errors.New("memory not found")
+
 // Store implements store.Store using SQLite with sqlite-vec extension
 type Store struct {
 	db   *sql.DB
 	path string
 	dims int // embedding dimensions
 	mu   sync.RWMutex
+
+	template string // prompt template the embeddings were made with
+
+	busyRetries int
+	busyBackoff time.Duration
+
+	checkpointEvery int // rows between automatic checkpoints (<= 0 disables)
+	sinceCheckpoint int // rows written by AddBatch since the last one
+
+	topk TopKConfig // strategy switch points for ranking search results
+
+	vec    bool  // search through the sqlite-vec extension
+	vecErr error // why Config.VecExtension couldn't be loaded
 }
 
 // Config configures the SQLite store
 type Config struct {
-	Path       string // Path to database file
-	Dimensions int    // Embedding dimensions (e.g., 768 for nomic-embed-text)
+	Path string // Path to database file
+
+	// Dimensions is the embedding length (e.g. 768 for nomic-embed-text).
+	// The first value a database is opened with is saved in it, and a
+	// different value is refused later. Zero uses the saved value; New
+	// returns ErrUnknownDimensions when there is none yet.
+	Dimensions int
+
+	// BusyTimeout is how long SQLite waits for a lock (default 5s).
+	// Writes that still fail with SQLITE_BUSY or SQLITE_LOCKED are retried
+	// BusyRetries more times (default 3, negative disables), waiting
+	// BusyBackoff (default 50ms) before the first retry and doubling it.
+	BusyTimeout time.Duration
+	BusyRetries int
+	BusyBackoff time.Duration
+
+	// CheckpointEvery is how many rows AddBatch writes between automatic
+	// WAL checkpoints (default DefaultCheckpointEvery, negative disables)
+	CheckpointEvery int
+
+	// TopK sets when search ranking switches between selection, a heap,
+	// and a full sort. Zero fields use DefaultTopKConfig; CalibrateTopK
+	// measures values for the current host.
+	TopK TopKConfig
+
+	// VecExtension is the path of the sqlite-vec loadable extension. When
+	// it loads, Search finds nearest neighbours through a vec0 table
+	// instead of comparing every embedding in Go. When it doesn't, New
+	// falls back to Go and VectorBackendError says why.
+	VecExtension string
+
+	// PromptTemplate names the prompt template embeddings are made with.
+	// A new database records it and keeps it; one that held embeddings
+	// before templates were recorded reports NoPromptTemplate.
+	PromptTemplate string
 }
 
 // New creates a new SQLite store
 func New(cfg Config) (*Store, error) {
+	if cfg.Dimensions < 0 {
+		return nil, fmt.Errorf("embedding dimensions must be positive, got %d", cfg.Dimensions)
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(cfg.Path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	// Open database with sqlite-vec extension
-	db, err := sql.Open("sqlite3", cfg.Path+"?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000")
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+	if cfg.BusyTimeout <= 0 {
+		cfg.BusyTimeout = DefaultBusyTimeout
+	}
+	if cfg.BusyRetries == 0 {
+		cfg.BusyRetries = DefaultBusyRetries
+	}
+	if cfg.BusyBackoff <= 0 {
+		cfg.BusyBackoff = DefaultBusyBackoff
+	}
+	if cfg.CheckpointEvery == 0 {
+		cfg.CheckpointEvery = DefaultCheckpointEvery
+	}
+	if cfg.TopK.SelectMaxK == 0 {
+		cfg.TopK.SelectMaxK = DefaultTopKConfig().SelectMaxK
+	}
+	if cfg.TopK.SortRatio <= 0 {
+		cfg.TopK.SortRatio = DefaultTopKConfig().SortRatio
+	}
+
+	// Open database, with the sqlite-vec extension if it loads
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=%d", cfg.Path, cfg.BusyTimeout.Milliseconds())
+	var db *sql.DB
+	var vecErr error
+	if cfg.VecExtension != "" {
+		db, vecErr = openVec(dsn, cfg.VecExtension)
+	}
+	if db == nil {
+		var err error
+		if db, err = sql.Open("sqlite3", dsn); err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
 	}
 
 	// Set pragmas for performance
@@ -63,9 +146,15 @@ func New(cfg Config) (*Store, error) {
 	}
 
 	s := &Store{
-		db:   db,
-		path: cfg.Path,
-		dims: cfg.Dimensions,
+		db:              db,
+		path:            cfg.Path,
+		dims:            cfg.Dimensions,
+		busyRetries:     cfg.BusyRetries,
+		busyBackoff:     cfg.BusyBackoff,
+		checkpointEvery: cfg.CheckpointEvery,
+		topk:            cfg.TopK,
+		vec:             cfg.VecExtension != "" && vecErr == nil,
+		vecErr:          vecErr,
 	}
 
 	// Initialize schema
@@ -73,6 +162,22 @@ func New(cfg Config) (*Store, error) {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
+	dims, err := s.resolveDimensions(cfg.Dimensions)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.dims = dims
+	if s.template, err = s.resolvePromptTemplate(cfg.PromptTemplate); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if s.vec {
+		if err := s.initVec(context.Background()); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
 
 	return s, nil
 }
@@ -100,6 +205,60 @@ func (s *Store) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_memories_file_path ON memories(file_path);
 	CREATE INDEX IF NOT EXISTS idx_memories_created_at ON memories(created_at);
 
+	-- Additional embeddings per memory, keyed by space (e.g. "summary")
+	CREATE TABLE IF NOT EXISTS memory_vectors (
+		memory_id TEXT NOT NULL,
+		space TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		PRIMARY KEY (memory_id, space)
+	);
+
+	CREATE TRIGGER IF NOT EXISTS memories_delete_vectors AFTER DELETE ON memories
+	BEGIN
+		DELETE FROM memory_vectors WHERE memory_id = old.id;
+	END;
+
+	-- Full content of indexed files, deduplicated by hash in file_blobs
+	CREATE TABLE IF NOT EXISTS files (
+		project TEXT NOT NULL,
+		path TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (project, path)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_files_hash ON files(hash);
+
+	CREATE TABLE IF NOT EXISTS file_blobs (
+		hash TEXT PRIMARY KEY,
+		content TEXT NOT NULL
+	);
+
+	-- The version of each file last indexed, so unchanged files are skipped
+	CREATE TABLE IF NOT EXISTS indexed_files (
+		project TEXT NOT NULL,
+		path TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		mod_time DATETIME,
+		chunk_count INTEGER NOT NULL DEFAULT 0,
+		indexed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (project, path)
+	);
+
+	-- Per-project key-value metadata (display name, description, ...)
+	CREATE TABLE IF NOT EXISTS project_meta (
+		project TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (project, key)
+	);
+
+	-- Store-wide settings, such as the embedding dimensions
+	CREATE TABLE IF NOT EXISTS store_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
 	-- Schema version tracking
 	CREATE TABLE IF NOT EXISTS schema_version (
 		version INTEGER PRIMARY KEY,
@@ -108,17 +267,80 @@ func (s *Store) initSchema() error {
 
 	-- Insert initial version if not exists
 	INSERT OR IGNORE INTO schema_version (version) VALUES (1);
+
+	-- Version 2 records the embedding dimensions in store_meta
+	INSERT OR IGNORE INTO schema_version (version) VALUES (2);
 	`
 
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	return s.migrateContentHash()
+}
+
+// migrateContentHash adds the content_hash column, used to find duplicate
+// content on import, to databases created before it existed
+func (s *Store) migrateContentHash() error {
+	var exists int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'content_hash'").Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to inspect memories table: %w", err)
+	}
+	if exists == 0 {
+		if _, err := s.db.Exec("ALTER TABLE memories ADD COLUMN content_hash TEXT"); err != nil {
+			return fmt.Errorf("failed to add content_hash column: %w", err)
+		}
+		if err := s.backfillContentHash(); err != nil {
+			return err
+		}
+	}
+	_, err = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_memories_project_hash ON memories(project, content_hash)")
 	return err
 }
 
+// backfillContentHash hashes the content of every existing memory
+func (s *Store) backfillContentHash() error {
+	rows, err := s.db.Query("SELECT id, content FROM memories")
+	if err != nil {
+		return fmt.Errorf("failed to read memories: %w", err)
+	}
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan memory: %w", err)
+		}
+		hashes[id] = hash.Identity(content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read memories: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for id, sum := range hashes {
+		if _, err := tx.Exec("UPDATE memories SET content_hash = ? WHERE id = ?", sum, id); err != nil {
+			return fmt.Errorf("failed to backfill content hash: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
 // Add creates a new memory
 func (s *Store) Add(ctx context.Context, memory *types.Memory) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.retryBusy(ctx, func() error { return s.add(ctx, memory) })
+}
+
+// add inserts a memory. Callers hold s.mu.
+func (s *Store) add(ctx context.Context, memory *types.Memory) error {
 	metadata, err := json.Marshal(memory.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
@@ -127,8 +349,8 @@ func (s *Store) Add(ctx context.Context, memory *types.Memory) error {
 	embedding := float32ToBytes(memory.Embedding)
 
 	query := `
-		INSERT INTO memories (id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO memories (id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at, content_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
@@ -148,6 +370,7 @@ func (s *Store) Add(ctx context.Context, memory *types.Memory) error {
 		embedding,
 		memory.CreatedAt,
 		memory.UpdatedAt,
+		hash.Identity(memory.Content),
 	)
 
 	if err != nil {
@@ -171,11 +394,69 @@ func (s *Store) Get(ctx context.Context, id string) (*types.Memory, error) {
 	return s.scanMemory(row)
 }
 
+// GetBatch retrieves memories by ID in the order given, with nil entries
+// for unknown IDs
+func (s *Store) GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error) {
+	memories := make([]*types.Memory, len(ids))
+	if len(ids) == 0 {
+		return memories, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]*types.Memory, len(ids))
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at
+			FROM memories WHERE id IN (`+placeholders+`)
+		`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memories: %w", err)
+		}
+		for rows.Next() {
+			memory, err := s.scanMemoryFromRows(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan memory: %w", err)
+			}
+			found[memory.ID] = memory
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memories: %w", err)
+		}
+	}
+
+	for i, id := range ids {
+		memories[i] = found[id]
+	}
+	return memories, nil
+}
+
 // Update modifies an existing memory
 func (s *Store) Update(ctx context.Context, memory *types.Memory) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.retryBusy(ctx, func() error { return s.update(ctx, memory) })
+}
+
+// update writes a memory and its summary embedding. Callers hold s.mu.
+func (s *Store) update(ctx context.Context, memory *types.Memory) error {
 	metadata, err := json.Marshal(memory.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
@@ -187,11 +468,17 @@ func (s *Store) Update(ctx context.Context, memory *types.Memory) error {
 	query := `
 		UPDATE memories
 		SET content = ?, project = ?, type = ?, file_path = ?, language = ?,
-		    metadata = ?, embedding = ?, updated_at = ?
+		    metadata = ?, embedding = ?, updated_at = ?, content_hash = ?
 		WHERE id = ?
 	`
 
-	result, err := s.db.ExecContext(ctx, query,
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query,
 		memory.Content,
 		memory.Project,
 		string(memory.Type),
@@ -200,6 +487,7 @@ func (s *Store) Update(ctx context.Context, memory *types.Memory) error {
 		string(metadata),
 		embedding,
 		memory.UpdatedAt,
+		hash.Identity(memory.Content),
 		memory.ID,
 	)
 
@@ -209,17 +497,165 @@ func (s *Store) Update(ctx context.Context, memory *types.Memory) error {
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("memory not found: %s", memory.ID)
+		return fmt.Errorf("%w: %s", ErrNotFound, memory.ID)
+	}
+
+	// A summary of the old content would no longer match it
+	if len(memory.SummaryEmbedding) > 0 {
+		_, err = tx.ExecContext(ctx, insertVectorQuery, memory.ID, spaceSummary, float32ToBytes(memory.SummaryEmbedding))
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM memory_vectors WHERE memory_id = ? AND space = ?", memory.ID, spaceSummary)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update summary embedding: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UpdateMetadata writes only the type and metadata of an existing memory
+func (s *Store) UpdateMetadata(ctx context.Context, memory *types.Memory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, err := json.Marshal(memory.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	memory.UpdatedAt = time.Now()
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE memories SET type = ?, metadata = ?, updated_at = ? WHERE id = ?",
+		string(memory.Type),
+		string(metadata),
+		memory.UpdatedAt,
+		memory.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, memory.ID)
 	}
 
 	return nil
 }
 
+// UpdateWhere retypes or tags every memory matching filter in one
+// transaction. Memories already in the target state are not counted.
+func (s *Store) UpdateWhere(ctx context.Context, filter store.UpdateFilter, changes store.UpdateChanges) (int, error) {
+	for _, tag := range changes.AddTags {
+		if tag == "" || strings.Contains(tag, ",") {
+			return 0, fmt.Errorf("invalid tag %q: tags must be non-empty and contain no commas", tag)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conditions, args := listConditions(store.ListOptions{
+		Project:        filter.Project,
+		Type:           filter.Type,
+		Language:       filter.Language,
+		FilePathPrefix: filter.FilePathPrefix,
+	})
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("SELECT id, type, metadata FROM memories WHERE %s", strings.Join(conditions, " AND "))
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select memories: %w", err)
+	}
+
+	// Work out every change before writing, since rows holds the connection
+	type update struct {
+		id, memType, metadata string
+	}
+	var updates []update
+	for rows.Next() {
+		var id, memType string
+		var metadataJSON sql.NullString
+		if err := rows.Scan(&id, &memType, &metadataJSON); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan memory: %w", err)
+		}
+
+		var metadata map[string]string
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			json.Unmarshal([]byte(metadataJSON.String), &metadata)
+		}
+
+		changed := false
+		if changes.Type != "" && memType != string(changes.Type) {
+			memType = string(changes.Type)
+			changed = true
+		}
+		if len(changes.AddTags) > 0 {
+			if tags := store.MergeTags(metadata[store.TagsKey], changes.AddTags...); tags != metadata[store.TagsKey] {
+				if metadata == nil {
+					metadata = make(map[string]string)
+				}
+				metadata[store.TagsKey] = tags
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		updates = append(updates, update{id, memType, string(encoded)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to select memories: %w", err)
+	}
+
+	if changes.DryRun || len(updates) == 0 {
+		return len(updates), nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE memories SET type = ?, metadata = ?, updated_at = ? WHERE id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, u := range updates {
+		if _, err := stmt.ExecContext(ctx, u.memType, u.metadata, now, u.id); err != nil {
+			return 0, fmt.Errorf("failed to update memory %s: %w", u.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit updates: %w", err)
+	}
+	return len(updates), nil
+}
+
 // Delete removes a memory by ID
 func (s *Store) Delete(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.retryBusy(ctx, func() error { return s.deleteOne(ctx, id) })
+}
+
+// deleteOne deletes a memory. Callers hold s.mu.
+func (s *Store) deleteOne(ctx context.Context, id string) error {
 	result, err := s.db.ExecContext(ctx, "DELETE FROM memories WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete memory: %w", err)
@@ -227,37 +663,105 @@ func (s *Store) Delete(ctx context.Context, id string) error {
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("memory not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
 	return nil
 }
 
+// deleteBatchSize bounds the number of bound parameters per DELETE, and
+// per SELECT in GetBatch
+const deleteBatchSize = 500
+
+// DeleteBatch removes memories by ID in a single transaction
+func (s *Store) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM memories WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete memories: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
 // AddBatch adds multiple memories efficiently
-func (s *Store) AddBatch(ctx context.Context, memories []*types.Memory) error {
+func (s *Store) AddBatch(ctx context.Context, memories []*types.Memory, opts store.AddOptions) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.retryBusy(ctx, func() error { return s.addBatch(ctx, memories, opts) }); err != nil {
+		return err
+	}
+	s.countWrites(ctx, len(memories))
+	return nil
+}
+
+// addBatch inserts memories in one transaction. Callers hold s.mu.
+func (s *Store) addBatch(ctx context.Context, memories []*types.Memory, opts store.AddOptions) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO memories (id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	query := `
+		INSERT INTO memories (id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at, content_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if opts.SkipExisting {
+		query += " ON CONFLICT(id) DO NOTHING"
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
+	vectorStmt, err := tx.PrepareContext(ctx, insertVectorQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer vectorStmt.Close()
+
 	now := time.Now()
-	for _, memory := range memories {
+	for i, memory := range memories {
 		metadata, err := json.Marshal(memory.Metadata)
 		if err != nil {
-			return fmt.Errorf("failed to marshal metadata: %w", err)
+			return &store.BatchError{ID: memory.ID, Index: i, Err: fmt.Errorf("failed to marshal metadata: %w", err)}
 		}
 
 		embedding := float32ToBytes(memory.Embedding)
@@ -267,7 +771,7 @@ func (s *Store) AddBatch(ctx context.Context, memories []*types.Memory) error {
 		}
 		memory.UpdatedAt = now
 
-		_, err = stmt.ExecContext(ctx,
+		result, err := stmt.ExecContext(ctx,
 			memory.ID,
 			memory.Content,
 			memory.Project,
@@ -278,88 +782,122 @@ func (s *Store) AddBatch(ctx context.Context, memories []*types.Memory) error {
 			embedding,
 			memory.CreatedAt,
 			memory.UpdatedAt,
+			hash.Identity(memory.Content),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert memory %s: %w", memory.ID, err)
+			return &store.BatchError{ID: memory.ID, Index: i, Err: fmt.Errorf("failed to insert memory: %w", err)}
+		}
+
+		// Skipped rows keep their existing vectors
+		if inserted, _ := result.RowsAffected(); inserted > 0 && len(memory.SummaryEmbedding) > 0 {
+			if _, err := vectorStmt.ExecContext(ctx, memory.ID, spaceSummary, float32ToBytes(memory.SummaryEmbedding)); err != nil {
+				return &store.BatchError{ID: memory.ID, Index: i, Err: fmt.Errorf("failed to insert summary embedding: %w", err)}
+			}
 		}
 	}
 
 	return tx.Commit()
 }
 
+// spaceSummary is the memory_vectors space holding summary embeddings
+const spaceSummary = "summary"
+
+// insertVectorQuery stores an additional embedding for a memory
+const insertVectorQuery = "INSERT OR REPLACE INTO memory_vectors (memory_id, space, embedding) VALUES (?, ?, ?)"
+
 // DeleteByProject removes all memories for a project
 func (s *Store) DeleteByProject(ctx context.Context, project string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.ExecContext(ctx, "DELETE FROM memories WHERE project = ?", project)
+	return s.retryBusy(ctx, func() error { return s.deleteByProject(ctx, project) })
+}
+
+// deleteByProject deletes a project's memories, files, and index records.
+// Callers hold s.mu.
+func (s *Store) deleteByProject(ctx context.Context, project string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM memories WHERE project = ?", project); err != nil {
 		return fmt.Errorf("failed to delete memories for project: %w", err)
 	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM files WHERE project = ?", project); err != nil {
+		return fmt.Errorf("failed to delete files for project: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM indexed_files WHERE project = ?", project); err != nil {
+		return fmt.Errorf("failed to delete indexed files for project: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM file_blobs WHERE hash NOT IN (SELECT hash FROM files)"); err != nil {
+		return fmt.Errorf("failed to delete file contents: %w", err)
+	}
 
-	return nil
+	return tx.Commit()
 }
 
 // Search finds similar memories using vector search
-func (s *Store) Search(ctx context.Context, embedding []float32, opts store.SearchOptions) ([]types.SearchResult, error) {
+func (s *Store) Search(ctx context.Context, embedding []float32, opts store.SearchOptions) ([]types.SearchResult, int, error) {
+	useVec := s.vec && len(embedding) == s.dims
+	if useVec {
+		if err := s.syncVec(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Build query with filters
-	conditions := []string{"1=1"}
-	args := []interface{}{}
-
-	if opts.Project != "" {
-		conditions = append(conditions, "project = ?")
-		args = append(args, opts.Project)
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
 	}
 
-	if len(opts.Types) > 0 {
-		placeholders := make([]string, len(opts.Types))
-		for i, t := range opts.Types {
-			placeholders[i] = "?"
-			args = append(args, string(t))
+	if useVec {
+		results, total, ok, err := s.searchVec(ctx, embedding, opts, limit)
+		if err != nil {
+			return nil, 0, err
 		}
-		conditions = append(conditions, fmt.Sprintf("type IN (%s)", strings.Join(placeholders, ",")))
-	}
-
-	if len(opts.FilePaths) > 0 {
-		pathConditions := make([]string, len(opts.FilePaths))
-		for i, fp := range opts.FilePaths {
-			pathConditions[i] = "file_path LIKE ?"
-			args = append(args, fp+"%")
+		if ok {
+			return results, total, nil
 		}
-		conditions = append(conditions, "("+strings.Join(pathConditions, " OR ")+")")
 	}
 
-	limit := opts.Limit
-	if limit <= 0 {
-		limit = 10
-	}
+	conditions, args := searchConditions(opts)
 
 	// Query all matching memories and compute similarity in Go
-	// (sqlite-vec extension would do this more efficiently, but this works without it)
 	query := fmt.Sprintf(`
-		SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at
+		SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at,
+		       (SELECT v.embedding FROM memory_vectors v WHERE v.memory_id = memories.id AND v.space = ?)
 		FROM memories
 		WHERE %s
 	`, strings.Join(conditions, " AND "))
+	args = append([]interface{}{spaceSummary}, args...)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query memories: %w", err)
+		return nil, 0, fmt.Errorf("failed to query memories: %w", err)
 	}
 	defer rows.Close()
 
 	var results []types.SearchResult
 	for rows.Next() {
-		memory, err := s.scanMemoryFromRows(rows)
+		var summaryBytes []byte
+		memory, err := s.scanMemoryFromRows(rows, &summaryBytes)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan memory: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan memory: %w", err)
 		}
 
-		// Calculate cosine similarity
+		// Calculate cosine similarity, matching either the content or its summary
 		similarity := cosineSimilarity(embedding, memory.Embedding)
+		if len(summaryBytes) > 0 {
+			memory.SummaryEmbedding = bytesToFloat32(summaryBytes)
+			if sim := cosineSimilarity(embedding, memory.SummaryEmbedding); sim > similarity {
+				similarity = sim
+			}
+		}
 
 		// Apply threshold filter
 		if opts.Threshold > 0 && similarity < opts.Threshold {
@@ -372,22 +910,13 @@ func (s *Store) Search(ctx context.Context, embedding []float32, opts store.Sear
 		})
 	}
 
-	// Sort by similarity descending
-	sortBySimilarity(results)
-
-	// Apply limit
-	if len(results) > limit {
-		results = results[:limit]
-	}
-
-	return results, nil
+	total := len(results)
+	return topKResults(results, limit, s.topk), total, nil
 }
 
-// List returns memories with filtering and pagination
-func (s *Store) List(ctx context.Context, opts store.ListOptions) ([]*types.Memory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
+// searchConditions builds the WHERE clause terms and arguments for the
+// filters of opts
+func searchConditions(opts store.SearchOptions) ([]string, []interface{}) {
 	conditions := []string{"1=1"}
 	args := []interface{}{}
 
@@ -396,11 +925,43 @@ func (s *Store) List(ctx context.Context, opts store.ListOptions) ([]*types.Memo
 		args = append(args, opts.Project)
 	}
 
-	if opts.Type != "" {
-		conditions = append(conditions, "type = ?")
-		args = append(args, string(opts.Type))
+	if len(opts.Types) > 0 {
+		placeholders := make([]string, len(opts.Types))
+		for i, t := range opts.Types {
+			placeholders[i] = "?"
+			args = append(args, string(t))
+		}
+		conditions = append(conditions, fmt.Sprintf("type IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	if len(opts.FilePaths) > 0 {
+		pathConditions := make([]string, len(opts.FilePaths))
+		for i, fp := range opts.FilePaths {
+			pathConditions[i] = `file_path LIKE ? ESCAPE '\'`
+			args = append(args, escapeLike(fp)+"%")
+		}
+		conditions = append(conditions, "("+strings.Join(pathConditions, " OR ")+")")
+	}
+
+	if len(opts.ExactFilePaths) > 0 {
+		placeholders := make([]string, len(opts.ExactFilePaths))
+		for i, fp := range opts.ExactFilePaths {
+			placeholders[i] = "?"
+			args = append(args, fp)
+		}
+		conditions = append(conditions, fmt.Sprintf("file_path IN (%s)", strings.Join(placeholders, ",")))
 	}
 
+	return appendMetadataConditions(conditions, args, opts.MetadataFilters)
+}
+
+// List returns memories with filtering and pagination
+func (s *Store) List(ctx context.Context, opts store.ListOptions) ([]*types.Memory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conditions, args := listConditions(opts)
+
 	orderBy := "created_at"
 	if opts.OrderBy != "" {
 		orderBy = opts.OrderBy
@@ -410,9 +971,12 @@ func (s *Store) List(ctx context.Context, opts store.ListOptions) ([]*types.Memo
 		order = "DESC"
 	}
 
+	// SQLite treats a negative LIMIT as unbounded
 	limit := opts.Limit
-	if limit <= 0 {
+	if limit == 0 {
 		limit = 100
+	} else if limit < 0 {
+		limit = -1
 	}
 
 	query := fmt.Sprintf(`
@@ -443,6 +1007,72 @@ func (s *Store) List(ctx context.Context, opts store.ListOptions) ([]*types.Memo
 	return memories, nil
 }
 
+// listConditions builds the WHERE clause terms and arguments for the
+// filters of opts
+func listConditions(opts store.ListOptions) ([]string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+
+	if opts.Project != "" {
+		conditions = append(conditions, "project = ?")
+		args = append(args, opts.Project)
+	}
+
+	if opts.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, string(opts.Type))
+	}
+
+	if opts.Language != "" {
+		conditions = append(conditions, "language = ?")
+		args = append(args, opts.Language)
+	}
+
+	if opts.FilePathPrefix != "" {
+		conditions = append(conditions, `file_path LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(opts.FilePathPrefix)+"%")
+	}
+
+	return appendMetadataConditions(conditions, args, opts.MetadataFilters)
+}
+
+// appendMetadataConditions adds a condition per metadata filter, in key
+// order so the same filters always build the same query
+func appendMetadataConditions(conditions []string, args []interface{}, filters map[string]string) ([]string, []interface{}) {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		conditions = append(conditions, "json_extract(metadata, ?) = ?")
+		args = append(args, metadataPath(k), filters[k])
+	}
+	return conditions, args
+}
+
+// metadataPath returns the JSON path of a metadata key. Quoting the key
+// keeps dots and brackets in it from being read as path syntax.
+func metadataPath(key string) string {
+	return `$."` + key + `"`
+}
+
+// CountList returns how many memories List would return without a limit
+func (s *Store) CountList(ctx context.Context, opts store.ListOptions) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conditions, args := listConditions(opts)
+	query := "SELECT COUNT(*) FROM memories WHERE " + strings.Join(conditions, " AND ")
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count memories: %w", err)
+	}
+	return count, nil
+}
+
 // Count returns the number of memories
 func (s *Store) Count(ctx context.Context, project string) (int, error) {
 	s.mu.RLock()
@@ -464,13 +1094,96 @@ func (s *Store) Count(ctx context.Context, project string) (int, error) {
 	return count, nil
 }
 
+// ListProjects returns every project with memories or metadata
+func (s *Store) ListProjects(ctx context.Context) ([]types.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT project, COUNT(*) FROM memories GROUP BY project
+		UNION ALL
+		SELECT DISTINCT project, 0 FROM project_meta
+		WHERE project NOT IN (SELECT DISTINCT project FROM memories)
+		ORDER BY 1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []types.Project
+	for rows.Next() {
+		var p types.Project
+		if err := rows.Scan(&p.Key, &p.MemoryCount); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+// GetProjectMeta returns the key-value metadata stored for a project
+func (s *Store) GetProjectMeta(ctx context.Context, project string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT key, value FROM project_meta WHERE project = ?", project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project metadata: %w", err)
+	}
+	defer rows.Close()
+
+	meta := make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan project metadata: %w", err)
+		}
+		meta[k] = v
+	}
+
+	return meta, rows.Err()
+}
+
+// SetProjectMeta upserts project metadata; an empty value deletes the key
+func (s *Store) SetProjectMeta(ctx context.Context, project string, meta map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for k, v := range meta {
+		if v == "" {
+			_, err = tx.ExecContext(ctx, "DELETE FROM project_meta WHERE project = ? AND key = ?", project, k)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO project_meta (project, key, value) VALUES (?, ?, ?)
+				ON CONFLICT(project, key) DO UPDATE SET value = excluded.value
+			`, project, k, v)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set project metadata %s: %w", k, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // Stats returns storage statistics
 func (s *Store) Stats(ctx context.Context) (*types.StatsResponse, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	stats := &types.StatsResponse{
-		MemoriesByType: make(map[string]int),
+		MemoriesByType:     make(map[string]int),
+		MemoriesByLanguage: make(map[string]int),
 	}
 
 	// Total count
@@ -494,6 +1207,22 @@ func (s *Store) Stats(ctx context.Context) (*types.StatsResponse, error) {
 		stats.MemoriesByType[memType] = count
 	}
 
+	// Count by language
+	langRows, err := s.db.QueryContext(ctx, "SELECT language, COUNT(*) FROM memories WHERE language != '' GROUP BY language")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language counts: %w", err)
+	}
+	defer langRows.Close()
+
+	for langRows.Next() {
+		var lang string
+		var count int
+		if err := langRows.Scan(&lang, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan language count: %w", err)
+		}
+		stats.MemoriesByLanguage[lang] = count
+	}
+
 	// Project count
 	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT project) FROM memories").Scan(&stats.ProjectCount); err != nil {
 		return nil, fmt.Errorf("failed to get project count: %w", err)
@@ -507,6 +1236,15 @@ func (s *Store) Stats(ctx context.Context) (*types.StatsResponse, error) {
 	return stats, nil
 }
 
+// Ping verifies the database connection with a trivial query
+func (s *Store) Ping(ctx context.Context) error {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
 // Close releases resources
 func (s *Store) Close() error {
 	return s.db.Close()
@@ -521,7 +1259,220 @@ func (s *Store) Compact(ctx context.Context) error {
 	return err
 }
 
+// Export streams memories in stable ID order, starting after opts.AfterID
+func (s *Store) Export(ctx context.Context, opts store.ExportOptions, fn func(*types.Memory) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conditions := []string{"id > ?"}
+	args := []interface{}{opts.AfterID}
+
+	if opts.Project != "" {
+		conditions = append(conditions, "project = ?")
+		args = append(args, opts.Project)
+	}
+
+	if err := s.iterate(ctx, conditions, args, "id", fn); err != nil {
+		return fmt.Errorf("failed to export memories: %w", err)
+	}
+	return nil
+}
+
+// Iterate calls fn for every memory matching the filters of opts, in the
+// order of opts, reading them through one cursor
+func (s *Store) Iterate(ctx context.Context, opts store.ListOptions, fn func(*types.Memory) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conditions, args := listConditions(opts)
+
+	orderBy := "created_at"
+	if opts.OrderBy != "" {
+		orderBy = opts.OrderBy
+	}
+	if opts.Descending {
+		orderBy += " DESC"
+	}
+	// Break ties by ID so the order is stable
+	orderBy += ", id"
+
+	if err := s.iterate(ctx, conditions, args, orderBy, fn); err != nil {
+		return fmt.Errorf("failed to iterate memories: %w", err)
+	}
+	return nil
+}
+
+// iterate runs a memory query and calls fn for each row until fn fails or
+// ctx is done. Callers hold s.mu.
+func (s *Store) iterate(ctx context.Context, conditions []string, args []interface{}, orderBy string, fn func(*types.Memory) error) error {
+	query := fmt.Sprintf(`
+		SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at
+		FROM memories
+		WHERE %s
+		ORDER BY %s
+	`, strings.Join(conditions, " AND "), orderBy)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		memory, err := s.scanMemoryFromRows(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan memory: %w", err)
+		}
+		if err := fn(memory); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Import inserts memories, resolving duplicates by ID or by content within
+// a project according to opts.OnConflict
+func (s *Store) Import(ctx context.Context, memories []*types.Memory, opts store.ImportOptions) (*store.ImportResult, error) {
+	policy, err := store.ParseConflictPolicy(string(opts.OnConflict))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO memories (id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at, content_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close()
+
+	now := time.Now()
+	result := &store.ImportResult{}
+	for _, memory := range memories {
+		// Preserve original timestamps; only fill in missing ones
+		if memory.CreatedAt.IsZero() {
+			memory.CreatedAt = now
+		}
+		if memory.UpdatedAt.IsZero() {
+			memory.UpdatedAt = memory.CreatedAt
+		}
+		sum := hash.Identity(memory.Content)
+
+		existingID, existingMeta, err := findDuplicate(ctx, tx, memory.ID, memory.Project, memory.Content, sum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import memory %s: %w", memory.ID, err)
+		}
+
+		switch {
+		case existingID == "":
+			result.Inserted++
+		case policy == store.ConflictSkip:
+			result.Skipped++
+			continue
+		case policy == store.ConflictMerge:
+			merged, changed := store.MergeMetadata(existingMeta, memory.Metadata)
+			if !changed {
+				result.Skipped++
+				continue
+			}
+			data, err := json.Marshal(merged)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, "UPDATE memories SET metadata = ?, updated_at = ? WHERE id = ?", string(data), now, existingID); err != nil {
+				return nil, fmt.Errorf("failed to merge memory %s: %w", memory.ID, err)
+			}
+			result.Merged++
+			continue
+		case policy == store.ConflictReplace:
+			// Deleting first keeps the incoming ID when content matched a different one
+			if _, err := tx.ExecContext(ctx, "DELETE FROM memories WHERE id = ?", existingID); err != nil {
+				return nil, fmt.Errorf("failed to replace memory %s: %w", memory.ID, err)
+			}
+			result.Replaced++
+		}
+
+		metadata, err := json.Marshal(memory.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		_, err = insert.ExecContext(ctx,
+			memory.ID,
+			memory.Content,
+			memory.Project,
+			string(memory.Type),
+			memory.FilePath,
+			memory.Language,
+			string(metadata),
+			float32ToBytes(memory.Embedding),
+			memory.CreatedAt,
+			memory.UpdatedAt,
+			sum,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import memory %s: %w", memory.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return result, nil
+}
+
+// findDuplicate returns the memory with the given ID, or else one in project
+// with the same content, and its metadata. The ID is empty when there is
+// none. The hash finds candidates through the index; comparing content too
+// means a hash collision can't merge two different memories.
+func findDuplicate(ctx context.Context, tx *sql.Tx, id, project, content, contentHash string) (string, map[string]string, error) {
+	var existingID string
+	var metadataJSON sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, metadata FROM memories
+		WHERE id = ? OR (project = ? AND content_hash = ? AND content = ?)
+		ORDER BY id = ? DESC
+		LIMIT 1
+	`, id, project, contentHash, content, id).Scan(&existingID, &metadataJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var metadata map[string]string
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &metadata); err != nil {
+			return "", nil, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	return existingID, metadata, nil
+}
+
 // scanMemory scans a single row into a Memory struct
+// likeEscaper escapes LIKE wildcards so a prefix matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes s for use in a LIKE pattern with ESCAPE '\'
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
 func (s *Store) scanMemory(row *sql.Row) (*types.Memory, error) {
 	var m types.Memory
 	var memType string
@@ -543,7 +1494,7 @@ func (s *Store) scanMemory(row *sql.Row) (*types.Memory, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("memory not found")
+			return nil, ErrNotFound
 		}
 		return nil, err
 	}
@@ -562,14 +1513,16 @@ func (s *Store) scanMemory(row *sql.Row) (*types.Memory, error) {
 }
 
 // scanMemoryFromRows scans from rows iterator
-func (s *Store) scanMemoryFromRows(rows *sql.Rows) (*types.Memory, error) {
+// scanMemoryFromRows scans the standard memory columns, followed by any
+// extra columns into extra
+func (s *Store) scanMemoryFromRows(rows *sql.Rows, extra ...interface{}) (*types.Memory, error) {
 	var m types.Memory
 	var memType string
 	var metadataJSON sql.NullString
 	var embeddingBytes []byte
 	var filePath, language sql.NullString
 
-	err := rows.Scan(
+	dest := []interface{}{
 		&m.ID,
 		&m.Content,
 		&m.Project,
@@ -580,7 +1533,8 @@ func (s *Store) scanMemoryFromRows(rows *sql.Rows) (*types.Memory, error) {
 		&embeddingBytes,
 		&m.CreatedAt,
 		&m.UpdatedAt,
-	)
+	}
+	err := rows.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -2,11 +2,15 @@ package sqlite
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/shivavenkatesh/moneta/internal/hash"
 	"github.com/shivavenkatesh/moneta/internal/store"
 	"github.com/shivavenkatesh/moneta/pkg/types"
 )
@@ -84,6 +88,107 @@ func TestStore_Get_NotFound(t *testing.T) {
 	}
 }
 
+func TestStore_GetBatch(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		err := s.Add(ctx, &types.Memory{
+			ID:        id,
+			Content:   "memory " + id,
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Embedding: generateTestEmbedding(768),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	// Results follow the requested order, with nil for unknown IDs
+	got, err := s.GetBatch(ctx, []string{"c", "missing", "a"})
+	if err != nil {
+		t.Fatalf("failed to get batch: %v", err)
+	}
+	if len(got) != 3 || got[0] == nil || got[0].ID != "c" || got[1] != nil || got[2] == nil || got[2].ID != "a" {
+		t.Fatalf("expected c, nil, a, got %v", got)
+	}
+	if got[0].Content != "memory c" || len(got[0].Embedding) != 768 {
+		t.Errorf("expected a fully loaded memory, got %+v", got[0])
+	}
+
+	got, err = s.GetBatch(ctx, nil)
+	if err != nil || len(got) != 0 {
+		t.Errorf("expected an empty batch to return nothing, got %v, %v", got, err)
+	}
+}
+
+func TestStore_NeighborChunks(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	add := func(id, path string, line int, chunk bool) *types.Memory {
+		t.Helper()
+		m := &types.Memory{
+			ID:        id,
+			Content:   "memory " + id,
+			Project:   "p",
+			Type:      types.TypeContext,
+			FilePath:  path,
+			Metadata:  map[string]string{"start_line": fmt.Sprint(line)},
+			Embedding: generateTestEmbedding(768),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if chunk {
+			m.Metadata["chunk_index"] = fmt.Sprint(line / 10)
+		}
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+		return m
+	}
+	// Added out of line order; l100 is line 100, after l40 numerically
+	add("l40", "a.go", 40, true)
+	add("l1", "a.go", 1, true)
+	hit := add("l20", "a.go", 20, true)
+	add("l100", "a.go", 100, true)
+	add("l10", "a.go", 10, true)
+	add("note", "a.go", 15, false)
+	add("other", "b.go", 30, true)
+
+	ids := func(memories []*types.Memory) string {
+		var out []string
+		for _, m := range memories {
+			out = append(out, m.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	got, err := s.NeighborChunks(ctx, hit, 1)
+	if err != nil {
+		t.Fatalf("failed to get neighbors: %v", err)
+	}
+	if ids(got) != "l10,l40" {
+		t.Errorf("expected l10,l40, got %s", ids(got))
+	}
+
+	// Fewer chunks than asked for on either side, and no hand-added notes
+	got, _ = s.NeighborChunks(ctx, hit, 5)
+	if ids(got) != "l1,l10,l40,l100" {
+		t.Errorf("expected every other chunk of a.go, got %s", ids(got))
+	}
+
+	got, err = s.NeighborChunks(ctx, &types.Memory{ID: "x", Project: "p", Content: "no location"}, 2)
+	if err != nil || len(got) != 0 {
+		t.Errorf("expected no neighbors for a memory without a location, got %s, %v", ids(got), err)
+	}
+}
+
 func TestStore_Update(t *testing.T) {
 	s := createTestStore(t)
 	defer s.Close()
@@ -190,7 +295,7 @@ func TestStore_AddBatch(t *testing.T) {
 		}
 	}
 
-	if err := s.AddBatch(ctx, memories); err != nil {
+	if err := s.AddBatch(ctx, memories, store.AddOptions{}); err != nil {
 		t.Fatalf("failed to add batch: %v", err)
 	}
 
@@ -204,6 +309,79 @@ func TestStore_AddBatch(t *testing.T) {
 	}
 }
 
+func TestStore_AddBatch_Conflict(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	if err := s.Add(ctx, &types.Memory{ID: "existing", Content: "original", Project: "test-project", Type: types.TypeContext}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	memories := []*types.Memory{
+		{ID: "new-1", Content: "new", Project: "test-project", Type: types.TypeContext},
+		{ID: "existing", Content: "conflict", Project: "test-project", Type: types.TypeContext},
+		{ID: "new-2", Content: "new", Project: "test-project", Type: types.TypeContext},
+	}
+
+	err := s.AddBatch(ctx, memories, store.AddOptions{})
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+
+	var batchErr *store.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *store.BatchError, got %T", err)
+	}
+	if batchErr.ID != "existing" || batchErr.Index != 1 {
+		t.Errorf("wrong failing row: id=%s index=%d", batchErr.ID, batchErr.Index)
+	}
+
+	// The whole batch is rolled back
+	count, _ := s.Count(ctx, "test-project")
+	if count != 1 {
+		t.Errorf("expected rollback to leave 1 memory, got %d", count)
+	}
+}
+
+func TestStore_AddBatch_SkipExisting(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	if err := s.Add(ctx, &types.Memory{ID: "existing", Content: "original", Project: "test-project", Type: types.TypeContext}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	memories := []*types.Memory{
+		{ID: "new-1", Content: "new", Project: "test-project", Type: types.TypeContext},
+		{ID: "existing", Content: "conflict", Project: "test-project", Type: types.TypeContext},
+		{ID: "new-2", Content: "new", Project: "test-project", Type: types.TypeContext},
+	}
+
+	if err := s.AddBatch(ctx, memories, store.AddOptions{SkipExisting: true}); err != nil {
+		t.Fatalf("failed to add batch: %v", err)
+	}
+
+	count, _ := s.Count(ctx, "test-project")
+	if count != 3 {
+		t.Errorf("expected 3 memories, got %d", count)
+	}
+
+	got, err := s.Get(ctx, "existing")
+	if err != nil {
+		t.Fatalf("failed to get memory: %v", err)
+	}
+	if got.Content != "original" {
+		t.Errorf("existing memory was overwritten: %s", got.Content)
+	}
+
+	// Retrying the same batch is a no-op
+	if err := s.AddBatch(ctx, memories, store.AddOptions{SkipExisting: true}); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+}
+
 func TestStore_DeleteByProject(t *testing.T) {
 	s := createTestStore(t)
 	defer s.Close()
@@ -269,7 +447,7 @@ func TestStore_Search(t *testing.T) {
 	queryEmbedding := generateTestEmbedding(768)
 	queryEmbedding[0] = 2.5 // Most similar to search-2 or search-3
 
-	results, err := s.Search(ctx, queryEmbedding, store.SearchOptions{
+	results, _, err := s.Search(ctx, queryEmbedding, store.SearchOptions{
 		Limit: 3,
 	})
 	if err != nil {
@@ -318,7 +496,7 @@ func TestStore_Search_WithFilters(t *testing.T) {
 	})
 
 	// Search with project filter
-	results, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
+	results, _, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
 		Project: "project1",
 		Limit:   10,
 	})
@@ -333,7 +511,7 @@ func TestStore_Search_WithFilters(t *testing.T) {
 	}
 
 	// Search with type filter
-	results, err = s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
+	results, _, err = s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
 		Types: []types.MemoryType{types.TypeArchitecture},
 		Limit: 10,
 	})
@@ -348,6 +526,52 @@ func TestStore_Search_WithFilters(t *testing.T) {
 	}
 }
 
+func TestStore_Search_FilePaths(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	paths := map[string]string{
+		"mem-1": "internal/store/sqlite/sqlite.go",
+		"mem-2": "internal/store/sqlite/sqlite_test.go",
+		"mem-3": "internal/server/server.go",
+	}
+	for id, fp := range paths {
+		s.Add(ctx, &types.Memory{
+			ID:        id,
+			Content:   "content " + id,
+			Project:   "project1",
+			Type:      types.TypeContext,
+			FilePath:  fp,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	// Prefix match picks up both files in the directory
+	results, _, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
+		FilePaths: []string{"internal/store/sqlite/sqlite"},
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 prefix matches, got %d", len(results))
+	}
+
+	// Exact match scopes to a single file
+	results, _, err = s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{
+		ExactFilePaths: []string{"internal/store/sqlite/sqlite.go"},
+		Limit:          10,
+	})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Memory.ID != "mem-1" {
+		t.Errorf("expected only mem-1 for exact match, got %d results", len(results))
+	}
+}
+
 func TestStore_List(t *testing.T) {
 	s := createTestStore(t)
 	defer s.Close()
@@ -455,6 +679,7 @@ func TestStore_Stats(t *testing.T) {
 		Content:   "Content",
 		Project:   "project2",
 		Type:      types.TypePattern,
+		Language:  "go",
 		Embedding: generateTestEmbedding(768),
 	})
 
@@ -472,6 +697,9 @@ func TestStore_Stats(t *testing.T) {
 	if len(stats.MemoriesByType) == 0 {
 		t.Error("expected memories by type to be populated")
 	}
+	if len(stats.MemoriesByLanguage) != 1 || stats.MemoriesByLanguage["go"] != 1 {
+		t.Errorf("expected one go memory by language, got %v", stats.MemoriesByLanguage)
+	}
 }
 
 func TestStore_Compact(t *testing.T) {
@@ -509,6 +737,148 @@ func TestStore_Compact(t *testing.T) {
 	}
 }
 
+func TestStore_Export(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for _, id := range []string{"c", "a", "b", "d"} {
+		project := "p1"
+		if id == "d" {
+			project = "p2"
+		}
+		if err := s.Add(ctx, &types.Memory{ID: id, Content: "content " + id, Project: project, Type: types.TypeContext}); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	collect := func(opts store.ExportOptions) string {
+		var ids []string
+		err := s.Export(ctx, opts, func(m *types.Memory) error {
+			ids = append(ids, m.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if got := collect(store.ExportOptions{}); got != "a,b,c,d" {
+		t.Errorf("expected all IDs in order, got %s", got)
+	}
+	if got := collect(store.ExportOptions{Project: "p1"}); got != "a,b,c" {
+		t.Errorf("expected p1 IDs, got %s", got)
+	}
+	if got := collect(store.ExportOptions{AfterID: "b"}); got != "c,d" {
+		t.Errorf("expected IDs after cursor, got %s", got)
+	}
+}
+
+func TestStore_Iterate(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for i, id := range []string{"c", "a", "b", "d"} {
+		project := "p1"
+		if id == "d" {
+			project = "p2"
+		}
+		m := &types.Memory{ID: id, Content: "content " + id, Project: project, Type: types.TypeContext}
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+		// Distinct update times give a defined order
+		m.UpdatedAt = time.Unix(int64(1000+i), 0)
+		if _, err := s.db.Exec("UPDATE memories SET updated_at = ? WHERE id = ?", m.UpdatedAt, id); err != nil {
+			t.Fatalf("failed to set updated_at: %v", err)
+		}
+	}
+
+	collect := func(opts store.ListOptions) string {
+		var ids []string
+		err := s.Iterate(ctx, opts, func(m *types.Memory) error {
+			ids = append(ids, m.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("iterate failed: %v", err)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if got := collect(store.ListOptions{OrderBy: "updated_at", Descending: true}); got != "d,b,a,c" {
+		t.Errorf("expected newest first, got %s", got)
+	}
+	if got := collect(store.ListOptions{Project: "p1", OrderBy: "updated_at", Limit: 1}); got != "c,a,b" {
+		t.Errorf("expected every p1 memory regardless of limit, got %s", got)
+	}
+
+	// An error from fn stops the walk and releases the cursor
+	stop := errors.New("stop")
+	calls := 0
+	err := s.Iterate(ctx, store.ListOptions{}, func(m *types.Memory) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected iteration to stop with fn's error after one call, got %v after %d", err, calls)
+	}
+	if err := s.Add(ctx, &types.Memory{ID: "e", Content: "content e", Project: "p1", Type: types.TypeContext}); err != nil {
+		t.Errorf("expected writes after a stopped iteration, got %v", err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	calls = 0
+	err = s.Iterate(cctx, store.ListOptions{}, func(m *types.Memory) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("expected cancellation after one call, got %v after %d", err, calls)
+	}
+}
+
+func TestStore_Import_SkipsExisting(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	if err := s.Add(ctx, &types.Memory{ID: "existing", Content: "original", Project: "p", Type: types.TypeContext}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	res, err := s.Import(ctx, []*types.Memory{
+		{ID: "existing", Content: "replacement", Project: "p", Type: types.TypeContext},
+		{ID: "new", Content: "new", Project: "p", Type: types.TypeContext, CreatedAt: created, UpdatedAt: created},
+	}, store.ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if res.Inserted != 1 || res.Skipped != 1 {
+		t.Errorf("expected 1 imported and 1 skipped, got %+v", res)
+	}
+
+	got, err := s.Get(ctx, "existing")
+	if err != nil {
+		t.Fatalf("failed to get memory: %v", err)
+	}
+	if got.Content != "original" {
+		t.Errorf("existing memory was overwritten: %s", got.Content)
+	}
+
+	got, err = s.Get(ctx, "new")
+	if err != nil {
+		t.Fatalf("failed to get memory: %v", err)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(created) {
+		t.Errorf("timestamps not preserved: %v / %v", got.CreatedAt, got.UpdatedAt)
+	}
+}
+
 // Helper functions
 
 func createTestStore(t *testing.T) *Store {
@@ -588,3 +958,829 @@ func BenchmarkStore_Search(b *testing.B) {
 		})
 	}
 }
+
+func TestStore_Ping(t *testing.T) {
+	s := createTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	s.Close()
+	if err := s.Ping(ctx); err == nil {
+		t.Error("expected Ping to fail on a closed store")
+	}
+}
+
+func TestStore_ProjectMeta(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	s.Add(ctx, &types.Memory{
+		ID:        "proj-1",
+		Content:   "Content",
+		Project:   "svc-v2",
+		Type:      types.TypeContext,
+		Embedding: generateTestEmbedding(768),
+	})
+
+	if err := s.SetProjectMeta(ctx, "svc-v2", map[string]string{"name": "Service", "description": "API"}); err != nil {
+		t.Fatalf("set meta failed: %v", err)
+	}
+	if err := s.SetProjectMeta(ctx, "empty", map[string]string{"name": "Empty"}); err != nil {
+		t.Fatalf("set meta failed: %v", err)
+	}
+
+	// Empty values delete keys
+	if err := s.SetProjectMeta(ctx, "svc-v2", map[string]string{"description": ""}); err != nil {
+		t.Fatalf("clear meta failed: %v", err)
+	}
+
+	meta, err := s.GetProjectMeta(ctx, "svc-v2")
+	if err != nil {
+		t.Fatalf("get meta failed: %v", err)
+	}
+	if meta["name"] != "Service" || len(meta) != 1 {
+		t.Errorf("unexpected meta: %v", meta)
+	}
+
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("list projects failed: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got %v", projects)
+	}
+	if projects[0].Key != "empty" || projects[0].MemoryCount != 0 {
+		t.Errorf("unexpected project: %+v", projects[0])
+	}
+	if projects[1].Key != "svc-v2" || projects[1].MemoryCount != 1 {
+		t.Errorf("unexpected project: %+v", projects[1])
+	}
+}
+
+func TestStore_List_NoLimit(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	memories := make([]*types.Memory, 120)
+	for i := range memories {
+		memories[i] = &types.Memory{
+			ID:        fmt.Sprintf("all-%03d", i),
+			Content:   "List content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Embedding: generateTestEmbedding(768),
+		}
+	}
+	if err := s.AddBatch(ctx, memories, store.AddOptions{}); err != nil {
+		t.Fatalf("add batch failed: %v", err)
+	}
+
+	// Zero uses the default page size
+	results, err := s.List(ctx, store.ListOptions{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(results) != 100 {
+		t.Errorf("expected default of 100 results, got %d", len(results))
+	}
+
+	results, err = s.List(ctx, store.ListOptions{Limit: store.NoLimit})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(results) != len(memories) {
+		t.Errorf("expected all %d results, got %d", len(memories), len(results))
+	}
+}
+
+func TestStore_DeleteBatch(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	for _, id := range []string{"del-1", "del-2", "del-3"} {
+		s.Add(ctx, &types.Memory{
+			ID:        id,
+			Content:   "Content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	deleted, err := s.DeleteBatch(ctx, []string{"del-1", "missing", "del-3"})
+	if err != nil {
+		t.Fatalf("delete batch failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("expected 2 deleted, got %d", deleted)
+	}
+
+	count, _ := s.Count(ctx, "test-project")
+	if count != 1 {
+		t.Errorf("expected 1 memory left, got %d", count)
+	}
+	if _, err := s.Get(ctx, "del-2"); err != nil {
+		t.Errorf("expected del-2 to remain: %v", err)
+	}
+
+	if deleted, err := s.DeleteBatch(ctx, nil); err != nil || deleted != 0 {
+		t.Errorf("expected empty batch to be a no-op, got %d, %v", deleted, err)
+	}
+}
+
+func TestStore_UpdateMetadata(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	memory := &types.Memory{
+		ID:        "test-1",
+		Content:   "Original content",
+		Project:   "test-project",
+		Type:      types.TypeContext,
+		Embedding: generateTestEmbedding(768),
+	}
+	if err := s.Add(ctx, memory); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	// Content changes must not be written by a metadata-only update
+	memory.Content = "Ignored"
+	memory.Type = types.TypeGotcha
+	memory.Metadata = map[string]string{"tag": "auth"}
+	if err := s.UpdateMetadata(ctx, memory); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+
+	got, err := s.Get(ctx, "test-1")
+	if err != nil {
+		t.Fatalf("failed to get memory: %v", err)
+	}
+	if got.Content != "Original content" {
+		t.Errorf("content should be unchanged, got %q", got.Content)
+	}
+	if got.Type != types.TypeGotcha || got.Metadata["tag"] != "auth" {
+		t.Errorf("metadata not updated: type=%s metadata=%v", got.Type, got.Metadata)
+	}
+	if len(got.Embedding) != 768 {
+		t.Errorf("embedding should be preserved, got %d dims", len(got.Embedding))
+	}
+
+	if err := s.UpdateMetadata(ctx, &types.Memory{ID: "missing"}); err == nil {
+		t.Error("expected an error for a missing memory")
+	}
+}
+
+func TestNew_RejectsZeroDimensions(t *testing.T) {
+	s, err := New(Config{Path: filepath.Join(t.TempDir(), "test.db"), Dimensions: -1})
+	if err == nil {
+		s.Close()
+		t.Error("expected an error for -1 dimensions")
+	}
+
+	// Zero means the saved dimensions, which a new database doesn't have
+	s, err = New(Config{Path: filepath.Join(t.TempDir(), "test.db")})
+	if !errors.Is(err, ErrUnknownDimensions) {
+		if err == nil {
+			s.Close()
+		}
+		t.Errorf("expected ErrUnknownDimensions for a new database, got %v", err)
+	}
+}
+
+func TestNew_SavesDimensions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	open := func(dims int) (*Store, error) {
+		s, err := New(Config{Path: path, Dimensions: dims})
+		if err == nil {
+			t.Cleanup(func() { s.Close() })
+		}
+		return s, err
+	}
+
+	// An empty store takes whatever it is opened with
+	if _, err := open(384); err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if _, err := open(0); !errors.Is(err, ErrUnknownDimensions) {
+		t.Errorf("expected an empty store to need dimensions, got %v", err)
+	}
+	s, err := open(16)
+	if err != nil {
+		t.Fatalf("expected an empty store to accept new dimensions, got %v", err)
+	}
+
+	// Stored embeddings fix the dimensions
+	s.Add(ctx, &types.Memory{ID: "m", Content: "c", Project: "p", Type: types.TypeContext, Embedding: make([]float32, 16)})
+	if s, err := open(0); err != nil || s.Dimensions() != 16 {
+		t.Fatalf("expected the saved 16 dimensions, got %v", err)
+	}
+	if _, err := open(768); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch for other dimensions, got %v", err)
+	}
+
+	// Databases from before dimensions were saved use a stored embedding
+	s.db.Exec("DELETE FROM store_meta")
+	if s, err := open(0); err != nil || s.Dimensions() != 16 {
+		t.Fatalf("expected 16 dimensions from the stored embedding, got %v", err)
+	}
+}
+
+func TestNew_SavesPromptTemplate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	open := func(template string) *Store {
+		s, err := New(Config{Path: path, Dimensions: 16, PromptTemplate: template})
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+
+	// A new store records its template and keeps it
+	if got := open("default").PromptTemplate(); got != "default" {
+		t.Errorf("expected a new store to record its template, got %q", got)
+	}
+	s := open("other")
+	if got := s.PromptTemplate(); got != "default" {
+		t.Errorf("expected the recorded template to be kept, got %q", got)
+	}
+
+	// A store embedded before templates were recorded embedded text unchanged
+	s.Add(ctx, &types.Memory{ID: "m", Content: "c", Project: "p", Type: types.TypeContext, Embedding: make([]float32, 16)})
+	s.db.Exec("DELETE FROM store_meta WHERE key = ?", promptTemplateKey)
+	if got := open("default").PromptTemplate(); got != NoPromptTemplate {
+		t.Errorf("expected an old store to report %q, got %q", NoPromptTemplate, got)
+	}
+	s = open("")
+	if got := s.PromptTemplate(); got != NoPromptTemplate {
+		t.Errorf("expected %q to be saved, got %q", NoPromptTemplate, got)
+	}
+
+	if err := s.SetPromptTemplate("default"); err != nil {
+		t.Fatalf("failed to set prompt template: %v", err)
+	}
+	if got := open("").PromptTemplate(); got != "default" {
+		t.Errorf("expected the new template after re-embedding, got %q", got)
+	}
+}
+
+func TestStore_Migrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := New(Config{Path: path, Dimensions: 768})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < migrateBatch+5; i++ {
+		embedding := make([]float32, 768)
+		embedding[0] = 1
+		s.Add(ctx, &types.Memory{ID: fmt.Sprintf("m%03d", i), Content: fmt.Sprintf("content %d", i), Project: "p", Type: types.TypeContext, Embedding: embedding})
+	}
+	if _, err := s.db.Exec(insertVectorQuery, "m000", spaceSummary, float32ToBytesAlloc(make([]float32, 768))); err != nil {
+		t.Fatalf("failed to add summary vector: %v", err)
+	}
+
+	// A failing embedder leaves the store at its old dimensions
+	err = s.Migrate(ctx, 384, func(string) ([]float32, error) { return make([]float32, 16), nil })
+	if err == nil || s.Dimensions() != 768 {
+		t.Fatalf("expected embeddings of the wrong size to fail the migration, got %v", err)
+	}
+
+	calls := 0
+	err = s.Migrate(ctx, 384, func(content string) ([]float32, error) {
+		calls++
+		embedding := make([]float32, 384)
+		embedding[1] = 1
+		return embedding, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if calls != migrateBatch+5 {
+		t.Errorf("expected every memory to be re-embedded once, got %d calls", calls)
+	}
+	if s.Dimensions() != 384 {
+		t.Errorf("expected 384 dimensions after migrating, got %d", s.Dimensions())
+	}
+
+	var stale int
+	s.db.QueryRow("SELECT COUNT(*) FROM memories WHERE length(embedding) != ?", 384*4).Scan(&stale)
+	if stale != 0 {
+		t.Errorf("expected every embedding to be rewritten, %d were not", stale)
+	}
+	var vectors int
+	s.db.QueryRow("SELECT COUNT(*) FROM memory_vectors").Scan(&vectors)
+	if vectors != 0 {
+		t.Errorf("expected old summary vectors to be dropped, got %d", vectors)
+	}
+
+	query := make([]float32, 384)
+	query[1] = 1
+	results, _, err := s.Search(ctx, query, store.SearchOptions{Limit: 1})
+	if err != nil || len(results) != 1 || results[0].Similarity < 0.99 {
+		t.Errorf("expected searches at the new dimensions to match, got %v (%v)", results, err)
+	}
+	s.Close()
+
+	if reopened, err := New(Config{Path: path, Dimensions: 384}); err != nil {
+		t.Errorf("expected the store to open at 384 dimensions, got %v", err)
+	} else {
+		reopened.Close()
+	}
+	if _, err := New(Config{Path: path, Dimensions: 768}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch at the old dimensions, got %v", err)
+	}
+}
+
+func TestStore_List_Language(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	for i, lang := range []string{"go", "go", "python", ""} {
+		s.Add(ctx, &types.Memory{
+			ID:        fmt.Sprintf("lang-%d", i),
+			Content:   "content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Language:  lang,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	results, err := s.List(ctx, store.ListOptions{Project: "test-project", Language: "go"})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 go memories, got %d", len(results))
+	}
+	for _, m := range results {
+		if m.Language != "go" {
+			t.Errorf("unexpected language %q", m.Language)
+		}
+	}
+}
+
+func TestStore_MetadataFilters(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	metas := []map[string]string{
+		{"team": "payments", "owner": "ana"},
+		{"team": "payments", "owner": "raj"},
+		{"team": "search"},
+		nil,
+		{"a.b": "dotted"},
+	}
+	for i, meta := range metas {
+		s.Add(ctx, &types.Memory{
+			ID:        fmt.Sprintf("meta-%d", i),
+			Content:   "content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Metadata:  meta,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	tests := []struct {
+		filters map[string]string
+		want    int
+	}{
+		{nil, 5},
+		{map[string]string{}, 5},
+		{map[string]string{"team": "payments"}, 2},
+		{map[string]string{"team": "payments", "owner": "raj"}, 1},
+		{map[string]string{"team": "payments", "owner": "lee"}, 0},
+		{map[string]string{"a.b": "dotted"}, 1},
+	}
+	for _, tt := range tests {
+		memories, err := s.List(ctx, store.ListOptions{Project: "test-project", MetadataFilters: tt.filters})
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		if len(memories) != tt.want {
+			t.Errorf("List with %v: expected %d memories, got %d", tt.filters, tt.want, len(memories))
+		}
+
+		results, _, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{Project: "test-project", Limit: 10, Threshold: -1, MetadataFilters: tt.filters})
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("Search with %v: expected %d results, got %d", tt.filters, tt.want, len(results))
+		}
+	}
+}
+
+func TestStore_List_FilePathPrefix(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	paths := []string{
+		"internal/store/store.go",
+		"internal/store/sqlite/sqlite.go",
+		"internal/server/server.go",
+		"internal/storeutil/util.go",
+		"internal/my_store/x.go",
+		"internal/myXstore/x.go",
+	}
+	for i, path := range paths {
+		s.Add(ctx, &types.Memory{
+			ID:        fmt.Sprintf("path-%d", i),
+			Content:   "content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			FilePath:  path,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	count := func(prefix string) int {
+		results, err := s.List(ctx, store.ListOptions{FilePathPrefix: prefix})
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		return len(results)
+	}
+
+	if n := count("internal/store/"); n != 2 {
+		t.Errorf("expected 2 memories under internal/store/, got %d", n)
+	}
+	if n := count("internal/"); n != len(paths) {
+		t.Errorf("expected %d memories under internal/, got %d", len(paths), n)
+	}
+
+	// LIKE wildcards in the prefix match literally
+	if n := count("internal/my_store/"); n != 1 {
+		t.Errorf("expected _ to match literally, got %d", n)
+	}
+}
+
+func TestStore_CountList(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		lang := "go"
+		if i%2 == 1 {
+			lang = "python"
+		}
+		s.Add(ctx, &types.Memory{
+			ID:        fmt.Sprintf("count-%d", i),
+			Content:   "content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Language:  lang,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	// Limit and offset don't affect the count
+	n, err := s.CountList(ctx, store.ListOptions{Project: "test-project", Language: "go", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 go memories, got %d", n)
+	}
+	if n, _ := s.CountList(ctx, store.ListOptions{Project: "other"}); n != 0 {
+		t.Errorf("expected 0 memories in other project, got %d", n)
+	}
+}
+
+func TestStore_Search_Total(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		s.Add(ctx, &types.Memory{
+			ID:        fmt.Sprintf("total-%d", i),
+			Content:   "content",
+			Project:   "test-project",
+			Type:      types.TypeContext,
+			Embedding: generateTestEmbedding(768),
+		})
+	}
+
+	results, total, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 || total != 5 {
+		t.Errorf("expected 2 results of 5 matches, got %d of %d", len(results), total)
+	}
+}
+
+func TestStore_Search_SummaryEmbedding(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	basis := func(i int) []float32 {
+		v := make([]float32, 768)
+		v[i] = 1
+		return v
+	}
+
+	memory := &types.Memory{
+		ID:               "chunk-1",
+		Content:          "func backoff() {}",
+		Project:          "test-project",
+		Type:             types.TypeContext,
+		Embedding:        basis(0),
+		SummaryEmbedding: basis(1),
+	}
+	if err := s.AddBatch(ctx, []*types.Memory{memory}, store.AddOptions{}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	results, _, err := s.Search(ctx, basis(1), store.SearchOptions{Limit: 5, Threshold: 0.9})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Similarity < 0.99 {
+		t.Fatalf("expected a match through the summary embedding, got %v", results)
+	}
+
+	// Updating without a summary drops the stale one
+	memory.SummaryEmbedding = nil
+	if err := s.Update(ctx, memory); err != nil {
+		t.Fatalf("failed to update memory: %v", err)
+	}
+	results, _, err = s.Search(ctx, basis(1), store.SearchOptions{Limit: 5, Threshold: 0.9})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected the summary embedding to be removed, got %d results", len(results))
+	}
+
+	// Deleting a memory deletes its vectors
+	memory.SummaryEmbedding = basis(1)
+	if err := s.Update(ctx, memory); err != nil {
+		t.Fatalf("failed to update memory: %v", err)
+	}
+	if err := s.Delete(ctx, "chunk-1"); err != nil {
+		t.Fatalf("failed to delete memory: %v", err)
+	}
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM memory_vectors").Scan(&count); err != nil {
+		t.Fatalf("failed to count vectors: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected vectors to be deleted with their memory, got %d", count)
+	}
+}
+
+func TestStore_UpdateWhere(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	for _, m := range []*types.Memory{
+		{ID: "doc-1", Content: "a", Project: "p", Type: types.TypeContext, FilePath: "docs/a.md"},
+		{ID: "doc-2", Content: "b", Project: "p", Type: types.TypeContext, FilePath: "docs/b.md", Metadata: map[string]string{"tags": "draft"}},
+		{ID: "src-1", Content: "c", Project: "p", Type: types.TypeContext, FilePath: "src/c.go"},
+		{ID: "other", Content: "d", Project: "q", Type: types.TypeContext, FilePath: "docs/d.md"},
+	} {
+		m.Embedding = generateTestEmbedding(768)
+		if err := s.Add(ctx, m); err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+	}
+
+	filter := store.UpdateFilter{Project: "p", FilePathPrefix: "docs/"}
+	changes := store.UpdateChanges{AddTags: []string{"documentation"}, DryRun: true}
+
+	n, err := s.UpdateWhere(ctx, filter, changes)
+	if err != nil || n != 2 {
+		t.Fatalf("dry run: expected 2 matches, got %d (%v)", n, err)
+	}
+	if m, _ := s.Get(ctx, "doc-1"); m.Metadata["tags"] != "" {
+		t.Error("dry run should not update")
+	}
+
+	changes.DryRun = false
+	if n, err := s.UpdateWhere(ctx, filter, changes); err != nil || n != 2 {
+		t.Fatalf("expected 2 updates, got %d (%v)", n, err)
+	}
+	if m, _ := s.Get(ctx, "doc-2"); m.Metadata["tags"] != "draft,documentation" {
+		t.Errorf("expected tags appended, got %q", m.Metadata["tags"])
+	}
+	if m, _ := s.Get(ctx, "other"); m.Metadata["tags"] != "" {
+		t.Error("other projects should not be updated")
+	}
+
+	// Memories already tagged are not counted again
+	if n, _ := s.UpdateWhere(ctx, filter, changes); n != 0 {
+		t.Errorf("expected no changes on repeat, got %d", n)
+	}
+
+	n, err = s.UpdateWhere(ctx, store.UpdateFilter{Project: "p", Type: types.TypeContext}, store.UpdateChanges{Type: types.TypeArchitecture})
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 retyped, got %d (%v)", n, err)
+	}
+	if m, _ := s.Get(ctx, "src-1"); m.Type != types.TypeArchitecture || len(m.Embedding) != 768 {
+		t.Errorf("expected retyped memory with its embedding, got type=%s dims=%d", m.Type, len(m.Embedding))
+	}
+
+	if _, err := s.UpdateWhere(ctx, filter, store.UpdateChanges{AddTags: []string{"a,b"}}); err == nil {
+		t.Error("expected an error for a tag containing a comma")
+	}
+}
+
+func TestStore_FileContent(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	h1, err := s.PutFileContent(ctx, "p", "/src/a.go", "package a\n")
+	if err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	h2, _ := s.PutFileContent(ctx, "p", "/src/copy.go", "package a\n")
+	if h1 != h2 {
+		t.Error("identical content should hash the same")
+	}
+
+	if got, err := s.GetFileContent(ctx, "p", "/src/a.go"); err != nil || got != "package a\n" {
+		t.Errorf("expected stored content, got %q (%v)", got, err)
+	}
+	if _, err := s.GetFileContent(ctx, "q", "/src/a.go"); err == nil {
+		t.Error("expected not found for another project")
+	}
+
+	// Replacing a file keeps content still referenced by another path
+	s.PutFileContent(ctx, "p", "/src/a.go", "package a // v2\n")
+	if got, _ := s.GetFileContent(ctx, "p", "/src/copy.go"); got != "package a\n" {
+		t.Errorf("shared content was lost: %q", got)
+	}
+	var blobs int
+	s.db.QueryRow("SELECT COUNT(*) FROM file_blobs").Scan(&blobs)
+	if blobs != 2 {
+		t.Errorf("expected 2 blobs, got %d", blobs)
+	}
+
+	// Deleting the project removes its files and their content
+	if err := s.DeleteByProject(ctx, "p"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	s.db.QueryRow("SELECT COUNT(*) FROM file_blobs").Scan(&blobs)
+	if blobs != 0 {
+		t.Errorf("expected blobs removed with the project, got %d", blobs)
+	}
+}
+
+func TestStore_HashCollisions(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// Forge collisions by storing other content under the hashes of the
+	// content about to be written
+	forged := hash.Identity("package b\n")
+	if _, err := s.db.Exec("INSERT INTO file_blobs (hash, content) VALUES (?, ?)", forged, "package evil\n"); err != nil {
+		t.Fatalf("failed to forge blob: %v", err)
+	}
+	if _, err := s.PutFileContent(ctx, "p", "/src/b.go", "package b\n"); !errors.Is(err, ErrHashCollision) {
+		t.Errorf("expected a hash collision error, got %v", err)
+	}
+
+	if err := s.Add(ctx, &types.Memory{ID: "m1", Content: "retry with backoff", Project: "p", Type: types.TypeContext}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+	if _, err := s.db.Exec("UPDATE memories SET content_hash = ? WHERE id = ?", hash.Identity("cap retries at five"), "m1"); err != nil {
+		t.Fatalf("failed to forge content hash: %v", err)
+	}
+	result, err := s.Import(ctx, []*types.Memory{{ID: "m2", Content: "cap retries at five", Project: "p", Type: types.TypeContext}}, store.ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Inserted != 1 {
+		t.Errorf("expected colliding content to import as a new memory, got %+v", result)
+	}
+}
+
+func TestStore_Import_OnConflict(t *testing.T) {
+	ctx := context.Background()
+	seed := func(t *testing.T) *Store {
+		s := createTestStore(t)
+		t.Cleanup(func() { s.Close() })
+		err := s.Add(ctx, &types.Memory{ID: "orig", Content: "use retries", Project: "p", Type: types.TypeContext,
+			Metadata: map[string]string{"tags": "net", "owner": "alice"}})
+		if err != nil {
+			t.Fatalf("failed to add memory: %v", err)
+		}
+		return s
+	}
+	// Same content under a new ID, and the same content in another project
+	incoming := func() []*types.Memory {
+		return []*types.Memory{
+			{ID: "copy", Content: "use retries", Project: "p", Type: types.TypeGotcha,
+				Metadata: map[string]string{"tags": "http, net", "owner": "bob"}},
+			{ID: "other", Content: "use retries", Project: "q", Type: types.TypeContext},
+		}
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		s := seed(t)
+		res, err := s.Import(ctx, incoming(), store.ImportOptions{})
+		if err != nil || res.Skipped != 1 || res.Inserted != 1 {
+			t.Fatalf("expected 1 skipped and 1 inserted, got %+v (%v)", res, err)
+		}
+		if _, err := s.Get(ctx, "copy"); err == nil {
+			t.Error("duplicate content should not be inserted")
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		s := seed(t)
+		res, err := s.Import(ctx, incoming(), store.ImportOptions{OnConflict: store.ConflictMerge})
+		if err != nil || res.Merged != 1 || res.Inserted != 1 {
+			t.Fatalf("expected 1 merged and 1 inserted, got %+v (%v)", res, err)
+		}
+		got, _ := s.Get(ctx, "orig")
+		if got.Metadata["tags"] != "net,http" || got.Metadata["owner"] != "bob" || got.Type != types.TypeContext {
+			t.Errorf("unexpected merge result: %+v", got)
+		}
+
+		// Merging again changes nothing
+		res, _ = s.Import(ctx, incoming(), store.ImportOptions{OnConflict: store.ConflictMerge})
+		if res.Merged != 0 || res.Skipped != 2 {
+			t.Errorf("expected a repeated merge to skip everything, got %+v", res)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		s := seed(t)
+		res, err := s.Import(ctx, incoming(), store.ImportOptions{OnConflict: store.ConflictReplace})
+		if err != nil || res.Replaced != 1 || res.Inserted != 1 {
+			t.Fatalf("expected 1 replaced and 1 inserted, got %+v (%v)", res, err)
+		}
+		if _, err := s.Get(ctx, "orig"); err == nil {
+			t.Error("expected the original to be replaced")
+		}
+		if got, _ := s.Get(ctx, "copy"); got == nil || got.Type != types.TypeGotcha {
+			t.Errorf("expected the incoming memory, got %+v", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		s := seed(t)
+		if _, err := s.Import(ctx, incoming(), store.ImportOptions{OnConflict: "overwrite"}); err == nil {
+			t.Error("expected an error for an unknown policy")
+		}
+	})
+}
+
+func TestStore_MigratesContentHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := New(Config{Path: path, Dimensions: 768})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	ctx := context.Background()
+	s.Add(ctx, &types.Memory{ID: "a", Content: "x", Project: "p", Type: types.TypeContext})
+
+	// Simulate a database from before content_hash existed
+	if _, err := s.db.Exec("DROP INDEX idx_memories_project_hash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec("ALTER TABLE memories DROP COLUMN content_hash"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = New(Config{Path: path, Dimensions: 768})
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer s.Close()
+	res, err := s.Import(ctx, []*types.Memory{{ID: "b", Content: "x", Project: "p", Type: types.TypeContext}}, store.ImportOptions{})
+	if err != nil || res.Skipped != 1 {
+		t.Errorf("expected existing content to be backfilled and matched, got %+v (%v)", res, err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package sqlite records the prompt template a database was embedded with
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// NoPromptTemplate is the template reported by databases that held
+// embeddings before templates were recorded. Their text was embedded
+// unchanged.
+const NoPromptTemplate = "none"
+
+// promptTemplateKey is the store_meta key holding the prompt template
+const promptTemplateKey = "prompt_template"
+
+// PromptTemplate returns the prompt template the store's embeddings were
+// made with, or "" if none is recorded yet
+func (s *Store) PromptTemplate() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.template
+}
+
+// SetPromptTemplate records the prompt template the store's embeddings are
+// made with, after they have all been re-embedded with it
+func (s *Store) SetPromptTemplate(name string) error {
+	if err := s.saveMeta(promptTemplateKey, name); err != nil {
+		return fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	s.mu.Lock()
+	s.template = name
+	s.mu.Unlock()
+	return nil
+}
+
+// resolvePromptTemplate returns the store's prompt template. A store with
+// none recorded takes want while it holds no embeddings, and
+// NoPromptTemplate once it does.
+func (s *Store) resolvePromptTemplate(want string) (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM store_meta WHERE key = ?", promptTemplateKey).Scan(&value)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to read prompt template: %w", err)
+	}
+
+	var embedded bool
+	err = s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM memories WHERE length(embedding) > 0)").Scan(&embedded)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	if embedded {
+		want = NoPromptTemplate
+	}
+	if want == "" {
+		return "", nil
+	}
+	if err := s.saveMeta(promptTemplateKey, want); err != nil {
+		return "", fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	return want, nil
+}
+
+// saveMeta upserts a store_meta value
+func (s *Store) saveMeta(key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO store_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	return err
+}
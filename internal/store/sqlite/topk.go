@@ -0,0 +1,123 @@
+// Package sqlite tunes how search results are ranked
+package sqlite
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// TopKConfig holds the switch points topKResults uses to pick a strategy.
+// Selection costs O(n·k), a heap O(n·log k), and a full sort O(n·log n), so
+// selection wins for tiny k and a sort only once k is a large part of n.
+type TopKConfig struct {
+	SelectMaxK int     `json:"select_max_k"` // Use selection for k up to this (negative never selects)
+	SortRatio  float64 `json:"sort_ratio"`   // Fully sort once k/n reaches this (1 sorts only when k >= n)
+}
+
+// DefaultTopKConfig returns switch points that suit most hosts
+func DefaultTopKConfig() TopKConfig {
+	return TopKConfig{SelectMaxK: 5, SortRatio: 1}
+}
+
+// TopKTiming is the measured cost of each strategy for one k
+type TopKTiming struct {
+	N, K   int
+	Select time.Duration
+	Heap   time.Duration
+	Sort   time.Duration
+}
+
+// calibrationRounds is how many times each strategy is timed per k, and
+// calibrationBudget roughly how long each round lasts
+const (
+	calibrationRounds = 5
+	calibrationBudget = time.Millisecond
+)
+
+// CalibrateTopK times each strategy on n random results over a range of k
+// and returns switch points fitted to this host, with the raw timings.
+// It takes a few hundred milliseconds.
+func CalibrateTopK(n int) (TopKConfig, []TopKTiming) {
+	if n < 16 {
+		n = 16
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	input := make([]types.SearchResult, n)
+	for i := range input {
+		input[i].Similarity = rng.Float32()
+	}
+	work := make([]types.SearchResult, n)
+
+	strategies := []func([]types.SearchResult, int) []types.SearchResult{
+		selectTopK,
+		heapTopK,
+		func(r []types.SearchResult, k int) []types.SearchResult {
+			sortBySimilarity(r)
+			return r[:k]
+		},
+	}
+
+	var timings []TopKTiming
+	for _, k := range calibrationKs(n) {
+		// Interleave rounds and keep each strategy's best, so a burst of
+		// noise hits every strategy instead of deciding the winner
+		var d [3]time.Duration
+		for round := 0; round < calibrationRounds; round++ {
+			for i, strategy := range strategies {
+				if t := timeStrategy(input, work, k, strategy); round == 0 || t < d[i] {
+					d[i] = t
+				}
+			}
+		}
+		timings = append(timings, TopKTiming{N: n, K: k, Select: d[0], Heap: d[1], Sort: d[2]})
+	}
+
+	return fitTopK(timings), timings
+}
+
+// fitTopK picks switch points from timings sorted by k. Selection covers
+// the run of small k where it beats the heap; sorting starts at the first
+// larger k where it beats the heap.
+func fitTopK(timings []TopKTiming) TopKConfig {
+	cfg := TopKConfig{SelectMaxK: -1, SortRatio: 1}
+	i := 0
+	for ; i < len(timings) && timings[i].Select <= timings[i].Heap; i++ {
+		cfg.SelectMaxK = timings[i].K
+	}
+	for ; i < len(timings); i++ {
+		if t := timings[i]; t.Sort < t.Heap {
+			cfg.SortRatio = float64(t.K) / float64(t.N)
+			break
+		}
+	}
+	return cfg
+}
+
+// calibrationKs returns the k values to time for n results: every small k,
+// then roughly doubling up to n/2
+func calibrationKs(n int) []int {
+	var ks []int
+	for k := 1; k <= 16 && k < n; k++ {
+		ks = append(ks, k)
+	}
+	for k := 24; k <= n/2; k *= 2 {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+// timeStrategy returns the average time strategy takes to rank a fresh copy
+// of input, repeating it for about calibrationBudget
+func timeStrategy(input, work []types.SearchResult, k int, strategy func([]types.SearchResult, int) []types.SearchResult) time.Duration {
+	runs := 0
+	start := time.Now()
+	for runs == 0 || time.Since(start) < calibrationBudget {
+		copy(work, input)
+		strategy(work, k)
+		runs++
+	}
+	return time.Since(start) / time.Duration(runs)
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func randomResults(n int) []types.SearchResult {
+	rng := rand.New(rand.NewSource(42))
+	results := make([]types.SearchResult, n)
+	for i := range results {
+		results[i] = types.SearchResult{
+			Memory:     types.Memory{ID: fmt.Sprintf("m%d", i)},
+			Similarity: rng.Float32(),
+		}
+	}
+	return results
+}
+
+func TestTopKResults_StrategiesAgree(t *testing.T) {
+	configs := map[string]TopKConfig{
+		"select": {SelectMaxK: 1000, SortRatio: 1},
+		"heap":   {SelectMaxK: -1, SortRatio: 1},
+		"sort":   {SelectMaxK: -1, SortRatio: 0.0001},
+	}
+
+	for _, k := range []int{1, 5, 20, 199, 200, 500} {
+		var want []types.SearchResult
+		for name, cfg := range configs {
+			input := randomResults(200)
+			got := topKResults(input, k, cfg)
+
+			wantLen := k
+			if wantLen > 200 {
+				wantLen = 200
+			}
+			if len(got) != wantLen {
+				t.Fatalf("%s k=%d: expected %d results, got %d", name, k, wantLen, len(got))
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].Similarity > got[i-1].Similarity {
+					t.Fatalf("%s k=%d: results not sorted at %d", name, k, i)
+				}
+			}
+			if want == nil {
+				want = got
+				continue
+			}
+			for i := range got {
+				if got[i].Similarity != want[i].Similarity {
+					t.Fatalf("%s k=%d: result %d has similarity %f, want %f", name, k, i, got[i].Similarity, want[i].Similarity)
+				}
+			}
+		}
+	}
+}
+
+func TestFitTopK(t *testing.T) {
+	ms := time.Millisecond
+	timings := []TopKTiming{
+		{N: 1000, K: 1, Select: 1 * ms, Heap: 2 * ms, Sort: 9 * ms},
+		{N: 1000, K: 2, Select: 2 * ms, Heap: 2 * ms, Sort: 9 * ms},
+		{N: 1000, K: 3, Select: 3 * ms, Heap: 2 * ms, Sort: 9 * ms},
+		{N: 1000, K: 4, Select: 1 * ms, Heap: 2 * ms, Sort: 9 * ms}, // Noise after the crossover is ignored
+		{N: 1000, K: 100, Select: 50 * ms, Heap: 5 * ms, Sort: 9 * ms},
+		{N: 1000, K: 250, Select: 90 * ms, Heap: 10 * ms, Sort: 9 * ms},
+		{N: 1000, K: 500, Select: 99 * ms, Heap: 12 * ms, Sort: 9 * ms},
+	}
+
+	cfg := fitTopK(timings)
+	if cfg.SelectMaxK != 2 {
+		t.Errorf("expected SelectMaxK 2, got %d", cfg.SelectMaxK)
+	}
+	if cfg.SortRatio != 0.25 {
+		t.Errorf("expected SortRatio 0.25, got %f", cfg.SortRatio)
+	}
+
+	cfg = fitTopK([]TopKTiming{{N: 1000, K: 1, Select: 3 * ms, Heap: 2 * ms, Sort: 9 * ms}})
+	if cfg.SelectMaxK != -1 || cfg.SortRatio != 1 {
+		t.Errorf("expected selection and sorting disabled, got %+v", cfg)
+	}
+}
+
+func TestCalibrateTopK(t *testing.T) {
+	if testing.Short() {
+		t.Skip("calibration times real work")
+	}
+
+	cfg, timings := CalibrateTopK(500)
+	if len(timings) == 0 {
+		t.Fatal("expected timings")
+	}
+	for _, tm := range timings {
+		if tm.N != 500 || tm.K <= 0 || tm.K > 250 {
+			t.Errorf("unexpected timing %+v", tm)
+		}
+	}
+	if cfg.SortRatio <= 0 || cfg.SortRatio > 1 {
+		t.Errorf("sort ratio out of range: %f", cfg.SortRatio)
+	}
+}
+
+func TestNew_TopKDefaults(t *testing.T) {
+	s := createTestStore(t)
+	if s.topk != DefaultTopKConfig() {
+		t.Errorf("expected default top-k config, got %+v", s.topk)
+	}
+}
+
+// BenchmarkTopKStrategies compares the strategies across sizes; the
+// crossovers it shows are what CalibrateTopK fits
+func BenchmarkTopKStrategies(b *testing.B) {
+	strategies := map[string]TopKConfig{
+		"select": {SelectMaxK: 1 << 30, SortRatio: 1},
+		"heap":   {SelectMaxK: -1, SortRatio: 1},
+		"sort":   {SelectMaxK: -1, SortRatio: 1e-9},
+	}
+
+	for _, n := range []int{1000, 10000} {
+		input := randomResults(n)
+		work := make([]types.SearchResult, n)
+		for _, k := range []int{3, 5, 10, 50, n / 4} {
+			for _, name := range []string{"select", "heap", "sort"} {
+				cfg := strategies[name]
+				b.Run(fmt.Sprintf("n=%d/k=%d/%s", n, k, name), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						copy(work, input)
+						topKResults(work, k, cfg)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,336 @@
+// Package sqlite searches with the sqlite-vec extension when it is available
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+// Vector search backends reported by VectorBackend
+const (
+	BackendGo  = "go"         // Every matching embedding is compared in Go
+	BackendVec = "sqlite-vec" // Nearest neighbours come from a vec0 table
+)
+
+// summaryVecPrefix marks memory_vec rows holding summary embeddings
+const summaryVecPrefix = "summary:"
+
+// vecMaxK is the largest k sqlite-vec accepts in a KNN query. Searches that
+// need more neighbours fall back to comparing in Go.
+const vecMaxK = 4096
+
+// vecMinK is the smallest number of neighbours fetched per search, so
+// filtered searches rarely need a second round
+const vecMinK = 64
+
+var (
+	vecDriversMu sync.Mutex
+	vecDrivers   = make(map[string]string) // extension path -> driver name
+)
+
+// vecDriver returns a database/sql driver name that loads the extension at
+// path into every connection
+func vecDriver(path string) string {
+	vecDriversMu.Lock()
+	defer vecDriversMu.Unlock()
+
+	if name, ok := vecDrivers[path]; ok {
+		return name
+	}
+	name := fmt.Sprintf("sqlite3_vec_%d", len(vecDrivers))
+	sql.Register(name, &sqlite3.SQLiteDriver{Extensions: []string{path}})
+	vecDrivers[path] = name
+	return name
+}
+
+// openVec opens dsn with the sqlite-vec extension at path loaded, and
+// checks that the extension works
+func openVec(dsn, path string) (*sql.DB, error) {
+	db, err := sql.Open(vecDriver(path), dsn)
+	if err != nil {
+		return nil, err
+	}
+	var version string
+	if err := db.QueryRow("SELECT vec_version()").Scan(&version); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// VectorBackend reports how Search finds similar memories: BackendVec when
+// the sqlite-vec extension is loaded, otherwise BackendGo
+func (s *Store) VectorBackend() string {
+	if s.vec {
+		return BackendVec
+	}
+	return BackendGo
+}
+
+// VectorBackendError returns why Config.VecExtension couldn't be used, or
+// nil if it is in use or wasn't configured
+func (s *Store) VectorBackendError() error {
+	return s.vecErr
+}
+
+// initVec creates the vec0 table and the triggers that queue changed
+// memories for it. The triggers only touch plain tables, so the database
+// still works without the extension; changes made then stay queued until
+// it is loaded again. Callers hold s.mu or have not shared the store yet.
+func (s *Store) initVec(ctx context.Context) error {
+	column := fmt.Sprintf("float[%d]", s.dims)
+
+	var existing string
+	err := s.db.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE name = 'memory_vec'").Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to look up vector table: %w", err)
+	}
+	rebuild := existing == "" || !strings.Contains(existing, column)
+	if existing != "" && rebuild {
+		if _, err := s.db.ExecContext(ctx, "DROP TABLE memory_vec"); err != nil {
+			return fmt.Errorf("failed to drop vector table: %w", err)
+		}
+	}
+
+	schema := fmt.Sprintf(`
+	CREATE VIRTUAL TABLE IF NOT EXISTS memory_vec USING vec0(
+		vec_id TEXT PRIMARY KEY,
+		embedding %s distance_metric=cosine
+	);
+
+	-- Memories whose vectors changed since memory_vec was last updated
+	CREATE TABLE IF NOT EXISTS vec_pending (memory_id TEXT PRIMARY KEY);
+
+	CREATE TRIGGER IF NOT EXISTS vec_pending_insert AFTER INSERT ON memories
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (new.id);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vec_pending_update AFTER UPDATE OF embedding ON memories
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (new.id);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vec_pending_delete AFTER DELETE ON memories
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (old.id);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vec_pending_vectors_insert AFTER INSERT ON memory_vectors
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (new.memory_id);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vec_pending_vectors_update AFTER UPDATE ON memory_vectors
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (new.memory_id);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vec_pending_vectors_delete AFTER DELETE ON memory_vectors
+	BEGIN
+		INSERT OR IGNORE INTO vec_pending (memory_id) VALUES (old.memory_id);
+	END;
+	`, column)
+	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("failed to create vector table: %w", err)
+	}
+
+	if rebuild {
+		if _, err := s.db.ExecContext(ctx, "INSERT OR IGNORE INTO vec_pending (memory_id) SELECT id FROM memories"); err != nil {
+			return fmt.Errorf("failed to queue memories for the vector table: %w", err)
+		}
+	}
+	return s.flushVec(ctx)
+}
+
+// syncVec brings memory_vec up to date with writes since the last search
+func (s *Store) syncVec(ctx context.Context) error {
+	s.mu.RLock()
+	var pending bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM vec_pending)").Scan(&pending)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to check vector table: %w", err)
+	}
+	if !pending {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushVec(ctx)
+}
+
+// flushVec rewrites the memory_vec rows of every queued memory. Embeddings
+// of another size than the store's are left out. Callers hold s.mu.
+func (s *Store) flushVec(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT memory_id FROM vec_pending")
+	if err != nil {
+		return fmt.Errorf("failed to read vector queue: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read vector queue: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read vector queue: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	size := s.dims * 4
+	for _, id := range ids {
+		stmts := []struct {
+			query string
+			args  []interface{}
+		}{
+			{"DELETE FROM memory_vec WHERE vec_id = ?", []interface{}{id}},
+			{"DELETE FROM memory_vec WHERE vec_id = ?", []interface{}{summaryVecPrefix + id}},
+			{"INSERT INTO memory_vec (vec_id, embedding) SELECT id, embedding FROM memories WHERE id = ? AND length(embedding) = ?",
+				[]interface{}{id, size}},
+			{"INSERT INTO memory_vec (vec_id, embedding) SELECT ? || memory_id, embedding FROM memory_vectors WHERE memory_id = ? AND space = ? AND length(embedding) = ?",
+				[]interface{}{summaryVecPrefix, id, spaceSummary, size}},
+			{"DELETE FROM vec_pending WHERE memory_id = ?", []interface{}{id}},
+		}
+		for _, st := range stmts {
+			if _, err := tx.ExecContext(ctx, st.query, st.args...); err != nil {
+				return fmt.Errorf("failed to update vector table: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit vector table: %w", err)
+	}
+	return nil
+}
+
+// searchVec finds the memories nearest to embedding through memory_vec,
+// then applies the filters of opts by joining the candidates against the
+// memories table. It fetches more neighbours until enough candidates pass
+// the filters and threshold. ok is false when that would exceed vecMaxK,
+// and the caller should compare in Go instead. Callers hold s.mu.
+func (s *Store) searchVec(ctx context.Context, embedding []float32, opts store.SearchOptions, limit int) (results []types.SearchResult, total int, ok bool, err error) {
+	conditions, args := searchConditions(opts)
+	query := fmt.Sprintf(`
+		SELECT id, content, project, type, file_path, language, metadata, embedding, created_at, updated_at,
+		       (SELECT v.embedding FROM memory_vectors v WHERE v.memory_id = memories.id AND v.space = ?)
+		FROM memories
+		WHERE id IN (SELECT value FROM json_each(?)) AND %s
+	`, strings.Join(conditions, " AND "))
+
+	for k := max(limit*4, vecMinK); k <= vecMaxK; k *= 4 {
+		neighbours, err := s.nearestVectors(ctx, embedding, k)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		exhausted := len(neighbours) < k
+
+		// Each memory scores its best vector, content or summary
+		best := make(map[string]float32, len(neighbours))
+		var ids []string
+		for _, n := range neighbours {
+			if sim, seen := best[n.id]; !seen {
+				best[n.id] = n.similarity
+				ids = append(ids, n.id)
+			} else if n.similarity > sim {
+				best[n.id] = n.similarity
+			}
+		}
+		idsJSON, err := json.Marshal(ids)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("failed to encode candidates: %w", err)
+		}
+
+		rows, err := s.db.QueryContext(ctx, query, append([]interface{}{spaceSummary, string(idsJSON)}, args...)...)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("failed to query memories: %w", err)
+		}
+		results = results[:0]
+		for rows.Next() {
+			var summaryBytes []byte
+			memory, err := s.scanMemoryFromRows(rows, &summaryBytes)
+			if err != nil {
+				rows.Close()
+				return nil, 0, false, fmt.Errorf("failed to scan memory: %w", err)
+			}
+			memory.SummaryEmbedding = bytesToFloat32(summaryBytes)
+			similarity := best[memory.ID]
+			if opts.Threshold > 0 && similarity < opts.Threshold {
+				continue
+			}
+			results = append(results, types.SearchResult{Memory: *memory, Similarity: similarity})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, 0, false, fmt.Errorf("failed to query memories: %w", err)
+		}
+
+		// With a threshold, everything past the farthest neighbour fetched
+		// is below it once that neighbour is, so the count is complete
+		if opts.Threshold > 0 {
+			if exhausted || neighbours[len(neighbours)-1].similarity < opts.Threshold {
+				return topKResults(results, limit, s.topk), len(results), true, nil
+			}
+			continue
+		}
+		if exhausted || len(results) >= limit {
+			count := fmt.Sprintf("SELECT COUNT(*) FROM memories WHERE %s", strings.Join(conditions, " AND "))
+			if err := s.db.QueryRowContext(ctx, count, args...).Scan(&total); err != nil {
+				return nil, 0, false, fmt.Errorf("failed to count memories: %w", err)
+			}
+			return topKResults(results, limit, s.topk), total, true, nil
+		}
+	}
+	return nil, 0, false, nil
+}
+
+// neighbour is a memory_vec row found by a KNN query
+type neighbour struct {
+	id         string // Memory ID
+	similarity float32
+}
+
+// nearestVectors returns the k vectors nearest to embedding, nearest first
+func (s *Store) nearestVectors(ctx context.Context, embedding []float32, k int) ([]neighbour, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT vec_id, distance FROM memory_vec
+		WHERE embedding MATCH ? AND k = ?
+		ORDER BY distance
+	`, float32ToBytes(embedding), k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vector table: %w", err)
+	}
+	defer rows.Close()
+
+	var neighbours []neighbour
+	for rows.Next() {
+		var id string
+		var distance float64
+		if err := rows.Scan(&id, &distance); err != nil {
+			return nil, fmt.Errorf("failed to scan neighbour: %w", err)
+		}
+		// Cosine distance is 1 - cosine similarity
+		neighbours = append(neighbours, neighbour{
+			id:         strings.TrimPrefix(id, summaryVecPrefix),
+			similarity: float32(1 - distance),
+		})
+	}
+	return neighbours, rows.Err()
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shivavenkatesh/moneta/internal/store"
+	"github.com/shivavenkatesh/moneta/pkg/types"
+)
+
+func TestNew_VecExtensionFallback(t *testing.T) {
+	s, err := New(Config{
+		Path:         filepath.Join(t.TempDir(), "test.db"),
+		Dimensions:   768,
+		VecExtension: filepath.Join(t.TempDir(), "missing-vec0.so"),
+	})
+	if err != nil {
+		t.Fatalf("expected a missing extension to fall back, got %v", err)
+	}
+	defer s.Close()
+
+	if s.VectorBackend() != BackendGo || s.VectorBackendError() == nil {
+		t.Errorf("expected the Go backend with a load error, got %s (%v)", s.VectorBackend(), s.VectorBackendError())
+	}
+
+	ctx := context.Background()
+	if err := s.Add(ctx, &types.Memory{ID: "m", Content: "c", Project: "p", Type: types.TypeContext, Embedding: generateTestEmbedding(768)}); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+	if results, _, err := s.Search(ctx, generateTestEmbedding(768), store.SearchOptions{Limit: 1}); err != nil || len(results) != 1 {
+		t.Errorf("expected search to work without the extension, got %d results (%v)", len(results), err)
+	}
+}
+
+func TestNew_DefaultVectorBackend(t *testing.T) {
+	s := createTestStore(t)
+	defer s.Close()
+	if s.VectorBackend() != BackendGo || s.VectorBackendError() != nil {
+		t.Errorf("expected the Go backend by default, got %s (%v)", s.VectorBackend(), s.VectorBackendError())
+	}
+}
+
+// TestStore_Search_VecMatchesGo needs the sqlite-vec extension; point
+// SQLITE_VEC_PATH at it to run
+func TestStore_Search_VecMatchesGo(t *testing.T) {
+	ext := os.Getenv("SQLITE_VEC_PATH")
+	if ext == "" {
+		t.Skip("SQLITE_VEC_PATH not set")
+	}
+
+	const dims = 32
+	path := filepath.Join(t.TempDir(), "test.db")
+	goStore, err := New(Config{Path: path, Dimensions: dims})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(7))
+	vector := func() []float32 {
+		v := make([]float32, dims)
+		for i := range v {
+			v[i] = rng.Float32()*2 - 1
+		}
+		return v
+	}
+	var memories []*types.Memory
+	for i := 0; i < 500; i++ {
+		m := &types.Memory{
+			ID:        fmt.Sprintf("m%03d", i),
+			Content:   fmt.Sprintf("memory %d", i),
+			Project:   []string{"a", "b"}[i%2],
+			Type:      types.TypeContext,
+			Embedding: vector(),
+		}
+		if i%10 == 0 {
+			m.SummaryEmbedding = vector()
+		}
+		memories = append(memories, m)
+	}
+	if err := goStore.AddBatch(ctx, memories, store.AddOptions{}); err != nil {
+		t.Fatalf("failed to add memories: %v", err)
+	}
+	goStore.Close()
+
+	vecStore, err := New(Config{Path: path, Dimensions: dims, VecExtension: ext})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer vecStore.Close()
+	if vecStore.VectorBackend() != BackendVec {
+		t.Fatalf("expected sqlite-vec, got %s (%v)", vecStore.VectorBackend(), vecStore.VectorBackendError())
+	}
+	goStore, _ = New(Config{Path: path, Dimensions: dims})
+	defer goStore.Close()
+
+	// Writes after opening are picked up by the next search
+	extra := &types.Memory{ID: "extra", Content: "extra", Project: "a", Type: types.TypeContext, Embedding: vector()}
+	if err := vecStore.Add(ctx, extra); err != nil {
+		t.Fatalf("failed to add memory: %v", err)
+	}
+
+	for _, opts := range []store.SearchOptions{
+		{Limit: 10},
+		{Limit: 5, Project: "b"},
+		{Limit: 20, Threshold: 0.3},
+		{Limit: 3, Project: "a", Threshold: 0.1},
+	} {
+		query := vector()
+		want, wantTotal, err := goStore.Search(ctx, query, opts)
+		if err != nil {
+			t.Fatalf("go search failed: %v", err)
+		}
+		got, gotTotal, err := vecStore.Search(ctx, query, opts)
+		if err != nil {
+			t.Fatalf("vec search failed: %v", err)
+		}
+		if gotTotal != wantTotal || len(got) != len(want) {
+			t.Fatalf("%+v: expected %d of %d results, got %d of %d", opts, len(want), wantTotal, len(got), gotTotal)
+		}
+		for i := range want {
+			if got[i].Memory.ID != want[i].Memory.ID {
+				t.Errorf("%+v: result %d is %s, want %s", opts, i, got[i].Memory.ID, want[i].Memory.ID)
+			}
+		}
+	}
+}
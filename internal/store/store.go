@@ -3,6 +3,9 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/shivavenkatesh/moneta/pkg/types"
 )
@@ -15,35 +18,252 @@ type Store interface {
 	// Get retrieves a memory by ID
 	Get(ctx context.Context, id string) (*types.Memory, error)
 
+	// GetBatch retrieves memories by ID in one query, in the order given.
+	// Unknown IDs leave nil entries rather than failing the batch.
+	GetBatch(ctx context.Context, ids []string) ([]*types.Memory, error)
+
 	// Update modifies an existing memory
 	Update(ctx context.Context, memory *types.Memory) error
 
+	// UpdateMetadata writes only the type and metadata of an existing
+	// memory, leaving content and embedding untouched
+	UpdateMetadata(ctx context.Context, memory *types.Memory) error
+
+	// UpdateWhere applies changes to every memory matching filter without
+	// touching content or embeddings, and returns how many memories changed
+	UpdateWhere(ctx context.Context, filter UpdateFilter, changes UpdateChanges) (int, error)
+
 	// Delete removes a memory by ID
 	Delete(ctx context.Context, id string) error
 
-	// AddBatch adds multiple memories efficiently
-	AddBatch(ctx context.Context, memories []*types.Memory) error
+	// DeleteBatch removes memories by ID in a single transaction and returns
+	// how many were actually deleted; unknown IDs are not an error
+	DeleteBatch(ctx context.Context, ids []string) (int, error)
+
+	// AddBatch adds multiple memories efficiently in a single transaction.
+	// A failing row rolls back the batch and is reported as a *BatchError.
+	AddBatch(ctx context.Context, memories []*types.Memory, opts AddOptions) error
 
 	// DeleteByProject removes all memories for a project
 	DeleteByProject(ctx context.Context, project string) error
 
-	// Search finds similar memories using vector search
-	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]types.SearchResult, error)
+	// Search finds similar memories using vector search. It also returns
+	// how many memories matched the filters and threshold before the limit.
+	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]types.SearchResult, int, error)
+
+	// KeywordSearch finds memories containing every word of query, without
+	// embeddings, for queries too short to embed well. Similarity is a
+	// match score in [0, 1], not a cosine.
+	KeywordSearch(ctx context.Context, query string, opts SearchOptions) ([]types.SearchResult, int, error)
 
 	// List returns memories with filtering and pagination
 	List(ctx context.Context, opts ListOptions) ([]*types.Memory, error)
 
+	// CountList returns how many memories match the filters of opts,
+	// ignoring its limit and offset
+	CountList(ctx context.Context, opts ListOptions) (int, error)
+
 	// Count returns the number of memories, optionally filtered by project
 	Count(ctx context.Context, project string) (int, error)
 
+	// ListProjects returns every project with memories or metadata, with
+	// memory counts. Display fields are left for the caller to fill in.
+	ListProjects(ctx context.Context) ([]types.Project, error)
+
+	// GetProjectMeta returns the key-value metadata stored for a project
+	GetProjectMeta(ctx context.Context, project string) (map[string]string, error)
+
+	// SetProjectMeta upserts project metadata; an empty value deletes the key
+	SetProjectMeta(ctx context.Context, project string, meta map[string]string) error
+
+	// PutFileContent stores the full content of an indexed file, replacing
+	// any earlier version, and returns its content hash. Identical content
+	// is stored once however many paths share it.
+	PutFileContent(ctx context.Context, project, path, content string) (string, error)
+
+	// GetFileContent returns the stored content of an indexed file
+	GetFileContent(ctx context.Context, project, path string) (string, error)
+
+	// GetIndexedFile returns what was recorded when project/path was last
+	// indexed, or nil if it never was
+	GetIndexedFile(ctx context.Context, project, path string) (*IndexedFile, error)
+
+	// PutIndexedFile records that a file was indexed, replacing any
+	// earlier record
+	PutIndexedFile(ctx context.Context, file IndexedFile) error
+
+	// DeleteByFilePath removes the chunks indexing stored for project/path
+	// and returns how many it removed. Memories added by hand with that
+	// file path are kept.
+	DeleteByFilePath(ctx context.Context, project, path string) (int, error)
+
+	// NeighborChunks returns up to n indexed chunks before and n after
+	// memory in the same file, ordered by start line
+	NeighborChunks(ctx context.Context, memory *types.Memory, n int) ([]*types.Memory, error)
+
 	// Stats returns storage statistics
 	Stats(ctx context.Context) (*types.StatsResponse, error)
 
+	// Ping verifies the store connection is healthy
+	Ping(ctx context.Context) error
+
 	// Close releases resources
 	Close() error
 
 	// Compact optimizes storage (VACUUM)
 	Compact(ctx context.Context) error
+
+	// Checkpoint flushes the write-ahead log into the database. An empty
+	// mode truncates the log afterwards.
+	Checkpoint(ctx context.Context, mode string) error
+
+	// Export streams memories in stable ID order, starting after opts.AfterID.
+	// fn must not call back into the store.
+	Export(ctx context.Context, opts ExportOptions, fn func(*types.Memory) error) error
+
+	// Iterate calls fn for every memory matching the filters of opts, in
+	// the order of opts, through a single cursor. Limit and Offset are
+	// ignored. It stops at the first error from fn, which it returns
+	// wrapped, or when ctx is done. fn must not call back into the store.
+	Iterate(ctx context.Context, opts ListOptions, fn func(*types.Memory) error) error
+
+	// Import inserts memories. A memory whose ID exists, or whose content
+	// matches a memory in the same project, is handled by opts.OnConflict.
+	Import(ctx context.Context, memories []*types.Memory, opts ImportOptions) (*ImportResult, error)
+}
+
+// UpdateFilter selects memories for UpdateWhere. Empty fields match all.
+type UpdateFilter struct {
+	Project        string
+	Type           types.MemoryType
+	Language       string
+	FilePathPrefix string
+}
+
+// UpdateChanges describes a bulk edit. Tags are kept in the "tags" metadata
+// key as a comma-separated list.
+type UpdateChanges struct {
+	Type    types.MemoryType // New type (empty = unchanged)
+	AddTags []string         // Tags to add where missing
+	DryRun  bool             // Count the memories that would change without updating
+}
+
+// TagsKey is the metadata key holding a memory's comma-separated tags
+const TagsKey = "tags"
+
+// MergeTags appends the tags missing from a comma-separated tag list. The
+// list is returned unchanged when nothing is missing.
+func MergeTags(list string, tags ...string) string {
+	have := make(map[string]bool)
+	for _, t := range strings.Split(list, ",") {
+		have[strings.TrimSpace(t)] = true
+	}
+	for _, t := range tags {
+		if t == "" || have[t] {
+			continue
+		}
+		have[t] = true
+		if list != "" {
+			list += ","
+		}
+		list += t
+	}
+	return list
+}
+
+// IndexedFile records the version of a file that was last indexed
+type IndexedFile struct {
+	Project    string
+	Path       string
+	Hash       string // SHA-256 of the file's bytes
+	ModTime    time.Time
+	ChunkCount int
+}
+
+// AddOptions configures batch inserts
+type AddOptions struct {
+	SkipExisting bool // Silently skip memories whose ID already exists
+}
+
+// BatchError identifies the memory that caused a batch operation to fail
+type BatchError struct {
+	ID    string // ID of the failing memory
+	Index int    // Position of the failing memory in the batch
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("memory %s (index %d): %v", e.ID, e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictPolicy decides what Import does with a memory that duplicates an
+// existing one
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing memory unchanged
+	ConflictSkip ConflictPolicy = "skip"
+
+	// ConflictMerge adds the incoming tags to the existing memory and copies
+	// its other metadata, overwriting keys present in both
+	ConflictMerge ConflictPolicy = "merge"
+
+	// ConflictReplace overwrites the existing memory with the incoming one
+	ConflictReplace ConflictPolicy = "replace"
+)
+
+// ParseConflictPolicy parses a policy name; empty selects ConflictSkip
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(name); policy {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictMerge, ConflictReplace:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (want skip, merge, or replace)", name)
+	}
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	OnConflict ConflictPolicy // Empty means ConflictSkip
+}
+
+// ImportResult counts what Import did with each memory
+type ImportResult struct {
+	Inserted int // New memories
+	Skipped  int // Duplicates left unchanged, including merges that changed nothing
+	Merged   int // Duplicates whose metadata was merged
+	Replaced int // Duplicates overwritten
+}
+
+// MergeMetadata merges incoming metadata into existing: tags are unioned
+// and other incoming keys win. It returns the result and whether it differs
+// from existing.
+func MergeMetadata(existing, incoming map[string]string) (map[string]string, bool) {
+	merged := make(map[string]string, len(existing)+len(incoming))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	changed := false
+	for k, v := range incoming {
+		if k == TagsKey {
+			var tags []string
+			for _, t := range strings.Split(v, ",") {
+				tags = append(tags, strings.TrimSpace(t))
+			}
+			v = MergeTags(merged[k], tags...)
+		}
+		if merged[k] != v {
+			merged[k] = v
+			changed = true
+		}
+	}
+	return merged, changed
 }
 
 // SearchOptions configures vector search
@@ -53,14 +273,45 @@ type SearchOptions struct {
 	Limit     int
 	Threshold float32  // Minimum similarity score (0-1)
 	FilePaths []string // Filter by file paths (prefix match)
+
+	// ExactFilePaths filters by exact file path (equality), for scoping to
+	// specific files rather than directories
+	ExactFilePaths []string
+
+	// MetadataFilters keeps memories whose metadata has every key set to
+	// the given value
+	MetadataFilters map[string]string
+
+	// Fuzzy lets KeywordSearch match words with typos. Search ignores it.
+	Fuzzy bool
 }
 
 // ListOptions configures listing queries
 type ListOptions struct {
-	Project    string
-	Type       types.MemoryType
-	Limit      int
-	Offset     int
-	OrderBy    string // "created_at", "updated_at"
-	Descending bool
+	Project        string
+	Type           types.MemoryType
+	Language       string
+	FilePathPrefix string // Only memories whose file path starts with this
+	Limit          int    // 0 uses the default page size (100); NoLimit returns everything
+	Offset         int
+	OrderBy        string // "created_at", "updated_at"
+	Descending     bool
+
+	// MetadataFilters keeps memories whose metadata has every key set to
+	// the given value
+	MetadataFilters map[string]string
+}
+
+// NoLimit as ListOptions.Limit removes the limit entirely. Prefer Iterate
+// for very large result sets.
+const NoLimit = -1
+
+// ExportOptions configures streaming export
+type ExportOptions struct {
+	Project string // Empty exports all projects
+	AfterID string // Resume cursor: only IDs greater than this are exported
+
+	// NoEmbeddings leaves embeddings out of an NDJSON export, for moving
+	// memories to a different embedding model; importing re-embeds them
+	NoEmbeddings bool
 }
@@ -0,0 +1,138 @@
+// Package summarize provides an Ollama-backed summarizer
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OllamaSummarizer summarizes chunks with Ollama's generate API. Any
+// instruction-following model works.
+type OllamaSummarizer struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// OllamaConfig configures the Ollama summarizer
+type OllamaConfig struct {
+	BaseURL string
+	Model   string
+	Timeout time.Duration // Per summary
+}
+
+// DefaultOllamaConfig returns sensible defaults
+func DefaultOllamaConfig() OllamaConfig {
+	return OllamaConfig{
+		BaseURL: getEnvOrDefault("OLLAMA_HOST", "http://localhost:11434"),
+		Model:   getEnvOrDefault("SUMMARY_MODEL", "llama3.2:1b"),
+		Timeout: 60 * time.Second,
+	}
+}
+
+// NewOllamaSummarizer creates a new Ollama summarizer
+func NewOllamaSummarizer(cfg OllamaConfig) *OllamaSummarizer {
+	defaults := DefaultOllamaConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaults.Model
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+
+	return &OllamaSummarizer{
+		baseURL:    cfg.BaseURL,
+		model:      cfg.Model,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// generateRequest is the request payload for Ollama generate API
+type generateRequest struct {
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// generateResponse is the response from Ollama generate API
+type generateResponse struct {
+	Response string `json:"response"`
+}
+
+// summaryPrompt asks the model for a single plain-English sentence
+const summaryPrompt = `Describe what the following %s code does in one plain-English sentence.
+Do not quote identifiers unless necessary. Reply with only the sentence.
+
+%s`
+
+// Summarize returns a one-line summary of content
+func (s *OllamaSummarizer) Summarize(ctx context.Context, content, language string) (string, error) {
+	if language == "" {
+		language = "source"
+	}
+
+	jsonBody, err := json.Marshal(generateRequest{
+		Model:   s.model,
+		Prompt:  fmt.Sprintf(summaryPrompt, language, content),
+		Options: map[string]interface{}{"temperature": 0},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/api/generate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	summary := firstLine(out.Response)
+	if summary == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return summary, nil
+}
+
+// firstLine returns the first non-empty line of a model reply
+func firstLine(reply string) string {
+	for _, line := range strings.Split(reply, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
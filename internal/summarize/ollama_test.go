@@ -0,0 +1,43 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaSummarizer_Summarize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req generateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Prompt, "go code") || !strings.Contains(req.Prompt, "func retry()") {
+			t.Errorf("prompt missing language or content: %q", req.Prompt)
+		}
+		json.NewEncoder(w).Encode(generateResponse{Response: "\n  Retries a request with backoff.\nExtra detail."})
+	}))
+	defer srv.Close()
+
+	s := NewOllamaSummarizer(OllamaConfig{BaseURL: srv.URL, Model: "test"})
+	summary, err := s.Summarize(context.Background(), "func retry() {}", "go")
+	if err != nil {
+		t.Fatalf("summarize failed: %v", err)
+	}
+	if summary != "Retries a request with backoff." {
+		t.Errorf("expected first non-empty line, got %q", summary)
+	}
+}
+
+func TestOllamaSummarizer_EmptyReply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(generateResponse{Response: "  \n"})
+	}))
+	defer srv.Close()
+
+	s := NewOllamaSummarizer(OllamaConfig{BaseURL: srv.URL, Model: "test"})
+	if _, err := s.Summarize(context.Background(), "x", ""); err == nil {
+		t.Error("expected an error for an empty summary")
+	}
+}
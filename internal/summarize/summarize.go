@@ -0,0 +1,11 @@
+// Package summarize generates short natural-language descriptions of code
+// chunks, embedded alongside the code so prose queries can match it
+package summarize
+
+import "context"
+
+// Summarizer describes a chunk of code in one sentence
+type Summarizer interface {
+	// Summarize returns a one-line summary of content
+	Summarize(ctx context.Context, content, language string) (string, error)
+}
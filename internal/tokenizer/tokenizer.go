@@ -0,0 +1,100 @@
+// Package tokenizer counts and encodes text as an embedding or language
+// model would see it
+package tokenizer
+
+import (
+	"hash/fnv"
+	"sort"
+	"unicode"
+)
+
+// Tokenizer splits text into model tokens
+type Tokenizer interface {
+	// Count returns how many tokens text encodes to
+	Count(text string) int
+
+	// Encode returns the token IDs of text
+	Encode(text string) []int
+}
+
+// Heuristic estimates tokens without a vocabulary. Runs of letters and
+// digits, and runs of other symbols, cost one token per four characters;
+// whitespace is free. That tracks BPE and WordPiece tokenizers on prose and
+// code closely enough for chunk sizes and prompt budgets.
+type Heuristic struct{}
+
+// Count returns the estimated token count of text
+func (Heuristic) Count(text string) int {
+	n := 0
+	pieces(text, func(string) { n++ })
+	return n
+}
+
+// Encode returns a hash of each estimated token. The IDs are stable but
+// belong to no model's vocabulary.
+func (Heuristic) Encode(text string) []int {
+	var ids []int
+	pieces(text, func(p string) {
+		h := fnv.New32a()
+		h.Write([]byte(p))
+		ids = append(ids, int(h.Sum32()))
+	})
+	return ids
+}
+
+// pieceRunes is how many characters of one run make a Heuristic token
+const pieceRunes = 4
+
+// pieces calls fn with each Heuristic token of text
+func pieces(text string, fn func(string)) {
+	start, class, runes := -1, 0, 0
+	for i, r := range text {
+		c := runeClass(r)
+		if start >= 0 && (c != class || runes == pieceRunes) {
+			fn(text[start:i])
+			start = -1
+		}
+		if c == 0 {
+			continue
+		}
+		if start < 0 {
+			start, class, runes = i, c, 0
+		}
+		runes++
+	}
+	if start >= 0 {
+		fn(text[start:])
+	}
+}
+
+// runeClass returns 0 for whitespace, 1 for word characters, and 2 for
+// other symbols
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Truncate returns the longest prefix of text, cut at a character boundary,
+// that t counts as at most maxTokens. maxTokens <= 0 keeps text whole.
+func Truncate(t Tokenizer, text string, maxTokens int) string {
+	if maxTokens <= 0 || t.Count(text) <= maxTokens {
+		return text
+	}
+
+	var offsets []int
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	// offsets[k] ends the prefix of k characters. The empty prefix always
+	// fits, so the first one too long has k >= 1.
+	k := sort.Search(len(offsets), func(k int) bool {
+		return t.Count(text[:offsets[k]]) > maxTokens
+	})
+	return text[:offsets[k-1]]
+}
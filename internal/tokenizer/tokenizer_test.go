@@ -0,0 +1,48 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeuristic_Count(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"one two three four five six seven eight nine ten", 13},
+		{"  \n ", 0},
+		{"func (s *Store) Get() {}", 10},
+		{strings.Repeat("x", 400), 100},
+	}
+	for _, tt := range tests {
+		if got := (Heuristic{}).Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q): expected %d, got %d", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestHeuristic_Encode(t *testing.T) {
+	ids := Heuristic{}.Encode("retry retry")
+	if len(ids) != 4 || ids[0] != ids[2] || ids[1] != ids[3] || ids[0] == ids[1] {
+		t.Errorf("expected stable IDs for repeated pieces, got %v", ids)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := "alpha beta gamma delta"
+	if got := Truncate(Heuristic{}, text, 0); got != text {
+		t.Errorf("expected no limit to keep the text, got %q", got)
+	}
+	if got := Truncate(Heuristic{}, text, 10); got != text {
+		t.Errorf("expected text within the limit to be kept, got %q", got)
+	}
+	// "alpha" is two pieces, "alph" and "a"
+	got := Truncate(Heuristic{}, text, 3)
+	if strings.TrimSpace(got) != "alpha beta" || (Heuristic{}).Count(got) != 3 {
+		t.Errorf("expected the longest prefix of 3 tokens, got %q", got)
+	}
+	if got := Truncate(Heuristic{}, "héllo wörld", 2); got != "héllo" && got != "héllo " {
+		t.Errorf("expected a cut at a character boundary, got %q", got)
+	}
+}
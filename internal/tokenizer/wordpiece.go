@@ -0,0 +1,181 @@
+// Package tokenizer loads WordPiece tokenizers from HuggingFace
+// tokenizer.json files
+package tokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordPiece is a BERT-style tokenizer, as used by nomic-embed-text,
+// all-MiniLM, and bge. Accents are not stripped, so counts for accented
+// text can run slightly high.
+type WordPiece struct {
+	vocab        map[string]int
+	unk          int
+	prefix       string
+	maxWordChars int
+	lowercase    bool
+}
+
+// tokenizerFile is the part of a tokenizer.json that WordPiece needs
+type tokenizerFile struct {
+	Normalizer *normalizer `json:"normalizer"`
+	Model      struct {
+		Type          string          `json:"type"`
+		UnkToken      string          `json:"unk_token"`
+		Prefix        *string         `json:"continuing_subword_prefix"`
+		MaxInputChars int             `json:"max_input_chars_per_word"`
+		Vocab         json.RawMessage `json:"vocab"`
+	} `json:"model"`
+}
+
+// normalizer is a tokenizer.json normalizer, possibly a sequence
+type normalizer struct {
+	Type        string        `json:"type"`
+	Lowercase   bool          `json:"lowercase"`
+	Normalizers []*normalizer `json:"normalizers"`
+}
+
+// lowercases reports whether n lowercases text
+func (n *normalizer) lowercases() bool {
+	if n == nil {
+		return false
+	}
+	switch n.Type {
+	case "Lowercase":
+		return true
+	case "BertNormalizer":
+		return n.Lowercase
+	}
+	for _, child := range n.Normalizers {
+		if child.lowercases() {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads a WordPiece tokenizer from a HuggingFace tokenizer.json
+func Load(path string) (*WordPiece, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer: %w", err)
+	}
+
+	var f tokenizerFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer %s: %w", path, err)
+	}
+	if f.Model.Type != "WordPiece" {
+		return nil, fmt.Errorf("unsupported tokenizer model %q in %s (want WordPiece)", f.Model.Type, path)
+	}
+
+	w := &WordPiece{
+		prefix:       "##",
+		maxWordChars: f.Model.MaxInputChars,
+		lowercase:    f.Normalizer.lowercases(),
+	}
+	if f.Model.Prefix != nil {
+		w.prefix = *f.Model.Prefix
+	}
+	if w.maxWordChars <= 0 {
+		w.maxWordChars = 100
+	}
+	if err := json.Unmarshal(f.Model.Vocab, &w.vocab); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer vocabulary: %w", err)
+	}
+	unk, ok := w.vocab[f.Model.UnkToken]
+	if !ok {
+		return nil, fmt.Errorf("unknown token %q is missing from the vocabulary of %s", f.Model.UnkToken, path)
+	}
+	w.unk = unk
+	return w, nil
+}
+
+// Count returns how many tokens text encodes to, without the [CLS] and
+// [SEP] tokens a model adds around it
+func (w *WordPiece) Count(text string) int {
+	return len(w.Encode(text))
+}
+
+// Encode returns the vocabulary IDs of text
+func (w *WordPiece) Encode(text string) []int {
+	if w.lowercase {
+		text = strings.ToLower(text)
+	}
+	var ids []int
+	for _, word := range splitWords(text) {
+		ids = w.appendWord(ids, word)
+	}
+	return ids
+}
+
+// appendWord appends the longest vocabulary pieces that spell word, or the
+// unknown token if it can't be spelled
+func (w *WordPiece) appendWord(ids []int, word string) []int {
+	if utf8.RuneCountInString(word) > w.maxWordChars {
+		return append(ids, w.unk)
+	}
+
+	n := len(ids)
+	for start := 0; start < len(word); {
+		end, id := len(word), -1
+		for end > start {
+			piece := word[start:end]
+			if start > 0 {
+				piece = w.prefix + piece
+			}
+			if v, ok := w.vocab[piece]; ok {
+				id = v
+				break
+			}
+			_, size := utf8.DecodeLastRuneInString(word[start:end])
+			end -= size
+		}
+		if id < 0 {
+			return append(ids[:n], w.unk)
+		}
+		ids = append(ids, id)
+		start = end
+	}
+	return ids
+}
+
+// splitWords splits text as BERT's pre-tokenizer does: on whitespace, with
+// every punctuation mark and CJK character a word of its own
+func splitWords(text string) []string {
+	var words []string
+	start := -1
+	for i, r := range text {
+		alone := isPunct(r) || unicode.Is(unicode.Han, r)
+		if start >= 0 && (alone || unicode.IsSpace(r)) {
+			words = append(words, text[start:i])
+			start = -1
+		}
+		switch {
+		case alone:
+			words = append(words, string(r))
+		case unicode.IsSpace(r):
+		case start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// isPunct reports whether BERT treats r as punctuation: any ASCII symbol,
+// or a Unicode punctuation mark
+func isPunct(r rune) bool {
+	if r >= 33 && r <= 47 || r >= 58 && r <= 64 || r >= 91 && r <= 96 || r >= 123 && r <= 126 {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
@@ -0,0 +1,54 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTokenizer writes a tokenizer.json with the given model and returns its path
+func writeTokenizer(t *testing.T, model string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	data := `{"normalizer": {"type": "BertNormalizer", "lowercase": true}, "model": ` + model + `}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_WordPiece(t *testing.T) {
+	path := writeTokenizer(t, `{"type": "WordPiece", "unk_token": "[UNK]", "continuing_subword_prefix": "##",
+		"max_input_chars_per_word": 100,
+		"vocab": {"[UNK]": 0, "un": 1, "##aff": 2, "##able": 3, "hello": 4, ",": 5, "world": 6}}`)
+	w, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	got := w.Encode("Hello, unaffable world!")
+	want := []int{4, 5, 1, 2, 3, 6, 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if n := w.Count("unaffably world"); n != 2 {
+		t.Errorf("expected an unspellable word to be one unknown token, got %d tokens", n)
+	}
+	if got := Truncate(w, "hello world hello", 2); strings.TrimSpace(got) != "hello world" {
+		t.Errorf("expected truncation by vocabulary tokens, got %q", got)
+	}
+}
+
+func TestLoad_Unsupported(t *testing.T) {
+	if _, err := Load(writeTokenizer(t, `{"type": "BPE", "vocab": {}}`)); err == nil || !strings.Contains(err.Error(), "BPE") {
+		t.Errorf("expected an unsupported model error, got %v", err)
+	}
+	if _, err := Load(writeTokenizer(t, `{"type": "WordPiece", "unk_token": "[UNK]", "vocab": {"a": 0}}`)); err == nil {
+		t.Error("expected an error for a vocabulary without the unknown token")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
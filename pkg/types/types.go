@@ -1,7 +1,12 @@
 // Package types defines the core data structures for Moneta
 package types
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Memory represents a single memory entry
 type Memory struct {
@@ -15,6 +20,10 @@ type Memory struct {
 	Embedding []float32         `json:"-"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
+
+	// SummaryEmbedding embeds a natural-language summary of Content, so
+	// searches can match either the content or its summary (optional)
+	SummaryEmbedding []float32 `json:"-"`
 }
 
 // MemoryType categorizes memories for better organization
@@ -29,6 +38,51 @@ const (
 	TypePreference   MemoryType = "preference"   // User coding preferences
 )
 
+// MemoryTypes lists every memory type
+var MemoryTypes = []MemoryType{
+	TypeArchitecture, TypePattern, TypeDecision, TypeGotcha, TypeContext, TypePreference,
+}
+
+// Valid reports whether t is one of MemoryTypes
+func (t MemoryType) Valid() bool {
+	for _, known := range MemoryTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// validateType rejects a type that isn't one of MemoryTypes
+func validateType(t MemoryType) error {
+	if t == "" {
+		return errors.New("type is required")
+	}
+	if !t.Valid() {
+		names := make([]string, len(MemoryTypes))
+		for i, known := range MemoryTypes {
+			names[i] = string(known)
+		}
+		return fmt.Errorf("unknown memory type %q (want %s)", t, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// Validate checks a memory before it is stored: it needs content and a
+// known type, and a summary embedding must be as long as its embedding
+func (m *Memory) Validate() error {
+	if m.Content == "" {
+		return errors.New("content is required")
+	}
+	if err := validateType(m.Type); err != nil {
+		return err
+	}
+	if len(m.SummaryEmbedding) > 0 && len(m.SummaryEmbedding) != len(m.Embedding) {
+		return fmt.Errorf("summary embedding has %d dimensions, embedding has %d", len(m.SummaryEmbedding), len(m.Embedding))
+	}
+	return nil
+}
+
 // Chunk represents a piece of code or text that was chunked
 type Chunk struct {
 	Content   string `json:"content"`
@@ -36,12 +90,20 @@ type Chunk struct {
 	EndLine   int    `json:"end_line"`
 	Type      string `json:"type"` // function, class, import, etc.
 	Name      string `json:"name"` // function/class name if applicable
+
+	// Overlap is the number of leading bytes of Content repeated from the
+	// previous chunk; Content[Overlap:] is the chunk's own core
+	Overlap int `json:"overlap,omitempty"`
 }
 
 // SearchResult represents a memory match with similarity score
 type SearchResult struct {
 	Memory     Memory  `json:"memory"`
 	Similarity float32 `json:"similarity"`
+
+	// Neighbors are the chunks around Memory in its file, in line order,
+	// when the search asked for them
+	Neighbors []Memory `json:"neighbors,omitempty"`
 }
 
 // AddMemoryRequest is the request payload for adding a memory
@@ -54,34 +116,288 @@ type AddMemoryRequest struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// Validate checks an add request: content is required, and a type, if
+// given, must be known. An empty type defaults to context.
+func (r *AddMemoryRequest) Validate() error {
+	if r.Content == "" {
+		return errors.New("content is required")
+	}
+	if r.Type != "" {
+		return validateType(r.Type)
+	}
+	return nil
+}
+
+// UpdateMemoryRequest is the request payload for updating a memory. Nil
+// fields are left unchanged, so an empty string sets a field to empty rather
+// than skipping it. Metadata is merged into the existing metadata; a key
+// with a null value is removed.
+type UpdateMemoryRequest struct {
+	Content  *string            `json:"content,omitempty"`
+	Type     *MemoryType        `json:"type,omitempty"`
+	FilePath *string            `json:"file_path,omitempty"`
+	Language *string            `json:"language,omitempty"`
+	Metadata map[string]*string `json:"metadata,omitempty"`
+}
+
+// Validate checks an update request: content and type, if given, can't be
+// emptied, and the type must be known
+func (r *UpdateMemoryRequest) Validate() error {
+	if r.Content != nil && *r.Content == "" {
+		return errors.New("content cannot be empty")
+	}
+	if r.Type != nil {
+		if *r.Type == "" {
+			return errors.New("type cannot be empty")
+		}
+		return validateType(*r.Type)
+	}
+	return nil
+}
+
 // SearchRequest is the request payload for searching memories
 type SearchRequest struct {
 	Query     string     `json:"query"`
 	Project   string     `json:"project,omitempty"`
 	Type      MemoryType `json:"type,omitempty"`
 	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"` // Results to skip, for paging
 	Threshold float32    `json:"threshold,omitempty"`
+
+	FilePaths      []string `json:"file_paths,omitempty"`       // File path prefixes (directory scoping)
+	ExactFilePaths []string `json:"exact_file_paths,omitempty"` // Exact file paths
+
+	// Metadata keeps memories whose metadata has every key set to the
+	// given value
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// TypeBoosts multiplies the similarity of results of the given types so
+	// preferred types rank higher without excluding others. When Type is also
+	// set, results are filtered to that type first and then boosted.
+	TypeBoosts map[MemoryType]float32 `json:"type_boosts,omitempty"`
+
+	// MaxPerFile caps how many results come from any single file path.
+	// Lower-ranked results from other files fill the freed slots. 0 means
+	// unlimited.
+	MaxPerFile int `json:"max_per_file,omitempty"`
+
+	// Rerank reorders candidates with the service's reranker, when one is
+	// configured. Falls back to vector order if reranking fails.
+	Rerank bool `json:"rerank,omitempty"`
+
+	// Expand also searches for related phrasings of the query, when the
+	// service has an expander, keeping each memory's best similarity.
+	// Every phrasing costs an extra embedding.
+	Expand bool `json:"expand,omitempty"`
+
+	// MinSemanticLen routes queries shorter than this many characters to
+	// keyword search, which needs no embedding, for search as you type.
+	// 0 uses the service's default.
+	MinSemanticLen int `json:"min_semantic_len,omitempty"`
+
+	// Fuzzy lets keyword searches match words with typos, so
+	// "parseEmbeddng" still finds parseEmbeddingStream
+	Fuzzy bool `json:"fuzzy,omitempty"`
+
+	// IncludeNeighbors attaches up to this many indexed chunks before and
+	// after each result in the same file, for surrounding code. The HTTP
+	// and MCP servers cap it at MaxIncludeNeighbors.
+	IncludeNeighbors int `json:"include_neighbors,omitempty"`
 }
 
+// MaxIncludeNeighbors caps SearchRequest.IncludeNeighbors for remote callers
+const MaxIncludeNeighbors = 10
+
 // SearchResponse is the response payload for search
 type SearchResponse struct {
 	Results []SearchResult `json:"results"`
-	Total   int            `json:"total"`
+	Total   int            `json:"total"`    // Memories matching the filters and threshold, across all pages
+	Limit   int            `json:"limit"`    // Effective limit after defaults and server caps
+	Offset  int            `json:"offset"`   // Results skipped before this page
+	HasMore bool           `json:"has_more"` // Whether a later page has results
 	Timing  int64          `json:"timing_ms"`
+
+	// ScoreScale names the range of Similarity: "cosine" or "unit" ([0, 1])
+	ScoreScale string `json:"score_scale,omitempty"`
+
+	// Mode is "keyword" when the query was too short for semantic search
+	// and its results were matched by keyword, with keyword scores in
+	// [0, 1]; otherwise "semantic"
+	Mode string `json:"mode,omitempty"`
 }
 
+// Search modes reported in SearchResponse.Mode
+const (
+	SearchModeSemantic = "semantic"
+	SearchModeKeyword  = "keyword"
+)
+
 // IndexRequest is the request payload for indexing a file or directory
 type IndexRequest struct {
 	Path     string `json:"path"`
 	Project  string `json:"project"`
 	Language string `json:"language,omitempty"` // Auto-detect if empty
+
+	// GenerateSummaries adds a per-directory memory listing the indexed files
+	// and their top-level declarations. Off by default since it adds embeddings.
+	GenerateSummaries bool `json:"generate_summaries,omitempty"`
+
+	// FollowSymlinks walks into symlinked directories. Each resolved
+	// directory is visited at most once, so symlink cycles terminate.
+	FollowSymlinks bool `json:"follow_symlinks,omitempty"`
+
+	// SummarizeChunks generates a one-line description of each chunk and
+	// embeds it alongside the code, so prose queries match code. Needs a
+	// configured summarizer and costs one generate call per chunk.
+	SummarizeChunks bool `json:"summarize_chunks,omitempty"`
+
+	// OutlineOnly indexes each function, method, and class of supported
+	// languages as just its leading comments and signature, dropping bodies
+	// and other code. Files in other languages are indexed in full.
+	OutlineOnly bool `json:"outline_only,omitempty"`
+
+	// Force reindexes every file. Otherwise a file whose content hasn't
+	// changed since it was last indexed is skipped, and a changed file's
+	// old chunks are replaced.
+	Force bool `json:"force,omitempty"`
+
+	// Resume skips the files an interrupted index of the same directory and
+	// project already finished, as recorded in its journal, even with Force
+	Resume bool `json:"resume,omitempty"`
+}
+
+// IndexProgress reports indexing progress after each file
+type IndexProgress struct {
+	FilesDone    int    `json:"files_done"`
+	FilesTotal   int    `json:"files_total"`
+	FilesSkipped int    `json:"files_skipped"` // Unchanged, or finished by the run resumed
+	Path         string `json:"path"`          // File just processed
+	Chunks       int    `json:"chunks"`        // Chunks stored so far
+}
+
+// ChunkChange identifies one chunk in an IndexDiff
+type ChunkChange struct {
+	FilePath  string `json:"file_path"`
+	Name      string `json:"name,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	ID        string `json:"id,omitempty"` // Stored memory, for removed and changed chunks
+}
+
+// IndexDiff previews what reindexing a path would change
+type IndexDiff struct {
+	Added     []ChunkChange `json:"added"`
+	Removed   []ChunkChange `json:"removed"`
+	Changed   []ChunkChange `json:"changed"` // Same file and chunk name, new content
+	Unchanged int           `json:"unchanged"`
+}
+
+// Project describes a project key and its optional display metadata
+type Project struct {
+	Key         string `json:"key"`
+	Name        string `json:"name,omitempty"`        // Human-friendly display name
+	Description string `json:"description,omitempty"` // Short description
+	MemoryCount int    `json:"memory_count"`
+}
+
+// ListResponse is the response payload for listing memories
+type ListResponse struct {
+	Memories []*Memory `json:"memories"`
+	Total    int       `json:"total"`    // Memories matching the filters, across all pages
+	Limit    int       `json:"limit"`    // Effective limit after server caps
+	Offset   int       `json:"offset"`   // Memories skipped before this page
+	HasMore  bool      `json:"has_more"` // Whether a later page has memories
+}
+
+// GetBatchRequest is the request payload for fetching memories by ID
+type GetBatchRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// GetBatchResponse is the response payload for fetching memories by ID
+type GetBatchResponse struct {
+	Memories []*Memory `json:"memories"` // In request order, null for unknown IDs
+}
+
+// IndexResponse is the response payload for indexing
+type IndexResponse struct {
+	Indexed int `json:"indexed"` // Number of chunks stored
+}
+
+// DeleteResponse is the response payload for deletes
+type DeleteResponse struct {
+	Deleted bool `json:"deleted"`
+}
+
+// ProjectsResponse is the response payload for listing projects
+type ProjectsResponse struct {
+	Projects []string  `json:"projects"` // Project keys
+	Details  []Project `json:"details"`
+}
+
+// StatusResponse is the response payload for health and readiness checks
+type StatusResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+}
+
+// ErrorResponse is the body of every API error
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// EmbedderStats reports embedder activity for this process. Embedders that
+// don't track statistics report zeros.
+type EmbedderStats struct {
+	Requests     int64   `json:"requests"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	CacheHitRate float64 `json:"cache_hit_rate"` // Percentage, 0-100
+
+	// Latency percentiles of uncached requests, accurate to within 25%
+	P50LatencyMs float64 `json:"p50_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+}
+
+// Metrics counts the activity of a service since it was created. Unlike
+// StatsResponse it describes the running process, not the store.
+type Metrics struct {
+	Adds       int64 `json:"adds"`
+	Searches   int64 `json:"searches"`
+	Indexes    int64 `json:"indexes"`    // Index runs, including failed ones
+	Embeddings int64 `json:"embeddings"` // Texts sent to the embedder, cached or not
+
+	// Searches answered from, and missing, the search cache. Both stay zero
+	// when search caching is disabled.
+	CacheHits   int64 `json:"cache_hits"`
+	CacheMisses int64 `json:"cache_misses"`
+
+	AvgSearchLatencyMs float64 `json:"avg_search_latency_ms"`
 }
 
 // StatsResponse contains statistics about the memory store
 type StatsResponse struct {
-	TotalMemories  int            `json:"total_memories"`
-	MemoriesByType map[string]int `json:"memories_by_type"`
-	ProjectCount   int            `json:"project_count"`
-	EmbeddingModel string         `json:"embedding_model"`
-	StorageBytes   int64          `json:"storage_bytes"`
+	TotalMemories      int            `json:"total_memories"`
+	MemoriesByType     map[string]int `json:"memories_by_type"`
+	MemoriesByLanguage map[string]int `json:"memories_by_language,omitempty"`
+	ProjectCount       int            `json:"project_count"`
+	EmbeddingModel     string         `json:"embedding_model"`
+	StorageBytes       int64          `json:"storage_bytes"`
+
+	// Embedder stats for this process, when the embedder reports them
+	EmbedRequests int64   `json:"embed_requests,omitempty"`
+	CacheHitRate  float64 `json:"cache_hit_rate,omitempty"` // Percentage, 0-100
+	CacheDisabled bool    `json:"cache_disabled,omitempty"` // Embedding cache was bypassed
+
+	// Latency percentiles of uncached embedder requests
+	EmbedP50LatencyMs float64 `json:"embed_p50_latency_ms,omitempty"`
+	EmbedP95LatencyMs float64 `json:"embed_p95_latency_ms,omitempty"`
+	EmbedP99LatencyMs float64 `json:"embed_p99_latency_ms,omitempty"`
+
+	// EmbedderCircuit is the embedder circuit breaker state (closed, open,
+	// half-open), when one is in use
+	EmbedderCircuit string `json:"embedder_circuit,omitempty"`
+
+	// Runtime counts the activity of the serving process, when served
+	Runtime *Metrics `json:"runtime,omitempty"`
 }
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUpdateMemoryRequest_JSONPointerSemantics(t *testing.T) {
+	var req UpdateMemoryRequest
+	body := `{"file_path": "", "type": "gotcha", "metadata": {"add": "v", "drop": null}}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	if req.Content != nil || req.Language != nil {
+		t.Error("expected absent fields to stay nil")
+	}
+	if req.FilePath == nil || *req.FilePath != "" {
+		t.Errorf("expected explicit empty file path, got %v", req.FilePath)
+	}
+	if req.Type == nil || *req.Type != TypeGotcha {
+		t.Errorf("expected type gotcha, got %v", req.Type)
+	}
+	if v, ok := req.Metadata["add"]; !ok || v == nil || *v != "v" {
+		t.Errorf("expected metadata add=v, got %v", v)
+	}
+	if v, ok := req.Metadata["drop"]; !ok || v != nil {
+		t.Errorf("expected metadata drop present and nil, got %v", v)
+	}
+
+	out, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var back UpdateMemoryRequest
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("unmarshal of %s failed: %v", out, err)
+	}
+	if back.Content != nil || back.FilePath == nil || *back.FilePath != "" {
+		t.Errorf("round trip lost pointer semantics: %s", out)
+	}
+	if v, ok := back.Metadata["drop"]; !ok || v != nil {
+		t.Errorf("round trip lost metadata removal: %s", out)
+	}
+}
+
+func TestUpdateMemoryRequest_EmptyBody(t *testing.T) {
+	var req UpdateMemoryRequest
+	if err := json.Unmarshal([]byte(`{}`), &req); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	out, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(out) != "{}" {
+		t.Errorf("expected empty request to marshal as {}, got %s", out)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	empty := ""
+	gotcha := TypeGotcha
+	blank := MemoryType("")
+	unknown := MemoryType("todo")
+	content := "new content"
+
+	tests := []struct {
+		name    string
+		v       interface{ Validate() error }
+		wantErr string
+	}{
+		{"memory", &Memory{Content: "c", Type: TypeContext, Embedding: []float32{1, 2}, SummaryEmbedding: []float32{3, 4}}, ""},
+		{"memory without content", &Memory{Type: TypeContext}, "content is required"},
+		{"memory without type", &Memory{Content: "c"}, "type is required"},
+		{"memory of unknown type", &Memory{Content: "c", Type: unknown}, `unknown memory type "todo"`},
+		{"memory with mismatched summary", &Memory{Content: "c", Type: TypeContext, Embedding: []float32{1, 2}, SummaryEmbedding: []float32{3}}, "summary embedding has 1 dimensions"},
+		{"memory with only a summary", &Memory{Content: "c", Type: TypeContext, SummaryEmbedding: []float32{3}}, "embedding has 0"},
+		{"add", &AddMemoryRequest{Content: "c"}, ""},
+		{"add without content", &AddMemoryRequest{Type: TypeGotcha}, "content is required"},
+		{"add of unknown type", &AddMemoryRequest{Content: "c", Type: unknown}, "unknown memory type"},
+		{"update", &UpdateMemoryRequest{Content: &content, Type: &gotcha}, ""},
+		{"update emptying content", &UpdateMemoryRequest{Content: &empty}, "content cannot be empty"},
+		{"update emptying type", &UpdateMemoryRequest{Type: &blank}, "type cannot be empty"},
+		{"update to unknown type", &UpdateMemoryRequest{Type: &unknown}, "unknown memory type"},
+	}
+	for _, tt := range tests {
+		err := tt.v.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}